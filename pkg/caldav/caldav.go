@@ -17,6 +17,7 @@
 package caldav
 
 import (
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -31,6 +32,8 @@ import (
 // DateFormat is the caldav date format
 const DateFormat = `20060102T150405`
 
+const dayFormat = `20060102`
+
 // Event holds a single caldav event
 type Event struct {
 	Summary     string
@@ -42,6 +45,8 @@ type Event struct {
 	Timestamp time.Time
 	Start     time.Time
 	End       time.Time
+	// If true, Start and End are the first and last day of the event
+	AllDay bool
 }
 
 // Todo holds a single VTODO
@@ -59,21 +64,52 @@ type Todo struct {
 	RelatedToUID string
 	Color        string
 
-	Start       time.Time
-	End         time.Time
-	DueDate     time.Time
-	Duration    time.Duration
+	Start    time.Time
+	End      time.Time
+	DueDate  time.Time
+	Duration time.Duration
+	// If true, Start, End and DueDate are dates without a time of day
+	AllDay      bool
 	RepeatAfter int64
 	RepeatMode  models.TaskRepeatMode
+	// RepeatRule takes precedence over RepeatAfter and RepeatMode
+	RepeatRule       string
+	RepeatExceptions []time.Time
+	Alarms           []Alarm
+	// PercentDone ranges from 0 to 1
+	PercentDone float64
+	// There is no property for checklists in VTODOs, the items are appended to the description instead
+	Checklist []ChecklistItem
+	// Past occurrences of a repeating todo which were done
+	CompletedOccurrences []CompletedOccurrence
 
 	Created time.Time
 	Updated time.Time // last-mod
 }
 
-// Alarm holds infos about an alarm from a caldav event
+// ChecklistItem holds a single item of the checklist of a todo
+type ChecklistItem struct {
+	Text string
+	Done bool
+}
+
+// CompletedOccurrence is a past occurrence of a repeating todo which was done
+type CompletedOccurrence struct {
+	DueDate   time.Time
+	Completed time.Time
+}
+
+// The heading which starts the checklist in the description of a todo
+const checklistDescriptionHeading = "## Checklist"
+
+// Alarm holds infos about an alarm from a caldav event or todo
 type Alarm struct {
 	Time        time.Time
 	Description string
+
+	// Only used for todos: If RelativeTo is set, the alarm triggers Duration relative to that date of the todo.
+	Duration   time.Duration
+	RelativeTo models.ReminderRelation
 }
 
 // Config is the caldav calendar config
@@ -115,6 +151,12 @@ PRODID:-//` + config.ProdID + `//EN` + getCaldavColor(config.Color)
 			e.UID = makeCalDavTimeFromTimeStamp(e.Timestamp) + utils.Sha256(e.Summary)
 		}
 
+		// The end date of all-day events is exclusive
+		end := e.End
+		if e.AllDay {
+			end = end.AddDate(0, 0, 1)
+		}
+
 		formattedDescription := ""
 		if e.Description != "" {
 			re := regexp.MustCompile(`\r?\n`)
@@ -127,8 +169,8 @@ UID:` + e.UID + `
 SUMMARY:` + e.Summary + getCaldavColor(e.Color) + `
 DESCRIPTION:` + formattedDescription + `
 DTSTAMP:` + makeCalDavTimeFromTimeStamp(e.Timestamp) + `
-DTSTART:` + makeCalDavTimeFromTimeStamp(e.Start) + `
-DTEND:` + makeCalDavTimeFromTimeStamp(e.End)
+DTSTART` + makeCalDavDateProperty(e.Start, e.AllDay) + `
+DTEND` + makeCalDavDateProperty(end, e.AllDay)
 
 		for _, a := range e.Alarms {
 			if a.Description == "" {
@@ -183,7 +225,7 @@ SUMMARY:` + t.Summary + getCaldavColor(t.Color)
 
 		if t.Start.Unix() > 0 {
 			caldavtodos += `
-DTSTART:` + makeCalDavTimeFromTimeStamp(t.Start)
+DTSTART` + makeCalDavDateProperty(t.Start, t.AllDay)
 			if t.Duration != 0 && t.DueDate.Unix() == 0 {
 				caldavtodos += `
 DURATION:PT` + formatDuration(t.Duration)
@@ -191,11 +233,12 @@ DURATION:PT` + formatDuration(t.Duration)
 		}
 		if t.End.Unix() > 0 {
 			caldavtodos += `
-DTEND:` + makeCalDavTimeFromTimeStamp(t.End)
+DTEND` + makeCalDavDateProperty(t.End, t.AllDay)
 		}
-		if t.Description != "" {
+		description := appendChecklistToDescription(t.Description, t.Checklist)
+		if description != "" {
 			re := regexp.MustCompile(`\r?\n`)
-			formattedDescription := re.ReplaceAllString(t.Description, "\\n")
+			formattedDescription := re.ReplaceAllString(description, "\\n")
 			caldavtodos += `
 DESCRIPTION:` + formattedDescription
 		}
@@ -216,7 +259,7 @@ RELATED-TO:` + t.RelatedToUID
 
 		if t.DueDate.Unix() > 0 {
 			caldavtodos += `
-DUE:` + makeCalDavTimeFromTimeStamp(t.DueDate)
+DUE` + makeCalDavDateProperty(t.DueDate, t.AllDay)
 		}
 
 		if t.Created.Unix() > 0 {
@@ -229,7 +272,19 @@ CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
 PRIORITY:` + strconv.Itoa(mapPriorityToCaldav(t.Priority))
 		}
 
-		if t.RepeatAfter > 0 || t.RepeatMode == models.TaskRepeatModeMonth {
+		if t.PercentDone > 0 {
+			caldavtodos += `
+PERCENT-COMPLETE:` + strconv.Itoa(int(math.Round(t.PercentDone*100)))
+		}
+
+		if t.RepeatRule != "" {
+			caldavtodos += `
+RRULE:` + t.RepeatRule
+			for _, e := range t.RepeatExceptions {
+				caldavtodos += `
+EXDATE` + makeCalDavDateProperty(e, t.AllDay)
+			}
+		} else if t.RepeatAfter > 0 || t.RepeatMode == models.TaskRepeatModeMonth {
 			if t.RepeatMode == models.TaskRepeatModeMonth {
 				caldavtodos += `
 RRULE:FREQ=MONTHLY;BYMONTHDAY=` + t.DueDate.Format("02") // Day of the month
@@ -239,11 +294,38 @@ RRULE:FREQ=SECONDLY;INTERVAL=` + strconv.FormatInt(t.RepeatAfter, 10)
 			}
 		}
 
+		for _, a := range t.Alarms {
+			if a.Description == "" {
+				a.Description = t.Summary
+			}
+
+			caldavtodos += `
+BEGIN:VALARM
+TRIGGER` + getCaldavTodoAlarmTrigger(t, a) + `
+ACTION:DISPLAY
+DESCRIPTION:` + a.Description + `
+END:VALARM`
+		}
+
 		caldavtodos += `
 LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
 
 		caldavtodos += `
 END:VTODO`
+
+		// Each done occurrence overrides its instance of the recurrence
+		for _, o := range t.CompletedOccurrences {
+			caldavtodos += `
+BEGIN:VTODO
+UID:` + t.UID + `
+DTSTAMP:` + makeCalDavTimeFromTimeStamp(t.Timestamp) + `
+RECURRENCE-ID` + makeCalDavDateProperty(o.DueDate, t.AllDay) + `
+SUMMARY:` + t.Summary + `
+DUE` + makeCalDavDateProperty(o.DueDate, t.AllDay) + `
+COMPLETED:` + makeCalDavTimeFromTimeStamp(o.Completed) + `
+STATUS:COMPLETED
+END:VTODO`
+		}
 	}
 
 	caldavtodos += `
@@ -252,19 +334,88 @@ END:VCALENDAR` // Need a line break
 	return
 }
 
+// appendChecklistToDescription adds the checklist of a todo as a markdown list to its description.
+func appendChecklistToDescription(description string, checklist []ChecklistItem) string {
+	if len(checklist) == 0 {
+		return description
+	}
+
+	if description != "" {
+		description += "\n\n"
+	}
+	description += checklistDescriptionHeading + "\n"
+	for _, item := range checklist {
+		if item.Done {
+			description += "\n* [x] " + item.Text
+		} else {
+			description += "\n* [ ] " + item.Text
+		}
+	}
+	return description
+}
+
+// removeChecklistFromDescription removes a checklist added by appendChecklistToDescription from the description
+// of a todo. The checklist is managed through the api, clients sending it back must not duplicate it.
+func removeChecklistFromDescription(description string) string {
+	i := strings.LastIndex(description, checklistDescriptionHeading+"\n")
+	if i == -1 {
+		return description
+	}
+
+	items := strings.TrimSpace(description[i+len(checklistDescriptionHeading):])
+	for _, line := range strings.Split(items, "\n") {
+		if !strings.HasPrefix(line, "* [ ] ") && !strings.HasPrefix(line, "* [x] ") {
+			return description
+		}
+	}
+
+	return strings.TrimRight(description[:i], "\n")
+}
+
 func makeCalDavTimeFromTimeStamp(ts time.Time) (caldavtime string) {
 	return ts.In(time.UTC).Format(DateFormat) + "Z"
 }
 
+// makeCalDavDateProperty returns the parameters and value of a date property, including the colon.
+// Dates of all-day todos are midnight UTC of their day and exported as a plain date.
+func makeCalDavDateProperty(ts time.Time, allDay bool) string {
+	if allDay {
+		return `;VALUE=DATE:` + ts.In(time.UTC).Format(dayFormat)
+	}
+	return `:` + makeCalDavTimeFromTimeStamp(ts)
+}
+
 func calcAlarmDateFromReminder(eventStart, reminder time.Time) (alarmTime string) {
-	diff := reminder.Sub(eventStart)
-	diffStr := strings.ToUpper(diff.String())
-	if diff < 0 {
-		alarmTime += `-`
+	return makeCalDavDuration(reminder.Sub(eventStart))
+}
+
+func makeCalDavDuration(duration time.Duration) (caldavDuration string) {
+	diffStr := strings.ToUpper(duration.String())
+	if duration < 0 {
+		caldavDuration += `-`
 		// We append the - at the beginning of the caldav flag, that would get in the way if the minutes
 		// themselves are also containing it
 		diffStr = diffStr[1:]
 	}
-	alarmTime += `PT` + diffStr
+	caldavDuration += `PT` + diffStr
 	return
 }
+
+// getCaldavTodoAlarmTrigger returns the parameters and value of the TRIGGER property of a todo alarm.
+// As per RFC 5545, RELATED=START refers to DTSTART and RELATED=END to DUE, or DTEND if a todo has no due date.
+// Alarms relative to the end date of a todo which also has a due date can't be expressed that way,
+// those are exported with their absolute time instead.
+func getCaldavTodoAlarmTrigger(t *Todo, a Alarm) string {
+	switch a.RelativeTo {
+	case models.ReminderRelationStartDate:
+		return `;RELATED=START:` + makeCalDavDuration(a.Duration)
+	case models.ReminderRelationDueDate:
+		return `;RELATED=END:` + makeCalDavDuration(a.Duration)
+	case models.ReminderRelationEndDate:
+		if t.DueDate.IsZero() {
+			return `;RELATED=END:` + makeCalDavDuration(a.Duration)
+		}
+	}
+
+	return `;VALUE=DATE-TIME:` + makeCalDavTimeFromTimeStamp(a.Time)
+}
@@ -481,6 +481,258 @@ DUE:20181201T011204Z
 RRULE:FREQ=SECONDLY;INTERVAL=435
 LAST-MODIFIED:00010101T000000Z
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with repeat rule",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:     "Todo #1",
+						Description: "Lorem Ipsum",
+						UID:         "randommduid",
+						Timestamp:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						DueDate:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						RepeatAfter: 435,
+						RepeatRule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+						RepeatExceptions: []time.Time{
+							time.Unix(1544836324, 0).In(config.GetTimeZone()),
+						},
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+DUE:20181201T011204Z
+RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH
+EXDATE:20181215T011204Z
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with completed occurrences",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:    "Todo #1",
+						UID:        "randommduid",
+						Timestamp:  time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						DueDate:    time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						RepeatRule: "FREQ=DAILY",
+						CompletedOccurrences: []CompletedOccurrence{
+							{
+								DueDate:   time.Unix(1543540324, 0).In(config.GetTimeZone()),
+								Completed: time.Unix(1543536000, 0).In(config.GetTimeZone()),
+							},
+						},
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DUE:20181201T011204Z
+RRULE:FREQ=DAILY
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+RECURRENCE-ID:20181130T011204Z
+SUMMARY:Todo #1
+DUE:20181130T011204Z
+COMPLETED:20181130T000000Z
+STATUS:COMPLETED
+END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "all-day",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:    "Todo #1",
+						UID:        "randommduid",
+						Timestamp:  time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						Start:      time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+						DueDate:    time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC).In(config.GetTimeZone()),
+						AllDay:     true,
+						RepeatRule: "FREQ=DAILY",
+						CompletedOccurrences: []CompletedOccurrence{
+							{
+								DueDate:   time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+								Completed: time.Unix(1543536000, 0).In(config.GetTimeZone()),
+							},
+						},
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DTSTART;VALUE=DATE:20181130
+DUE;VALUE=DATE:20181201
+RRULE:FREQ=DAILY
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+RECURRENCE-ID;VALUE=DATE:20181130
+SUMMARY:Todo #1
+DUE;VALUE=DATE:20181130
+COMPLETED:20181130T000000Z
+STATUS:COMPLETED
+END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with alarms",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:   "Todo #1",
+						UID:       "randomuid",
+						Timestamp: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						Start:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						End:       time.Unix(1543636724, 0).In(config.GetTimeZone()),
+						DueDate:   time.Unix(1543646724, 0).In(config.GetTimeZone()),
+						Alarms: []Alarm{
+							{
+								Time: time.Unix(1543626824, 0).In(config.GetTimeZone()),
+							},
+							{
+								Time:       time.Unix(1543643124, 0).In(config.GetTimeZone()),
+								Duration:   -time.Hour,
+								RelativeTo: models.ReminderRelationDueDate,
+							},
+							{
+								Time:        time.Unix(1543627624, 0).In(config.GetTimeZone()),
+								Duration:    15 * time.Minute,
+								RelativeTo:  models.ReminderRelationStartDate,
+								Description: "Get started",
+							},
+							{
+								Time:       time.Unix(1543636124, 0).In(config.GetTimeZone()),
+								Duration:   -10 * time.Minute,
+								RelativeTo: models.ReminderRelationEndDate,
+							},
+						},
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DTSTART:20181201T011204Z
+DTEND:20181201T035844Z
+DUE:20181201T064524Z
+BEGIN:VALARM
+TRIGGER;VALUE=DATE-TIME:20181201T011344Z
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+BEGIN:VALARM
+TRIGGER;RELATED=END:-PT1H0M0S
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+BEGIN:VALARM
+TRIGGER;RELATED=START:PT15M0S
+ACTION:DISPLAY
+DESCRIPTION:Get started
+END:VALARM
+BEGIN:VALARM
+TRIGGER;VALUE=DATE-TIME:20181201T034844Z
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with checklist and percent done",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:     "Todo #1",
+						Description: "Lorem Ipsum",
+						UID:         "randommduid",
+						Timestamp:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						PercentDone: 0.5,
+						Checklist: []ChecklistItem{
+							{Text: "First item", Done: true},
+							{Text: "Second item"},
+						},
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum\n\n## Checklist\n\n* [x] First item\n* [ ] Second item
+PERCENT-COMPLETE:50
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
 END:VCALENDAR`,
 		},
 	}
@@ -17,12 +17,15 @@
 package caldav
 
 import (
+	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/rrule"
 
 	ics "github.com/arran4/golang-ical"
 )
@@ -35,6 +38,35 @@ func GetCaldavTodosForTasks(list *models.ListWithTasksAndBuckets, listTasks []*m
 
 		duration := t.EndDate.Sub(t.StartDate)
 
+		var checklist []ChecklistItem
+		for _, item := range t.ChecklistItems {
+			checklist = append(checklist, ChecklistItem{
+				Text: item.Text,
+				Done: item.Done,
+			})
+		}
+
+		var completed []CompletedOccurrence
+		for _, c := range t.Completions {
+			// Occurrences without a due date can't be referenced in the recurrence
+			if c.DueDate.IsZero() {
+				continue
+			}
+			completed = append(completed, CompletedOccurrence{
+				DueDate:   c.DueDate,
+				Completed: c.DoneAt,
+			})
+		}
+
+		var alarms []Alarm
+		for _, r := range t.Reminders {
+			alarms = append(alarms, Alarm{
+				Time:       r.Reminder,
+				Duration:   time.Duration(r.RelativePeriod) * time.Second,
+				RelativeTo: r.RelativeTo,
+			})
+		}
+
 		caldavtodos = append(caldavtodos, &Todo{
 			Timestamp:   t.Updated,
 			UID:         t.UID,
@@ -42,15 +74,22 @@ func GetCaldavTodosForTasks(list *models.ListWithTasksAndBuckets, listTasks []*m
 			Description: t.Description,
 			Completed:   t.DoneAt,
 			// Organizer:     &t.CreatedBy, // Disabled until we figure out how this works
-			Priority:    t.Priority,
-			Start:       t.StartDate,
-			End:         t.EndDate,
-			Created:     t.Created,
-			Updated:     t.Updated,
-			DueDate:     t.DueDate,
-			Duration:    duration,
-			RepeatAfter: t.RepeatAfter,
-			RepeatMode:  t.RepeatMode,
+			Priority:             t.Priority,
+			Start:                t.StartDate,
+			End:                  t.EndDate,
+			Created:              t.Created,
+			Updated:              t.Updated,
+			DueDate:              t.DueDate,
+			Duration:             duration,
+			AllDay:               t.AllDay,
+			RepeatAfter:          t.RepeatAfter,
+			RepeatMode:           t.RepeatMode,
+			RepeatRule:           t.RepeatRule,
+			RepeatExceptions:     t.RepeatExceptions,
+			Alarms:               alarms,
+			PercentDone:          t.PercentDone,
+			Checklist:            checklist,
+			CompletedOccurrences: completed,
 		})
 	}
 
@@ -62,16 +101,81 @@ func GetCaldavTodosForTasks(list *models.ListWithTasksAndBuckets, listTasks []*m
 	return ParseTodos(caldavConfig, caldavtodos)
 }
 
+// GetCaldavEventsForTasks returns all tasks with a date as events. Tasks with a start date span until their end or
+// due date, tasks with only a due date are events at that date.
+func GetCaldavEventsForTasks(title string, tasks []*models.Task) string {
+	var events []*Event
+	for _, t := range tasks {
+		start, end := t.StartDate, t.EndDate
+		if start.IsZero() {
+			start = t.DueDate
+		}
+		if start.IsZero() {
+			continue
+		}
+		if end.IsZero() && t.DueDate.After(start) {
+			end = t.DueDate
+		}
+		if end.Before(start) {
+			end = start
+		}
+
+		var alarms []Alarm
+		for _, r := range t.Reminders {
+			alarms = append(alarms, Alarm{Time: r.Reminder})
+		}
+
+		events = append(events, &Event{
+			Summary:     t.Title,
+			Description: t.Description,
+			UID:         t.UID,
+			Alarms:      alarms,
+			Timestamp:   t.Updated,
+			Start:       start,
+			End:         end,
+			AllDay:      t.AllDay,
+		})
+	}
+
+	return ParseEvents(&Config{
+		Name:   title,
+		ProdID: "Vikunja Todo App",
+	}, events)
+}
+
 func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 	parsed, err := ics.ParseCalendar(strings.NewReader(content))
 	if err != nil {
 		return nil, err
 	}
 
+	// Repeating todos can contain overrides of single occurrences next to the todo itself, only the latter is used
+	component := parsed.Components[0]
+	for _, c := range parsed.Components {
+		if _, is := c.(*ics.VTodo); is && !hasProperty(c, "RECURRENCE-ID") {
+			component = c
+			break
+		}
+	}
+
 	// We put the task details in a map to be able to handle them more easily
 	task := make(map[string]string)
-	for _, c := range parsed.Components[0].UnknownPropertiesIANAProperties() {
+	var exceptions []time.Time
+	var allDay bool
+	for _, c := range component.UnknownPropertiesIANAProperties() {
 		task[c.IANAToken] = c.Value
+
+		// A todo with dates instead of date-times is all-day
+		if (c.IANAToken == "DUE" || c.IANAToken == "DTSTART") && isDateValue(c) {
+			allDay = true
+		}
+
+		// Exceptions can be specified multiple times and hold multiple dates each
+		if c.IANAToken == "EXDATE" {
+			for _, e := range strings.Split(c.Value, ",") {
+				exceptions = append(exceptions, caldavTimeToTimestamp(e))
+			}
+		}
 	}
 
 	// Parse the priority
@@ -90,6 +194,16 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 
 	description := strings.ReplaceAll(task["DESCRIPTION"], "\\,", ",")
 	description = strings.ReplaceAll(description, "\\n", "\n")
+	description = removeChecklistFromDescription(description)
+
+	var percentDone float64
+	if _, ok := task["PERCENT-COMPLETE"]; ok {
+		percent, err := strconv.ParseInt(task["PERCENT-COMPLETE"], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		percentDone = float64(percent) / 100
+	}
 
 	vTask = &models.Task{
 		UID:         task["UID"],
@@ -100,16 +214,126 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 		Updated:     caldavTimeToTimestamp(task["DTSTAMP"]),
 		StartDate:   caldavTimeToTimestamp(task["DTSTART"]),
 		DoneAt:      caldavTimeToTimestamp(task["COMPLETED"]),
+		PercentDone: percentDone,
+		AllDay:      allDay,
 	}
 
 	if task["STATUS"] == "COMPLETED" {
 		vTask.Done = true
 	}
 
+	if task["RRULE"] != "" {
+		rule, err := rrule.Parse(task["RRULE"])
+		if err != nil {
+			return nil, err
+		}
+		vTask.RepeatRule = rule.String()
+		vTask.RepeatExceptions = exceptions
+	}
+
 	if duration > 0 && !vTask.StartDate.IsZero() {
 		vTask.EndDate = vTask.StartDate.Add(duration)
 	}
 
+	// Dates are midnight UTC of their day already and should not be moved into the timezone of the user
+	if allDay {
+		vTask.SetDatesAsCalendarDates()
+	}
+
+	vTask.Reminders, err = parseVAlarms(component, vTask)
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func isDateValue(p ics.IANAProperty) bool {
+	if len(p.ICalParameters["VALUE"]) > 0 {
+		return p.ICalParameters["VALUE"][0] == "DATE"
+	}
+	return len(p.Value) == len(dayFormat)
+}
+
+func hasProperty(component ics.Component, name string) bool {
+	for _, p := range component.UnknownPropertiesIANAProperties() {
+		if p.IANAToken == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVAlarms converts all alarms of a VTODO into reminders. Alarms with a duration trigger are converted into
+// reminders relative to the task date they refer to, alarms with a date-time trigger into absolute reminders.
+func parseVAlarms(component ics.Component, vTask *models.Task) (reminders []*models.TaskReminder, err error) {
+	for _, sub := range component.SubComponents() {
+		alarm, is := sub.(*ics.VAlarm)
+		if !is {
+			continue
+		}
+
+		trigger := alarm.GetProperty(ics.ComponentPropertyTrigger)
+		if trigger == nil {
+			continue
+		}
+
+		if len(trigger.ICalParameters["VALUE"]) > 0 && trigger.ICalParameters["VALUE"][0] == "DATE-TIME" {
+			reminders = append(reminders, &models.TaskReminder{
+				Reminder: caldavTimeToTimestamp(trigger.Value),
+			})
+			continue
+		}
+
+		duration, err := parseCalDavDuration(trigger.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		// Triggers are relative to the start by default. Some clients omit that even if the todo only has a due date.
+		relativeTo := models.ReminderRelationStartDate
+		if vTask.StartDate.IsZero() ||
+			(len(trigger.ICalParameters["RELATED"]) > 0 && trigger.ICalParameters["RELATED"][0] == "END") {
+			relativeTo = models.ReminderRelationDueDate
+			if vTask.DueDate.IsZero() && !vTask.EndDate.IsZero() {
+				relativeTo = models.ReminderRelationEndDate
+			}
+		}
+
+		reminders = append(reminders, &models.TaskReminder{
+			RelativeTo:     relativeTo,
+			RelativePeriod: int64(duration.Seconds()),
+		})
+	}
+
+	return
+}
+
+var caldavDurationRegex = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// https://tools.ietf.org/html/rfc5545#section-3.3.6
+func parseCalDavDuration(dur string) (duration time.Duration, err error) {
+	parts := caldavDurationRegex.FindStringSubmatch(dur)
+	if parts == nil {
+		return 0, fmt.Errorf("invalid caldav duration %s", dur)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if parts[i+2] == "" {
+			continue
+		}
+		amount, err := strconv.ParseInt(parts[i+2], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(amount) * unit
+	}
+
+	if parts[1] == "-" {
+		duration = -duration
+	}
+
 	return
 }
 
@@ -22,6 +22,7 @@ import (
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/models"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/d4l3k/messagediff.v1"
 )
 
@@ -85,6 +86,232 @@ END:VCALENDAR`,
 				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
 			},
 		},
+		{
+			name: "With checklist and percent done",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum\n\n## Checklist\n\n* [x] First item\n* [ ] Second item
+PERCENT-COMPLETE:50
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				PercentDone: 0.5,
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		},
+		{
+			name: "With alarms",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+DTSTART:20181201T011204
+DUE:20181201T064524
+BEGIN:VALARM
+TRIGGER;VALUE=DATE-TIME:20181201T011344
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+BEGIN:VALARM
+TRIGGER;RELATED=END:-P1DT1H
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+BEGIN:VALARM
+TRIGGER:PT15M
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				StartDate:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				DueDate:     time.Unix(1543646724, 0).In(config.GetTimeZone()),
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Reminders: []*models.TaskReminder{
+					{
+						Reminder: time.Unix(1543626824, 0).In(config.GetTimeZone()),
+					},
+					{
+						RelativeTo:     models.ReminderRelationDueDate,
+						RelativePeriod: -90000,
+					},
+					{
+						RelativeTo:     models.ReminderRelationStartDate,
+						RelativePeriod: 900,
+					},
+				},
+			},
+		},
+		{
+			name: "With repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+DUE:20181201T011204
+RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=5
+EXDATE:20181228T011204,20190125T011204
+EXDATE:20190222T011204
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				DueDate:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				RepeatRule:  "FREQ=MONTHLY;COUNT=5;BYDAY=-1FR",
+				RepeatExceptions: []time.Time{
+					time.Unix(1545959524, 0).In(config.GetTimeZone()),
+					time.Unix(1548378724, 0).In(config.GetTimeZone()),
+					time.Unix(1550797924, 0).In(config.GetTimeZone()),
+				},
+			},
+		},
+		{
+			name: "With a completed occurrence before the todo",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+RECURRENCE-ID:20181130T011204
+SUMMARY:Todo #1
+DUE:20181130T011204
+COMPLETED:20181130T000000
+STATUS:COMPLETED
+END:VTODO
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DUE:20181201T011204
+RRULE:FREQ=DAILY
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:      "Todo #1",
+				UID:        "randomuid",
+				DueDate:    time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Updated:    time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				RepeatRule: "FREQ=DAILY",
+			},
+		},
+		{
+			name: "All-day",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DTSTART;VALUE=DATE:20181130
+DUE;VALUE=DATE:20181201
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: func() *models.Task {
+				task := &models.Task{
+					Title:     "Todo #1",
+					UID:       "randomuid",
+					StartDate: time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+					DueDate:   time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+					Updated:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+					AllDay:    true,
+				}
+				task.SetDatesAsCalendarDates()
+				return task
+			}(),
+		},
+		{
+			name: "With an invalid repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+RRULE:FREQ=FORTNIGHTLY
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantErr: true,
+		},
+		{
+			name: "With an invalid alarm",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DUE:20181201T064524
+BEGIN:VALARM
+TRIGGER:yesterday
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -99,3 +326,66 @@ END:VCALENDAR`,
 		})
 	}
 }
+
+func TestGetCaldavEventsForTasks(t *testing.T) {
+	tasks := []*models.Task{
+		{
+			Title:     "Task with start and end",
+			UID:       "uid1",
+			Updated:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			StartDate: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			EndDate:   time.Unix(1543627824, 0).In(config.GetTimeZone()),
+		},
+		{
+			Title:   "Task with due date",
+			UID:     "uid2",
+			Updated: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			DueDate: time.Unix(1543627824, 0).In(config.GetTimeZone()),
+		},
+		{
+			Title:   "Task without dates",
+			UID:     "uid3",
+			Updated: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+		},
+		{
+			Title:     "All-day task",
+			UID:       "uid4",
+			Updated:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			StartDate: time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+			DueDate:   time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+			AllDay:    true,
+		},
+	}
+
+	assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VEVENT
+UID:uid1
+SUMMARY:Task with start and end
+DESCRIPTION:
+DTSTAMP:20181201T011204Z
+DTSTART:20181201T011204Z
+DTEND:20181201T013024Z
+END:VEVENT
+BEGIN:VEVENT
+UID:uid2
+SUMMARY:Task with due date
+DESCRIPTION:
+DTSTAMP:20181201T011204Z
+DTSTART:20181201T013024Z
+DTEND:20181201T013024Z
+END:VEVENT
+BEGIN:VEVENT
+UID:uid4
+SUMMARY:All-day task
+DESCRIPTION:
+DTSTAMP:20181201T011204Z
+DTSTART;VALUE=DATE:20181130
+DTEND;VALUE=DATE:20181202
+END:VEVENT
+END:VCALENDAR`, GetCaldavEventsForTasks("test", tasks))
+}
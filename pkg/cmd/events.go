@@ -0,0 +1,156 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/migration"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var eventsFlagDeleteAll bool
+
+func init() {
+	eventsPoisonDeleteCmd.Flags().BoolVarP(&eventsFlagDeleteAll, "all", "a", false, "Remove all events from the poison queue.")
+
+	eventsPoisonCmd.AddCommand(eventsPoisonListCmd, eventsPoisonShowCmd, eventsPoisonDeleteCmd)
+	eventsCmd.AddCommand(eventsPoisonCmd)
+	rootCmd.AddCommand(eventsCmd)
+}
+
+func parseIDArgs(args []string) []int64 {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid id: %s", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Inspect the event queue.",
+	// This does not use FullInit on purpose: It would start consuming events in this process.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		initialize.LightInit()
+		migration.Migrate(nil)
+		initialize.InitEngines()
+	},
+}
+
+var eventsPoisonCmd = &cobra.Command{
+	Use:   "poison",
+	Short: "Manage events which could not be handled, even after retrying.",
+}
+
+var eventsPoisonListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Shows a list of all events in the poison queue.",
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		poisoned, err := events.GetPoisonedEvents(s)
+		if err != nil {
+			log.Fatalf("Error getting poisoned events: %s", err)
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{
+			"ID",
+			"UUID",
+			"Topic",
+			"Handler",
+			"Reason",
+			"Created",
+		})
+
+		for _, p := range poisoned {
+			table.Append([]string{
+				strconv.FormatInt(p.ID, 10),
+				p.UUID,
+				p.Topic,
+				p.Handler,
+				p.Reason,
+				p.Created.Format(time.RFC3339),
+			})
+		}
+
+		table.Render()
+	},
+}
+
+var eventsPoisonShowCmd = &cobra.Command{
+	Use:   "show [id]",
+	Short: "Shows the payload and metadata of an event in the poison queue.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		id := parseIDArgs(args)[0]
+		p, exists, err := events.GetPoisonedEventByID(s, id)
+		if err != nil {
+			log.Fatalf("Error getting poisoned event: %s", err)
+		}
+		if !exists {
+			log.Fatalf("Poisoned event %d does not exist.", id)
+		}
+
+		fmt.Printf("ID:       %d\nUUID:     %s\nTopic:    %s\nHandler:  %s\nReason:   %s\nCreated:  %s\nMetadata: %s\nPayload:  %s\n",
+			p.ID, p.UUID, p.Topic, p.Handler, p.Reason, p.Created.Format(time.RFC3339), p.Metadata, p.Payload)
+	},
+}
+
+var eventsPoisonDeleteCmd = &cobra.Command{
+	Use:   "delete [id...]",
+	Short: "Removes events from the poison queue.",
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !eventsFlagDeleteAll {
+			return fmt.Errorf("provide at least one id or --all")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		deleted, err := events.DeletePoisonedEvents(s, parseIDArgs(args))
+		if err != nil {
+			_ = s.Rollback()
+			log.Fatalf("Error deleting poisoned events: %s", err)
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Fatalf("Error deleting poisoned events: %s", err)
+		}
+
+		fmt.Printf("Removed %d events from the poison queue.\n", deleted)
+	},
+}
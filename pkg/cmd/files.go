@@ -0,0 +1,65 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package cmd
+
+import (
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	filesFlagMigrateFrom string
+	filesFlagMigrateTo   string
+)
+
+func init() {
+	filesMigrateCmd.Flags().StringVarP(&filesFlagMigrateFrom, "from", "f", "", "The file backend to copy the files from. Defaults to the configured files.type.")
+	filesMigrateCmd.Flags().StringVarP(&filesFlagMigrateTo, "to", "t", "", "The file backend to copy the files to, either local or s3.")
+	_ = filesMigrateCmd.MarkFlagRequired("to")
+
+	filesCmd.AddCommand(filesMigrateCmd)
+	rootCmd.AddCommand(filesCmd)
+}
+
+var filesCmd = &cobra.Command{
+	Use:   "files",
+	Short: "Manage the files stored by Vikunja.",
+}
+
+var filesMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copies all files from one file backend to another.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		from := filesFlagMigrateFrom
+		if from == "" {
+			from = config.FilesType.GetString()
+		}
+
+		migrated, err := files.Migrate(from, filesFlagMigrateTo)
+		if err != nil {
+			log.Fatalf("Error migrating files, %d files were copied before the error: %s", migrated, err)
+		}
+
+		log.Infof("Copied %d files from %s to %s.", migrated, from, filesFlagMigrateTo)
+		log.Infof("Set files.type to %s to use them. The files in %s were not removed.", filesFlagMigrateTo, from)
+	},
+}
@@ -0,0 +1,101 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package cmd
+
+import (
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	repairCmd.AddCommand(repairPositionsCmd)
+	repairCmd.AddCommand(repairAttachmentPreviewsCmd)
+	repairCmd.AddCommand(repairFileHashesCmd)
+	rootCmd.AddCommand(repairCmd)
+}
+
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Fix inconsistent data.",
+}
+
+var repairPositionsCmd = &cobra.Command{
+	Use:   "positions",
+	Short: "Spreads out the positions of all tasks and kanban buckets evenly, keeping their order.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		lists, buckets, err := models.RepairPositions(s)
+		if err != nil {
+			_ = s.Rollback()
+			log.Fatalf("Error repairing positions: %s", err)
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Fatalf("Error saving everything: %s", err)
+		}
+
+		log.Infof("Repaired the positions of tasks and buckets in %d lists and of tasks in %d buckets.", lists, buckets)
+	},
+}
+
+var repairAttachmentPreviewsCmd = &cobra.Command{
+	Use:   "attachment-previews",
+	Short: "Generates blur hashes and previews for all image attachments which don't have them yet.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		count, err := models.GenerateMissingAttachmentPreviews(s)
+		if err != nil {
+			_ = s.Rollback()
+			log.Fatalf("Error generating attachment previews: %s", err)
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Fatalf("Error saving everything: %s", err)
+		}
+
+		log.Infof("Generated previews for %d image attachments.", count)
+	},
+}
+
+var repairFileHashesCmd = &cobra.Command{
+	Use:   "file-hashes",
+	Short: "Stores all files which were uploaded before files were stored by their content hash by their hash.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInit()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := files.HashExistingFiles()
+		if err != nil {
+			log.Fatalf("Error storing files by their hash: %s", err)
+		}
+
+		log.Infof("Stored %d files by their content hash.", count)
+	},
+}
@@ -27,10 +27,12 @@ import (
 	"github.com/asaskevich/govalidator"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/initialize"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/user"
+	"github.com/c2h5oh/datasize"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -46,6 +48,7 @@ var (
 	userFlagEnableUser            bool
 	userFlagDisableUser           bool
 	userFlagDeleteNow             bool
+	userFlagStorageQuota          string
 )
 
 func init() {
@@ -61,6 +64,7 @@ func init() {
 	userUpdateCmd.Flags().StringVarP(&userFlagUsername, "username", "u", "", "The new username of the user.")
 	userUpdateCmd.Flags().StringVarP(&userFlagEmail, "email", "e", "", "The new email address of the user.")
 	userUpdateCmd.Flags().StringVarP(&userFlagAvatar, "avatar-provider", "a", "", "The new avatar provider of the new user.")
+	userUpdateCmd.Flags().StringVarP(&userFlagStorageQuota, "storage-quota", "q", "", "The new maximum size of all files of the user together, like 1GB. 0 means no limit, default uses the configured default quota.")
 
 	// Reset PW flags
 	userResetPasswordCmd.Flags().BoolVarP(&userFlagResetPasswordDirectly, "direct", "d", false, "If provided, reset the password directly instead of sending the user a reset mail.")
@@ -143,16 +147,36 @@ var userListCmd = &cobra.Command{
 			"Username",
 			"Email",
 			"Status",
+			"Storage used",
+			"Storage quota",
 			"Created",
 			"Updated",
 		})
 
 		for _, u := range users {
+			usage, err := files.GetStorageUsageForUser(s, u.ID)
+			if err != nil {
+				log.Fatalf("Error getting the storage usage of user %d: %s", u.ID, err)
+			}
+			storageQuota, err := u.GetStorageQuota()
+			if err != nil {
+				log.Fatalf("Error getting the storage quota of user %d: %s", u.ID, err)
+			}
+			quota := "unlimited"
+			if storageQuota > 0 {
+				quota = datasize.ByteSize(storageQuota).HumanReadable()
+			}
+			if u.StorageQuota == user.DefaultStorageQuota {
+				quota += " (default)"
+			}
+
 			table.Append([]string{
 				strconv.FormatInt(u.ID, 10),
 				u.Username,
 				u.Email,
 				u.Status.String(),
+				datasize.ByteSize(usage).HumanReadable(),
+				quota,
 				u.Created.Format(time.RFC3339),
 				u.Updated.Format(time.RFC3339),
 			})
@@ -231,6 +255,22 @@ var userUpdateCmd = &cobra.Command{
 			log.Fatalf("Error updating the user: %s", err)
 		}
 
+		if userFlagStorageQuota != "" {
+			quota := user.DefaultStorageQuota
+			if userFlagStorageQuota != "default" {
+				size, err := user.ParseStorageQuota(userFlagStorageQuota)
+				if err != nil {
+					_ = s.Rollback()
+					log.Fatalf("Error updating the user: %s", err)
+				}
+				quota = int64(size)
+			}
+			if err := u.SetStorageQuota(s, quota); err != nil {
+				_ = s.Rollback()
+				log.Fatalf("Error updating the storage quota: %s", err)
+			}
+		}
+
 		if err := s.Commit(); err != nil {
 			log.Fatalf("Error saving everything: %s", err)
 		}
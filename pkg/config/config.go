@@ -63,6 +63,7 @@ const (
 	ServiceEnableEmailReminders  Key = `service.enableemailreminders`
 	ServiceEnableUserDeletion    Key = `service.enableuserdeletion`
 	ServiceMaxAvatarSize         Key = `service.maxavatarsize`
+	ServiceTrashRetentionDays    Key = `service.trashretentiondays`
 
 	AuthLocalEnabled      Key = `auth.local.enabled`
 	AuthOpenIDEnabled     Key = `auth.openid.enabled`
@@ -125,8 +126,16 @@ const (
 	RateLimitLimit   Key = `ratelimit.limit`
 	RateLimitStore   Key = `ratelimit.store`
 
-	FilesBasePath Key = `files.basepath`
-	FilesMaxSize  Key = `files.maxsize`
+	FilesBasePath       Key = `files.basepath`
+	FilesMaxSize        Key = `files.maxsize`
+	FilesType           Key = `files.type`
+	FilesS3Endpoint     Key = `files.s3.endpoint`
+	FilesS3Bucket       Key = `files.s3.bucket`
+	FilesS3Region       Key = `files.s3.region`
+	FilesS3AccessKey    Key = `files.s3.accesskey`
+	FilesS3SecretKey    Key = `files.s3.secretkey`
+	FilesS3UsePathStyle Key = `files.s3.usepathstyle`
+	FilesInstanceQuota  Key = `files.instancequota`
 
 	MigrationTodoistEnable             Key = `migration.todoist.enable`
 	MigrationTodoistClientID           Key = `migration.todoist.clientid`
@@ -154,6 +163,8 @@ const (
 
 	KeyvalueType Key = `keyvalue.type`
 
+	EventsType Key = `events.type`
+
 	MetricsEnabled  Key = `metrics.enabled`
 	MetricsUsername Key = `metrics.username`
 	MetricsPassword Key = `metrics.password`
@@ -169,6 +180,11 @@ const (
 	DefaultSettingsLanguage                    Key = `defaultsettings.language`
 	DefaultSettingsTimezone                    Key = `defaultsettings.timezone`
 	DefaultSettingsOverdueTaskRemindersTime    Key = `defaultsettings.overdue_tasks_reminders_time`
+	DefaultSettingsStorageQuota                Key = `defaultsettings.storage_quota`
+
+	WebhooksEnabled              Key = `webhooks.enabled`
+	WebhooksTimeoutSeconds       Key = `webhooks.timeoutseconds`
+	WebhooksAllowInternalTargets Key = `webhooks.allowinternaltargets`
 )
 
 // GetString returns a string config value
@@ -298,6 +314,7 @@ func InitDefaultConfig() {
 	ServiceEnableEmailReminders.setDefault(true)
 	ServiceEnableUserDeletion.setDefault(true)
 	ServiceMaxAvatarSize.setDefault(1024)
+	ServiceTrashRetentionDays.setDefault(30)
 
 	// Auth
 	AuthLocalEnabled.setDefault(true)
@@ -360,6 +377,10 @@ func InitDefaultConfig() {
 	// Files
 	FilesBasePath.setDefault("files")
 	FilesMaxSize.setDefault("20MB")
+	FilesType.setDefault("local")
+	FilesS3Region.setDefault("us-east-1")
+	FilesS3UsePathStyle.setDefault(false)
+	FilesInstanceQuota.setDefault("0")
 	// Cors
 	CorsEnable.setDefault(true)
 	CorsOrigins.setDefault([]string{"*"})
@@ -376,12 +397,19 @@ func InitDefaultConfig() {
 	BackgroundsUnsplashEnabled.setDefault(false)
 	// Key Value
 	KeyvalueType.setDefault("memory")
+	// Events
+	EventsType.setDefault("memory")
 	// Metrics
 	MetricsEnabled.setDefault(false)
 	// Settings
 	DefaultSettingsAvatarProvider.setDefault("initials")
 	DefaultSettingsOverdueTaskRemindersEnabled.setDefault(true)
 	DefaultSettingsOverdueTaskRemindersTime.setDefault("9:00")
+	DefaultSettingsStorageQuota.setDefault("0")
+	// Webhook
+	WebhooksEnabled.setDefault(true)
+	WebhooksTimeoutSeconds.setDefault(30)
+	WebhooksAllowInternalTargets.setDefault(false)
 }
 
 // InitConfig initializes the config, sets defaults etc.
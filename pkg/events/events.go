@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/log"
 	vmetrics "code.vikunja.io/api/pkg/metrics"
 	"github.com/ThreeDotsLabs/watermill"
@@ -30,7 +31,7 @@ import (
 	"github.com/ThreeDotsLabs/watermill/pubsub/gochannel"
 )
 
-var pubsub *gochannel.GoChannel
+var pubsub message.Publisher
 
 // Event represents the event interface used by all events
 type Event interface {
@@ -52,25 +53,38 @@ func InitEvents() (err error) {
 	metricsBuilder := metrics.NewPrometheusMetricsBuilder(vmetrics.GetRegistry(), "", "")
 	metricsBuilder.AddPrometheusRouterMetrics(router)
 
-	pubsub = gochannel.NewGoChannel(
-		gochannel.Config{
-			OutputChannelBuffer: 1024,
-		},
-		logger,
-	)
+	var newSubscriber func(consumerGroup string) message.Subscriber
+	switch config.EventsType.GetString() {
+	case "memory":
+		channel := gochannel.NewGoChannel(
+			gochannel.Config{
+				OutputChannelBuffer: 1024,
+			},
+			logger,
+		)
+		pubsub = channel
+		newSubscriber = func(consumerGroup string) message.Subscriber {
+			return channel
+		}
+	case "redis":
+		pubsub = newRedisPublisher()
+		newSubscriber = func(consumerGroup string) message.Subscriber {
+			return newRedisSubscriber(consumerGroup, logger)
+		}
+	case "sql":
+		pubsub = &sqlPublisher{}
+		newSubscriber = func(consumerGroup string) message.Subscriber {
+			return newSQLSubscriber(consumerGroup, logger)
+		}
+	default:
+		log.Fatalf("Unknown events type %s", config.EventsType.GetString())
+	}
 
-	poison, err := middleware.PoisonQueue(pubsub, "poison")
+	poison, err := middleware.PoisonQueue(pubsub, poisonTopic)
 	if err != nil {
 		return err
 	}
-	router.AddNoPublisherHandler("poison.logger", "poison", pubsub, func(msg *message.Message) error {
-		meta := ""
-		for s, m := range msg.Metadata {
-			meta += s + "=" + m + ", "
-		}
-		log.Errorf("Error while handling message %s, %s payload=%s", msg.UUID, meta, string(msg.Payload))
-		return nil
-	})
+	router.AddNoPublisherHandler(poisonHandlerName, poisonTopic, newSubscriber(poisonHandlerName), handlePoisonedMessage)
 
 	router.AddMiddleware(
 		poison,
@@ -88,7 +102,8 @@ func InitEvents() (err error) {
 
 	for topic, funcs := range listeners {
 		for _, handler := range funcs {
-			router.AddNoPublisherHandler(topic+"."+handler.Name(), topic, pubsub, handler.Handle)
+			handlerName := topic + "." + handler.Name()
+			router.AddNoPublisherHandler(handlerName, topic, newSubscriber(handlerName), handler.Handle)
 		}
 	}
 
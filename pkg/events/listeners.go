@@ -18,7 +18,9 @@ package events
 
 import "github.com/ThreeDotsLabs/watermill/message"
 
-// Listener represents something that listens to events
+// Listener represents something that listens to events.
+// With the redis and sql transports events are delivered at least once, so Handle can be called more than once
+// for the same event if an instance stops before it could record that the event was handled.
 type Listener interface {
 	Handle(msg *message.Message) error
 	Name() string
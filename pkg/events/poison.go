@@ -0,0 +1,113 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/ThreeDotsLabs/watermill/message/router/middleware"
+	"xorm.io/xorm"
+)
+
+const (
+	poisonTopic       = "poison"
+	poisonHandlerName = "poison.logger"
+)
+
+// PoisonedEvent holds an event which could not be handled by one of its listeners, even after retrying.
+type PoisonedEvent struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The uuid of the original message.
+	UUID string `xorm:"varchar(50) not null" json:"uuid"`
+	// The topic (event name) the message was dispatched to.
+	Topic string `xorm:"varchar(250) not null index" json:"topic"`
+	// The name of the handler which failed to process the message.
+	Handler string `xorm:"varchar(250) not null" json:"handler"`
+	// The last error returned by the handler.
+	Reason   string `xorm:"text null" json:"reason"`
+	Payload  string `xorm:"longtext not null" json:"payload"`
+	Metadata string `xorm:"longtext null" json:"metadata"`
+
+	Created time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName returns the table name for poisoned events
+func (*PoisonedEvent) TableName() string {
+	return "events_poisoned"
+}
+
+// GetTables returns all structs which are also a table.
+func GetTables() []interface{} {
+	return []interface{}{
+		&QueuedEvent{},
+		&EventConsumer{},
+		&PoisonedEvent{},
+	}
+}
+
+func handlePoisonedMessage(msg *message.Message) error {
+	meta := ""
+	for s, m := range msg.Metadata {
+		meta += s + "=" + m + ", "
+	}
+	log.Errorf("Error while handling message %s, %s payload=%s", msg.UUID, meta, string(msg.Payload))
+
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return err
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err = s.Insert(&PoisonedEvent{
+		UUID:     msg.UUID,
+		Topic:    msg.Metadata.Get(middleware.PoisonedTopicKey),
+		Handler:  msg.Metadata.Get(middleware.PoisonedHandlerKey),
+		Reason:   msg.Metadata.Get(middleware.ReasonForPoisonedKey),
+		Payload:  string(msg.Payload),
+		Metadata: string(metadata),
+	})
+	return err
+}
+
+// GetPoisonedEvents returns all events which ended up in the poison queue, oldest first.
+func GetPoisonedEvents(s *xorm.Session) (poisoned []*PoisonedEvent, err error) {
+	poisoned = []*PoisonedEvent{}
+	err = s.OrderBy("id asc").Find(&poisoned)
+	return
+}
+
+// GetPoisonedEventByID returns a single event from the poison queue.
+func GetPoisonedEventByID(s *xorm.Session, id int64) (poisoned *PoisonedEvent, exists bool, err error) {
+	poisoned = &PoisonedEvent{}
+	exists, err = s.Where("id = ?", id).Get(poisoned)
+	return
+}
+
+// DeletePoisonedEvents removes events from the poison queue. If no ids are provided, all poisoned events are removed.
+func DeletePoisonedEvents(s *xorm.Session, ids []int64) (deleted int64, err error) {
+	if len(ids) == 0 {
+		return s.Where("1 = 1").Delete(&PoisonedEvent{})
+	}
+	return s.In("id", ids).Delete(&PoisonedEvent{})
+}
@@ -0,0 +1,279 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/red"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	redisStreamPrefix = "vikunja:events:"
+	// Streams are trimmed to roughly this length. Trimming only affects messages every consumer group is long done with.
+	redisStreamMaxLen = 10000
+	// Messages which were delivered to a consumer but not acked for this long are claimed by another consumer.
+	redisClaimMinIdle = time.Minute
+	// While a message is being handled, its idle time is reset in this interval so no other consumer claims it.
+	redisHeartbeatInterval = 20 * time.Second
+	redisReadBlock         = time.Second
+)
+
+func getRedisClient() *redis.Client {
+	client := red.GetRedis()
+	if client == nil {
+		log.Fatal("Redis must be enabled and configured to use redis as events type.")
+	}
+	return client
+}
+
+type redisPublisher struct {
+	client *redis.Client
+}
+
+func newRedisPublisher() *redisPublisher {
+	return &redisPublisher{client: getRedisClient()}
+}
+
+// Publish appends the messages to the stream of the topic.
+func (p *redisPublisher) Publish(topic string, messages ...*message.Message) error {
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return err
+		}
+
+		err = p.client.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: redisStreamPrefix + topic,
+			MaxLen: redisStreamMaxLen,
+			Approx: true,
+			Values: map[string]interface{}{
+				"uuid":     msg.UUID,
+				"payload":  string(msg.Payload),
+				"metadata": string(metadata),
+			},
+		}).Err()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the publisher. The redis client is shared and stays open.
+func (p *redisPublisher) Close() error {
+	return nil
+}
+
+// redisSubscriber reads messages of a topic through a redis stream consumer group.
+// Every handler has its own consumer group, all instances of Vikunja share it. That way every message is usually
+// handled by only one instance per handler, no matter how many instances are running. Messages are only acked after
+// they were handled, so a message is handled again by another instance if the one handling it dies or stalls before
+// acking it. Delivery is at least once.
+type redisSubscriber struct {
+	client        *redis.Client
+	consumerGroup string
+	consumerName  string
+	logger        watermill.LoggerAdapter
+
+	closing chan struct{}
+	closed  bool
+	mutex   sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func newRedisSubscriber(consumerGroup string, logger watermill.LoggerAdapter) *redisSubscriber {
+	hostname, _ := os.Hostname()
+	return &redisSubscriber{
+		client:        getRedisClient(),
+		consumerGroup: consumerGroup,
+		consumerName:  hostname + "-" + watermill.NewShortUUID(),
+		logger:        logger,
+		closing:       make(chan struct{}),
+	}
+}
+
+// Subscribe creates the consumer group of the subscriber if it does not exist and starts reading messages.
+func (sub *redisSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	stream := redisStreamPrefix + topic
+
+	// New groups start at the end of the stream so newly added listeners don't process old events.
+	err := sub.client.XGroupCreateMkStream(ctx, stream, sub.consumerGroup, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+
+	output := make(chan *message.Message)
+	sub.wg.Add(1)
+	go func() {
+		defer sub.wg.Done()
+		defer close(output)
+		sub.consume(ctx, stream, output)
+	}()
+
+	return output, nil
+}
+
+func (sub *redisSubscriber) consume(ctx context.Context, stream string, output chan *message.Message) {
+	logFields := watermill.LogFields{"stream": stream, "consumer_group": sub.consumerGroup, "consumer": sub.consumerName}
+
+	for {
+		select {
+		case <-sub.closing:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// Take over messages of consumers which died while handling them
+		claimed, _, err := sub.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   stream,
+			Group:    sub.consumerGroup,
+			Consumer: sub.consumerName,
+			MinIdle:  redisClaimMinIdle,
+			Start:    "0-0",
+			Count:    10,
+		}).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			sub.logger.Error("Could not claim pending messages", err, logFields)
+			sub.wait(ctx, redisReadBlock)
+			continue
+		}
+
+		messages := claimed
+		if len(messages) == 0 {
+			streams, err := sub.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    sub.consumerGroup,
+				Consumer: sub.consumerName,
+				Streams:  []string{stream, ">"},
+				Count:    10,
+				Block:    redisReadBlock,
+			}).Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				if ctx.Err() == nil {
+					sub.logger.Error("Could not read messages", err, logFields)
+					sub.wait(ctx, redisReadBlock)
+				}
+				continue
+			}
+			for _, s := range streams {
+				messages = append(messages, s.Messages...)
+			}
+		}
+
+		for _, m := range messages {
+			if !sub.handle(ctx, stream, m, output) {
+				return
+			}
+		}
+	}
+}
+
+// handle sends the message to the router and waits until it was acked. Nacked messages are sent again.
+// Returns false if the subscriber is closing.
+func (sub *redisSubscriber) handle(ctx context.Context, stream string, m redis.XMessage, output chan *message.Message) bool {
+	heartbeat := time.NewTicker(redisHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		msg := redisMessageToWatermill(m)
+		msg.SetContext(ctx)
+
+		select {
+		case output <- msg:
+		case <-sub.closing:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+
+	waitForAck:
+		for {
+			select {
+			case <-msg.Acked():
+				err := sub.client.XAck(ctx, stream, sub.consumerGroup, m.ID).Err()
+				if err != nil {
+					sub.logger.Error("Could not ack message", err, watermill.LogFields{"stream": stream, "message_id": m.ID})
+				}
+				return true
+			case <-msg.Nacked():
+				break waitForAck
+			case <-heartbeat.C:
+				// Claiming our own message resets its idle time, which keeps other consumers from claiming it
+				err := sub.client.XClaimJustID(ctx, &redis.XClaimArgs{
+					Stream:   stream,
+					Group:    sub.consumerGroup,
+					Consumer: sub.consumerName,
+					Messages: []string{m.ID},
+				}).Err()
+				if err != nil {
+					sub.logger.Error("Could not extend message claim", err, watermill.LogFields{"stream": stream, "message_id": m.ID})
+				}
+			case <-sub.closing:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+}
+
+func (sub *redisSubscriber) wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-sub.closing:
+	case <-ctx.Done():
+	}
+}
+
+// Close stops all consumers of the subscriber. Messages which are being handled will be claimed by another
+// consumer once they are idle for long enough.
+func (sub *redisSubscriber) Close() error {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if sub.closed {
+		return nil
+	}
+	sub.closed = true
+	close(sub.closing)
+	sub.wg.Wait()
+	return nil
+}
+
+func redisMessageToWatermill(m redis.XMessage) *message.Message {
+	uuid, _ := m.Values["uuid"].(string)
+	payload, _ := m.Values["payload"].(string)
+	metadata, _ := m.Values["metadata"].(string)
+
+	msg := message.NewMessage(uuid, []byte(payload))
+	if metadata != "" {
+		_ = json.Unmarshal([]byte(metadata), &msg.Metadata)
+	}
+	return msg
+}
@@ -0,0 +1,456 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"xorm.io/builder"
+)
+
+const (
+	// A consumer holds the lease of its consumer group for this long. The lease is renewed while handling messages.
+	sqlLeaseDuration = time.Minute
+	sqlLeaseRenewal  = 20 * time.Second
+	// How often to look for new messages published by other instances.
+	sqlPollInterval = 5 * time.Second
+	// Messages of topics without any consumer are removed after this duration.
+	sqlUnconsumedRetention = 24 * time.Hour
+)
+
+// QueuedEvent is an event stored in the database until all consumer groups of its topic handled it.
+type QueuedEvent struct {
+	ID       int64  `xorm:"bigint autoincr not null unique pk"`
+	Topic    string `xorm:"varchar(250) not null index"`
+	UUID     string `xorm:"varchar(50) not null"`
+	Payload  string `xorm:"longtext not null"`
+	Metadata string `xorm:"longtext null"`
+
+	Created time.Time `xorm:"created not null"`
+}
+
+// TableName returns the table name for queued events
+func (*QueuedEvent) TableName() string {
+	return "events_queue"
+}
+
+// EventConsumer holds the position of a consumer group (one per handler) in the queue of its topic.
+// Only the instance holding the lease of a consumer group processes its messages. The position is moved after a
+// message was handled, so if an instance dies or loses its lease in between, the message is handled again by the next
+// holder of the lease. Delivery is at least once.
+type EventConsumer struct {
+	ConsumerGroup string    `xorm:"varchar(250) not null pk"`
+	Topic         string    `xorm:"varchar(250) not null index"`
+	LastEventID   int64     `xorm:"bigint not null default 0"`
+	LockedBy      string    `xorm:"varchar(250) null"`
+	LockedUntil   time.Time `xorm:"null"`
+}
+
+// TableName returns the table name for event consumers
+func (*EventConsumer) TableName() string {
+	return "events_consumers"
+}
+
+// sqlWakeups allows the publisher to notify subscribers in the same process about new messages
+// so they don't have to wait for the next poll.
+var sqlWakeups = struct {
+	sync.Mutex
+	channels map[string][]chan struct{}
+}{channels: make(map[string][]chan struct{})}
+
+func sqlWakeupChannel(topic string) chan struct{} {
+	sqlWakeups.Lock()
+	defer sqlWakeups.Unlock()
+	c := make(chan struct{}, 1)
+	sqlWakeups.channels[topic] = append(sqlWakeups.channels[topic], c)
+	return c
+}
+
+func notifySQLSubscribers(topic string) {
+	sqlWakeups.Lock()
+	defer sqlWakeups.Unlock()
+	for _, c := range sqlWakeups.channels[topic] {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type sqlPublisher struct{}
+
+// Publish stores the messages in the queue table.
+func (p *sqlPublisher) Publish(topic string, messages ...*message.Message) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	for _, msg := range messages {
+		metadata, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Insert(&QueuedEvent{
+			Topic:    topic,
+			UUID:     msg.UUID,
+			Payload:  string(msg.Payload),
+			Metadata: string(metadata),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	notifySQLSubscribers(topic)
+	return nil
+}
+
+// Close closes the publisher.
+func (p *sqlPublisher) Close() error {
+	return nil
+}
+
+type sqlSubscriber struct {
+	consumerGroup string
+	consumerName  string
+	logger        watermill.LoggerAdapter
+
+	closing chan struct{}
+	closed  bool
+	mutex   sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func newSQLSubscriber(consumerGroup string, logger watermill.LoggerAdapter) *sqlSubscriber {
+	hostname, _ := os.Hostname()
+	return &sqlSubscriber{
+		consumerGroup: consumerGroup,
+		consumerName:  hostname + "-" + watermill.NewShortUUID(),
+		logger:        logger,
+		closing:       make(chan struct{}),
+	}
+}
+
+// Subscribe registers the consumer group of the subscriber if it does not exist and starts reading messages.
+func (sub *sqlSubscriber) Subscribe(ctx context.Context, topic string) (<-chan *message.Message, error) {
+	err := sub.registerConsumerGroup(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make(chan *message.Message)
+	wakeup := sqlWakeupChannel(topic)
+	sub.wg.Add(1)
+	go func() {
+		defer sub.wg.Done()
+		defer close(output)
+		sub.consume(ctx, topic, wakeup, output)
+	}()
+
+	return output, nil
+}
+
+func (sub *sqlSubscriber) registerConsumerGroup(topic string) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	exists, err := s.Where("consumer_group = ?", sub.consumerGroup).Exist(&EventConsumer{})
+	if err != nil || exists {
+		return err
+	}
+
+	// New consumer groups start at the end of the queue so newly added listeners don't process old events.
+	latest := &QueuedEvent{}
+	_, err = s.Where("topic = ?", topic).OrderBy("id desc").Get(latest)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(&EventConsumer{
+		ConsumerGroup: sub.consumerGroup,
+		Topic:         topic,
+		LastEventID:   latest.ID,
+	})
+	if err != nil {
+		// Another instance might have registered the group in the meantime
+		exists, err2 := s.Where("consumer_group = ?", sub.consumerGroup).Exist(&EventConsumer{})
+		if err2 == nil && exists {
+			return nil
+		}
+	}
+	return err
+}
+
+// acquireLease tries to get or renew the lease for the consumer group. It returns the consumer if successful.
+func (sub *sqlSubscriber) acquireLease() (*EventConsumer, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	now := time.Now()
+	updated, err := s.
+		Where("consumer_group = ?", sub.consumerGroup).
+		And(builder.Or(
+			builder.Eq{"locked_by": sub.consumerName},
+			builder.IsNull{"locked_by"},
+			builder.Eq{"locked_by": ""},
+			builder.Lt{"locked_until": now},
+		)).
+		Cols("locked_by", "locked_until").
+		Update(&EventConsumer{
+			LockedBy:    sub.consumerName,
+			LockedUntil: now.Add(sqlLeaseDuration),
+		})
+	if err != nil || updated == 0 {
+		return nil, err
+	}
+
+	consumer := &EventConsumer{}
+	_, err = s.Where("consumer_group = ?", sub.consumerGroup).Get(consumer)
+	return consumer, err
+}
+
+// commit moves the consumer group past the message, but only if the lease is still held by this consumer.
+func (sub *sqlSubscriber) commit(eventID int64) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	updated, err := s.
+		Where("consumer_group = ? AND locked_by = ?", sub.consumerGroup, sub.consumerName).
+		Cols("last_event_id").
+		Update(&EventConsumer{LastEventID: eventID})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		sub.logger.Error("Lost lease while handling message, it may be handled again", nil, watermill.LogFields{"consumer_group": sub.consumerGroup, "event_id": eventID})
+	}
+	return nil
+}
+
+func (sub *sqlSubscriber) releaseLease() {
+	s := db.NewSession()
+	defer s.Close()
+
+	_, err := s.
+		Where("consumer_group = ? AND locked_by = ?", sub.consumerGroup, sub.consumerName).
+		Cols("locked_by", "locked_until").
+		Update(&EventConsumer{})
+	if err != nil {
+		sub.logger.Error("Could not release lease", err, watermill.LogFields{"consumer_group": sub.consumerGroup})
+	}
+}
+
+func (sub *sqlSubscriber) consume(ctx context.Context, topic string, wakeup chan struct{}, output chan *message.Message) {
+	defer sub.releaseLease()
+	logFields := watermill.LogFields{"topic": topic, "consumer_group": sub.consumerGroup, "consumer": sub.consumerName}
+
+	for {
+		select {
+		case <-sub.closing:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		consumer, err := sub.acquireLease()
+		if err != nil {
+			sub.logger.Error("Could not acquire lease", err, logFields)
+		}
+		if consumer == nil {
+			// Another instance is processing this consumer group
+			if !sub.wait(ctx, wakeup) {
+				return
+			}
+			continue
+		}
+
+		event, err := sub.nextEvent(topic, consumer.LastEventID)
+		if err != nil {
+			sub.logger.Error("Could not get next message", err, logFields)
+		}
+		if event == nil {
+			if !sub.wait(ctx, wakeup) {
+				return
+			}
+			continue
+		}
+
+		if !sub.handle(ctx, event, output) {
+			return
+		}
+	}
+}
+
+func (sub *sqlSubscriber) nextEvent(topic string, lastEventID int64) (*QueuedEvent, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	event := &QueuedEvent{}
+	exists, err := s.
+		Where("topic = ? AND id > ?", topic, lastEventID).
+		OrderBy("id asc").
+		Get(event)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return event, nil
+}
+
+// handle sends the message to the router and waits until it was acked. Nacked messages are sent again.
+// Returns false if the subscriber is closing.
+func (sub *sqlSubscriber) handle(ctx context.Context, event *QueuedEvent, output chan *message.Message) bool {
+	renewal := time.NewTicker(sqlLeaseRenewal)
+	defer renewal.Stop()
+
+	for {
+		msg := message.NewMessage(event.UUID, []byte(event.Payload))
+		if event.Metadata != "" {
+			_ = json.Unmarshal([]byte(event.Metadata), &msg.Metadata)
+		}
+		msg.SetContext(ctx)
+
+		select {
+		case output <- msg:
+		case <-sub.closing:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+
+	waitForAck:
+		for {
+			select {
+			case <-msg.Acked():
+				err := sub.commit(event.ID)
+				if err != nil {
+					sub.logger.Error("Could not commit message", err, watermill.LogFields{"consumer_group": sub.consumerGroup, "event_id": event.ID})
+				}
+				return true
+			case <-msg.Nacked():
+				break waitForAck
+			case <-renewal.C:
+				_, err := sub.acquireLease()
+				if err != nil {
+					sub.logger.Error("Could not renew lease", err, watermill.LogFields{"consumer_group": sub.consumerGroup})
+				}
+			case <-sub.closing:
+				return false
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+}
+
+// wait blocks until new messages were published in this process or the poll interval elapsed.
+// Returns false if the subscriber is closing.
+func (sub *sqlSubscriber) wait(ctx context.Context, wakeup chan struct{}) bool {
+	select {
+	case <-wakeup:
+	case <-time.After(sqlPollInterval):
+	case <-sub.closing:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+// Close stops all consumers of the subscriber and releases their leases.
+func (sub *sqlSubscriber) Close() error {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if sub.closed {
+		return nil
+	}
+	sub.closed = true
+	close(sub.closing)
+	sub.wg.Wait()
+	return nil
+}
+
+// RegisterQueueCleanupCron registers a cron function to remove events from the sql queue
+// once every consumer group of their topic handled them.
+func RegisterQueueCleanupCron() {
+	if config.EventsType.GetString() != "sql" {
+		return
+	}
+
+	const logPrefix = "[Events Queue Cleanup Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		consumers := []*EventConsumer{}
+		err := s.Find(&consumers)
+		if err != nil {
+			log.Errorf(logPrefix+"Error getting event consumers: %s", err)
+			return
+		}
+
+		minOffsets := make(map[string]int64)
+		for _, c := range consumers {
+			offset, has := minOffsets[c.Topic]
+			if !has || c.LastEventID < offset {
+				minOffsets[c.Topic] = c.LastEventID
+			}
+		}
+
+		var deleted int64
+		topics := make([]string, 0, len(minOffsets))
+		for topic, offset := range minOffsets {
+			topics = append(topics, topic)
+			d, err := s.Where("topic = ? AND id <= ?", topic, offset).Delete(&QueuedEvent{})
+			if err != nil {
+				log.Errorf(logPrefix+"Error removing handled events of topic %s: %s", topic, err)
+				return
+			}
+			deleted += d
+		}
+
+		var cond builder.Cond = builder.Lt{"created": time.Now().Add(-sqlUnconsumedRetention)}
+		if len(topics) > 0 {
+			cond = builder.And(cond, builder.NotIn("topic", topics))
+		}
+		d, err := s.Where(cond).Delete(&QueuedEvent{})
+		if err != nil {
+			log.Errorf(logPrefix+"Error removing unconsumed events: %s", err)
+			return
+		}
+		deleted += d
+
+		if deleted > 0 {
+			log.Debugf(logPrefix+"Deleted %d events", deleted)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register events queue cleanup cron: %s", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func receive(t *testing.T, messages <-chan *message.Message) *message.Message {
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for message")
+		return nil
+	}
+}
+
+func TestSQLTransport(t *testing.T) {
+	logger := log.NewWatermillLogger()
+	publisher := &sqlPublisher{}
+
+	t.Run("new consumer groups start at the end of the queue", func(t *testing.T) {
+		err := publisher.Publish("test.old", message.NewMessage(watermill.NewUUID(), []byte("old")))
+		assert.NoError(t, err)
+
+		sub := newSQLSubscriber("test.old.handler", logger)
+		err = sub.registerConsumerGroup("test.old")
+		assert.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+		consumer := &EventConsumer{}
+		_, err = s.Where("consumer_group = ?", "test.old.handler").Get(consumer)
+		assert.NoError(t, err)
+		latest := &QueuedEvent{}
+		_, err = s.Where("topic = ?", "test.old").OrderBy("id desc").Get(latest)
+		assert.NoError(t, err)
+		assert.Equal(t, latest.ID, consumer.LastEventID)
+	})
+	t.Run("delivers and commits messages", func(t *testing.T) {
+		sub := newSQLSubscriber("test.topic.handler", logger)
+		messages, err := sub.Subscribe(context.Background(), "test.topic")
+		assert.NoError(t, err)
+		defer sub.Close()
+
+		err = publisher.Publish("test.topic", message.NewMessage("first", []byte("1")), message.NewMessage("second", []byte("2")))
+		assert.NoError(t, err)
+
+		msg := receive(t, messages)
+		assert.Equal(t, "first", msg.UUID)
+		assert.Equal(t, "1", string(msg.Payload))
+		msg.Nack()
+
+		// Nacked messages are delivered again
+		msg = receive(t, messages)
+		assert.Equal(t, "first", msg.UUID)
+		msg.Ack()
+
+		msg = receive(t, messages)
+		assert.Equal(t, "second", msg.UUID)
+
+		// Only one consumer may process a consumer group at a time
+		other := newSQLSubscriber("test.topic.handler", logger)
+		consumer, err := other.acquireLease()
+		assert.NoError(t, err)
+		assert.Nil(t, consumer)
+
+		msg.Ack()
+
+		assert.Eventually(t, func() bool {
+			s := db.NewSession()
+			defer s.Close()
+			consumer := &EventConsumer{}
+			_, err := s.Where("consumer_group = ?", "test.topic.handler").Get(consumer)
+			assert.NoError(t, err)
+			latest := &QueuedEvent{}
+			_, err = s.Where("topic = ?", "test.topic").OrderBy("id desc").Get(latest)
+			assert.NoError(t, err)
+			return consumer.LastEventID == latest.ID
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestPoisonQueue(t *testing.T) {
+	msg := message.NewMessage(watermill.NewUUID(), []byte(`{"foo":"bar"}`))
+	msg.Metadata.Set("topic_poisoned", "task.created")
+	msg.Metadata.Set("handler_poisoned", "task.created.some.listener")
+	msg.Metadata.Set("reason_poisoned", "something went wrong")
+
+	err := handlePoisonedMessage(msg)
+	assert.NoError(t, err)
+
+	s := db.NewSession()
+	defer s.Close()
+
+	poisoned, err := GetPoisonedEvents(s)
+	assert.NoError(t, err)
+	assert.Len(t, poisoned, 1)
+	assert.Equal(t, msg.UUID, poisoned[0].UUID)
+	assert.Equal(t, "task.created", poisoned[0].Topic)
+	assert.Equal(t, "task.created.some.listener", poisoned[0].Handler)
+	assert.Equal(t, "something went wrong", poisoned[0].Reason)
+	assert.Equal(t, `{"foo":"bar"}`, poisoned[0].Payload)
+
+	deleted, err := DeletePoisonedEvents(s, []int64{poisoned[0].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
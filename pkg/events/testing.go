@@ -52,6 +52,11 @@ func AssertDispatched(t *testing.T, event Event) {
 	assert.True(t, found, "Failed to assert "+event.Name()+" has been dispatched.")
 }
 
+// DispatchedTestEvents returns all events dispatched since Fake was called.
+func DispatchedTestEvents() []Event {
+	return dispatchedTestEvents
+}
+
 // TestListener takes an event and a listener and calls the listener's Handle method.
 func TestListener(t *testing.T, event Event, listener Listener) {
 	content, err := json.Marshal(event)
@@ -0,0 +1,161 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"errors"
+	"os"
+
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+	"xorm.io/xorm/schemas"
+)
+
+// FileBlob is the stored content shared by all files with the same content hash.
+// Its row is locked while a file starts using the content or while the content is removed, so that content is never
+// removed while a file which is not committed yet is about to use it. This works across multiple Vikunja instances
+// sharing the same database.
+type FileBlob struct {
+	Hash string `xorm:"varchar(64) not null pk"`
+}
+
+// TableName is the table name for file blobs
+func (FileBlob) TableName() string {
+	return "file_blobs"
+}
+
+func (b *FileBlob) getFileName() string {
+	return (&File{Hash: b.Hash}).getFileName()
+}
+
+// lockBlob creates the blob row of a content hash if it does not exist yet and locks it until the transaction
+// of the session ends.
+func lockBlob(s *xorm.Session, hash string) error {
+	for {
+		var query string
+		switch db.Type() {
+		case schemas.POSTGRES:
+			query = "INSERT INTO file_blobs (hash) VALUES (?) ON CONFLICT DO NOTHING"
+		case schemas.MYSQL:
+			query = "INSERT IGNORE INTO file_blobs (hash) VALUES (?)"
+		default:
+			query = "INSERT OR IGNORE INTO file_blobs (hash) VALUES (?)"
+		}
+		_, err := s.Exec(query, hash)
+		if err != nil {
+			return err
+		}
+
+		// The row could have been removed together with its content right after it was inserted,
+		// in that case it is created again.
+		exists, err := s.Where("hash = ?", hash).ForUpdate().Get(&FileBlob{})
+		if err != nil || exists {
+			return err
+		}
+	}
+}
+
+// removeBlobIfUnused removes the stored content of a hash if no file uses it anymore.
+// It runs in its own transaction so it only sees committed files.
+func removeBlobIfUnused(hash string) (removed bool, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	if err := s.Begin(); err != nil {
+		return false, err
+	}
+
+	blob := &FileBlob{}
+	exists, err := s.Where("hash = ?", hash).ForUpdate().Get(blob)
+	if err != nil || !exists {
+		_ = s.Rollback()
+		return false, err
+	}
+
+	references, err := s.Where("hash = ?", hash).Count(&File{})
+	if err != nil || references > 0 {
+		_ = s.Rollback()
+		return false, err
+	}
+
+	_, err = s.Where("hash = ?", hash).Delete(&FileBlob{})
+	if err != nil {
+		_ = s.Rollback()
+		return false, err
+	}
+
+	err = afs.Remove(blob.getFileName())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		_ = s.Rollback()
+		return false, err
+	}
+
+	return true, s.Commit()
+}
+
+// RemoveUnusedBlobs removes all stored content no file uses anymore.
+// Deleting a file only removes its row, the content is removed here once the deletion is committed.
+// It returns the number of removed blobs.
+func RemoveUnusedBlobs() (removed int, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	hashes := []string{}
+	err = s.
+		Table("file_blobs").
+		Where(builder.NotIn("hash", builder.Select("hash").From("files").Where(builder.NotNull{"hash"}))).
+		Cols("hash").
+		Find(&hashes)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, hash := range hashes {
+		wasRemoved, err := removeBlobIfUnused(hash)
+		if err != nil {
+			return removed, err
+		}
+		if wasRemoved {
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// RegisterUnusedBlobCleanupCron registers a cron function which removes stored content no file uses anymore.
+func RegisterUnusedBlobCleanupCron() {
+	const logPrefix = "[Unused File Content Cleanup Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		removed, err := RemoveUnusedBlobs()
+		if err != nil {
+			log.Errorf(logPrefix+"Error removing unused file content: %s", err)
+			return
+		}
+		if removed > 0 {
+			log.Debugf(logPrefix+"Removed %d unused blobs", removed)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register unused file content cleanup cron: %s", err)
+	}
+}
@@ -0,0 +1,102 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile_DeleteWithSession(t *testing.T) {
+	t.Run("Content is removed after commit", func(t *testing.T) {
+		initFixtures(t)
+		file, err := Create(bytes.NewReader([]byte("deleted content")), "file", 15, &testauth{id: 1})
+		assert.NoError(t, err)
+		db.AssertExists(t, "file_blobs", map[string]interface{}{"hash": file.Hash}, false)
+
+		s := db.NewSession()
+		defer s.Close()
+		err = s.Begin()
+		assert.NoError(t, err)
+
+		err = (&File{ID: file.ID}).DeleteWithSession(s)
+		assert.NoError(t, err)
+		_, err = FileStat(file.getFileName())
+		assert.NoError(t, err)
+
+		err = s.Commit()
+		assert.NoError(t, err)
+		_, err = FileStat(file.getFileName())
+		assert.True(t, os.IsNotExist(err))
+		db.AssertMissing(t, "file_blobs", map[string]interface{}{"hash": file.Hash})
+	})
+	t.Run("Content is kept on rollback", func(t *testing.T) {
+		initFixtures(t)
+		file, err := Create(bytes.NewReader([]byte("kept content")), "file", 12, &testauth{id: 1})
+		assert.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+		err = s.Begin()
+		assert.NoError(t, err)
+
+		err = (&File{ID: file.ID}).DeleteWithSession(s)
+		assert.NoError(t, err)
+		err = s.Rollback()
+		assert.NoError(t, err)
+
+		_, err = FileStat(file.getFileName())
+		assert.NoError(t, err)
+		db.AssertExists(t, "files", map[string]interface{}{"id": file.ID}, false)
+	})
+}
+
+func TestRemoveUnusedBlobs(t *testing.T) {
+	initFixtures(t)
+	// There are no fixtures for blobs, those of other tests are still there
+	_, err := x.Where("1 = 1").Delete(&FileBlob{})
+	assert.NoError(t, err)
+
+	used, err := Create(bytes.NewReader([]byte("used content")), "file", 12, &testauth{id: 1})
+	assert.NoError(t, err)
+
+	// Content left behind by a deletion which was committed without removing it
+	unused := &FileBlob{Hash: "0000000000000000000000000000000000000000000000000000000000000000"}
+	_, err = x.Insert(unused)
+	assert.NoError(t, err)
+	err = afero.WriteFile(afs, config.FilesBasePath.GetString()+"/"+unused.Hash, []byte("unused"), 0644)
+	assert.NoError(t, err)
+
+	removed, err := RemoveUnusedBlobs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	_, err = FileStat(unused.getFileName())
+	assert.True(t, os.IsNotExist(err))
+	db.AssertMissing(t, "file_blobs", map[string]interface{}{"hash": unused.Hash})
+
+	_, err = FileStat(used.getFileName())
+	assert.NoError(t, err)
+	db.AssertExists(t, "file_blobs", map[string]interface{}{"hash": used.Hash}, false)
+}
@@ -45,5 +45,6 @@ func SetEngine() (err error) {
 func GetTables() []interface{} {
 	return []interface{}{
 		&File{},
+		&FileBlob{},
 	}
 }
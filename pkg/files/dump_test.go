@@ -0,0 +1,47 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	initFixtures(t)
+	ta := &testauth{id: 1}
+	first, err := Create(bytes.NewReader([]byte("same content")), "first", 12, ta)
+	assert.NoError(t, err)
+	second, err := Create(bytes.NewReader([]byte("same content")), "second", 12, ta)
+	assert.NoError(t, err)
+
+	allFiles, err := Dump()
+	assert.NoError(t, err)
+	assert.Len(t, allFiles, 4)
+
+	// Files sharing their content are each part of the dump
+	for _, id := range []int64{first.ID, second.ID} {
+		content, err := io.ReadAll(allFiles[id])
+		assert.NoError(t, err)
+		assert.Equal(t, "same content", string(content))
+	}
+	content, err := io.ReadAll(allFiles[1])
+	assert.NoError(t, err)
+	assert.Equal(t, "testfile1", string(content))
+}
@@ -16,7 +16,13 @@
 
 package files
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+
+	"code.vikunja.io/web"
+	"github.com/c2h5oh/datasize"
+)
 
 // ErrFileDoesNotExist defines an error where a file does not exist in the db
 type ErrFileDoesNotExist struct {
@@ -66,3 +72,68 @@ func IsErrFileIsNotUnsplashFile(err error) bool {
 	_, ok := err.(ErrFileIsNotUnsplashFile)
 	return ok
 }
+
+// ErrStorageQuotaExceeded defines an error where a file would exceed the storage quota of the user uploading it
+type ErrStorageQuotaExceeded struct {
+	UserID int64
+	Quota  uint64
+	Usage  uint64
+	Size   uint64
+}
+
+// Error is the error implementation of ErrStorageQuotaExceeded
+func (err ErrStorageQuotaExceeded) Error() string {
+	return fmt.Sprintf("storage quota exceeded [UserID: %d, Quota: %d, Usage: %d, Size: %d]", err.UserID, err.Quota, err.Usage, err.Size)
+}
+
+// IsErrStorageQuotaExceeded checks if an error is ErrStorageQuotaExceeded
+func IsErrStorageQuotaExceeded(err error) bool {
+	_, ok := err.(ErrStorageQuotaExceeded)
+	return ok
+}
+
+// ErrCodeStorageQuotaExceeded holds the unique world-error code of this error
+const ErrCodeStorageQuotaExceeded = 20001
+
+// HTTPError holds the http error description
+func (err ErrStorageQuotaExceeded) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusRequestEntityTooLarge,
+		Code:     ErrCodeStorageQuotaExceeded,
+		Message: fmt.Sprintf(
+			"This file would exceed your storage quota of %s, you are already using %s.",
+			datasize.ByteSize(err.Quota).HumanReadable(),
+			datasize.ByteSize(err.Usage).HumanReadable(),
+		),
+	}
+}
+
+// ErrInstanceStorageQuotaExceeded defines an error where a file would exceed the storage quota of the whole instance
+type ErrInstanceStorageQuotaExceeded struct {
+	Quota uint64
+	Usage uint64
+	Size  uint64
+}
+
+// Error is the error implementation of ErrInstanceStorageQuotaExceeded
+func (err ErrInstanceStorageQuotaExceeded) Error() string {
+	return fmt.Sprintf("instance storage quota exceeded [Quota: %d, Usage: %d, Size: %d]", err.Quota, err.Usage, err.Size)
+}
+
+// IsErrInstanceStorageQuotaExceeded checks if an error is ErrInstanceStorageQuotaExceeded
+func IsErrInstanceStorageQuotaExceeded(err error) bool {
+	_, ok := err.(ErrInstanceStorageQuotaExceeded)
+	return ok
+}
+
+// ErrCodeInstanceStorageQuotaExceeded holds the unique world-error code of this error
+const ErrCodeInstanceStorageQuotaExceeded = 20002
+
+// HTTPError holds the http error description
+func (err ErrInstanceStorageQuotaExceeded) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusInsufficientStorage,
+		Code:     ErrCodeInstanceStorageQuotaExceeded,
+		Message:  "There is not enough storage left on this instance to store this file.",
+	}
+}
@@ -17,12 +17,14 @@
 package files
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,10 +35,33 @@ var afs *afero.Afero
 
 // InitFileHandler creates a new file handler for the file backend we want to use
 func InitFileHandler() {
-	fs = afero.NewOsFs()
+	var err error
+	fs, err = newFileSystem(config.FilesType.GetString())
+	if err != nil {
+		log.Fatalf("Could not initialize the file backend: %s", err)
+	}
 	afs = &afero.Afero{Fs: fs}
 }
 
+// newFileSystem returns the file system for a file backend type
+func newFileSystem(kind string) (afero.Fs, error) {
+	switch kind {
+	case "local":
+		return afero.NewOsFs(), nil
+	case "s3":
+		return newS3Fs(
+			config.FilesS3Endpoint.GetString(),
+			config.FilesS3Bucket.GetString(),
+			config.FilesS3Region.GetString(),
+			config.FilesS3AccessKey.GetString(),
+			config.FilesS3SecretKey.GetString(),
+			config.FilesS3UsePathStyle.GetBool(),
+		)
+	default:
+		return nil, fmt.Errorf("unknown file backend type %s, must be one of local or s3", kind)
+	}
+}
+
 // InitTestFileHandler initializes a new memory file system for testing
 func InitTestFileHandler() {
 	fs = afero.NewMemMapFs()
@@ -56,6 +81,9 @@ func InitTestFileFixtures(t *testing.T) {
 	filename := config.FilesBasePath.GetString() + "/1"
 	err := afero.WriteFile(afs, filename, []byte("testfile1"), 0644)
 	assert.NoError(t, err)
+	filename = config.FilesBasePath.GetString() + "/2"
+	err = afero.WriteFile(afs, filename, []byte("testfile2"), 0644)
+	assert.NoError(t, err)
 }
 
 // InitTests handles the actual bootstrapping of the test env
@@ -66,12 +94,13 @@ func InitTests() {
 		log.Fatal(err)
 	}
 
-	err = x.Sync2(GetTables()...)
+	// The users are needed to check their storage quota
+	err = x.Sync2(append(GetTables(), user.GetTables()...)...)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = db.InitTestFixtures("files")
+	err = db.InitTestFixtures("files", "users")
 	if err != nil {
 		log.Fatal(err)
 	}
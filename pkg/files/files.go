@@ -17,9 +17,12 @@
 package files
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -39,6 +42,8 @@ type File struct {
 	Name string `xorm:"text not null" json:"name"`
 	Mime string `xorm:"text null" json:"mime"`
 	Size uint64 `xorm:"bigint not null" json:"size"`
+	// The sha256 hash of the file content. Files with the same content share the same stored blob.
+	Hash string `xorm:"varchar(64) null INDEX" json:"-"`
 
 	Created     time.Time `xorm:"created" json:"created"`
 	CreatedByID int64     `xorm:"bigint not null" json:"-"`
@@ -55,11 +60,31 @@ func (File) TableName() string {
 }
 
 func (f *File) getFileName() string {
+	if f.Hash != "" {
+		return config.FilesBasePath.GetString() + "/" + f.Hash
+	}
+	// Files created before they were stored by their content hash are stored by their id
 	return config.FilesBasePath.GetString() + "/" + strconv.FormatInt(f.ID, 10)
 }
 
+// loadHash loads the content hash of a file if only its id is known
+func (f *File) loadHash() error {
+	if f.Hash != "" {
+		return nil
+	}
+
+	file := &File{}
+	_, err := x.Where("id = ?", f.ID).Cols("hash").Get(file)
+	f.Hash = file.Hash
+	return err
+}
+
 // LoadFileByID returns a file by its ID
 func (f *File) LoadFileByID() (err error) {
+	err = f.loadHash()
+	if err != nil {
+		return
+	}
 	f.File, err = afs.Open(f.getFileName())
 	return
 }
@@ -91,23 +116,69 @@ func CreateWithMime(f io.Reader, realname string, realsize uint64, a web.Auth, m
 	return
 }
 
-func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, realsize uint64, a web.Auth, mime string, checkFileSizeLimit bool) (file *File, err error) {
+// CreateWithMimeAndSession creates a new file with the given session.
+// If checkLimits is true, the file must not be larger than the configured maximum file size and needs to fit
+// into the storage quotas.
+func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, realsize uint64, a web.Auth, mime string, checkLimits bool) (file *File, err error) {
+	// The stored content of the file stays locked until the file is committed, which needs a transaction
+	if !s.IsInTx() {
+		if err = s.Begin(); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				_ = s.Rollback()
+				return
+			}
+			err = s.Commit()
+		}()
+	}
+
 	// Get and parse the configured file size
 	var maxSize datasize.ByteSize
 	err = maxSize.UnmarshalText([]byte(config.FilesMaxSize.GetString()))
 	if err != nil {
 		return nil, err
 	}
-	if realsize > maxSize.Bytes() && checkFileSizeLimit {
+	if realsize > maxSize.Bytes() && checkLimits {
 		return nil, ErrFileIsTooLarge{Size: realsize}
 	}
 
-	// We first insert the file into the db to get it's ID
+	content, hash, size, err := bufferAndHash(f)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = content.Close()
+		_ = os.Remove(content.Name())
+	}()
+
+	// Not all callers know the size of a file before reading it
+	if realsize == 0 {
+		realsize = size
+		if realsize > maxSize.Bytes() && checkLimits {
+			return nil, ErrFileIsTooLarge{Size: realsize}
+		}
+	}
+
+	if checkLimits {
+		err = checkStorageQuota(s, a, realsize, hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	file = &File{
 		Name:        realname,
 		Size:        realsize,
 		CreatedByID: a.GetID(),
 		Mime:        mime,
+		Hash:        hash,
+	}
+
+	err = lockBlob(s, hash)
+	if err != nil {
+		return
 	}
 
 	_, err = s.Insert(file)
@@ -115,36 +186,94 @@ func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, rea
 		return
 	}
 
-	// Save the file to storage with its new ID as path
-	err = file.Save(f)
+	// Identical files are only stored once
+	exists, err := afs.Exists(file.getFileName())
+	if err != nil || exists {
+		return
+	}
+
+	err = file.Save(content)
 	return
 }
 
+// bufferAndHash writes the content of a file to a temporary file to calculate its hash and size before storing it.
+// The returned file is positioned at its start.
+func bufferAndHash(f io.Reader) (content *os.File, hash string, size uint64, err error) {
+	content, err = os.CreateTemp("", "vikunja-file-")
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	h := sha256.New()
+	written, err := io.Copy(io.MultiWriter(content, h), f)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = content.Close()
+		_ = os.Remove(content.Name())
+		return nil, "", 0, err
+	}
+
+	return content, hex.EncodeToString(h.Sum(nil)), uint64(written), nil
+}
+
 // Delete removes a file from the DB and the file system
 func (f *File) Delete() (err error) {
 	s := db.NewSession()
 	defer s.Close()
 
-	deleted, err := s.Where("id = ?", f.ID).Delete(f)
+	err = f.DeleteWithSession(s)
 	if err != nil {
 		_ = s.Rollback()
 		return err
 	}
-	if deleted == 0 {
-		_ = s.Rollback()
+
+	return s.Commit()
+}
+
+// DeleteWithSession removes a file from the DB and the file system with the given session.
+// The stored content is only removed once the deletion is committed and no other file uses it anymore.
+func (f *File) DeleteWithSession(s *xorm.Session) (err error) {
+	file := &File{}
+	exists, err := s.Where("id = ?", f.ID).Get(file)
+	if err != nil {
+		return err
+	}
+	if !exists {
 		return ErrFileDoesNotExist{FileID: f.ID}
 	}
+	f.Hash = file.Hash
+
+	if f.Hash != "" {
+		hash := f.Hash
+		_, err = s.
+			Where("id = ?", f.ID).
+			After(func(interface{}) {
+				if _, err := removeBlobIfUnused(hash); err != nil {
+					// The content will be removed by the cleanup cron instead
+					log.Errorf("Error removing the content of file %d: %s", f.ID, err)
+				}
+			}).
+			Delete(&File{})
+		return err
+	}
+
+	_, err = s.Where("id = ?", f.ID).Delete(&File{})
+	if err != nil {
+		return err
+	}
 
+	// Files created before they were stored by their content hash don't share their content with any other file
 	err = afs.Remove(f.getFileName())
 	if err != nil {
 		var perr *os.PathError
 		if errors.As(err, &perr) {
 			// Don't fail when removing the file failed
-			log.Errorf("Error deleting file %d: %w", err)
-			return s.Commit()
+			log.Errorf("Error deleting file %d: %s", f.ID, err)
+			return nil
 		}
 
-		_ = s.Rollback()
 		return err
 	}
 
@@ -153,5 +282,31 @@ func (f *File) Delete() (err error) {
 
 // Save saves a file to storage
 func (f *File) Save(fcontent io.Reader) error {
-	return afs.WriteReader(f.getFileName(), fcontent)
+	if err := f.loadHash(); err != nil {
+		return err
+	}
+	return saveFile(afs, f.getFileName(), fcontent)
+}
+
+func saveFile(afs *afero.Afero, filename string, content io.Reader) error {
+	err := afs.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := afs.Create(filename)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, content)
+	if err != nil {
+		// Closing an s3 file would otherwise complete the upload with incomplete content
+		if s3f, is := file.(*s3File); is {
+			s3f.abort(err)
+		}
+		_ = file.Close()
+		return err
+	}
+	// Some backends like s3 only store the file once it is closed
+	return file.Close()
 }
@@ -17,10 +17,13 @@
 package files
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"testing"
 
+	"code.vikunja.io/api/pkg/db"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,6 +72,29 @@ func TestCreate(t *testing.T) {
 		assert.Equal(t, uint64(100), file.Size)
 
 	})
+	t.Run("Identical content", func(t *testing.T) {
+		initFixtures(t)
+		ta := &testauth{id: 1}
+		first, err := Create(bytes.NewReader([]byte("same content")), "first", 12, ta)
+		assert.NoError(t, err)
+		second, err := Create(bytes.NewReader([]byte("same content")), "second", 12, ta)
+		assert.NoError(t, err)
+		third, err := Create(bytes.NewReader([]byte("other content")), "third", 13, ta)
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.Equal(t, first.Hash, second.Hash)
+		assert.NotEqual(t, first.Hash, third.Hash)
+		assert.Equal(t, first.getFileName(), second.getFileName())
+
+		// Both files have the complete content
+		f := &File{ID: second.ID}
+		err = f.LoadFileByID()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(f.File)
+		assert.NoError(t, err)
+		assert.Equal(t, "same content", string(content))
+	})
 	t.Run("Too Large", func(t *testing.T) {
 		initFixtures(t)
 		tf := &testfile{
@@ -88,6 +114,48 @@ func TestFile_Delete(t *testing.T) {
 		err := f.Delete()
 		assert.NoError(t, err)
 	})
+	t.Run("Shared content", func(t *testing.T) {
+		initFixtures(t)
+		ta := &testauth{id: 1}
+		first, err := Create(bytes.NewReader([]byte("same content")), "first", 12, ta)
+		assert.NoError(t, err)
+		second, err := Create(bytes.NewReader([]byte("same content")), "second", 12, ta)
+		assert.NoError(t, err)
+
+		// The content is still used by the second file
+		err = (&File{ID: first.ID}).Delete()
+		assert.NoError(t, err)
+		_, err = FileStat(second.getFileName())
+		assert.NoError(t, err)
+
+		// The last reference is gone
+		err = (&File{ID: second.ID}).Delete()
+		assert.NoError(t, err)
+		_, err = FileStat(second.getFileName())
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("Shared content in the same transaction", func(t *testing.T) {
+		initFixtures(t)
+		ta := &testauth{id: 1}
+		first, err := Create(bytes.NewReader([]byte("same content")), "first", 12, ta)
+		assert.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+		err = s.Begin()
+		assert.NoError(t, err)
+
+		// The second file is not committed yet but already uses the content
+		second, err := CreateWithMimeAndSession(s, bytes.NewReader([]byte("same content")), "second", 12, ta, "", true)
+		assert.NoError(t, err)
+		err = (&File{ID: first.ID}).DeleteWithSession(s)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		_, err = FileStat(second.getFileName())
+		assert.NoError(t, err)
+	})
 	t.Run("Nonexisting", func(t *testing.T) {
 		initFixtures(t)
 		f := &File{ID: 9999}
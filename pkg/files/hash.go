@@ -0,0 +1,114 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+
+	"xorm.io/xorm"
+)
+
+// HashExistingFiles moves all files which were stored before files were stored by their content hash to their hash,
+// removing duplicate copies of the same content along the way. Files which don't exist in the file backend are skipped.
+// It returns the number of files it moved.
+func HashExistingFiles() (hashed int, err error) {
+	files := []*File{}
+	err = x.Where("hash IS NULL OR hash = ''").OrderBy("id asc").Find(&files)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range files {
+		err = f.moveToHash()
+		if errors.Is(err, os.ErrNotExist) {
+			log.Warningf("File %d does not exist in the file backend, skipping it", f.ID)
+			continue
+		}
+		if err != nil {
+			return hashed, err
+		}
+
+		log.Debugf("Stored file %d by its hash", f.ID)
+		hashed++
+	}
+
+	return hashed, nil
+}
+
+// moveToHash stores the content of a file which is stored by its id by its content hash.
+// The file is only updated once its content is stored under the new name and the old copy is only removed after that,
+// so nothing is lost when this is interrupted.
+func (f *File) moveToHash() error {
+	oldName := f.getFileName()
+	old, err := afs.Open(oldName)
+	if err != nil {
+		return err
+	}
+	content, hash, _, err := bufferAndHash(old)
+	_ = old.Close()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = content.Close()
+		_ = os.Remove(content.Name())
+	}()
+
+	s := db.NewSession()
+	defer s.Close()
+	if err := s.Begin(); err != nil {
+		return err
+	}
+
+	f.Hash = hash
+	err = f.storeHashed(s, content)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+	err = s.Commit()
+	if err != nil {
+		return err
+	}
+
+	return afs.Remove(oldName)
+}
+
+func (f *File) storeHashed(s *xorm.Session, content io.Reader) error {
+	err := lockBlob(s, f.Hash)
+	if err != nil {
+		return err
+	}
+
+	exists, err := afs.Exists(f.getFileName())
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err = saveFile(afs, f.getFileName(), content)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Where("id = ?", f.ID).Cols("hash").Update(f)
+	return err
+}
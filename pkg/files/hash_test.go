@@ -0,0 +1,68 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashExistingFiles(t *testing.T) {
+	initFixtures(t)
+
+	// File 3 has the same content as file 1, file 4 is missing in the file backend
+	_, err := x.Insert(&File{ID: 3, Name: "copy", Size: 100, CreatedByID: 1})
+	assert.NoError(t, err)
+	_, err = x.Insert(&File{ID: 4, Name: "missing", Size: 100, CreatedByID: 1})
+	assert.NoError(t, err)
+	err = afs.WriteFile(config.FilesBasePath.GetString()+"/3", []byte("testfile1"), 0644)
+	assert.NoError(t, err)
+
+	hashed, err := HashExistingFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, hashed)
+
+	first := &File{ID: 1}
+	err = first.LoadFileMetaByID()
+	assert.NoError(t, err)
+	copied := &File{ID: 3}
+	err = copied.LoadFileMetaByID()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, first.Hash)
+	assert.Equal(t, first.Hash, copied.Hash)
+	missing := &File{ID: 4}
+	err = missing.LoadFileMetaByID()
+	assert.NoError(t, err)
+	assert.Empty(t, missing.Hash)
+
+	// The content is only stored once, by its hash
+	content, err := afs.ReadFile(config.FilesBasePath.GetString() + "/" + first.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, "testfile1", string(content))
+	for _, name := range []string{"/1", "/2", "/3"} {
+		exists, err := afs.Exists(config.FilesBasePath.GetString() + name)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+
+	// Running it again does nothing
+	hashed, err = HashExistingFiles()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, hashed)
+}
@@ -0,0 +1,88 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"errors"
+	"os"
+
+	"code.vikunja.io/api/pkg/log"
+
+	"github.com/spf13/afero"
+)
+
+// Migrate copies all files from one file backend to another, for example from "local" to "s3".
+// Files which don't exist in the source backend are skipped. The originals are left untouched,
+// after switching files.type to the new backend they can be removed.
+// It returns the number of copied files.
+func Migrate(from, to string) (migrated int, err error) {
+	if from == to {
+		return 0, errors.New("the source and target file backend must be different")
+	}
+
+	source, err := newFileSystem(from)
+	if err != nil {
+		return 0, err
+	}
+	target, err := newFileSystem(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return migrateFiles(&afero.Afero{Fs: source}, &afero.Afero{Fs: target})
+}
+
+func migrateFiles(source, target *afero.Afero) (migrated int, err error) {
+	files := []*File{}
+	err = x.OrderBy("id asc").Find(&files)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range files {
+		// Files with the same content share one stored blob which only needs to be copied once
+		if f.Hash != "" {
+			exists, err := target.Exists(f.getFileName())
+			if err != nil {
+				return migrated, err
+			}
+			if exists {
+				migrated++
+				continue
+			}
+		}
+
+		content, err := source.Open(f.getFileName())
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				log.Warningf("File %d does not exist in the source file backend, skipping it", f.ID)
+				continue
+			}
+			return migrated, err
+		}
+
+		err = saveFile(target, f.getFileName(), content)
+		_ = content.Close()
+		if err != nil {
+			return migrated, err
+		}
+
+		log.Debugf("Migrated file %d", f.ID)
+		migrated++
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,52 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateFiles(t *testing.T) {
+	initFixtures(t)
+	source := afs
+	target := &afero.Afero{Fs: afero.NewMemMapFs()}
+
+	// File 2 is missing in the source and should be skipped
+	err := source.Remove(config.FilesBasePath.GetString() + "/2")
+	assert.NoError(t, err)
+
+	migrated, err := migrateFiles(source, target)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+
+	content, err := target.ReadFile(config.FilesBasePath.GetString() + "/1")
+	assert.NoError(t, err)
+	assert.Equal(t, "testfile1", string(content))
+	exists, err := target.Exists(config.FilesBasePath.GetString() + "/2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestMigrate(t *testing.T) {
+	_, err := Migrate("local", "local")
+	assert.Error(t, err)
+	_, err = Migrate("local", "ftp")
+	assert.Error(t, err)
+}
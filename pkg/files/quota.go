@@ -0,0 +1,125 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+
+	"code.vikunja.io/web"
+	"github.com/c2h5oh/datasize"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// GetStorageUsageForUser returns the size of all files created by a user in bytes.
+// Files with the same content share their stored blob, so their size is only counted once.
+func GetStorageUsageForUser(s *xorm.Session, userID int64) (uint64, error) {
+	return getStorageUsage(s, builder.Eq{"created_by_id": userID})
+}
+
+// GetTotalStorageUsage returns the size of all stored files in bytes
+func GetTotalStorageUsage(s *xorm.Session) (uint64, error) {
+	return getStorageUsage(s, builder.NewCond())
+}
+
+func getStorageUsage(s *xorm.Session, cond builder.Cond) (uint64, error) {
+	// Files created before they were stored by their content hash have a blob of their own
+	unhashed, err := s.
+		Where(cond).
+		And(builder.Or(builder.IsNull{"hash"}, builder.Eq{"hash": ""})).
+		SumInt(&File{}, "size")
+	if err != nil {
+		return 0, err
+	}
+
+	blobs := builder.
+		Select("MAX(size) AS size").
+		From("files").
+		Where(builder.And(cond, builder.NotNull{"hash"}, builder.Neq{"hash": ""})).
+		GroupBy("hash")
+	var hashed int64
+	_, err = s.SQL(builder.Select("COALESCE(SUM(size), 0)").From(blobs, "blobs")).Get(&hashed)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(unhashed + hashed), nil
+}
+
+// storageUsageOf returns how much a new file with the given size and hash adds to the usage matching cond.
+// A file whose content is already stored does not need any more space.
+func storageUsageOf(s *xorm.Session, cond builder.Cond, size uint64, hash string) (uint64, error) {
+	exists, err := s.Where(cond).And("hash = ?", hash).Exist(&File{})
+	if err != nil || exists {
+		return 0, err
+	}
+	return size, nil
+}
+
+// checkStorageQuota makes sure a new file of the given size and content hash fits into the storage quota of the
+// instance and of the user creating it.
+func checkStorageQuota(s *xorm.Session, a web.Auth, size uint64, hash string) error {
+	var instanceQuota datasize.ByteSize
+	err := instanceQuota.UnmarshalText([]byte(config.FilesInstanceQuota.GetString()))
+	if err != nil {
+		return err
+	}
+	if instanceQuota > 0 {
+		usage, err := GetTotalStorageUsage(s)
+		if err != nil {
+			return err
+		}
+		added, err := storageUsageOf(s, builder.NewCond(), size, hash)
+		if err != nil {
+			return err
+		}
+		if usage+added > instanceQuota.Bytes() {
+			return ErrInstanceStorageQuotaExceeded{Quota: instanceQuota.Bytes(), Usage: usage, Size: size}
+		}
+	}
+
+	// Link shares don't have a quota of their own
+	if a.GetID() <= 0 {
+		return nil
+	}
+
+	u, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return err
+	}
+	quota, err := u.GetStorageQuota()
+	if err != nil {
+		return err
+	}
+	if quota == 0 {
+		return nil
+	}
+
+	usage, err := GetStorageUsageForUser(s, u.ID)
+	if err != nil {
+		return err
+	}
+	added, err := storageUsageOf(s, builder.Eq{"created_by_id": u.ID}, size, hash)
+	if err != nil {
+		return err
+	}
+	if usage+added > quota {
+		return ErrStorageQuotaExceeded{UserID: u.ID, Quota: quota, Usage: usage, Size: size}
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"bytes"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUserStorageQuota(t *testing.T, userID int64, quota int64) {
+	s := db.NewSession()
+	defer s.Close()
+	err := (&user.User{ID: userID}).SetStorageQuota(s, quota)
+	assert.NoError(t, err)
+	assert.NoError(t, s.Commit())
+}
+
+func TestGetStorageUsageForUser(t *testing.T) {
+	initFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	usage, err := GetStorageUsageForUser(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(200), usage)
+
+	usage, err = GetStorageUsageForUser(s, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), usage)
+
+	t.Run("Shared content is counted once", func(t *testing.T) {
+		initFixtures(t)
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 50, &testauth{id: 1})
+		assert.NoError(t, err)
+		_, err = Create(bytes.NewReader([]byte("content")), "file copy", 50, &testauth{id: 1})
+		assert.NoError(t, err)
+		_, err = Create(bytes.NewReader([]byte("content")), "file", 50, &testauth{id: 2})
+		assert.NoError(t, err)
+
+		s := db.NewSession()
+		defer s.Close()
+
+		usage, err := GetStorageUsageForUser(s, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(250), usage)
+
+		usage, err = GetStorageUsageForUser(s, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(50), usage)
+
+		usage, err = GetTotalStorageUsage(s)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(250), usage)
+	})
+}
+
+func TestCreate_StorageQuota(t *testing.T) {
+	t.Run("Within the user quota", func(t *testing.T) {
+		initFixtures(t)
+		setUserStorageQuota(t, 1, 250)
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 50, &testauth{id: 1})
+		assert.NoError(t, err)
+	})
+	t.Run("Exceeding the user quota", func(t *testing.T) {
+		initFixtures(t)
+		setUserStorageQuota(t, 1, 250)
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 51, &testauth{id: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+	})
+	t.Run("Storing the same content again", func(t *testing.T) {
+		initFixtures(t)
+		setUserStorageQuota(t, 1, 250)
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 50, &testauth{id: 1})
+		assert.NoError(t, err)
+		_, err = Create(bytes.NewReader([]byte("content")), "file copy", 50, &testauth{id: 1})
+		assert.NoError(t, err)
+		_, err = Create(bytes.NewReader([]byte("other content")), "file", 1, &testauth{id: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+	})
+	t.Run("Unknown size", func(t *testing.T) {
+		initFixtures(t)
+		setUserStorageQuota(t, 1, 210)
+
+		file, err := Create(bytes.NewReader([]byte("content")), "file", 0, &testauth{id: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(7), file.Size)
+
+		_, err = Create(bytes.NewReader([]byte("other content")), "file", 0, &testauth{id: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+	})
+	t.Run("Default quota of existing users", func(t *testing.T) {
+		initFixtures(t)
+		config.DefaultSettingsStorageQuota.Set("250B")
+		defer config.DefaultSettingsStorageQuota.Set("0")
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 51, &testauth{id: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+
+		// A quota of their own replaces the default quota
+		setUserStorageQuota(t, 1, 0)
+		_, err = Create(bytes.NewReader([]byte("content")), "file", 51, &testauth{id: 1})
+		assert.NoError(t, err)
+	})
+	t.Run("Other users are not affected", func(t *testing.T) {
+		initFixtures(t)
+		setUserStorageQuota(t, 1, 250)
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 100, &testauth{id: 2})
+		assert.NoError(t, err)
+	})
+	t.Run("Link share", func(t *testing.T) {
+		initFixtures(t)
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 100, &testauth{id: -2})
+		assert.NoError(t, err)
+	})
+	t.Run("Exceeding the instance quota", func(t *testing.T) {
+		initFixtures(t)
+		config.FilesInstanceQuota.Set("300")
+		defer config.FilesInstanceQuota.Set("0")
+
+		_, err := Create(bytes.NewReader([]byte("content")), "file", 100, &testauth{id: 2})
+		assert.NoError(t, err)
+		_, err = Create(bytes.NewReader([]byte("other content")), "file", 1, &testauth{id: 2})
+		assert.Error(t, err)
+		assert.True(t, IsErrInstanceStorageQuotaExceeded(err))
+	})
+	t.Run("Without checking limits", func(t *testing.T) {
+		initFixtures(t)
+		setUserStorageQuota(t, 1, 100)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := CreateWithMimeAndSession(s, bytes.NewReader([]byte("content")), "file", 100, &testauth{id: 1}, "", false)
+		assert.NoError(t, err)
+	})
+}
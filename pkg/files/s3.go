@@ -0,0 +1,357 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/spf13/afero"
+)
+
+var errS3Unsupported = errors.New("operation not supported by the s3 file backend")
+
+// s3PartSize is the size of the parts uploads are split into. Only one part is held in memory at a time.
+const s3PartSize = 16 * 1024 * 1024
+
+// s3Fs stores files as objects in an s3-compatible object storage.
+// It only implements what Vikunja needs to save, load, stat and delete files, everything else returns an error.
+// Files are streamed from and to the storage, reading a file after seeking in it only fetches the remaining range.
+type s3Fs struct {
+	client *minio.Client
+	bucket string
+}
+
+func newS3Fs(endpoint, bucket, region, accessKey, secretKey string, usePathStyle bool) (*s3Fs, error) {
+	if endpoint == "" || bucket == "" {
+		return nil, errors.New("the s3 file backend needs an endpoint and a bucket")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %s, it needs to be a full url like https://s3.amazonaws.com", endpoint)
+	}
+
+	lookup := minio.BucketLookupDNS
+	if usePathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:       u.Scheme == "https",
+		Region:       region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create s3 client: %w", err)
+	}
+
+	return &s3Fs{
+		client: client,
+		bucket: bucket,
+	}, nil
+}
+
+func (fs *s3Fs) objectKey(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// s3Error turns errors about missing objects into errors which satisfy os.IsNotExist
+func s3Error(op, name string, err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound {
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+	return err
+}
+
+// Name returns the name of this file system
+func (fs *s3Fs) Name() string {
+	return "s3"
+}
+
+// Create returns a file which streams everything written to it to the storage.
+// The upload is only complete once the file is closed.
+func (fs *s3Fs) Create(name string) (afero.File, error) {
+	pr, pw := io.Pipe()
+	f := &s3File{
+		name: name,
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+	go func() {
+		_, err := fs.client.PutObject(context.Background(), fs.bucket, fs.objectKey(name), pr, -1, minio.PutObjectOptions{
+			PartSize: s3PartSize,
+		})
+		// Unblocks the writer if the upload failed before reading everything
+		_ = pr.CloseWithError(err)
+		f.done <- err
+	}()
+	return f, nil
+}
+
+// Mkdir does nothing because object storages don't have directories
+func (fs *s3Fs) Mkdir(_ string, _ os.FileMode) error {
+	return nil
+}
+
+// MkdirAll does nothing because object storages don't have directories
+func (fs *s3Fs) MkdirAll(_ string, _ os.FileMode) error {
+	return nil
+}
+
+// Open returns a read only file which fetches its content from the storage while reading it
+func (fs *s3Fs) Open(name string) (afero.File, error) {
+	obj, err := fs.client.GetObject(context.Background(), fs.bucket, fs.objectKey(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error("open", name, err)
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		return nil, s3Error("open", name, err)
+	}
+	return &s3ReadFile{
+		Object: obj,
+		name:   name,
+		info:   newS3FileInfo(name, info),
+	}, nil
+}
+
+// OpenFile opens a file for reading or creates a new one for writing
+func (fs *s3Fs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	if flag == os.O_RDONLY {
+		return fs.Open(name)
+	}
+	if flag&os.O_CREATE != 0 && flag&os.O_APPEND == 0 {
+		return fs.Create(name)
+	}
+	return nil, errS3Unsupported
+}
+
+// Remove deletes a file
+func (fs *s3Fs) Remove(name string) error {
+	err := fs.client.RemoveObject(context.Background(), fs.bucket, fs.objectKey(name), minio.RemoveObjectOptions{})
+	if err != nil {
+		return s3Error("remove", name, err)
+	}
+	return nil
+}
+
+// RemoveAll is not supported
+func (fs *s3Fs) RemoveAll(_ string) error {
+	return errS3Unsupported
+}
+
+// Rename is not supported
+func (fs *s3Fs) Rename(_, _ string) error {
+	return errS3Unsupported
+}
+
+// Stat returns the size and modification time of a file
+func (fs *s3Fs) Stat(name string) (os.FileInfo, error) {
+	info, err := fs.client.StatObject(context.Background(), fs.bucket, fs.objectKey(name), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error("stat", name, err)
+	}
+	return newS3FileInfo(name, info), nil
+}
+
+// Chmod does nothing because object storages don't have file permissions
+func (fs *s3Fs) Chmod(_ string, _ os.FileMode) error {
+	return nil
+}
+
+// Chown does nothing because object storages don't have file owners
+func (fs *s3Fs) Chown(_ string, _, _ int) error {
+	return nil
+}
+
+// Chtimes is not supported
+func (fs *s3Fs) Chtimes(_ string, _ time.Time, _ time.Time) error {
+	return errS3Unsupported
+}
+
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func newS3FileInfo(name string, info minio.ObjectInfo) *s3FileInfo {
+	return &s3FileInfo{
+		name:    path.Base(name),
+		size:    info.Size,
+		modTime: info.LastModified,
+	}
+}
+
+func (fi *s3FileInfo) Name() string {
+	return fi.name
+}
+
+func (fi *s3FileInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi *s3FileInfo) Mode() os.FileMode {
+	return 0644
+}
+
+func (fi *s3FileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func (fi *s3FileInfo) IsDir() bool {
+	return false
+}
+
+func (fi *s3FileInfo) Sys() interface{} {
+	return nil
+}
+
+// s3ReadFile is a read only file. Reading it streams the object from the storage, seeking makes the next read
+// request only the range from the new offset. This allows serving files with http.ServeContent.
+type s3ReadFile struct {
+	*minio.Object
+	name string
+	info *s3FileInfo
+}
+
+func (f *s3ReadFile) Name() string {
+	return f.name
+}
+
+func (f *s3ReadFile) Stat() (os.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *s3ReadFile) Write(_ []byte) (int, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3ReadFile) WriteAt(_ []byte, _ int64) (int, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3ReadFile) WriteString(_ string) (int, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3ReadFile) Readdir(_ int) ([]os.FileInfo, error) {
+	return nil, errS3Unsupported
+}
+
+func (f *s3ReadFile) Readdirnames(_ int) ([]string, error) {
+	return nil, errS3Unsupported
+}
+
+func (f *s3ReadFile) Sync() error {
+	return nil
+}
+
+func (f *s3ReadFile) Truncate(_ int64) error {
+	return errS3Unsupported
+}
+
+// s3File is a write only file which streams everything written to it to the storage.
+// Content is uploaded in parts of s3PartSize, the upload is complete once the file is closed.
+type s3File struct {
+	name   string
+	pw     *io.PipeWriter
+	done   chan error
+	closed bool
+}
+
+func (f *s3File) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, afero.ErrFileClosed
+	}
+	return f.pw.Write(p)
+}
+
+func (f *s3File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// abort makes the upload fail so that incomplete content is never stored when closing the file
+func (f *s3File) abort(err error) {
+	_ = f.pw.CloseWithError(err)
+}
+
+func (f *s3File) Close() error {
+	if f.closed {
+		return afero.ErrFileClosed
+	}
+	f.closed = true
+
+	if err := f.pw.Close(); err != nil {
+		return err
+	}
+	return <-f.done
+}
+
+func (f *s3File) Name() string {
+	return f.name
+}
+
+func (f *s3File) Stat() (os.FileInfo, error) {
+	return nil, errS3Unsupported
+}
+
+func (f *s3File) Read(_ []byte) (int, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3File) ReadAt(_ []byte, _ int64) (int, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3File) Seek(_ int64, _ int) (int64, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3File) WriteAt(_ []byte, _ int64) (int, error) {
+	return 0, errS3Unsupported
+}
+
+func (f *s3File) Readdir(_ int) ([]os.FileInfo, error) {
+	return nil, errS3Unsupported
+}
+
+func (f *s3File) Readdirnames(_ int) ([]string, error) {
+	return nil, errS3Unsupported
+}
+
+func (f *s3File) Sync() error {
+	return nil
+}
+
+func (f *s3File) Truncate(_ int64) error {
+	return errS3Unsupported
+}
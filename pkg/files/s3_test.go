@@ -0,0 +1,215 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package files
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal in-memory s3 api with path style requests and multipart uploads
+type fakeS3 struct {
+	sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") ||
+		r.Header.Get("X-Amz-Date") == "" ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	query := r.URL.Query()
+	uploadID := query.Get("uploadId")
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID = strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[uploadID] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+	case r.Method == http.MethodPut && uploadID != "":
+		part, _ := strconv.Atoi(query.Get("partNumber"))
+		body, _ := io.ReadAll(r.Body)
+		f.uploads[uploadID][part] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodPost && uploadID != "":
+		parts := f.uploads[uploadID]
+		numbers := make([]int, 0, len(parts))
+		for number := range parts {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		content := []byte{}
+		for _, number := range numbers {
+			content = append(content, parts[number]...)
+		}
+		delete(f.uploads, uploadID)
+		f.objects[r.URL.Path] = content
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><ETag>%s</ETag></CompleteMultipartUploadResult>", etag(content))
+	case r.Method == http.MethodDelete && uploadID != "":
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		content, exists := f.objects[r.URL.Path]
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte("<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>"))
+			}
+			return
+		}
+		w.Header().Set("ETag", etag(content))
+		// Handles range requests
+		http.ServeContent(w, r, "", time.Date(2022, 11, 5, 10, 0, 0, 0, time.UTC), bytes.NewReader(content))
+	}
+}
+
+func etag(content []byte) string {
+	sum := md5.Sum(content)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// newTestS3Fs returns an s3 file system for tests. It uses a real s3-compatible storage like a local MinIO if
+// VIKUNJA_TESTS_S3_ENDPOINT and the other VIKUNJA_TESTS_S3_* variables are set, a fake one otherwise.
+func newTestS3Fs(t *testing.T) *s3Fs {
+	if endpoint := os.Getenv("VIKUNJA_TESTS_S3_ENDPOINT"); endpoint != "" {
+		fs, err := newS3Fs(
+			endpoint,
+			os.Getenv("VIKUNJA_TESTS_S3_BUCKET"),
+			os.Getenv("VIKUNJA_TESTS_S3_REGION"),
+			os.Getenv("VIKUNJA_TESTS_S3_ACCESSKEY"),
+			os.Getenv("VIKUNJA_TESTS_S3_SECRETKEY"),
+			true,
+		)
+		assert.NoError(t, err)
+		return fs
+	}
+
+	server := httptest.NewServer(&fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}})
+	t.Cleanup(server.Close)
+	fs, err := newS3Fs(server.URL, "bucket", "us-east-1", "access", "secret", true)
+	assert.NoError(t, err)
+	return fs
+}
+
+func TestS3Fs(t *testing.T) {
+	fs := newTestS3Fs(t)
+	afs := &afero.Afero{Fs: fs}
+	filename := "files/test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	err := saveFile(afs, filename, bytes.NewReader([]byte("s3 testfile")))
+	assert.NoError(t, err)
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := afs.Stat(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), info.Size())
+	})
+	t.Run("Open", func(t *testing.T) {
+		f, err := afs.Open(filename)
+		assert.NoError(t, err)
+		content, err := io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "s3 testfile", string(content))
+
+		// Serving files needs seeking
+		_, err = f.Seek(3, io.SeekStart)
+		assert.NoError(t, err)
+		content, err = io.ReadAll(f)
+		assert.NoError(t, err)
+		assert.Equal(t, "testfile", string(content))
+		assert.NoError(t, f.Close())
+	})
+	t.Run("ServeContent range", func(t *testing.T) {
+		f, err := afs.Open(filename)
+		assert.NoError(t, err)
+		defer f.Close()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Range", "bytes=3-")
+		rec := httptest.NewRecorder()
+		http.ServeContent(rec, req, "test", time.Now(), f)
+		assert.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, "testfile", rec.Body.String())
+	})
+	t.Run("Remove", func(t *testing.T) {
+		err := afs.Remove(filename)
+		assert.NoError(t, err)
+		_, err = afs.Open(filename)
+		assert.Error(t, err)
+		assert.True(t, os.IsNotExist(err))
+		_, err = afs.Stat(filename)
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("multiple parts", func(t *testing.T) {
+		content := bytes.Repeat([]byte("a"), s3PartSize+10)
+		err := saveFile(afs, filename+"-large", bytes.NewReader(content))
+		assert.NoError(t, err)
+
+		info, err := afs.Stat(filename + "-large")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(content)), info.Size())
+		assert.NoError(t, afs.Remove(filename+"-large"))
+	})
+	t.Run("failed copy is not stored", func(t *testing.T) {
+		err := saveFile(afs, filename+"-failed", io.MultiReader(
+			bytes.NewReader([]byte("incomplete")),
+			iotest.ErrReader(errors.New("read failed")),
+		))
+		assert.Error(t, err)
+
+		_, err = afs.Stat(filename + "-failed")
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestNewS3Fs(t *testing.T) {
+	_, err := newS3Fs("", "bucket", "us-east-1", "", "", false)
+	assert.Error(t, err)
+	_, err = newS3Fs("https://s3.example.com", "", "us-east-1", "", "", false)
+	assert.Error(t, err)
+	_, err = newS3Fs("s3.example.com", "bucket", "us-east-1", "", "", false)
+	assert.Error(t, err)
+}
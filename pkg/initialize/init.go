@@ -98,6 +98,10 @@ func FullInit() {
 	user.RegisterDeletionNotificationCron()
 	models.RegisterUserDeletionCron()
 	models.RegisterOldExportCleanupCron()
+	models.RegisterRealtimeEventCleanupCron()
+	models.RegisterTrashPurgeCron()
+	events.RegisterQueueCleanupCron()
+	files.RegisterUnusedBlobCleanupCron()
 
 	// Start processing events
 	go func() {
@@ -0,0 +1,77 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.vikunja.io/api/pkg/models"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeds(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		t.Run("Events", func(t *testing.T) {
+			rec, err := newTestRequest(t, http.MethodGet, apiv1.GetListFeed, "", nil, map[string]string{"token": "feedtesttoken", "list": "1.ics"})
+			assert.NoError(t, err)
+			assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+			assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:Test1")
+			assert.Contains(t, rec.Body.String(), "BEGIN:VEVENT")
+			assert.Contains(t, rec.Body.String(), "SUMMARY:task #5 higher due date")
+			assert.NotContains(t, rec.Body.String(), "SUMMARY:task #1\n")
+			assert.NotContains(t, rec.Body.String(), "BEGIN:VTODO")
+		})
+		t.Run("Todos", func(t *testing.T) {
+			rec, err := newTestRequest(t, http.MethodGet, apiv1.GetListFeed, "", url.Values{"type": []string{"todos"}}, map[string]string{"token": "feedtesttoken", "list": "1.ics"})
+			assert.NoError(t, err)
+			assert.Contains(t, rec.Body.String(), "BEGIN:VTODO")
+			assert.Contains(t, rec.Body.String(), "SUMMARY:task #1\n")
+			assert.NotContains(t, rec.Body.String(), "BEGIN:VEVENT")
+		})
+		t.Run("Forbidden", func(t *testing.T) {
+			_, err := newTestRequest(t, http.MethodGet, apiv1.GetListFeed, "", nil, map[string]string{"token": "feedtesttoken", "list": "5.ics"})
+			assert.Error(t, err)
+			assertHandlerErrorCode(t, err, models.ErrCodeUserDoesNotHaveAccessToList)
+		})
+		t.Run("Invalid id", func(t *testing.T) {
+			_, err := newTestRequest(t, http.MethodGet, apiv1.GetListFeed, "", nil, map[string]string{"token": "feedtesttoken", "list": "lorem.ics"})
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		})
+	})
+	t.Run("Saved filter", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodGet, apiv1.GetSavedFilterFeed, "", nil, map[string]string{"token": "feedtesttoken", "filter": "1.ics"})
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:testfilter1")
+	})
+	t.Run("Assigned", func(t *testing.T) {
+		rec, err := newTestRequest(t, http.MethodGet, apiv1.GetAssignedTasksFeed, "", url.Values{"type": []string{"todos"}}, map[string]string{"token": "feedtesttoken"})
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:Assigned to user1")
+		assert.Contains(t, rec.Body.String(), "SUMMARY:task #30 with assignees")
+		assert.NotContains(t, rec.Body.String(), "SUMMARY:task #1\n")
+	})
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := newTestRequest(t, http.MethodGet, apiv1.GetListFeed, "", nil, map[string]string{"token": "invalidtoken", "list": "1.ics"})
+		assert.Error(t, err)
+		assertHandlerErrorCode(t, err, user.ErrCodeInvalidFeedToken)
+	})
+}
@@ -0,0 +1,61 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package integrations
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"code.vikunja.io/api/pkg/files"
+	apiv1 "code.vikunja.io/api/pkg/routes/api/v1"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// getTaskAttachment needs its own request setup because the test env replaces the file system
+// with an empty one which does not contain the fixture files.
+func getTaskAttachment(t *testing.T, queryParams url.Values) (rec *httptest.ResponseRecorder, err error) {
+	rec, c := testRequestSetup(t, http.MethodGet, "", queryParams, map[string]string{"task": "1", "attachment": "1"})
+	files.InitTestFileFixtures(t)
+	addUserTokenToContext(t, &testuser1, c)
+	err = apiv1.GetTaskAttachment(c)
+	return
+}
+
+func TestTaskAttachmentDownload(t *testing.T) {
+	t.Run("Original", func(t *testing.T) {
+		rec, err := getTaskAttachment(t, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "testfile1", rec.Body.String())
+	})
+	t.Run("Preview", func(t *testing.T) {
+		rec, err := getTaskAttachment(t, url.Values{"preview_size": []string{"sm"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "testfile2", rec.Body.String())
+	})
+	t.Run("Nonexisting preview", func(t *testing.T) {
+		rec, err := getTaskAttachment(t, url.Values{"preview_size": []string{"xl"}})
+		assert.NoError(t, err)
+		assert.Equal(t, "testfile1", rec.Body.String())
+	})
+	t.Run("Invalid preview size", func(t *testing.T) {
+		_, err := getTaskAttachment(t, url.Values{"preview_size": []string{"huge"}})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	})
+}
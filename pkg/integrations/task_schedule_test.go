@@ -0,0 +1,76 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package integrations
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		testHandler := webHandlerTest{
+			user: &testuser1,
+			strFunc: func() handler.CObject {
+				return &models.ListSchedule{}
+			},
+			t: t,
+		}
+		t.Run("Normal", func(t *testing.T) {
+			rec, err := testHandler.testReadOneWithUser(nil, map[string]string{"list": "1"})
+			assert.NoError(t, err)
+			assert.Contains(t, rec.Body.String(), `"list_id":1,`)
+			assert.Contains(t, rec.Body.String(), `"task_id":1,`)
+		})
+		t.Run("Forbidden", func(t *testing.T) {
+			_, err := testHandler.testReadOneWithUser(nil, map[string]string{"list": "20"})
+			assert.Error(t, err)
+			assert.Contains(t, err.(*echo.HTTPError).Message, `You don't have the right to see this`)
+		})
+	})
+	t.Run("Saved filter", func(t *testing.T) {
+		testHandler := webHandlerTest{
+			user: &testuser1,
+			strFunc: func() handler.CObject {
+				return &models.SavedFilterSchedule{}
+			},
+			t: t,
+		}
+		t.Run("Normal", func(t *testing.T) {
+			rec, err := testHandler.testReadOneWithUser(nil, map[string]string{"filter": "1"})
+			assert.NoError(t, err)
+			// The pseudo list of saved filter 1
+			assert.Contains(t, rec.Body.String(), `"list_id":-2,`)
+			assert.Contains(t, rec.Body.String(), `"tasks":[{`)
+		})
+		t.Run("Nonexisting", func(t *testing.T) {
+			_, err := testHandler.testReadOneWithUser(nil, map[string]string{"filter": "9999"})
+			assert.Error(t, err)
+			assertHandlerErrorCode(t, err, models.ErrCodeSavedFilterDoesNotExist)
+		})
+		t.Run("Forbidden", func(t *testing.T) {
+			testHandler.user = &testuser2
+			defer func() { testHandler.user = &testuser1 }()
+			_, err := testHandler.testReadOneWithUser(nil, map[string]string{"filter": "1"})
+			assert.Error(t, err)
+			assert.Contains(t, err.(*echo.HTTPError).Message, `You don't have the right to see this`)
+		})
+	})
+}
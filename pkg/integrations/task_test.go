@@ -96,22 +96,32 @@ func TestTask(t *testing.T) {
 				assert.NotContains(t, rec.Body.String(), `"due_date":"2020-02-10T10:00:00Z"`)
 			})
 			t.Run("Reminders", func(t *testing.T) {
-				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"reminder_dates": ["2020-02-10T10:00:00Z","2020-02-11T10:00:00Z"]}`)
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"reminders": [{"reminder": "2020-02-10T10:00:00Z"},{"reminder": "2020-02-11T10:00:00Z"}]}`)
 				assert.NoError(t, err)
-				assert.Contains(t, rec.Body.String(), `"reminder_dates":["2020-02-10T10:00:00Z","2020-02-11T10:00:00Z"]`)
-				assert.NotContains(t, rec.Body.String(), `"reminder_dates": null`)
+				assert.Contains(t, rec.Body.String(), `"reminders":[{"reminder":"2020-02-10T10:00:00Z","relative_period":0,"relative_to":""},{"reminder":"2020-02-11T10:00:00Z","relative_period":0,"relative_to":""}]`)
+				assert.NotContains(t, rec.Body.String(), `"reminders":null`)
+			})
+			t.Run("Relative reminders", func(t *testing.T) {
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"due_date": "2020-02-10T10:00:00Z", "reminders": [{"relative_to": "due_date", "relative_period": -3600}]}`)
+				assert.NoError(t, err)
+				assert.Contains(t, rec.Body.String(), `"reminders":[{"reminder":"2020-02-10T09:00:00Z","relative_period":-3600,"relative_to":"due_date"}]`)
+			})
+			t.Run("Relative reminders without the date they're relative to", func(t *testing.T) {
+				_, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"reminders": [{"relative_to": "start_date", "relative_period": -3600}]}`)
+				assert.Error(t, err)
+				assertHandlerErrorCode(t, err, models.ErrCodeReminderRelativeToMissing)
 			})
 			t.Run("Reminders unset to empty array", func(t *testing.T) {
-				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "27"}, `{"reminder_dates": []}`)
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "27"}, `{"reminders": []}`)
 				assert.NoError(t, err)
-				assert.Contains(t, rec.Body.String(), `"reminder_dates":null`)
-				assert.NotContains(t, rec.Body.String(), `"reminder_dates":[1543626724,1543626824]`)
+				assert.Contains(t, rec.Body.String(), `"reminders":null`)
+				assert.NotContains(t, rec.Body.String(), `"reminder":"2018-12-01T01:12:04Z"`)
 			})
 			t.Run("Reminders unset to null", func(t *testing.T) {
-				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "27"}, `{"reminder_dates": null}`)
+				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "27"}, `{"reminders": null}`)
 				assert.NoError(t, err)
-				assert.Contains(t, rec.Body.String(), `"reminder_dates":null`)
-				assert.NotContains(t, rec.Body.String(), `"reminder_dates":[1543626724,1543626824]`)
+				assert.Contains(t, rec.Body.String(), `"reminders":null`)
+				assert.NotContains(t, rec.Body.String(), `"reminder":"2018-12-01T01:12:04Z"`)
 			})
 			t.Run("Repeat after", func(t *testing.T) {
 				rec, err := testHandler.testUpdateWithUser(nil, map[string]string{"listtask": "1"}, `{"repeat_after":3600}`)
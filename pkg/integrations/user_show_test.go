@@ -31,5 +31,7 @@ func TestUserShow(t *testing.T) {
 		assert.Contains(t, rec.Body.String(), `"id":1`)
 		assert.Contains(t, rec.Body.String(), `"username":"user1"`)
 		assert.NotContains(t, rec.Body.String(), `"email":""`)
+		assert.Contains(t, rec.Body.String(), `"storage_usage":200`)
+		assert.Contains(t, rec.Body.String(), `"storage_quota":0`)
 	})
 }
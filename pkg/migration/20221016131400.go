@@ -0,0 +1,70 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type webhooks20221016131400 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	TargetURL   string    `xorm:"text not null" json:"target_url"`
+	Events      []string  `xorm:"JSON not null" json:"events"`
+	Secret      string    `xorm:"text null" json:"secret"`
+	ListID      int64     `xorm:"bigint null index" json:"list_id"`
+	NamespaceID int64     `xorm:"bigint null index" json:"namespace_id"`
+	CreatedByID int64     `xorm:"bigint not null" json:"-"`
+	Created     time.Time `xorm:"created not null" json:"created"`
+	Updated     time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (webhooks20221016131400) TableName() string {
+	return "webhooks"
+}
+
+type webhookDeliveries20221016131400 struct {
+	ID         int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	WebhookID  int64     `xorm:"bigint not null index" json:"webhook_id"`
+	MessageID  string    `xorm:"varchar(50) not null index" json:"message_id"`
+	EventName  string    `xorm:"varchar(250) not null" json:"event_name"`
+	Attempt    int       `xorm:"not null default 1" json:"attempt"`
+	Payload    string    `xorm:"longtext null" json:"payload"`
+	StatusCode int       `xorm:"null" json:"status_code"`
+	Response   string    `xorm:"text null" json:"response"`
+	Success    bool      `xorm:"not null default false" json:"success"`
+	Created    time.Time `xorm:"created not null" json:"created"`
+}
+
+func (webhookDeliveries20221016131400) TableName() string {
+	return "webhook_deliveries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221016131400",
+		Description: "Add webhooks and webhook deliveries",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(webhooks20221016131400{}, webhookDeliveries20221016131400{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,51 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type realtimeEvents20221018140500 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	EventName   string    `xorm:"varchar(250) not null" json:"event_name"`
+	TaskID      int64     `xorm:"bigint null" json:"task_id"`
+	ListID      int64     `xorm:"bigint null index" json:"list_id"`
+	NamespaceID int64     `xorm:"bigint null" json:"namespace_id"`
+	Payload     string    `xorm:"longtext null" json:"payload"`
+	Created     time.Time `xorm:"created not null index" json:"created"`
+}
+
+func (realtimeEvents20221018140500) TableName() string {
+	return "realtime_events"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221018140500",
+		Description: "Add realtime events table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(realtimeEvents20221018140500{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,81 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type eventsQueue20221020103000 struct {
+	ID       int64     `xorm:"bigint autoincr not null unique pk"`
+	Topic    string    `xorm:"varchar(250) not null index"`
+	UUID     string    `xorm:"varchar(50) not null"`
+	Payload  string    `xorm:"longtext not null"`
+	Metadata string    `xorm:"longtext null"`
+	Created  time.Time `xorm:"created not null"`
+}
+
+func (eventsQueue20221020103000) TableName() string {
+	return "events_queue"
+}
+
+type eventsConsumers20221020103000 struct {
+	ConsumerGroup string    `xorm:"varchar(250) not null pk"`
+	Topic         string    `xorm:"varchar(250) not null index"`
+	LastEventID   int64     `xorm:"bigint not null default 0"`
+	LockedBy      string    `xorm:"varchar(250) null"`
+	LockedUntil   time.Time `xorm:"null"`
+}
+
+func (eventsConsumers20221020103000) TableName() string {
+	return "events_consumers"
+}
+
+type eventsPoisoned20221020103000 struct {
+	ID       int64     `xorm:"bigint autoincr not null unique pk"`
+	UUID     string    `xorm:"varchar(50) not null"`
+	Topic    string    `xorm:"varchar(250) not null index"`
+	Handler  string    `xorm:"varchar(250) not null"`
+	Reason   string    `xorm:"text null"`
+	Payload  string    `xorm:"longtext not null"`
+	Metadata string    `xorm:"longtext null"`
+	Created  time.Time `xorm:"created not null"`
+}
+
+func (eventsPoisoned20221020103000) TableName() string {
+	return "events_poisoned"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221020103000",
+		Description: "Add tables for the sql events transport and poisoned events",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(
+				eventsQueue20221020103000{},
+				eventsConsumers20221020103000{},
+				eventsPoisoned20221020103000{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
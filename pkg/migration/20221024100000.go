@@ -0,0 +1,112 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"strconv"
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type tasks20221024100000 struct {
+	ID               int64       `xorm:"bigint autoincr not null unique pk"`
+	RepeatAfter      int64       `xorm:"bigint INDEX null"`
+	RepeatMode       int         `xorm:"not null default 0"`
+	RepeatRule       string      `xorm:"text null"`
+	RepeatExceptions []time.Time `xorm:"JSON null"`
+}
+
+func (tasks20221024100000) TableName() string {
+	return "tasks"
+}
+
+// repeatAfterToRule20221024100000 returns a recurrence rule with the largest frequency the interval fits in.
+func repeatAfterToRule20221024100000(seconds int64) string {
+	frequencies := []struct {
+		freq    string
+		seconds int64
+	}{
+		{"WEEKLY", 60 * 60 * 24 * 7},
+		{"DAILY", 60 * 60 * 24},
+		{"HOURLY", 60 * 60},
+		{"MINUTELY", 60},
+		{"SECONDLY", 1},
+	}
+
+	for _, f := range frequencies {
+		if seconds%f.seconds != 0 {
+			continue
+		}
+		interval := seconds / f.seconds
+		if interval == 1 {
+			return "FREQ=" + f.freq
+		}
+		return "FREQ=" + f.freq + ";INTERVAL=" + strconv.FormatInt(interval, 10)
+	}
+
+	return ""
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221024100000",
+		Description: "Add repeat rules to tasks and migrate the existing repeat modes to them",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(tasks20221024100000{})
+			if err != nil {
+				return err
+			}
+
+			const (
+				repeatModeDefault         = 0
+				repeatModeMonth           = 1
+				repeatModeFromCurrentDate = 2
+			)
+
+			tasks := []*tasks20221024100000{}
+			err = tx.Where("repeat_after > 0 OR repeat_mode = ?", repeatModeMonth).Find(&tasks)
+			if err != nil {
+				return err
+			}
+
+			for _, task := range tasks {
+				// repeat_after and repeat_mode stay as they are for clients which don't know about repeat rules.
+				// Repeating from the current date is still handled by the repeat mode.
+				switch task.RepeatMode {
+				case repeatModeMonth:
+					task.RepeatRule = "FREQ=MONTHLY"
+				case repeatModeDefault, repeatModeFromCurrentDate:
+					task.RepeatRule = repeatAfterToRule20221024100000(task.RepeatAfter)
+				}
+
+				_, err = tx.Where("id = ?", task.ID).
+					Cols("repeat_rule").
+					Update(task)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
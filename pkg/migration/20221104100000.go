@@ -0,0 +1,53 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskAttachments20221104100000 struct {
+	BlurHash string `xorm:"varchar(50) null" json:"blur_hash"`
+}
+
+func (taskAttachments20221104100000) TableName() string {
+	return "task_attachments"
+}
+
+type taskAttachmentPreviews20221104100000 struct {
+	ID               int64  `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskAttachmentID int64  `xorm:"bigint not null INDEX" json:"-"`
+	Size             string `xorm:"varchar(2) not null" json:"-"`
+	FileID           int64  `xorm:"bigint not null" json:"-"`
+}
+
+func (taskAttachmentPreviews20221104100000) TableName() string {
+	return "task_attachment_previews"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221104100000",
+		Description: "Add previews and blur hashes of image attachments",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskAttachments20221104100000{}, taskAttachmentPreviews20221104100000{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
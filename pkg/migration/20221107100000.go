@@ -0,0 +1,49 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type fileBlobs20221107100000 struct {
+	Hash string `xorm:"varchar(64) not null pk"`
+}
+
+func (fileBlobs20221107100000) TableName() string {
+	return "file_blobs"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20221107100000",
+		Description: "Add a table for the stored content of files to lock it while files start or stop using it",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(fileBlobs20221107100000{})
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec("INSERT INTO file_blobs (hash) SELECT DISTINCT hash FROM files WHERE hash IS NOT NULL AND hash != ''")
+			return err
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
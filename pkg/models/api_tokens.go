@@ -0,0 +1,318 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/web"
+
+	"golang.org/x/crypto/pbkdf2"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// APITokenPrefix is prepended to every api token to make them distinguishable from jwt tokens
+const APITokenPrefix = `tk_`
+
+const (
+	apiTokenLength         = 40
+	apiTokenSaltLength     = 10
+	apiTokenHashIterations = 10000
+	apiTokenHashLength     = 50
+)
+
+// APIToken is a long-lived personal access token which can be used to access the api with a limited set of scopes
+type APIToken struct {
+	// The unique, numeric id of this api token.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"token"`
+	// A human-readable name for this token
+	Title string `xorm:"not null" json:"title" valid:"required"`
+	// The actual api token. Only shown once, when creating the token.
+	Token string `xorm:"-" json:"token,omitempty"`
+
+	TokenSalt      string `xorm:"not null" json:"-"`
+	TokenHash      string `xorm:"not null unique" json:"-"`
+	TokenLastEight string `xorm:"not null index varchar(8)" json:"-"`
+
+	// The scopes this token has. Possible values are available via the /user/settings/token/scopes endpoint.
+	Scopes []string `xorm:"JSON not null" json:"scopes" valid:"required"`
+	// The date when this token expires.
+	ExpiresAt time.Time `xorm:"not null" json:"expires_at" valid:"required"`
+
+	// A timestamp when this token was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	OwnerID int64     `xorm:"bigint not null" json:"-"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for api tokens
+func (*APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// These are all scopes an api token can have. A write scope includes the read scope of the same resource,
+// an admin scope includes the write and read scopes.
+var apiTokenScopes = []string{
+	"tasks:read",
+	"tasks:write",
+	"lists:read",
+	"lists:write",
+	"lists:admin",
+	"namespaces:read",
+	"namespaces:write",
+	"namespaces:admin",
+	"labels:read",
+	"labels:write",
+	"teams:read",
+	"teams:write",
+	"filters:read",
+	"filters:write",
+	"notifications:read",
+	"notifications:write",
+	"user:read",
+}
+
+const (
+	apiTokenScopeLevelRead  = "read"
+	apiTokenScopeLevelWrite = "write"
+	apiTokenScopeLevelAdmin = "admin"
+)
+
+var apiTokenScopeLevels = map[string]int{
+	apiTokenScopeLevelRead:  0,
+	apiTokenScopeLevelWrite: 1,
+	apiTokenScopeLevelAdmin: 2,
+}
+
+// GetAvailableAPITokenScopes returns all scopes an api token can have
+func GetAvailableAPITokenScopes() []string {
+	return apiTokenScopes
+}
+
+func isValidAPITokenScope(scope string) bool {
+	for _, s := range apiTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAPITokenScopeForRoute returns the scope which is needed to access a route with an api token.
+// The path is the route as registered with echo, without the /api/v1 prefix.
+// If no scope allows access to the route, an empty string is returned.
+func GetAPITokenScopeForRoute(method, path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) == 0 {
+		return ""
+	}
+
+	resource := parts[0]
+	switch resource {
+	case "tasks", "lists", "namespaces", "labels", "teams", "filters", "notifications", "user":
+	case "subscriptions":
+		resource = "notifications"
+	case "tasktemplates":
+		resource = "tasks"
+	default:
+		return ""
+	}
+
+	// Everything below a task or the tasks of a list are tasks
+	for i, p := range parts {
+		if p == "tasks" && i > 0 && (resource == "lists" || resource == "namespaces") {
+			resource = "tasks"
+		}
+	}
+
+	level := apiTokenScopeLevelWrite
+	if method == "GET" {
+		level = apiTokenScopeLevelRead
+	}
+
+	if resource == "lists" || resource == "namespaces" {
+		if method == "DELETE" && len(parts) == 2 {
+			level = apiTokenScopeLevelAdmin
+		}
+		// Managing who has access to a list or namespace and where its data goes always needs admin rights
+		if len(parts) > 2 {
+			switch parts[2] {
+			case "users", "teams", "shares", "webhooks":
+				level = apiTokenScopeLevelAdmin
+			case "restore":
+				// Restoring needs the same right as moving it to the trash
+				level = apiTokenScopeLevelAdmin
+			case "custom-fields":
+				// Only list admins can manage custom fields
+				if method != "GET" {
+					level = apiTokenScopeLevelAdmin
+				}
+			}
+		}
+	}
+
+	scope := resource + ":" + level
+	if !isValidAPITokenScope(scope) {
+		return ""
+	}
+	return scope
+}
+
+// HasScope checks if the token has a scope or a scope which includes it
+func (t *APIToken) HasScope(scope string) bool {
+	resource, level, found := strings.Cut(scope, ":")
+	if !found {
+		return false
+	}
+
+	for _, s := range t.Scopes {
+		r, l, _ := strings.Cut(s, ":")
+		if r == resource && apiTokenScopeLevels[l] >= apiTokenScopeLevels[level] {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIToken(token, salt string) string {
+	hash := pbkdf2.Key([]byte(token), []byte(salt), apiTokenHashIterations, apiTokenHashLength, sha256.New)
+	return hex.EncodeToString(hash)
+}
+
+// GetAPITokenByTokenString returns the api token matching a clear text token.
+// Expired tokens are returned as well, the caller needs to check the expiry date.
+func GetAPITokenByTokenString(s *xorm.Session, token string) (apiToken *APIToken, err error) {
+	if !strings.HasPrefix(token, APITokenPrefix) || len(token) < 8 {
+		return nil, &ErrAPITokenInvalid{}
+	}
+
+	candidates := []*APIToken{}
+	err = s.Where("token_last_eight = ?", token[len(token)-8:]).Find(&candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range candidates {
+		hash := hashAPIToken(token, t.TokenSalt)
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(t.TokenHash)) == 1 {
+			return t, nil
+		}
+	}
+
+	return nil, &ErrAPITokenInvalid{}
+}
+
+// Create creates a new api token for the current user
+// @Summary Create a new api token
+// @Description Creates a new api token for the current user. The token is only returned once, make sure to save it.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param token body models.APIToken true "The token object with required fields"
+// @Success 200 {object} models.APIToken "The created api token."
+// @Failure 400 {object} web.HTTPError "Invalid token object provided."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/token [put]
+func (t *APIToken) Create(s *xorm.Session, a web.Auth) (err error) {
+	if len(t.Scopes) == 0 {
+		return &ErrInvalidAPITokenScope{}
+	}
+	for _, scope := range t.Scopes {
+		if !isValidAPITokenScope(scope) {
+			return &ErrInvalidAPITokenScope{Scope: scope}
+		}
+	}
+
+	if !t.ExpiresAt.After(time.Now()) {
+		return &ErrAPITokenExpiryInPast{}
+	}
+
+	t.ID = 0
+	t.OwnerID = a.GetID()
+	t.Token = APITokenPrefix + utils.MakeRandomString(apiTokenLength)
+	t.TokenSalt = utils.MakeRandomString(apiTokenSaltLength)
+	t.TokenHash = hashAPIToken(t.Token, t.TokenSalt)
+	t.TokenLastEight = t.Token[len(t.Token)-8:]
+
+	_, err = s.Insert(t)
+	return err
+}
+
+// ReadAll returns all api tokens of the current user
+// @Summary Get all api tokens of the current user
+// @Description Returns all api tokens the current user has created. The tokens themselves are never returned.
+// @tags user
+// @Accept json
+// @Produce json
+// @Param page query int false "The page number, used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of tokens per page. This parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search tokens by their title."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.APIToken "The list of all tokens"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /user/settings/token [get]
+func (t *APIToken) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	tokens := []*APIToken{}
+
+	var where builder.Cond = builder.Eq{"owner_id": a.GetID()}
+	if search != "" {
+		where = builder.And(where, db.ILIKE("title", search))
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	query := s.Where(where).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&tokens)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalCount, err := s.Where(where).Count(&APIToken{})
+	return tokens, len(tokens), totalCount, err
+}
+
+// Delete revokes an api token
+// @Summary Deletes an existing api token
+// @Description Delete any of the current user's api tokens. The token can't be used anymore afterwards.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Param token path int true "Token ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The token does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /user/settings/token/{token} [delete]
+func (t *APIToken) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = s.Where("id = ? AND owner_id = ?", t.ID, a.GetID()).Delete(&APIToken{})
+	return err
+}
@@ -0,0 +1,219 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	token := &APIToken{}
+	tokens, count, total, err := token.ReadAll(s, u, "", 1, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, tokens, 2)
+	assert.Empty(t, tokens.([]*APIToken)[0].Token)
+}
+
+func TestAPIToken_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:read", "lists:admin"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		can, err := token.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		err = token.Create(s, u)
+		assert.NoError(t, err)
+		assert.Regexp(t, "^"+APITokenPrefix, token.Token)
+
+		db.AssertExists(t, "api_tokens", map[string]interface{}{
+			"id":               token.ID,
+			"owner_id":         u.ID,
+			"token_last_eight": token.Token[len(token.Token)-8:],
+		}, false)
+
+		fromDB, err := GetAPITokenByTokenString(s, token.Token)
+		assert.NoError(t, err)
+		assert.Equal(t, token.ID, fromDB.ID)
+	})
+	t.Run("invalid scope", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:admin"},
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		err := token.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAPITokenScope(err))
+	})
+	t.Run("expiry in the past", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{
+			Title:     "new token",
+			Scopes:    []string{"tasks:read"},
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}
+		err := token.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenExpiryInPast(err))
+	})
+	t.Run("forbidden for link shares", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{}
+		can, err := token.CanCreate(s, &LinkSharing{})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestAPIToken_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{ID: 1}
+		can, err := token.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		err = token.Delete(s, u)
+		assert.NoError(t, err)
+		db.AssertMissing(t, "api_tokens", map[string]interface{}{"id": 1})
+	})
+	t.Run("token of another user", func(t *testing.T) {
+		u := &user.User{ID: 2}
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{ID: 1}
+		can, err := token.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token := &APIToken{ID: 9999}
+		_, err := token.CanDelete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenDoesNotExist(err))
+	})
+}
+
+func TestGetAPITokenByTokenString(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	t.Run("valid", func(t *testing.T) {
+		token, err := GetAPITokenByTokenString(s, "tk_2eef46f40ebab3304919ab2e7e39993f75f29d2e")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), token.ID)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := GetAPITokenByTokenString(s, "tk_loremipsum75f29d2e")
+		assert.Error(t, err)
+		assert.True(t, IsErrAPITokenInvalid(err))
+	})
+}
+
+func TestGetAPITokenScopeForRoute(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{"GET", "/tasks/all", "tasks:read"},
+		{"POST", "/tasks/:listtask", "tasks:write"},
+		{"PUT", "/tasks/:task/comments", "tasks:write"},
+		{"GET", "/lists/:list/tasks", "tasks:read"},
+		{"PUT", "/lists/:list", "lists:write"},
+		{"GET", "/lists", "lists:read"},
+		{"DELETE", "/lists/:list", "lists:admin"},
+		{"PUT", "/lists/:list/users", "lists:admin"},
+		{"GET", "/lists/:list/webhooks", "lists:admin"},
+		{"GET", "/lists/:list/custom-fields", "lists:read"},
+		{"PUT", "/lists/:list/custom-fields", "lists:admin"},
+		{"GET", "/tasktemplates", "tasks:read"},
+		{"PUT", "/tasktemplates/:tasktemplate/tasks", "tasks:write"},
+		{"PUT", "/tasks/quick-add", "tasks:write"},
+		{"PUT", "/namespaces/:namespace/lists", "namespaces:write"},
+		{"PUT", "/namespaces/:namespace/restore", "namespaces:admin"},
+		{"PUT", "/tasks/:task/restore", "tasks:write"},
+		{"GET", "/trash", ""},
+		{"GET", "/user", "user:read"},
+		{"PUT", "/user/settings/token", ""},
+		{"POST", "/user/password", ""},
+		{"POST", "/tokenTest", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			assert.Equal(t, test.scope, GetAPITokenScopeForRoute(test.method, test.path))
+		})
+	}
+}
+
+func TestAPIToken_HasScope(t *testing.T) {
+	token := &APIToken{Scopes: []string{"tasks:write", "lists:admin", "labels:read"}}
+
+	assert.True(t, token.HasScope("tasks:read"))
+	assert.True(t, token.HasScope("tasks:write"))
+	assert.True(t, token.HasScope("lists:read"))
+	assert.True(t, token.HasScope("lists:admin"))
+	assert.True(t, token.HasScope("labels:read"))
+	assert.False(t, token.HasScope("labels:write"))
+	assert.False(t, token.HasScope("namespaces:read"))
+}
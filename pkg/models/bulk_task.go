@@ -17,6 +17,8 @@
 package models
 
 import (
+	"time"
+
 	"code.vikunja.io/web"
 	"github.com/imdario/mergo"
 	"xorm.io/xorm"
@@ -79,10 +81,40 @@ func (bt *BulkTask) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/bulk [post]
 func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
+	if !bt.Task.repeatRuleSent && bt.Task.RepeatRule == "" {
+		bt.Task.RepeatRule = repeatRuleFromLegacyFields(bt.Task.RepeatAfter, bt.Task.RepeatMode)
+	}
+	if err := bt.Task.validateRepeatRule(); err != nil {
+		return err
+	}
+
+	var loc *time.Location
 	for _, oldtask := range bt.Tasks {
 
+		oldActivityValues := getTaskActivityValues(oldtask)
+		oldTitle, oldDescription := oldtask.Title, oldtask.Description
+		oldEndDate := oldtask.EndDate
+		allDay := oldtask.AllDay
+		allDayDatesBefore := getAllDayDates(oldtask)
+
+		if bt.Task.Done && !oldtask.Done {
+			if err := checkTaskIsNotBlocked(s, oldtask); err != nil {
+				return err
+			}
+		}
+
 		// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
+		wasCompleted := bt.Task.Done && !oldtask.Done
 		updateDone(oldtask, &bt.Task)
+		if wasCompleted && (!bt.Task.Done || oldtask.isRepeating()) {
+			if err := recordTaskCompletion(s, a, oldtask, &bt.Task); err != nil {
+				return err
+			}
+		}
+		if wasCompleted && !bt.Task.Done {
+			allDayDatesBefore = getAllDayDates(&bt.Task)
+			allDayDatesBefore.AllDay = allDay
+		}
 
 		// Update the assignees
 		if err := oldtask.updateTaskAssignees(s, bt.Assignees, a); err != nil {
@@ -101,6 +133,24 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 			oldtask.Done = false
 		}
 
+		// repeat_after and repeat_mode need to describe the new repeat rule of the task
+		if err := oldtask.validateRepeatRule(); err != nil {
+			return err
+		}
+
+		// Bulk updates do not change whether a task is all-day, but new dates of all-day tasks are calendar dates
+		oldtask.AllDay = allDay
+		if oldtask.AllDay {
+			if loc == nil {
+				loc, err = getTimezoneForAuth(s, a)
+				if err != nil {
+					return err
+				}
+			}
+			oldtask.dateOnlyFields = bt.Task.dateOnlyFields
+			oldtask.normalizeAllDayDates(loc, allDayDatesBefore)
+		}
+
 		_, err = s.ID(oldtask.ID).
 			Cols("title",
 				"description",
@@ -108,6 +158,9 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 				"due_date",
 				"reminders",
 				"repeat_after",
+				"repeat_mode",
+				"repeat_rule",
+				"repeat_exceptions",
 				"priority",
 				"start_date",
 				"end_date").
@@ -115,6 +168,29 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 		if err != nil {
 			return err
 		}
+
+		// The dates of the task might have changed, relative reminders need to follow them
+		err = oldtask.updateRelativeReminders(s)
+		if err != nil {
+			return err
+		}
+
+		err = recordTaskUpdateActivity(s, a, oldtask.ID, oldActivityValues, getTaskActivityValues(oldtask))
+		if err != nil {
+			return err
+		}
+
+		err = recordTaskRevision(s, a, oldtask, oldTitle, oldDescription)
+		if err != nil {
+			return err
+		}
+
+		if oldtask.EndDate.After(oldEndDate) {
+			err = shiftFollowingTasks(s, a, oldtask)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return
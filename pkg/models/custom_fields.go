@@ -0,0 +1,741 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"github.com/vectordotdev/go-datemath"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// CustomFieldType is the type of the values of a custom field
+type CustomFieldType string
+
+// All types a custom field can have
+const (
+	CustomFieldTypeText        CustomFieldType = "text"
+	CustomFieldTypeNumber      CustomFieldType = "number"
+	CustomFieldTypeDate        CustomFieldType = "date"
+	CustomFieldTypeSelect      CustomFieldType = "select"
+	CustomFieldTypeMultiSelect CustomFieldType = "multiselect"
+	CustomFieldTypeUser        CustomFieldType = "user"
+)
+
+func (ct CustomFieldType) isValid() bool {
+	switch ct {
+	case
+		CustomFieldTypeText,
+		CustomFieldTypeNumber,
+		CustomFieldTypeDate,
+		CustomFieldTypeSelect,
+		CustomFieldTypeMultiSelect,
+		CustomFieldTypeUser:
+		return true
+	}
+	return false
+}
+
+func (ct CustomFieldType) hasOptions() bool {
+	return ct == CustomFieldTypeSelect || ct == CustomFieldTypeMultiSelect
+}
+
+// valueColumn returns the column of task_custom_field_values holding values of this type
+func (ct CustomFieldType) valueColumn() string {
+	switch ct {
+	case CustomFieldTypeNumber:
+		return "number_value"
+	case CustomFieldTypeDate:
+		return "date_value"
+	case CustomFieldTypeUser:
+		return "user_id"
+	default:
+		return "text_value"
+	}
+}
+
+// CustomField is an additional, typed attribute every task in a list can have
+type CustomField struct {
+	// The unique, numeric id of this custom field.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"customfield"`
+	ListID int64 `xorm:"bigint not null index" json:"list_id" param:"list"`
+
+	// The title of the custom field.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The type of the values of this field. Can be one of text, number, date, select, multiselect or user.
+	// It cannot be changed after the field was created.
+	Type CustomFieldType `xorm:"varchar(50) not null" json:"type"`
+	// The values users can choose from. Only used (and required) for select and multiselect fields.
+	// When removing an option, it is also removed from all tasks.
+	Options []string `xorm:"JSON null" json:"options"`
+
+	// The user who created this custom field.
+	CreatedBy   *user.User `xorm:"-" json:"created_by"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this custom field was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this custom field was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the custom fields table
+func (*CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// TaskCustomFieldValue holds the value of a custom field for one task. Multiselect fields have one row per selected option.
+type TaskCustomFieldValue struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk"`
+	TaskID      int64     `xorm:"bigint not null index"`
+	FieldID     int64     `xorm:"bigint not null index"`
+	TextValue   string    `xorm:"text null"`
+	NumberValue float64   `xorm:"double null"`
+	DateValue   time.Time `xorm:"DATETIME null"`
+	UserID      int64     `xorm:"bigint null"`
+}
+
+// TableName holds the table name for the task custom field values table
+func (*TaskCustomFieldValue) TableName() string {
+	return "task_custom_field_values"
+}
+
+const customFieldTaskFieldPrefix = "custom_field_"
+
+// getCustomFieldIDFromTaskField returns the id of the custom field if the task field used to filter or sort
+// refers to one, like "custom_field_42".
+func getCustomFieldIDFromTaskField(fieldName string) (fieldID int64, is bool) {
+	if !strings.HasPrefix(fieldName, customFieldTaskFieldPrefix) {
+		return 0, false
+	}
+	fieldID, err := strconv.ParseInt(strings.TrimPrefix(fieldName, customFieldTaskFieldPrefix), 10, 64)
+	if err != nil || fieldID < 1 {
+		return 0, false
+	}
+	return fieldID, true
+}
+
+func (cf *CustomField) validate() error {
+	if !cf.Type.isValid() {
+		return &ErrInvalidCustomFieldType{Type: cf.Type}
+	}
+
+	if !cf.Type.hasOptions() {
+		if len(cf.Options) > 0 {
+			return &ErrInvalidCustomFieldOptions{Type: cf.Type}
+		}
+		cf.Options = nil
+		return nil
+	}
+
+	if len(cf.Options) == 0 {
+		return &ErrInvalidCustomFieldOptions{Type: cf.Type}
+	}
+	seen := make(map[string]bool, len(cf.Options))
+	for _, o := range cf.Options {
+		if o == "" || seen[o] {
+			return &ErrInvalidCustomFieldOptions{Type: cf.Type}
+		}
+		seen[o] = true
+	}
+	return nil
+}
+
+func (cf *CustomField) hasOption(option string) bool {
+	for _, o := range cf.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func getCustomField(s *xorm.Session, listID, fieldID int64) (field *CustomField, err error) {
+	field = &CustomField{}
+	exists, err := s.
+		Where("id = ? AND list_id = ?", fieldID, listID).
+		Get(field)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCustomFieldDoesNotExist{FieldID: fieldID, ListID: listID}
+	}
+	return
+}
+
+func getCustomFieldByID(s *xorm.Session, fieldID int64) (field *CustomField, err error) {
+	field = &CustomField{}
+	exists, err := s.
+		Where("id = ?", fieldID).
+		Get(field)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCustomFieldDoesNotExist{FieldID: fieldID}
+	}
+	return
+}
+
+// Create adds a custom field to a list
+// @Summary Create a custom field
+// @Description Adds a new custom field to a list. Only list admins can do this.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param field body models.CustomField true "The custom field"
+// @Success 201 {object} models.CustomField "The created custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user is not an admin of the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields [put]
+func (cf *CustomField) Create(s *xorm.Session, a web.Auth) (err error) {
+	cf.ID = 0
+	err = cf.validate()
+	if err != nil {
+		return err
+	}
+
+	cf.CreatedBy, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+	cf.CreatedByID = cf.CreatedBy.ID
+
+	_, err = s.Insert(cf)
+	return err
+}
+
+// ReadOne returns one custom field
+// @Summary Get one custom field
+// @Description Returns one custom field of a list.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param customfield path int true "Custom field ID"
+// @Success 200 {object} models.CustomField "The custom field."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields/{customfield} [get]
+func (cf *CustomField) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	field, err := getCustomField(s, cf.ListID, cf.ID)
+	if err != nil {
+		return err
+	}
+
+	*cf = *field
+	return addCreatorsToCustomFields(s, []*CustomField{cf})
+}
+
+// ReadAll returns all custom fields of a list
+// @Summary Get all custom fields of a list
+// @Description Returns all custom fields of a list.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Success 200 {array} models.CustomField "The custom fields"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields [get]
+func (cf *CustomField) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := cf.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	fields := []*CustomField{}
+	query := s.
+		Where("list_id = ?", cf.ListID).
+		OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&fields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addCreatorsToCustomFields(s, fields)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where("list_id = ?", cf.ListID).
+		Count(&CustomField{})
+	return fields, len(fields), numberOfTotalItems, err
+}
+
+// Update changes a custom field
+// @Summary Update a custom field
+// @Description Changes the title or options of a custom field. The type of a field can't be changed. Only list admins can do this.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param customfield path int true "Custom field ID"
+// @Param field body models.CustomField true "The custom field"
+// @Success 200 {object} models.CustomField "The updated custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user is not an admin of the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields/{customfield} [post]
+func (cf *CustomField) Update(s *xorm.Session, _ web.Auth) (err error) {
+	saved, err := getCustomField(s, cf.ListID, cf.ID)
+	if err != nil {
+		return err
+	}
+
+	cf.Type = saved.Type
+	err = cf.validate()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		ID(cf.ID).
+		Cols("title", "options").
+		Update(cf)
+	if err != nil {
+		return err
+	}
+
+	// Tasks can't keep an option which does not exist anymore
+	if cf.Type.hasOptions() {
+		_, err = s.
+			Where(builder.And(
+				builder.Eq{"field_id": cf.ID},
+				builder.NotIn("text_value", cf.Options),
+			)).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return err
+		}
+	}
+
+	cf.CreatedByID = saved.CreatedByID
+	cf.Created = saved.Created
+	return addCreatorsToCustomFields(s, []*CustomField{cf})
+}
+
+// Delete removes a custom field
+// @Summary Delete a custom field
+// @Description Removes a custom field and its values on all tasks. Only list admins can do this.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param customfield path int true "Custom field ID"
+// @Success 200 {object} models.Message "The custom field was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user is not an admin of the list."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/custom-fields/{customfield} [delete]
+func (cf *CustomField) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("field_id = ?", cf.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ? AND list_id = ?", cf.ID, cf.ListID).
+		Delete(&CustomField{})
+	return err
+}
+
+func addCreatorsToCustomFields(s *xorm.Session, fields []*CustomField) error {
+	creatorIDs := make([]int64, 0, len(fields))
+	for _, f := range fields {
+		creatorIDs = append(creatorIDs, f.CreatedByID)
+	}
+
+	creators, err := getUsersOrLinkSharesFromIDs(s, creatorIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		f.CreatedBy = creators[f.CreatedByID]
+	}
+	return nil
+}
+
+// parseTaskValue converts the json value of the field on a task to the rows which will be stored for it.
+// An empty value returns no rows.
+func (cf *CustomField) parseTaskValue(s *xorm.Session, raw interface{}) (values []*TaskCustomFieldValue, err error) {
+	invalid := &ErrInvalidCustomFieldValue{FieldID: cf.ID, Value: raw}
+
+	switch cf.Type {
+	case CustomFieldTypeText, CustomFieldTypeSelect:
+		text, is := raw.(string)
+		if !is {
+			return nil, invalid
+		}
+		if text == "" {
+			return nil, nil
+		}
+		if cf.Type == CustomFieldTypeSelect && !cf.hasOption(text) {
+			return nil, invalid
+		}
+		return []*TaskCustomFieldValue{{TextValue: text}}, nil
+	case CustomFieldTypeMultiSelect:
+		var options []string
+		switch v := raw.(type) {
+		case []string:
+			options = v
+		case []interface{}:
+			for _, o := range v {
+				option, is := o.(string)
+				if !is {
+					return nil, invalid
+				}
+				options = append(options, option)
+			}
+		default:
+			return nil, invalid
+		}
+		seen := make(map[string]bool, len(options))
+		for _, option := range options {
+			if !cf.hasOption(option) {
+				return nil, invalid
+			}
+			if seen[option] {
+				continue
+			}
+			seen[option] = true
+			values = append(values, &TaskCustomFieldValue{TextValue: option})
+		}
+		return values, nil
+	case CustomFieldTypeNumber:
+		number, is := customFieldNumber(raw)
+		if !is {
+			return nil, invalid
+		}
+		return []*TaskCustomFieldValue{{NumberValue: number}}, nil
+	case CustomFieldTypeDate:
+		var date time.Time
+		switch v := raw.(type) {
+		case time.Time:
+			date = v
+		case string:
+			if v == "" {
+				return nil, nil
+			}
+			date, err = time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, invalid
+			}
+		default:
+			return nil, invalid
+		}
+		if date.IsZero() {
+			return nil, nil
+		}
+		return []*TaskCustomFieldValue{{DateValue: date.In(config.GetTimeZone())}}, nil
+	case CustomFieldTypeUser:
+		number, is := customFieldNumber(raw)
+		if !is || number < 1 || number != math.Trunc(number) {
+			return nil, invalid
+		}
+		u, err := user.GetUserByID(s, int64(number))
+		if err != nil {
+			return nil, err
+		}
+		canRead, _, err := (&List{ID: cf.ListID}).CanRead(s, u)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrUserDoesNotHaveAccessToList{ListID: cf.ListID, UserID: u.ID}
+		}
+		return []*TaskCustomFieldValue{{UserID: u.ID}}, nil
+	}
+
+	return nil, invalid
+}
+
+// customFieldNumber returns the value as float64. Values decoded from json are always float64, values which come
+// from a task read before are of the type they are returned as.
+func customFieldNumber(raw interface{}) (number float64, is bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// taskValue converts the stored rows of the field on a task to the value returned in the task json
+func (cf *CustomField) taskValue(values []*TaskCustomFieldValue) interface{} {
+	switch cf.Type {
+	case CustomFieldTypeNumber:
+		return values[0].NumberValue
+	case CustomFieldTypeDate:
+		return values[0].DateValue
+	case CustomFieldTypeUser:
+		return values[0].UserID
+	case CustomFieldTypeMultiSelect:
+		options := make([]string, 0, len(values))
+		for _, v := range values {
+			options = append(options, v.TextValue)
+		}
+		return options
+	default:
+		return values[0].TextValue
+	}
+}
+
+func addCustomFieldValuesToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	values := []*TaskCustomFieldValue{}
+	err := s.
+		In("task_id", taskIDs).
+		OrderBy("id asc").
+		Find(&values)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range taskMap {
+		t.CustomFields = nil
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	valuesByTask := make(map[int64]map[int64][]*TaskCustomFieldValue)
+	for _, v := range values {
+		fieldIDs = append(fieldIDs, v.FieldID)
+		if _, exists := valuesByTask[v.TaskID]; !exists {
+			valuesByTask[v.TaskID] = make(map[int64][]*TaskCustomFieldValue)
+		}
+		valuesByTask[v.TaskID][v.FieldID] = append(valuesByTask[v.TaskID][v.FieldID], v)
+	}
+
+	fields := make(map[int64]*CustomField)
+	err = s.In("id", fieldIDs).Find(&fields)
+	if err != nil {
+		return err
+	}
+
+	for taskID, taskValues := range valuesByTask {
+		t, exists := taskMap[taskID]
+		if !exists {
+			continue
+		}
+		t.CustomFields = make(map[int64]interface{}, len(taskValues))
+		for fieldID, fieldValues := range taskValues {
+			field, exists := fields[fieldID]
+			if !exists {
+				continue
+			}
+			t.CustomFields[fieldID] = field.taskValue(fieldValues)
+		}
+	}
+
+	return nil
+}
+
+// updateCustomFieldValues sets the values of all custom fields in the map on the task.
+// Fields which are not in the map are left untouched, fields with an empty value are removed from the task.
+func (t *Task) updateCustomFieldValues(s *xorm.Session, values map[int64]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	for fieldID := range values {
+		fieldIDs = append(fieldIDs, fieldID)
+	}
+	sort.Slice(fieldIDs, func(i, j int) bool {
+		return fieldIDs[i] < fieldIDs[j]
+	})
+
+	fields := make(map[int64]*CustomField, len(fieldIDs))
+	err := s.In("id", fieldIDs).Find(&fields)
+	if err != nil {
+		return err
+	}
+
+	for _, fieldID := range fieldIDs {
+		field, exists := fields[fieldID]
+		if !exists || field.ListID != t.ListID {
+			return &ErrCustomFieldDoesNotExist{FieldID: fieldID, ListID: t.ListID}
+		}
+
+		var rows []*TaskCustomFieldValue
+		if values[fieldID] != nil {
+			rows, err = field.parseTaskValue(s, values[fieldID])
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = s.
+			Where("task_id = ? AND field_id = ?", t.ID, fieldID).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			row.TaskID = t.ID
+			row.FieldID = fieldID
+			_, err = s.Insert(row)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeCustomFieldValuesOfOtherLists removes all values of custom fields which don't belong to the list of the task.
+// This is needed after moving a task to another list.
+func (t *Task) removeCustomFieldValuesOfOtherLists(s *xorm.Session) error {
+	_, err := s.
+		Where(builder.And(
+			builder.Eq{"task_id": t.ID},
+			builder.NotIn("field_id", builder.Select("id").From("custom_fields").Where(builder.Eq{"list_id": t.ListID})),
+		)).
+		Delete(&TaskCustomFieldValue{})
+	return err
+}
+
+// getFilterValue converts a raw filter value to the type of the field
+func (cf *CustomField) getFilterValue(rawValue string) (value interface{}, err error) {
+	switch cf.Type {
+	case CustomFieldTypeNumber:
+		return strconv.ParseFloat(rawValue, 64)
+	case CustomFieldTypeUser:
+		return strconv.ParseInt(rawValue, 10, 64)
+	case CustomFieldTypeDate:
+		t, err := datemath.Parse(rawValue)
+		if err == nil {
+			return t.Time(datemath.WithLocation(config.GetTimeZone())), nil
+		}
+		return parseTimeFromUserInput(rawValue)
+	default:
+		return rawValue, nil
+	}
+}
+
+// getCustomFieldFilterCond returns the condition for a filter on a custom field. The value of the filter was not converted
+// when parsing the filter because that depends on the type of the field.
+func getCustomFieldFilterCond(s *xorm.Session, fieldID int64, f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
+	field, err := getCustomFieldByID(s, fieldID)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.comparator == taskFilterComparatorLike && field.Type.valueColumn() != "text_value" {
+		return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+	}
+
+	valueFilter := &taskFilter{
+		field:      field.Type.valueColumn(),
+		comparator: f.comparator,
+	}
+
+	switch raw := f.value.(type) {
+	case string:
+		valueFilter.value, err = field.getFilterValue(raw)
+	case []string:
+		values := make([]interface{}, 0, len(raw))
+		for _, r := range raw {
+			v, err := field.getFilterValue(r)
+			if err != nil {
+				return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+			}
+			values = append(values, v)
+		}
+		valueFilter.value = values
+	default:
+		return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+	}
+	if err != nil {
+		return nil, ErrInvalidTaskFilterValue{Field: f.field, Value: f.value}
+	}
+
+	valueCond, err := getFilterCond(valueFilter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cond = builder.In("id",
+		builder.
+			Select("task_id").
+			From("task_custom_field_values").
+			Where(builder.And(builder.Eq{"field_id": fieldID}, valueCond)),
+	)
+
+	// Tasks without a value for this field don't have a row in the values table
+	if includeNulls {
+		cond = builder.Or(cond, builder.NotIn("id",
+			builder.
+				Select("task_id").
+				From("task_custom_field_values").
+				Where(builder.Eq{"field_id": fieldID}),
+		))
+	}
+
+	return cond, nil
+}
+
+// getCustomFieldSortColumn returns the expression to sort tasks by the value of a custom field.
+// The field id is an integer and the column depends only on the type, which makes this safe to use in an order by clause.
+func getCustomFieldSortColumn(s *xorm.Session, fieldID int64) (string, error) {
+	field, err := getCustomFieldByID(s, fieldID)
+	if err != nil {
+		return "", err
+	}
+
+	return "(SELECT MIN(`" + field.Type.valueColumn() + "`) FROM `task_custom_field_values` " +
+		"WHERE `task_custom_field_values`.`task_id` = `tasks`.`id` " +
+		"AND `task_custom_field_values`.`field_id` = " + strconv.FormatInt(field.ID, 10) + ")", nil
+}
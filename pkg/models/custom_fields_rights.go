@@ -0,0 +1,58 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can see the custom fields of a list
+func (cf *CustomField) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	l := &List{ID: cf.ListID}
+	return l.CanRead(s, a)
+}
+
+// CanCreate checks if a user can add custom fields to a list
+func (cf *CustomField) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	l := &List{ID: cf.ListID}
+	return l.IsAdmin(s, a)
+}
+
+func (cf *CustomField) canModify(s *xorm.Session, a web.Auth) (bool, error) {
+	l := &List{ID: cf.ListID}
+	isAdmin, err := l.IsAdmin(s, a)
+	if err != nil || !isAdmin {
+		return false, err
+	}
+
+	_, err = getCustomField(s, cf.ListID, cf.ID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CanUpdate checks if a user can update a custom field
+func (cf *CustomField) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canModify(s, a)
+}
+
+// CanDelete checks if a user can delete a custom field
+func (cf *CustomField) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canModify(s, a)
+}
@@ -0,0 +1,426 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomField_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ListID:  1,
+			Title:   "Severity",
+			Type:    CustomFieldTypeSelect,
+			Options: []string{"low", "high"},
+		}
+		can, err := cf.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = cf.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), cf.CreatedBy.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "custom_fields", map[string]interface{}{
+			"id":            cf.ID,
+			"list_id":       1,
+			"title":         "Severity",
+			"type":          "select",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ListID: 1,
+			Title:  "Severity",
+			Type:   "color",
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldType(err))
+	})
+	t.Run("select without options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ListID: 1,
+			Title:  "Severity",
+			Type:   CustomFieldTypeSelect,
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldOptions(err))
+	})
+	t.Run("options on a text field", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ListID:  1,
+			Title:   "Severity",
+			Type:    CustomFieldTypeText,
+			Options: []string{"low"},
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldOptions(err))
+	})
+	t.Run("no admin", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// User 1 only has write access to list 10
+		cf := &CustomField{ListID: 10}
+		can, err := cf.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestCustomField_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	cf := &CustomField{ListID: 1}
+	fields, _, total, err := cf.ReadAll(s, u, "", 0, 50)
+	assert.NoError(t, err)
+	assert.Len(t, fields, 6)
+	assert.Equal(t, int64(6), total)
+	assert.Equal(t, "Customer", fields.([]*CustomField)[0].Title)
+	assert.Equal(t, []string{"staging", "production"}, fields.([]*CustomField)[2].Options)
+}
+
+func TestCustomField_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("removing an option removes its values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ID:      4,
+			ListID:  1,
+			Title:   "Platforms",
+			Options: []string{"web", "android"},
+		}
+		err := cf.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, CustomFieldTypeMultiSelect, cf.Type)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    1,
+			"field_id":   4,
+			"text_value": "web",
+		}, false)
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    1,
+			"field_id":   4,
+			"text_value": "ios",
+		})
+	})
+	t.Run("field of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ID:     7,
+			ListID: 1,
+			Title:  "Other",
+		}
+		err := cf.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+}
+
+func TestCustomField_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	cf := &CustomField{ID: 2, ListID: 1}
+	can, err := cf.CanDelete(s, u)
+	assert.NoError(t, err)
+	assert.True(t, can)
+	err = cf.Delete(s, u)
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "custom_fields", map[string]interface{}{
+		"id": 2,
+	})
+	db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+		"field_id": 2,
+	})
+}
+
+func TestTask_UpdateCustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "test",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				1: nil,
+				2: float64(13),
+				3: "production",
+				4: []interface{}{"android"},
+				6: float64(1),
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, map[int64]interface{}{
+			2: float64(13),
+			3: "production",
+			4: []string{"android"},
+			6: int64(1),
+		}, task.CustomFields)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  1,
+			"field_id": 1,
+		})
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    1,
+			"field_id":   3,
+			"text_value": "production",
+		}, false)
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  1,
+			"field_id": 6,
+			"user_id":  1,
+		}, false)
+	})
+	t.Run("invalid option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "test",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				3: "development",
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("invalid number", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "test",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				2: "five",
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("user without access to the list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "test",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				6: float64(2),
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToList(err))
+	})
+	t.Run("field of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "test",
+			ListID: 1,
+			CustomFields: map[int64]interface{}{
+				7: "Foo",
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+	t.Run("moving the task removes the values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:     1,
+			Title:  "test",
+			ListID: 2,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id": 1,
+		})
+	})
+}
+
+func TestTaskCollection_CustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	getTaskIDs := func(t *testing.T, tc *TaskCollection) (ids []int64) {
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return
+	}
+
+	t.Run("filter by number", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_2"},
+			FilterComparator: []string{"greater"},
+			FilterValue:      []string{"4"},
+		})
+		assert.Equal(t, []int64{1, 3}, ids)
+	})
+	t.Run("filter by text with like", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_1"},
+			FilterComparator: []string{"like"},
+			FilterValue:      []string{"acm"},
+		})
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("filter by multiselect with in", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_4"},
+			FilterComparator: []string{"in"},
+			FilterValue:      []string{"android,ios"},
+		})
+		assert.Equal(t, []int64{1}, ids)
+	})
+	t.Run("filter by select including nulls", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:             1,
+			FilterBy:           []string{"custom_field_3", "id"},
+			FilterComparator:   []string{"equals", "less"},
+			FilterValue:        []string{"staging", "4"},
+			FilterConcat:       "and",
+			FilterIncludeNulls: true,
+		})
+		assert.Equal(t, []int64{1, 2, 3}, ids)
+	})
+	t.Run("like on a number field", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"custom_field_2"},
+			FilterComparator: []string{"like"},
+			FilterValue:      []string{"5"},
+		}
+		_, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterValue(err))
+	})
+	t.Run("sort by number", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		ids := getTaskIDs(t, &TaskCollection{
+			ListID:           1,
+			SortBy:           []string{"custom_field_2", "id"},
+			OrderBy:          []string{"desc", "asc"},
+			FilterBy:         []string{"id"},
+			FilterComparator: []string{"less"},
+			FilterValue:      []string{"5"},
+		})
+		assert.Equal(t, []int64{3, 1, 2, 4}, ids)
+	})
+}
@@ -19,6 +19,7 @@ package models
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/web"
@@ -875,6 +876,362 @@ func (err ErrUserAlreadyAssigned) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrTaskRevisionDoesNotExist represents an error where a task revision does not exist
+type ErrTaskRevisionDoesNotExist struct {
+	TaskID     int64
+	RevisionID int64
+}
+
+// IsErrTaskRevisionDoesNotExist checks if an error is ErrTaskRevisionDoesNotExist.
+func IsErrTaskRevisionDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTaskRevisionDoesNotExist)
+	return ok
+}
+
+func (err *ErrTaskRevisionDoesNotExist) Error() string {
+	return fmt.Sprintf("Task revision does not exist [TaskID: %d, RevisionID: %d]", err.TaskID, err.RevisionID)
+}
+
+// ErrCodeTaskRevisionDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskRevisionDoesNotExist = 4022
+
+// HTTPError holds the http error description
+func (err ErrTaskRevisionDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskRevisionDoesNotExist,
+		Message:  "This task revision does not exist.",
+	}
+}
+
+// ErrReminderRelativeToMissing represents an error where a relative reminder references a task date which is not set.
+type ErrReminderRelativeToMissing struct {
+	TaskID     int64
+	RelativeTo ReminderRelation
+}
+
+// IsErrReminderRelativeToMissing checks if an error is ErrReminderRelativeToMissing.
+func IsErrReminderRelativeToMissing(err error) bool {
+	_, ok := err.(*ErrReminderRelativeToMissing)
+	return ok
+}
+
+func (err *ErrReminderRelativeToMissing) Error() string {
+	return fmt.Sprintf("Relative reminder references an unset task date [TaskID: %d, RelativeTo: %s]", err.TaskID, err.RelativeTo)
+}
+
+// ErrCodeReminderRelativeToMissing holds the unique world-error code of this error
+const ErrCodeReminderRelativeToMissing = 4023
+
+// HTTPError holds the http error description
+func (err ErrReminderRelativeToMissing) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeReminderRelativeToMissing,
+		Message:  "Cannot compute a relative reminder without the task date it is relative to.",
+	}
+}
+
+// ErrInvalidReminderRelation represents an error where a relative reminder references an unknown task date.
+type ErrInvalidReminderRelation struct {
+	RelativeTo ReminderRelation
+}
+
+// IsErrInvalidReminderRelation checks if an error is ErrInvalidReminderRelation.
+func IsErrInvalidReminderRelation(err error) bool {
+	_, ok := err.(*ErrInvalidReminderRelation)
+	return ok
+}
+
+func (err *ErrInvalidReminderRelation) Error() string {
+	return fmt.Sprintf("Invalid reminder relation [RelativeTo: %s]", err.RelativeTo)
+}
+
+// ErrCodeInvalidReminderRelation holds the unique world-error code of this error
+const ErrCodeInvalidReminderRelation = 4024
+
+// HTTPError holds the http error description
+func (err ErrInvalidReminderRelation) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidReminderRelation,
+		Message:  "A relative reminder can only be relative to due_date, start_date or end_date.",
+	}
+}
+
+// ErrInvalidRepeatRule represents an error where the repeat rule of a task is not a valid recurrence rule.
+type ErrInvalidRepeatRule struct {
+	RepeatRule string
+	Reason     string
+}
+
+// IsErrInvalidRepeatRule checks if an error is ErrInvalidRepeatRule.
+func IsErrInvalidRepeatRule(err error) bool {
+	_, ok := err.(*ErrInvalidRepeatRule)
+	return ok
+}
+
+func (err *ErrInvalidRepeatRule) Error() string {
+	return fmt.Sprintf("Invalid repeat rule [RepeatRule: %s, Reason: %s]", err.RepeatRule, err.Reason)
+}
+
+// ErrCodeInvalidRepeatRule holds the unique world-error code of this error
+const ErrCodeInvalidRepeatRule = 4025
+
+// HTTPError holds the http error description
+func (err ErrInvalidRepeatRule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidRepeatRule,
+		Message:  "The repeat rule is not a valid recurrence rule: " + err.Reason,
+	}
+}
+
+// ErrChecklistItemDoesNotExist represents an error where a checklist item does not exist on a task.
+type ErrChecklistItemDoesNotExist struct {
+	ItemID int64
+	TaskID int64
+}
+
+// IsErrChecklistItemDoesNotExist checks if an error is ErrChecklistItemDoesNotExist.
+func IsErrChecklistItemDoesNotExist(err error) bool {
+	_, ok := err.(*ErrChecklistItemDoesNotExist)
+	return ok
+}
+
+func (err *ErrChecklistItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Checklist item does not exist [ItemID: %d, TaskID: %d]", err.ItemID, err.TaskID)
+}
+
+// ErrCodeChecklistItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeChecklistItemDoesNotExist = 4026
+
+// HTTPError holds the http error description
+func (err ErrChecklistItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeChecklistItemDoesNotExist,
+		Message:  "This checklist item does not exist on this task.",
+	}
+}
+
+// ErrTaskIsBlocked represents an error where a task is marked as done while a task blocking it is not done yet.
+type ErrTaskIsBlocked struct {
+	TaskID         int64
+	BlockingTaskID int64
+}
+
+// IsErrTaskIsBlocked checks if an error is ErrTaskIsBlocked.
+func IsErrTaskIsBlocked(err error) bool {
+	_, ok := err.(*ErrTaskIsBlocked)
+	return ok
+}
+
+func (err *ErrTaskIsBlocked) Error() string {
+	return fmt.Sprintf("Task is blocked by another task which is not done [TaskID: %d, BlockingTaskID: %d]", err.TaskID, err.BlockingTaskID)
+}
+
+// ErrCodeTaskIsBlocked holds the unique world-error code of this error
+const ErrCodeTaskIsBlocked = 4027
+
+// HTTPError holds the http error description
+func (err ErrTaskIsBlocked) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskIsBlocked,
+		Message:  fmt.Sprintf("This task cannot be marked as done because task %d which is blocking it is not done yet.", err.BlockingTaskID),
+	}
+}
+
+// ErrRelationWouldCreateCycle represents an error where a new task relation would create a cycle.
+type ErrRelationWouldCreateCycle struct {
+	TaskID      int64
+	OtherTaskID int64
+	Kind        RelationKind
+}
+
+// IsErrRelationWouldCreateCycle checks if an error is ErrRelationWouldCreateCycle.
+func IsErrRelationWouldCreateCycle(err error) bool {
+	_, ok := err.(*ErrRelationWouldCreateCycle)
+	return ok
+}
+
+func (err *ErrRelationWouldCreateCycle) Error() string {
+	return fmt.Sprintf("Task relation would create a cycle [TaskID: %d, OtherTaskID: %d, Kind: %s]", err.TaskID, err.OtherTaskID, err.Kind)
+}
+
+// ErrCodeRelationWouldCreateCycle holds the unique world-error code of this error
+const ErrCodeRelationWouldCreateCycle = 4028
+
+// HTTPError holds the http error description
+func (err ErrRelationWouldCreateCycle) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeRelationWouldCreateCycle,
+		Message:  "This relation would create a cycle between the tasks.",
+	}
+}
+
+// ErrTaskDependencyCycle represents an error where the precedes/follows relations of the tasks in a list form a cycle.
+type ErrTaskDependencyCycle struct {
+	ListID int64
+}
+
+// IsErrTaskDependencyCycle checks if an error is ErrTaskDependencyCycle.
+func IsErrTaskDependencyCycle(err error) bool {
+	_, ok := err.(*ErrTaskDependencyCycle)
+	return ok
+}
+
+func (err *ErrTaskDependencyCycle) Error() string {
+	return fmt.Sprintf("The task relations form a cycle [ListID: %d]", err.ListID)
+}
+
+// ErrCodeTaskDependencyCycle holds the unique world-error code of this error
+const ErrCodeTaskDependencyCycle = 4029
+
+// HTTPError holds the http error description
+func (err ErrTaskDependencyCycle) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeTaskDependencyCycle,
+		Message:  "The precedes and follows relations of the tasks form a cycle, the schedule cannot be computed.",
+	}
+}
+
+// ErrInvalidTaskIdentifier represents an error where a task identifier is not in the form of <list identifier>-<index>
+type ErrInvalidTaskIdentifier struct {
+	Identifier string
+}
+
+// IsErrInvalidTaskIdentifier checks if an error is ErrInvalidTaskIdentifier.
+func IsErrInvalidTaskIdentifier(err error) bool {
+	_, ok := err.(*ErrInvalidTaskIdentifier)
+	return ok
+}
+
+func (err *ErrInvalidTaskIdentifier) Error() string {
+	return fmt.Sprintf("Task identifier is invalid [Identifier: %s]", err.Identifier)
+}
+
+// ErrCodeInvalidTaskIdentifier holds the unique world-error code of this error
+const ErrCodeInvalidTaskIdentifier = 4030
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskIdentifier) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskIdentifier,
+		Message:  "A task identifier must consist of the identifier of a list and the index of the task, for example PROJ-42.",
+	}
+}
+
+// ErrTaskIdentifierDoesNotExist represents an error where no task has or had a given identifier
+type ErrTaskIdentifierDoesNotExist struct {
+	Identifier string
+}
+
+// IsErrTaskIdentifierDoesNotExist checks if an error is ErrTaskIdentifierDoesNotExist.
+func IsErrTaskIdentifierDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTaskIdentifierDoesNotExist)
+	return ok
+}
+
+func (err *ErrTaskIdentifierDoesNotExist) Error() string {
+	return fmt.Sprintf("Task identifier does not exist [Identifier: %s]", err.Identifier)
+}
+
+// ErrCodeTaskIdentifierDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskIdentifierDoesNotExist = 4031
+
+// HTTPError holds the http error description
+func (err ErrTaskIdentifierDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskIdentifierDoesNotExist,
+		Message:  fmt.Sprintf("There is no task with the identifier %s.", err.Identifier),
+	}
+}
+
+// ErrQuickAddListDoesNotExist represents an error where a list mentioned in quick add magic does not exist
+type ErrQuickAddListDoesNotExist struct {
+	List string
+}
+
+// IsErrQuickAddListDoesNotExist checks if an error is ErrQuickAddListDoesNotExist.
+func IsErrQuickAddListDoesNotExist(err error) bool {
+	_, ok := err.(*ErrQuickAddListDoesNotExist)
+	return ok
+}
+
+func (err *ErrQuickAddListDoesNotExist) Error() string {
+	return fmt.Sprintf("Quick add list does not exist [List: %s]", err.List)
+}
+
+// ErrCodeQuickAddListDoesNotExist holds the unique world-error code of this error
+const ErrCodeQuickAddListDoesNotExist = 4032
+
+// HTTPError holds the http error description
+func (err ErrQuickAddListDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeQuickAddListDoesNotExist,
+		Message:  fmt.Sprintf("There is no list with the title or identifier %s.", err.List),
+	}
+}
+
+// ErrQuickAddUserDoesNotExist represents an error where a user mentioned in quick add magic does not exist
+type ErrQuickAddUserDoesNotExist struct {
+	Username string
+}
+
+// IsErrQuickAddUserDoesNotExist checks if an error is ErrQuickAddUserDoesNotExist.
+func IsErrQuickAddUserDoesNotExist(err error) bool {
+	_, ok := err.(*ErrQuickAddUserDoesNotExist)
+	return ok
+}
+
+func (err *ErrQuickAddUserDoesNotExist) Error() string {
+	return fmt.Sprintf("Quick add user does not exist [Username: %s]", err.Username)
+}
+
+// ErrCodeQuickAddUserDoesNotExist holds the unique world-error code of this error
+const ErrCodeQuickAddUserDoesNotExist = 4033
+
+// HTTPError holds the http error description
+func (err ErrQuickAddUserDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeQuickAddUserDoesNotExist,
+		Message:  fmt.Sprintf("There is no user with the username %s.", err.Username),
+	}
+}
+
+// ErrQuickAddNoList represents an error where quick add does not know in which list to create a task
+type ErrQuickAddNoList struct{}
+
+// IsErrQuickAddNoList checks if an error is ErrQuickAddNoList.
+func IsErrQuickAddNoList(err error) bool {
+	_, ok := err.(*ErrQuickAddNoList)
+	return ok
+}
+
+func (err *ErrQuickAddNoList) Error() string {
+	return "Quick add has no list to create tasks in"
+}
+
+// ErrCodeQuickAddNoList holds the unique world-error code of this error
+const ErrCodeQuickAddNoList = 4034
+
+// HTTPError holds the http error description
+func (err ErrQuickAddNoList) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeQuickAddNoList,
+		Message:  "You need to provide a list, mention one with +list or set a default list in your settings.",
+	}
+}
+
 // =================
 // Namespace errors
 // =================
@@ -1682,3 +2039,637 @@ func (err ErrLinkSharePasswordInvalid) HTTPError() web.HTTPError {
 		Message:  "The provided link share password is invalid.",
 	}
 }
+
+// ==============
+// Webhook errors
+// ==============
+
+// ErrWebhookDoesNotExist represents an error where a webhook does not exist
+type ErrWebhookDoesNotExist struct {
+	WebhookID int64
+}
+
+// IsErrWebhookDoesNotExist checks if an error is ErrWebhookDoesNotExist.
+func IsErrWebhookDoesNotExist(err error) bool {
+	_, ok := err.(*ErrWebhookDoesNotExist)
+	return ok
+}
+
+func (err *ErrWebhookDoesNotExist) Error() string {
+	return fmt.Sprintf("Webhook does not exist [WebhookID: %d]", err.WebhookID)
+}
+
+// ErrCodeWebhookDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebhookDoesNotExist = 14001
+
+// HTTPError holds the http error description
+func (err ErrWebhookDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebhookDoesNotExist,
+		Message:  "This webhook does not exist.",
+	}
+}
+
+// ErrInvalidWebhookEvent represents an error where a webhook should subscribe to an event which does not exist
+type ErrInvalidWebhookEvent struct {
+	EventName string
+}
+
+// IsErrInvalidWebhookEvent checks if an error is ErrInvalidWebhookEvent.
+func IsErrInvalidWebhookEvent(err error) bool {
+	_, ok := err.(*ErrInvalidWebhookEvent)
+	return ok
+}
+
+func (err *ErrInvalidWebhookEvent) Error() string {
+	return fmt.Sprintf("Webhook event is invalid [EventName: %s]", err.EventName)
+}
+
+// ErrCodeInvalidWebhookEvent holds the unique world-error code of this error
+const ErrCodeInvalidWebhookEvent = 14002
+
+// HTTPError holds the http error description
+func (err ErrInvalidWebhookEvent) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidWebhookEvent,
+		Message:  "The webhook needs to subscribe to at least one event and all events must be valid.",
+	}
+}
+
+// ErrWebhookDeliveryFailed represents an error where a webhook target did not accept a delivery
+type ErrWebhookDeliveryFailed struct {
+	WebhookID  int64
+	StatusCode int
+}
+
+// IsErrWebhookDeliveryFailed checks if an error is ErrWebhookDeliveryFailed.
+func IsErrWebhookDeliveryFailed(err error) bool {
+	_, ok := err.(*ErrWebhookDeliveryFailed)
+	return ok
+}
+
+func (err *ErrWebhookDeliveryFailed) Error() string {
+	return fmt.Sprintf("Webhook target did not accept the delivery [WebhookID: %d, StatusCode: %d]", err.WebhookID, err.StatusCode)
+}
+
+// ErrCodeWebhookDeliveryFailed holds the unique world-error code of this error
+const ErrCodeWebhookDeliveryFailed = 14003
+
+// HTTPError holds the http error description
+func (err ErrWebhookDeliveryFailed) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadGateway,
+		Code:     ErrCodeWebhookDeliveryFailed,
+		Message:  "The webhook target did not accept the delivery.",
+	}
+}
+
+// ErrWebhookTargetNotAllowed represents an error where a webhook target resolves to an address webhooks may not be sent to
+type ErrWebhookTargetNotAllowed struct {
+	Address string
+}
+
+// IsErrWebhookTargetNotAllowed checks if an error is ErrWebhookTargetNotAllowed.
+func IsErrWebhookTargetNotAllowed(err error) bool {
+	_, ok := err.(*ErrWebhookTargetNotAllowed)
+	return ok
+}
+
+func (err *ErrWebhookTargetNotAllowed) Error() string {
+	return fmt.Sprintf("Webhook target address is not allowed [Address: %s]", err.Address)
+}
+
+// ErrCodeWebhookTargetNotAllowed holds the unique world-error code of this error
+const ErrCodeWebhookTargetNotAllowed = 14004
+
+// HTTPError holds the http error description
+func (err ErrWebhookTargetNotAllowed) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeWebhookTargetNotAllowed,
+		Message:  "Webhooks can't be sent to loopback, link-local or private addresses.",
+	}
+}
+
+// ================
+// API Token errors
+// ================
+
+// ErrAPITokenDoesNotExist represents an error where an api token does not exist
+type ErrAPITokenDoesNotExist struct {
+	TokenID int64
+}
+
+// IsErrAPITokenDoesNotExist checks if an error is ErrAPITokenDoesNotExist.
+func IsErrAPITokenDoesNotExist(err error) bool {
+	_, ok := err.(*ErrAPITokenDoesNotExist)
+	return ok
+}
+
+func (err *ErrAPITokenDoesNotExist) Error() string {
+	return fmt.Sprintf("API token does not exist [TokenID: %d]", err.TokenID)
+}
+
+// ErrCodeAPITokenDoesNotExist holds the unique world-error code of this error
+const ErrCodeAPITokenDoesNotExist = 15001
+
+// HTTPError holds the http error description
+func (err ErrAPITokenDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeAPITokenDoesNotExist,
+		Message:  "This api token does not exist.",
+	}
+}
+
+// ErrInvalidAPITokenScope represents an error where an api token should be created with a scope which does not exist
+type ErrInvalidAPITokenScope struct {
+	Scope string
+}
+
+// IsErrInvalidAPITokenScope checks if an error is ErrInvalidAPITokenScope.
+func IsErrInvalidAPITokenScope(err error) bool {
+	_, ok := err.(*ErrInvalidAPITokenScope)
+	return ok
+}
+
+func (err *ErrInvalidAPITokenScope) Error() string {
+	return fmt.Sprintf("API token scope is invalid [Scope: %s]", err.Scope)
+}
+
+// ErrCodeInvalidAPITokenScope holds the unique world-error code of this error
+const ErrCodeInvalidAPITokenScope = 15002
+
+// HTTPError holds the http error description
+func (err ErrInvalidAPITokenScope) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAPITokenScope,
+		Message:  "The api token needs at least one scope and all scopes must be valid.",
+	}
+}
+
+// ErrAPITokenExpiryInPast represents an error where an api token should be created with an expiry date in the past
+type ErrAPITokenExpiryInPast struct{}
+
+// IsErrAPITokenExpiryInPast checks if an error is ErrAPITokenExpiryInPast.
+func IsErrAPITokenExpiryInPast(err error) bool {
+	_, ok := err.(*ErrAPITokenExpiryInPast)
+	return ok
+}
+
+func (err *ErrAPITokenExpiryInPast) Error() string {
+	return "API token expiry date is in the past"
+}
+
+// ErrCodeAPITokenExpiryInPast holds the unique world-error code of this error
+const ErrCodeAPITokenExpiryInPast = 15003
+
+// HTTPError holds the http error description
+func (err ErrAPITokenExpiryInPast) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeAPITokenExpiryInPast,
+		Message:  "The expiry date of an api token must be in the future.",
+	}
+}
+
+// ErrAPITokenInvalid represents an error where an api token is invalid or expired
+type ErrAPITokenInvalid struct{}
+
+// IsErrAPITokenInvalid checks if an error is ErrAPITokenInvalid.
+func IsErrAPITokenInvalid(err error) bool {
+	_, ok := err.(*ErrAPITokenInvalid)
+	return ok
+}
+
+func (err *ErrAPITokenInvalid) Error() string {
+	return "API token is invalid or expired"
+}
+
+// ErrCodeAPITokenInvalid holds the unique world-error code of this error
+const ErrCodeAPITokenInvalid = 15004
+
+// HTTPError holds the http error description
+func (err ErrAPITokenInvalid) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusUnauthorized,
+		Code:     ErrCodeAPITokenInvalid,
+		Message:  "The api token is invalid or expired.",
+	}
+}
+
+// ErrAPITokenScopeMissing represents an error where an api token does not have the scope needed to access a route
+type ErrAPITokenScopeMissing struct {
+	Scope string
+}
+
+// IsErrAPITokenScopeMissing checks if an error is ErrAPITokenScopeMissing.
+func IsErrAPITokenScopeMissing(err error) bool {
+	_, ok := err.(*ErrAPITokenScopeMissing)
+	return ok
+}
+
+func (err *ErrAPITokenScopeMissing) Error() string {
+	return fmt.Sprintf("API token is missing the scope for this route [Scope: %s]", err.Scope)
+}
+
+// ErrCodeAPITokenScopeMissing holds the unique world-error code of this error
+const ErrCodeAPITokenScopeMissing = 15005
+
+// HTTPError holds the http error description
+func (err ErrAPITokenScopeMissing) HTTPError() web.HTTPError {
+	if err.Scope == "" {
+		return web.HTTPError{
+			HTTPCode: http.StatusForbidden,
+			Code:     ErrCodeAPITokenScopeMissing,
+			Message:  "This route cannot be accessed with an api token.",
+		}
+	}
+	return web.HTTPError{
+		HTTPCode: http.StatusForbidden,
+		Code:     ErrCodeAPITokenScopeMissing,
+		Message:  "The api token needs the scope " + err.Scope + " to access this route.",
+	}
+}
+
+// ====================
+// Time tracking errors
+// ====================
+
+// ErrTimeEntryDoesNotExist represents an error where a time entry does not exist
+type ErrTimeEntryDoesNotExist struct {
+	TaskID      int64
+	TimeEntryID int64
+}
+
+// IsErrTimeEntryDoesNotExist checks if an error is ErrTimeEntryDoesNotExist.
+func IsErrTimeEntryDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTimeEntryDoesNotExist)
+	return ok
+}
+
+func (err *ErrTimeEntryDoesNotExist) Error() string {
+	return fmt.Sprintf("Time entry does not exist [TaskID: %d, TimeEntryID: %d]", err.TaskID, err.TimeEntryID)
+}
+
+// ErrCodeTimeEntryDoesNotExist holds the unique world-error code of this error
+const ErrCodeTimeEntryDoesNotExist = 16001
+
+// HTTPError holds the http error description
+func (err ErrTimeEntryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTimeEntryDoesNotExist,
+		Message:  "This time entry does not exist.",
+	}
+}
+
+// ErrInvalidTimeEntryPeriod represents an error where a time entry has no start or ends before it started
+type ErrInvalidTimeEntryPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsErrInvalidTimeEntryPeriod checks if an error is ErrInvalidTimeEntryPeriod.
+func IsErrInvalidTimeEntryPeriod(err error) bool {
+	_, ok := err.(*ErrInvalidTimeEntryPeriod)
+	return ok
+}
+
+func (err *ErrInvalidTimeEntryPeriod) Error() string {
+	return fmt.Sprintf("Time entry period is invalid [Start: %s, End: %s]", err.Start, err.End)
+}
+
+// ErrCodeInvalidTimeEntryPeriod holds the unique world-error code of this error
+const ErrCodeInvalidTimeEntryPeriod = 16002
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimeEntryPeriod) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTimeEntryPeriod,
+		Message:  "A time entry needs a start and either an end after the start or a positive duration.",
+	}
+}
+
+// ErrTimerAlreadyRunning represents an error where a user tries to start a timer while another one is still running
+type ErrTimerAlreadyRunning struct {
+	UserID int64
+	TaskID int64
+}
+
+// IsErrTimerAlreadyRunning checks if an error is ErrTimerAlreadyRunning.
+func IsErrTimerAlreadyRunning(err error) bool {
+	_, ok := err.(*ErrTimerAlreadyRunning)
+	return ok
+}
+
+func (err *ErrTimerAlreadyRunning) Error() string {
+	return fmt.Sprintf("Timer is already running [UserID: %d, TaskID: %d]", err.UserID, err.TaskID)
+}
+
+// ErrCodeTimerAlreadyRunning holds the unique world-error code of this error
+const ErrCodeTimerAlreadyRunning = 16003
+
+// HTTPError holds the http error description
+func (err ErrTimerAlreadyRunning) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusConflict,
+		Code:     ErrCodeTimerAlreadyRunning,
+		Message:  fmt.Sprintf("You already have a running timer on task %d. Stop it before starting a new one.", err.TaskID),
+	}
+}
+
+// ErrNoRunningTimer represents an error where a user tries to stop a timer which is not running
+type ErrNoRunningTimer struct {
+	UserID int64
+	TaskID int64
+}
+
+// IsErrNoRunningTimer checks if an error is ErrNoRunningTimer.
+func IsErrNoRunningTimer(err error) bool {
+	_, ok := err.(*ErrNoRunningTimer)
+	return ok
+}
+
+func (err *ErrNoRunningTimer) Error() string {
+	return fmt.Sprintf("No running timer [UserID: %d, TaskID: %d]", err.UserID, err.TaskID)
+}
+
+// ErrCodeNoRunningTimer holds the unique world-error code of this error
+const ErrCodeNoRunningTimer = 16004
+
+// HTTPError holds the http error description
+func (err ErrNoRunningTimer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNoRunningTimer,
+		Message:  "You don't have a running timer on this task.",
+	}
+}
+
+// ErrInvalidTimeReportRange represents an error where the date range of a time report is invalid
+type ErrInvalidTimeReportRange struct {
+	From string
+	To   string
+}
+
+// IsErrInvalidTimeReportRange checks if an error is ErrInvalidTimeReportRange.
+func IsErrInvalidTimeReportRange(err error) bool {
+	_, ok := err.(*ErrInvalidTimeReportRange)
+	return ok
+}
+
+func (err *ErrInvalidTimeReportRange) Error() string {
+	return fmt.Sprintf("Time report range is invalid [From: %s, To: %s]", err.From, err.To)
+}
+
+// ErrCodeInvalidTimeReportRange holds the unique world-error code of this error
+const ErrCodeInvalidTimeReportRange = 16005
+
+// HTTPError holds the http error description
+func (err ErrInvalidTimeReportRange) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTimeReportRange,
+		Message:  "The date range of the time report is invalid. Dates must be formatted as YYYY-MM-DD or RFC 3339 and the start must be before the end.",
+	}
+}
+
+// ===================
+// Custom field errors
+// ===================
+
+// ErrCustomFieldDoesNotExist represents an error where a custom field does not exist
+type ErrCustomFieldDoesNotExist struct {
+	FieldID int64
+	ListID  int64
+}
+
+// IsErrCustomFieldDoesNotExist checks if an error is ErrCustomFieldDoesNotExist.
+func IsErrCustomFieldDoesNotExist(err error) bool {
+	_, ok := err.(*ErrCustomFieldDoesNotExist)
+	return ok
+}
+
+func (err *ErrCustomFieldDoesNotExist) Error() string {
+	return fmt.Sprintf("Custom field does not exist [FieldID: %d, ListID: %d]", err.FieldID, err.ListID)
+}
+
+// ErrCodeCustomFieldDoesNotExist holds the unique world-error code of this error
+const ErrCodeCustomFieldDoesNotExist = 17001
+
+// HTTPError holds the http error description
+func (err ErrCustomFieldDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCustomFieldDoesNotExist,
+		Message:  "This custom field does not exist in this list.",
+	}
+}
+
+// ErrInvalidCustomFieldType represents an error where a custom field should be created with a type which does not exist
+type ErrInvalidCustomFieldType struct {
+	Type CustomFieldType
+}
+
+// IsErrInvalidCustomFieldType checks if an error is ErrInvalidCustomFieldType.
+func IsErrInvalidCustomFieldType(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldType)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldType) Error() string {
+	return fmt.Sprintf("Custom field type is invalid [Type: %s]", err.Type)
+}
+
+// ErrCodeInvalidCustomFieldType holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldType = 17002
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldType) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldType,
+		Message:  "The custom field type is invalid. Valid types are text, number, date, select, multiselect and user.",
+	}
+}
+
+// ErrInvalidCustomFieldOptions represents an error where the options of a custom field don't fit its type
+type ErrInvalidCustomFieldOptions struct {
+	Type CustomFieldType
+}
+
+// IsErrInvalidCustomFieldOptions checks if an error is ErrInvalidCustomFieldOptions.
+func IsErrInvalidCustomFieldOptions(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldOptions)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldOptions) Error() string {
+	return fmt.Sprintf("Custom field options are invalid [Type: %s]", err.Type)
+}
+
+// ErrCodeInvalidCustomFieldOptions holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldOptions = 17003
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldOptions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldOptions,
+		Message:  "Select fields need at least one option and all options must be unique and not empty. Other fields can't have options.",
+	}
+}
+
+// ErrInvalidCustomFieldValue represents an error where the value of a custom field on a task does not fit the field
+type ErrInvalidCustomFieldValue struct {
+	FieldID int64
+	Value   interface{}
+}
+
+// IsErrInvalidCustomFieldValue checks if an error is ErrInvalidCustomFieldValue.
+func IsErrInvalidCustomFieldValue(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldValue)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldValue) Error() string {
+	return fmt.Sprintf("Custom field value is invalid [FieldID: %d, Value: %v]", err.FieldID, err.Value)
+}
+
+// ErrCodeInvalidCustomFieldValue holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldValue = 17004
+
+// HTTPError holds the http error description
+func (err ErrInvalidCustomFieldValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldValue,
+		Message:  fmt.Sprintf("The value for custom field %d does not match the type or options of the field.", err.FieldID),
+	}
+}
+
+// ====================
+// Task template errors
+// ====================
+
+// ErrTaskTemplateDoesNotExist represents an error where a task template does not exist
+type ErrTaskTemplateDoesNotExist struct {
+	TemplateID int64
+}
+
+// IsErrTaskTemplateDoesNotExist checks if an error is ErrTaskTemplateDoesNotExist.
+func IsErrTaskTemplateDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTaskTemplateDoesNotExist)
+	return ok
+}
+
+func (err *ErrTaskTemplateDoesNotExist) Error() string {
+	return fmt.Sprintf("Task template does not exist [TemplateID: %d]", err.TemplateID)
+}
+
+// ErrCodeTaskTemplateDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskTemplateDoesNotExist = 18001
+
+// HTTPError holds the http error description
+func (err ErrTaskTemplateDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskTemplateDoesNotExist,
+		Message:  "This task template does not exist.",
+	}
+}
+
+// ErrInvalidTaskTemplateReminder represents an error where a reminder of a task template is not relative to a task date
+type ErrInvalidTaskTemplateReminder struct {
+	TemplateID int64
+}
+
+// IsErrInvalidTaskTemplateReminder checks if an error is ErrInvalidTaskTemplateReminder.
+func IsErrInvalidTaskTemplateReminder(err error) bool {
+	_, ok := err.(*ErrInvalidTaskTemplateReminder)
+	return ok
+}
+
+func (err *ErrInvalidTaskTemplateReminder) Error() string {
+	return fmt.Sprintf("Task template reminder is not relative [TemplateID: %d]", err.TemplateID)
+}
+
+// ErrCodeInvalidTaskTemplateReminder holds the unique world-error code of this error
+const ErrCodeInvalidTaskTemplateReminder = 18002
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskTemplateReminder) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskTemplateReminder,
+		Message:  "All reminders of a task template must be relative to the due, start or end date of the task.",
+	}
+}
+
+// ============
+// Trash errors
+// ============
+
+// ErrTrashItemDoesNotExist represents an error where an item which should be restored is not in the trash
+type ErrTrashItemDoesNotExist struct {
+	Kind string
+	ID   int64
+}
+
+// IsErrTrashItemDoesNotExist checks if an error is ErrTrashItemDoesNotExist.
+func IsErrTrashItemDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTrashItemDoesNotExist)
+	return ok
+}
+
+func (err *ErrTrashItemDoesNotExist) Error() string {
+	return fmt.Sprintf("Trash item does not exist [Kind: %s, ID: %d]", err.Kind, err.ID)
+}
+
+// ErrCodeTrashItemDoesNotExist holds the unique world-error code of this error
+const ErrCodeTrashItemDoesNotExist = 19001
+
+// HTTPError holds the http error description
+func (err ErrTrashItemDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTrashItemDoesNotExist,
+		Message:  fmt.Sprintf("This %s is not in the trash.", err.Kind),
+	}
+}
+
+// ErrTrashItemParentIsDeleted represents an error where an item cannot be restored because the list or namespace
+// it belongs to is in the trash as well
+type ErrTrashItemParentIsDeleted struct {
+	Kind       string
+	ID         int64
+	ParentKind string
+	ParentID   int64
+}
+
+// IsErrTrashItemParentIsDeleted checks if an error is ErrTrashItemParentIsDeleted.
+func IsErrTrashItemParentIsDeleted(err error) bool {
+	_, ok := err.(*ErrTrashItemParentIsDeleted)
+	return ok
+}
+
+func (err *ErrTrashItemParentIsDeleted) Error() string {
+	return fmt.Sprintf("Trash item parent is deleted [Kind: %s, ID: %d, ParentKind: %s, ParentID: %d]", err.Kind, err.ID, err.ParentKind, err.ParentID)
+}
+
+// ErrCodeTrashItemParentIsDeleted holds the unique world-error code of this error
+const ErrCodeTrashItemParentIsDeleted = 19002
+
+// HTTPError holds the http error description
+func (err ErrTrashItemParentIsDeleted) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTrashItemParentIsDeleted,
+		Message:  fmt.Sprintf("The %s this %s belongs to is in the trash as well, restore it first.", err.ParentKind, err.Kind),
+	}
+}
@@ -17,6 +17,8 @@
 package models
 
 import (
+	"encoding/json"
+
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 )
@@ -277,3 +279,19 @@ type UserDataExportRequestedEvent struct {
 func (t *UserDataExportRequestedEvent) Name() string {
 	return "user.export.requested"
 }
+
+////////////////////
+// Webhook Events //
+////////////////////
+
+// WebhookDeliveryRequestedEvent represents a WebhookDeliveryRequestedEvent event
+type WebhookDeliveryRequestedEvent struct {
+	WebhookID int64
+	EventName string
+	Payload   json.RawMessage
+}
+
+// Name defines the name for WebhookDeliveryRequestedEvent
+func (t *WebhookDeliveryRequestedEvent) Name() string {
+	return "webhook.delivery.requested"
+}
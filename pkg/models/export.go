@@ -205,6 +205,66 @@ func exportListsAndTasks(s *xorm.Session, u *user.User, wr *zip.Writer) (err err
 		taskMap[c.TaskID].Comments = append(taskMap[c.TaskID].Comments, c)
 	}
 
+	activities := []*TaskActivity{}
+	err = s.
+		Join("LEFT", "tasks", "tasks.id = task_activities.task_id").
+		In("tasks.list_id", listIDs).
+		OrderBy("task_activities.id asc").
+		Find(&activities)
+	if err != nil {
+		return
+	}
+
+	err = addActorsToTaskActivities(s, activities)
+	if err != nil {
+		return
+	}
+
+	for _, a := range activities {
+		if _, exists := taskMap[a.TaskID]; !exists {
+			log.Debugf("[User Data Export] Task %d does not exist for activity %d, omitting", a.TaskID, a.ID)
+			continue
+		}
+		taskMap[a.TaskID].Activity = append(taskMap[a.TaskID].Activity, a)
+	}
+
+	timeEntries := []*TimeEntry{}
+	err = s.
+		Join("LEFT", "tasks", "tasks.id = time_entries.task_id").
+		In("tasks.list_id", listIDs).
+		OrderBy("time_entries.start_time asc").
+		Find(&timeEntries)
+	if err != nil {
+		return
+	}
+
+	err = addUsersToTimeEntries(s, timeEntries)
+	if err != nil {
+		return
+	}
+
+	for _, te := range timeEntries {
+		if _, exists := taskMap[te.TaskID]; !exists {
+			log.Debugf("[User Data Export] Task %d does not exist for time entry %d, omitting", te.TaskID, te.ID)
+			continue
+		}
+		taskMap[te.TaskID].TimeEntries = append(taskMap[te.TaskID].TimeEntries, te)
+	}
+
+	customFields := []*CustomField{}
+	err = s.In("list_id", listIDs).OrderBy("id asc").Find(&customFields)
+	if err != nil {
+		return
+	}
+
+	for _, f := range customFields {
+		if _, exists := listMap[f.ListID]; !exists {
+			log.Debugf("[User Data Export] List %d does not exist for custom field %d, omitting", f.ListID, f.ID)
+			continue
+		}
+		listMap[f.ListID].CustomFields = append(listMap[f.ListID].CustomFields, f)
+	}
+
 	buckets := []*Bucket{}
 	err = s.In("list_id", listIDs).Find(&buckets)
 	if err != nil {
@@ -0,0 +1,89 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"code.vikunja.io/api/pkg/user"
+
+	"xorm.io/xorm"
+)
+
+// TaskFeed holds everything needed to render a read-only calendar feed
+type TaskFeed struct {
+	Title string
+	Tasks []*Task
+}
+
+func getTasksForFeed(s *xorm.Session, u *user.User, tc *TaskCollection) (tasks []*Task, err error) {
+	result, _, _, err := tc.ReadAll(s, u, "", -1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]*Task), nil
+}
+
+// GetTaskFeedForList returns the feed with all tasks of a list
+func GetTaskFeedForList(s *xorm.Session, u *user.User, listID int64) (feed *TaskFeed, err error) {
+	l := &List{ID: listID}
+	can, _, err := l.CanRead(s, u)
+	if err != nil {
+		return nil, err
+	}
+	if !can {
+		return nil, ErrUserDoesNotHaveAccessToList{ListID: listID, UserID: u.ID}
+	}
+
+	tasks, err := getTasksForFeed(s, u, &TaskCollection{ListID: listID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskFeed{Title: l.Title, Tasks: tasks}, nil
+}
+
+// GetTaskFeedForSavedFilter returns the feed with all tasks matching a saved filter
+func GetTaskFeedForSavedFilter(s *xorm.Session, u *user.User, filterID int64) (feed *TaskFeed, err error) {
+	sf := &SavedFilter{ID: filterID}
+	can, _, err := sf.CanRead(s, u)
+	if err != nil {
+		return nil, err
+	}
+	if !can {
+		return nil, ErrGenericForbidden{}
+	}
+
+	tasks, err := getTasksForFeed(s, u, &TaskCollection{ListID: getListIDFromSavedFilterID(filterID)})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskFeed{Title: sf.Title, Tasks: tasks}, nil
+}
+
+// GetTaskFeedForAssignee returns the feed with all tasks assigned to a user
+func GetTaskFeedForAssignee(s *xorm.Session, u *user.User) (feed *TaskFeed, err error) {
+	tasks, err := getTasksForFeed(s, u, &TaskCollection{
+		FilterBy:         []string{"assignees"},
+		FilterComparator: []string{"in"},
+		FilterValue:      []string{u.Username},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaskFeed{Title: "Assigned to " + u.GetName(), Tasks: tasks}, nil
+}
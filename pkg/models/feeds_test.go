@@ -0,0 +1,91 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feedContainsTask(feed *TaskFeed, id int64) bool {
+	for _, t := range feed.Tasks {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetTaskFeedForList(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := GetTaskFeedForList(s, &user.User{ID: 1}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test1", feed.Title)
+		assert.True(t, feedContainsTask(feed, 1))
+		assert.False(t, feedContainsTask(feed, 32))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTaskFeedForList(s, &user.User{ID: 1}, 5)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToList(err))
+	})
+}
+
+func TestGetTaskFeedForSavedFilter(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		feed, err := GetTaskFeedForSavedFilter(s, &user.User{ID: 1}, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "testfilter1", feed.Title)
+		assert.False(t, feedContainsTask(feed, 1))
+	})
+	t.Run("not the owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTaskFeedForSavedFilter(s, &user.User{ID: 2}, 1)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestGetTaskFeedForAssignee(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	feed, err := GetTaskFeedForAssignee(s, &user.User{ID: 1, Username: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Assigned to user1", feed.Title)
+	assert.True(t, feedContainsTask(feed, 30))
+	assert.False(t, feedContainsTask(feed, 1))
+}
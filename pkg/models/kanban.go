@@ -263,7 +263,11 @@ func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
 
 	b.Position = calculateDefaultPosition(b.ID, b.Position)
 	_, err = s.Where("id = ?", b.ID).Update(b)
-	return
+	if err != nil {
+		return
+	}
+
+	return ensureBucketPositionSpacing(s, b)
 }
 
 // Update Updates an existing bucket
@@ -304,7 +308,11 @@ func (b *Bucket) Update(s *xorm.Session, a web.Auth) (err error) {
 			"position",
 		).
 		Update(b)
-	return
+	if err != nil {
+		return
+	}
+
+	return ensureBucketPositionSpacing(s, b)
 }
 
 // Delete removes a bucket, but no tasks
@@ -66,7 +66,29 @@ func (LabelTask) TableName() string {
 // @Router /tasks/{task}/labels/{label} [delete]
 func (lt *LabelTask) Delete(s *xorm.Session, a web.Auth) (err error) {
 	_, err = s.Delete(&LabelTask{LabelID: lt.LabelID, TaskID: lt.TaskID})
-	return err
+	if err != nil {
+		return err
+	}
+
+	title, err := getLabelTitleForActivity(s, lt.LabelID)
+	if err != nil {
+		return err
+	}
+
+	return recordTaskActivity(s, a, lt.TaskID, taskActivityFieldLabels, title, "")
+}
+
+// Returns the title of a label to record it in the task activity. Falls back to the label id if the label
+// does not exist.
+func getLabelTitleForActivity(s *xorm.Session, labelID int64) (string, error) {
+	label, err := getLabelByIDSimple(s, labelID)
+	if IsErrLabelDoesNotExist(err) {
+		return strconv.FormatInt(labelID, 10), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return label.Title, nil
 }
 
 // Create adds a label to a task
@@ -100,6 +122,16 @@ func (lt *LabelTask) Create(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	title, err := getLabelTitleForActivity(s, lt.LabelID)
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskActivity(s, a, lt.TaskID, taskActivityFieldLabels, "", title)
+	if err != nil {
+		return err
+	}
+
 	err = updateListByTaskID(s, lt.TaskID)
 	return
 }
@@ -270,7 +302,16 @@ func (t *Task) updateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 	if len(labels) == 0 && len(t.Labels) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(LabelTask{})
-		return err
+		if err != nil {
+			return err
+		}
+		for _, l := range t.Labels {
+			err = recordTaskActivity(s, creator, t.ID, taskActivityFieldLabels, l.Title, "")
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -299,6 +340,10 @@ func (t *Task) updateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 		// Put all labels which are only on the old list to the trash
 		if !found {
 			labelsToDelete = append(labelsToDelete, oldLabel.ID)
+			err = recordTaskActivity(s, creator, t.ID, taskActivityFieldLabels, oldLabel.Title, "")
+			if err != nil {
+				return err
+			}
 		} else {
 			t.Labels = append(t.Labels, oldLabel)
 		}
@@ -346,6 +391,10 @@ func (t *Task) updateTaskLabels(s *xorm.Session, creator web.Auth, labels []*Lab
 		if err != nil {
 			return err
 		}
+		err = recordTaskActivity(s, creator, t.ID, taskActivityFieldLabels, "", label.Title)
+		if err != nil {
+			return err
+		}
 		t.Labels = append(t.Labels, label)
 	}
 
@@ -41,6 +41,7 @@ func TestLabelTask_ReadAll(t *testing.T) {
 			Username:                     "user2",
 			Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 			Issuer:                       "local",
+			StorageQuota:                 user.DefaultStorageQuota,
 			EmailRemindersEnabled:        true,
 			OverdueTasksRemindersEnabled: true,
 			OverdueTasksRemindersTime:    "09:00",
@@ -52,6 +52,7 @@ func TestLabel_ReadAll(t *testing.T) {
 		Username:                     "user1",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -103,6 +104,7 @@ func TestLabel_ReadAll(t *testing.T) {
 							Username:                     "user2",
 							Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 							Issuer:                       "local",
+							StorageQuota:                 user.DefaultStorageQuota,
 							EmailRemindersEnabled:        true,
 							OverdueTasksRemindersEnabled: true,
 							OverdueTasksRemindersTime:    "09:00",
@@ -168,6 +170,7 @@ func TestLabel_ReadOne(t *testing.T) {
 		Username:                     "user1",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -230,6 +233,7 @@ func TestLabel_ReadOne(t *testing.T) {
 					Username:                     "user2",
 					Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 					Issuer:                       "local",
+					StorageQuota:                 user.DefaultStorageQuota,
 					EmailRemindersEnabled:        true,
 					OverdueTasksRemindersEnabled: true,
 					OverdueTasksRemindersTime:    "09:00",
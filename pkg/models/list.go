@@ -55,6 +55,11 @@ type List struct {
 	// Whether or not a list is archived.
 	IsArchived bool `xorm:"not null default false" json:"is_archived" query:"is_archived"`
 
+	// If true, tasks in this list cannot be marked as done as long as any of the tasks blocking them is not done.
+	EnforceBlockingRelations bool `xorm:"not null default false" json:"enforce_blocking_relations"`
+	// If true, tasks following another task are moved forward when the end date of the other task moves past their start date.
+	ShiftFollowingTasks bool `xorm:"not null default false" json:"shift_following_tasks"`
+
 	// The id of the file this list has set as background
 	BackgroundFileID int64 `xorm:"null" json:"-"`
 	// Holds extra information about the background set since some background providers require attribution or similar. If not null, the background can be accessed at /lists/{listID}/background
@@ -77,6 +82,10 @@ type List struct {
 	// A timestamp when this list was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
 
+	// When this list was moved to the trash and who did it. Items in the trash are only returned by the trash endpoint.
+	Deleted     time.Time `xorm:"deleted null INDEX" json:"-"`
+	DeletedByID int64     `xorm:"bigint null" json:"-"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -88,6 +97,8 @@ type ListWithTasksAndBuckets struct {
 	// Only used for migration.
 	Buckets          []*Bucket `xorm:"-" json:"buckets"`
 	BackgroundFileID int64     `xorm:"null" json:"background_file_id"`
+	// Only used for migration.
+	CustomFields []*CustomField `xorm:"-" json:"custom_fields"`
 }
 
 // TableName returns a better name for the lists table
@@ -294,8 +305,12 @@ func GetListSimpleByID(s *xorm.Session, listID int64) (list *List, err error) {
 		return nil, ErrListDoesNotExist{ID: listID}
 	}
 
+	// Lists in a namespace which is in the trash are hidden together with it
 	exists, err := s.
-		Where("id = ?", listID).
+		Where(builder.And(
+			builder.Eq{"id": listID},
+			builder.NotIn("namespace_id", builder.Select("id").From("namespaces").Where(builder.NotNull{"deleted"})),
+		)).
 		OrderBy("position").
 		Get(list)
 	if err != nil {
@@ -367,12 +382,16 @@ func getUserListsStatement(userID int64) *builder.Builder {
 		Join("LEFT", "team_members tm2", "tm2.team_id = tl.team_id").
 		Join("LEFT", "users_lists ul", "ul.list_id = l.id").
 		Join("LEFT", "users_namespaces un", "un.namespace_id = l.namespace_id").
-		Where(builder.Or(
-			builder.Eq{"tm.user_id": userID},
-			builder.Eq{"tm2.user_id": userID},
-			builder.Eq{"ul.user_id": userID},
-			builder.Eq{"un.user_id": userID},
-			builder.Eq{"l.owner_id": userID},
+		Where(builder.And(
+			builder.Or(
+				builder.Eq{"tm.user_id": userID},
+				builder.Eq{"tm2.user_id": userID},
+				builder.Eq{"ul.user_id": userID},
+				builder.Eq{"un.user_id": userID},
+				builder.Eq{"l.owner_id": userID},
+			),
+			builder.IsNull{"l.deleted"},
+			builder.IsNull{"n.deleted"},
 		)).
 		OrderBy("position").
 		GroupBy("l.id")
@@ -651,6 +670,8 @@ func UpdateList(s *xorm.Session, list *List, auth web.Auth, updateListBackground
 	colsToUpdate := []string{
 		"title",
 		"is_archived",
+		"enforce_blocking_relations",
+		"shift_following_tasks",
 		"identifier",
 		"hex_color",
 		"namespace_id",
@@ -680,6 +701,23 @@ func UpdateList(s *xorm.Session, list *List, auth web.Auth, updateListBackground
 		}
 	}
 
+	// Keep the old identifiers of all tasks working when the identifier of the list changes
+	oldList, err := GetListSimpleByID(s, list.ID)
+	if err != nil {
+		return err
+	}
+	if oldList.Identifier != list.Identifier {
+		tasks := []*Task{}
+		err = s.Unscoped().Where("list_id = ?", list.ID).Find(&tasks)
+		if err != nil {
+			return err
+		}
+		err = addTaskIdentifierRedirects(s, oldList.Identifier, tasks)
+		if err != nil {
+			return err
+		}
+	}
+
 	_, err = s.
 		ID(list.ID).
 		Cols(colsToUpdate...).
@@ -783,7 +821,7 @@ func (l *List) Create(s *xorm.Session, a web.Auth) (err error) {
 
 // Delete implements the delete method of CRUDable
 // @Summary Deletes a list
-// @Description Delets a list
+// @Description Moves a list with all of its tasks to the trash. It can be restored from there until it is removed permanently after the configured retention period.
 // @tags list
 // @Produce json
 // @Security JWTKeyAuth
@@ -795,26 +833,62 @@ func (l *List) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Router /lists/{id} [delete]
 func (l *List) Delete(s *xorm.Session, a web.Auth) (err error) {
 
+	// The tasks stay where they are, they are hidden together with the list.
+	err = moveToTrash(s, a, l.ID, &List{})
+	if err != nil {
+		return
+	}
+
+	return events.Dispatch(&ListDeletedEvent{
+		List: l,
+		Doer: a,
+	})
+}
+
+// deleteListPermanently removes a list with all of its tasks, regardless of whether it is in the trash or not.
+func deleteListPermanently(s *xorm.Session, l *List, a web.Auth) (err error) {
+
 	// Delete the list
-	_, err = s.ID(l.ID).Delete(&List{})
+	_, err = s.Unscoped().ID(l.ID).Delete(&List{})
 	if err != nil {
 		return
 	}
 
-	// Delete all tasks on that list
+	// Delete all tasks on that list, including the ones in the trash.
 	// Using the loop to make sure all related entities to all tasks are properly deleted as well.
-	tasks, _, _, err := getRawTasksForLists(s, []*List{l}, a, &taskOptions{})
+	tasks := []*Task{}
+	err = s.Unscoped().Where("list_id = ?", l.ID).Find(&tasks)
 	if err != nil {
 		return
 	}
 
 	for _, task := range tasks {
-		err = task.Delete(s, a)
+		err = deleteTaskPermanently(s, task, a)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Delete all custom fields, their values were deleted with the tasks
+	_, err = s.Where("list_id = ?", l.ID).Delete(&CustomField{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.Where("list_id = ?", l.ID).Delete(&TaskTemplate{})
+	if err != nil {
+		return
+	}
+
+	err = deleteWebhooksOf(s, builder.Eq{"list_id": l.ID})
+	if err != nil {
+		return
+	}
+
+	if !l.Deleted.IsZero() {
+		return nil
+	}
+
 	return events.Dispatch(&ListDeletedEvent{
 		List: l,
 		Doer: a,
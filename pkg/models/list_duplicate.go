@@ -108,7 +108,27 @@ func (ld *ListDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 
 	log.Debugf("Duplicated all buckets from list %d into %d", ld.ListID, ld.List.ID)
 
-	err = duplicateTasks(s, doer, ld, bucketMap)
+	// Duplicate custom fields
+	// Old field ID as key, new id as value
+	customFieldMap := make(map[int64]int64)
+	customFields := []*CustomField{}
+	err = s.Where("list_id = ?", ld.ListID).Find(&customFields)
+	if err != nil {
+		return
+	}
+	for _, f := range customFields {
+		oldID := f.ID
+		f.ID = 0
+		f.ListID = ld.List.ID
+		if err := f.Create(s, doer); err != nil {
+			return err
+		}
+		customFieldMap[oldID] = f.ID
+	}
+
+	log.Debugf("Duplicated all custom fields from list %d into %d", ld.ListID, ld.List.ID)
+
+	err = duplicateTasks(s, doer, ld, bucketMap, customFieldMap)
 	if err != nil {
 		return
 	}
@@ -202,7 +222,7 @@ func (ld *ListDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 	return
 }
 
-func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap map[int64]int64) (err error) {
+func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap map[int64]int64, customFieldMap map[int64]int64) (err error) {
 	// Get all tasks + all task details
 	tasks, _, _, err := getTasksForLists(s, []*List{{ID: ld.ListID}}, doer, &taskOptions{})
 	if err != nil {
@@ -224,6 +244,7 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap
 		t.ListID = ld.List.ID
 		t.BucketID = bucketMap[t.BucketID]
 		t.UID = ""
+		t.CustomFields = nil // Copied below
 		err := createTask(s, t, doer, false)
 		if err != nil {
 			return err
@@ -332,6 +353,27 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ListDuplicate, bucketMap
 
 	log.Debugf("Duplicated all comments from list %d into %d", ld.ListID, ld.List.ID)
 
+	// Custom field values
+	customFieldValues := []*TaskCustomFieldValue{}
+	err = s.In("task_id", oldTaskIDs).Find(&customFieldValues)
+	if err != nil {
+		return
+	}
+	for _, v := range customFieldValues {
+		fieldID, exists := customFieldMap[v.FieldID]
+		if !exists {
+			continue
+		}
+		v.ID = 0
+		v.FieldID = fieldID
+		v.TaskID = taskMap[v.TaskID]
+		if _, err := s.Insert(v); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Duplicated all custom field values from list %d into %d", ld.ListID, ld.List.ID)
+
 	// Relations in that list
 	// Low-Effort: Only copy those relations which are between tasks in the same list
 	// because we can do that without a lot of hassle
@@ -246,17 +246,55 @@ func TestList_CreateOrUpdate(t *testing.T) {
 }
 
 func TestList_Delete(t *testing.T) {
-	db.LoadAndAssertFixtures(t)
-	s := db.NewSession()
-	list := List{
-		ID: 1,
-	}
-	err := list.Delete(s, &user.User{ID: 1})
-	assert.NoError(t, err)
-	err = s.Commit()
-	assert.NoError(t, err)
-	db.AssertMissing(t, "lists", map[string]interface{}{
-		"id": 1,
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		list := List{
+			ID: 1,
+		}
+		err := list.Delete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		// The list is only moved to the trash, its tasks stay where they are
+		db.AssertExists(t, "lists", map[string]interface{}{
+			"id":            1,
+			"deleted_by_id": 1,
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":      1,
+			"list_id": 1,
+		}, false)
+		_, err = GetListSimpleByID(s, 1)
+		assert.Error(t, err)
+		assert.True(t, IsErrListDoesNotExist(err))
+	})
+	t.Run("permanently", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		list := &List{
+			ID: 1,
+		}
+		err := deleteListPermanently(s, list, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		db.AssertMissing(t, "lists", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"list_id": 1,
+		})
+		db.AssertMissing(t, "webhooks", map[string]interface{}{
+			"list_id": 1,
+		})
+		db.AssertMissing(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id": 1,
+		})
+		// Webhooks of other lists are not affected
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id": 3,
+		}, false)
 	})
 }
 
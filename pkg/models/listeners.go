@@ -19,6 +19,7 @@ package models
 import (
 	"encoding/json"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/log"
@@ -51,6 +52,15 @@ func RegisterListeners() {
 	events.RegisterListener((&TaskCreatedEvent{}).Name(), &HandleTaskCreateMentions{})
 	events.RegisterListener((&TaskUpdatedEvent{}).Name(), &HandleTaskUpdatedMentions{})
 	events.RegisterListener((&UserDataExportRequestedEvent{}).Name(), &HandleUserDataExport{})
+	for _, eventName := range realtimeEvents {
+		events.RegisterListener(eventName, &RecordRealtimeEvent{EventName: eventName})
+	}
+	if config.WebhooksEnabled.GetBool() {
+		for _, eventName := range webhookEvents {
+			events.RegisterListener(eventName, &WebhookListener{EventName: eventName})
+		}
+		events.RegisterListener((&WebhookDeliveryRequestedEvent{}).Name(), &SendWebhookDelivery{})
+	}
 }
 
 //////
@@ -55,12 +55,26 @@ func GetTables() []interface{} {
 		&LinkSharing{},
 		&TaskRelation{},
 		&TaskAttachment{},
+		&TaskAttachmentPreview{},
 		&TaskComment{},
 		&Bucket{},
 		&UnsplashPhoto{},
 		&SavedFilter{},
 		&Subscription{},
 		&Favorite{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&APIToken{},
+		&RealtimeEvent{},
+		&TaskActivity{},
+		&TaskRevision{},
+		&TimeEntry{},
+		&CustomField{},
+		&TaskCustomFieldValue{},
+		&TaskTemplate{},
+		&TaskChecklistItem{},
+		&TaskIdentifierRedirect{},
+		&TaskCompletion{},
 	}
 }
 
@@ -61,6 +61,10 @@ type Namespace struct {
 	// A timestamp when this namespace was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
 
+	// When this namespace was moved to the trash and who did it. Items in the trash are only returned by the trash endpoint.
+	Deleted     time.Time `xorm:"deleted null INDEX" json:"-"`
+	DeletedByID int64     `xorm:"bigint null" json:"-"`
+
 	// If set to true, will only return the namespaces, not their lists.
 	NamespacesOnly bool `xorm:"-" json:"-" query:"namespaces_only"`
 
@@ -268,7 +272,8 @@ func getNamespacesWithLists(s *xorm.Session, namespaces *map[int64]*NamespaceWit
 		Or("users_namespaces.user_id = ?", userID).
 		GroupBy("namespaces.id").
 		Where(filterCond).
-		Where(isArchivedCond)
+		Where(isArchivedCond).
+		Where(builder.IsNull{"namespaces.deleted"})
 	if limit > 0 {
 		query = query.Limit(limit, start)
 	}
@@ -289,6 +294,7 @@ func getNamespacesWithLists(s *xorm.Session, namespaces *map[int64]*NamespaceWit
 		GroupBy("namespaces.id").
 		Where(filterCond).
 		Where(isArchivedCond).
+		Where(builder.IsNull{"namespaces.deleted"}).
 		Count(&NamespaceWithLists{})
 	return numberOfTotalItems, err
 }
@@ -362,6 +368,8 @@ func getSharedListsInNamespace(s *xorm.Session, archived bool, doer *user.User)
 			builder.Eq{"ul.user_id": doer.ID},
 			builder.Neq{"l.owner_id": doer.ID},
 		)).
+		And(builder.IsNull{"l.deleted"}).
+		And(builder.NotIn("l.namespace_id", builder.Select("id").From("namespaces").Where(builder.NotNull{"deleted"}))).
 		GroupBy("l.id")
 	if !archived {
 		iListQuery.And("l.is_archived = false")
@@ -411,7 +419,11 @@ func getFavoriteLists(s *xorm.Session, lists []*List, namespaceIDs []int64, doer
 		From("tasks").
 		Join("INNER", "lists", "tasks.list_id = lists.id").
 		Join("INNER", "namespaces", "lists.namespace_id = namespaces.id").
-		Where(builder.In("namespaces.id", namespaceIDs))
+		Where(builder.And(
+			builder.In("namespaces.id", namespaceIDs),
+			builder.IsNull{"tasks.deleted"},
+			builder.IsNull{"lists.deleted"},
+		))
 
 	var favoriteCount int64
 	favoriteCount, err = s.
@@ -649,7 +661,7 @@ func CreateNewNamespaceForUser(s *xorm.Session, user *user.User) (err error) {
 
 // Delete deletes a namespace
 // @Summary Deletes a namespace
-// @Description Delets a namespace
+// @Description Moves a namespace with all of its lists to the trash. It can be restored from there until it is removed permanently after the configured retention period.
 // @tags namespace
 // @Produce json
 // @Security JWTKeyAuth
@@ -660,50 +672,61 @@ func CreateNewNamespaceForUser(s *xorm.Session, user *user.User) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /namespaces/{id} [delete]
 func (n *Namespace) Delete(s *xorm.Session, a web.Auth) (err error) {
-	return deleteNamespace(s, n, a, true)
-}
-
-func deleteNamespace(s *xorm.Session, n *Namespace, a web.Auth, withLists bool) (err error) {
 	// Check if the namespace exists
 	_, err = GetNamespaceByID(s, n.ID)
 	if err != nil {
 		return
 	}
 
-	// Delete the namespace
-	_, err = s.ID(n.ID).Delete(&Namespace{})
+	// The lists stay where they are, they are hidden together with the namespace.
+	err = moveToTrash(s, a, n.ID, &Namespace{})
 	if err != nil {
 		return
 	}
 
-	namespaceDeleted := &NamespaceDeletedEvent{
+	return events.Dispatch(&NamespaceDeletedEvent{
 		Namespace: n,
 		Doer:      a,
-	}
-
-	if !withLists {
-		return events.Dispatch(namespaceDeleted)
-	}
+	})
+}
 
-	// Delete all lists with their tasks
-	lists, err := GetListsByNamespaceID(s, n.ID, &user.User{})
+// deleteNamespacePermanently removes a namespace, regardless of whether it is in the trash or not.
+func deleteNamespacePermanently(s *xorm.Session, n *Namespace, a web.Auth, withLists bool) (err error) {
+	// Delete the namespace
+	_, err = s.Unscoped().ID(n.ID).Delete(&Namespace{})
 	if err != nil {
 		return
 	}
 
-	if len(lists) == 0 {
-		return events.Dispatch(namespaceDeleted)
+	err = deleteWebhooksOf(s, builder.Eq{"namespace_id": n.ID})
+	if err != nil {
+		return
 	}
 
-	// Looping over all lists to let the list handle properly cleaning up the tasks and everything else associated with it.
-	for _, list := range lists {
-		err = list.Delete(s, a)
+	if withLists {
+		// Looping over all lists to let the list handle properly cleaning up the tasks and everything else associated with it.
+		lists := []*List{}
+		err = s.Unscoped().Where("namespace_id = ?", n.ID).Find(&lists)
 		if err != nil {
-			return err
+			return
+		}
+
+		for _, list := range lists {
+			err = deleteListPermanently(s, list, a)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	return events.Dispatch(namespaceDeleted)
+	if !n.Deleted.IsZero() {
+		return nil
+	}
+
+	return events.Dispatch(&NamespaceDeletedEvent{
+		Namespace: n,
+		Doer:      a,
+	})
 }
 
 // Update implements the update method via the interface
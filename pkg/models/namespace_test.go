@@ -175,9 +175,47 @@ func TestNamespace_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
+		db.AssertExists(t, "namespaces", map[string]interface{}{
+			"id":            1,
+			"deleted_by_id": 1,
+		}, false)
+		// The lists are hidden together with the namespace
+		db.AssertExists(t, "lists", map[string]interface{}{
+			"id":           1,
+			"namespace_id": 1,
+		}, false)
+		_, err = GetNamespaceByID(s, 1)
+		assert.True(t, IsErrNamespaceDoesNotExist(err))
+		_, err = GetListSimpleByID(s, 1)
+		assert.True(t, IsErrListDoesNotExist(err))
+	})
+	t.Run("permanently", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		n := &Namespace{
+			ID: 1,
+		}
+		err := deleteNamespacePermanently(s, n, u, true)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
 		db.AssertMissing(t, "namespaces", map[string]interface{}{
 			"id": 1,
 		})
+		db.AssertMissing(t, "lists", map[string]interface{}{
+			"namespace_id": 1,
+		})
+		// The webhooks of the namespace and its lists are gone as well
+		db.AssertMissing(t, "webhooks", map[string]interface{}{
+			"namespace_id": 1,
+		})
+		db.AssertMissing(t, "webhooks", map[string]interface{}{
+			"list_id": 1,
+		})
+		db.AssertMissing(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id": 1,
+		})
 	})
 	t.Run("nonexisting", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
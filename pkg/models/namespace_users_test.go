@@ -148,6 +148,7 @@ func TestNamespaceUser_ReadAll(t *testing.T) {
 			Username:                     "user1",
 			Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 			Issuer:                       "local",
+			StorageQuota:                 user.DefaultStorageQuota,
 			EmailRemindersEnabled:        true,
 			OverdueTasksRemindersEnabled: true,
 			OverdueTasksRemindersTime:    "09:00",
@@ -162,6 +163,7 @@ func TestNamespaceUser_ReadAll(t *testing.T) {
 			Username:                     "user2",
 			Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 			Issuer:                       "local",
+			StorageQuota:                 user.DefaultStorageQuota,
 			EmailRemindersEnabled:        true,
 			OverdueTasksRemindersEnabled: true,
 			OverdueTasksRemindersTime:    "09:00",
@@ -0,0 +1,253 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/log"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// Clients put an entity between two others by using the middle of their positions. After enough moves between
+// the same neighbours there is no room left between them, so all positions of the neighbours are spread out again
+// once two of them are closer than this fraction of the range all positions of the list or bucket span.
+const minRelativePositionSpacing = 1e-9
+
+// Floats lose precision the larger they get, so two positions are always considered too close when they are closer
+// than this fraction of the position itself, even if the range of positions is small.
+const minPositionPrecision = 1e-12
+
+// The distance between positions after rebalancing, the same as calculateDefaultPosition uses.
+var positionSpacing = math.Pow(2, 16)
+
+// How many entities are updated in one query when rebalancing.
+const rebalanceBatchSize = 500
+
+type positionedEntity struct {
+	ID        int64
+	DefaultID int64
+	Position  float64
+}
+
+type positionRange struct {
+	MinPosition float64
+	MaxPosition float64
+}
+
+// positionIsTooClose checks if another entity matching cond has a position next to the given one.
+// Entities without a position (0) are not considered since they get a default position when they are read.
+func positionIsTooClose(s *xorm.Session, table, column string, cond builder.Cond, id int64, position float64) (bool, error) {
+	if position == 0 {
+		return false, nil
+	}
+
+	cond = builder.And(cond, builder.Neq{"id": id}, builder.Neq{column: 0})
+
+	r := &positionRange{}
+	has, err := s.
+		Table(table).
+		Select("MIN(" + column + ") AS min_position, MAX(" + column + ") AS max_position").
+		Where(cond).
+		Get(r)
+	if err != nil || !has {
+		return false, err
+	}
+
+	spacing := (math.Max(r.MaxPosition, position) - math.Min(r.MinPosition, position)) * minRelativePositionSpacing
+	spacing = math.Max(spacing, math.Abs(position)*minPositionPrecision)
+
+	return s.
+		Table(table).
+		Where(cond).
+		And(column+" >= ?", position-spacing).
+		And(column+" <= ?", position+spacing).
+		Exist()
+}
+
+// rebalancePositions spreads the positions of all entities matching cond out evenly, keeping their order.
+// Entities without a position are put where the default position they get when they are created
+// (calculated from defaultColumn) would put them.
+func rebalancePositions(s *xorm.Session, table, column, defaultColumn string, cond builder.Cond) error {
+	entities := []*positionedEntity{}
+	err := s.
+		Table(table).
+		Select("id, " + defaultColumn + " AS default_id, " + column + " AS position").
+		Where(cond).
+		Find(&entities)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entities {
+		e.Position = calculateDefaultPosition(e.DefaultID, e.Position)
+	}
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Position == entities[j].Position {
+			return entities[i].ID < entities[j].ID
+		}
+		return entities[i].Position < entities[j].Position
+	})
+
+	for start := 0; start < len(entities); start += rebalanceBatchSize {
+		end := start + rebalanceBatchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		// Updating the entities one by one takes ages for large lists, hence all entities of a batch are updated
+		// in one query. The positions are numbers we calculated ourselves, so it's safe to put them in the query.
+		ids := make([]int64, 0, end-start)
+		query := strings.Builder{}
+		query.WriteString("UPDATE " + table + " SET " + column + " = CASE id")
+		for i := start; i < end; i++ {
+			ids = append(ids, entities[i].ID)
+			query.WriteString(" WHEN " + strconv.FormatInt(entities[i].ID, 10) +
+				" THEN " + strconv.FormatFloat(float64(i+1)*positionSpacing, 'f', -1, 64))
+		}
+		query.WriteString(" END WHERE ")
+
+		where, args, err := builder.ToSQL(builder.In("id", ids))
+		if err != nil {
+			return err
+		}
+		query.WriteString(where)
+
+		_, err = s.Exec(append([]interface{}{query.String()}, args...)...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func rebalanceTaskPositions(s *xorm.Session, listID int64) error {
+	log.Debugf("Rebalancing positions of tasks in list %d", listID)
+	return rebalancePositions(s, "tasks", "position", "`index`", builder.Eq{"list_id": listID})
+}
+
+func rebalanceTaskKanbanPositions(s *xorm.Session, bucketID int64) error {
+	log.Debugf("Rebalancing kanban positions of tasks in bucket %d", bucketID)
+	return rebalancePositions(s, "tasks", "kanban_position", "`index`", builder.Eq{"bucket_id": bucketID})
+}
+
+func rebalanceBucketPositions(s *xorm.Session, listID int64) error {
+	log.Debugf("Rebalancing positions of buckets in list %d", listID)
+	return rebalancePositions(s, "buckets", "position", "id", builder.Eq{"list_id": listID})
+}
+
+// ensureTaskPositionSpacing rebalances the positions of the neighbours of a task if there is no room left
+// between them and updates the positions of the task with the new ones.
+func ensureTaskPositionSpacing(s *xorm.Session, t *Task, checkPosition, checkKanbanPosition bool) (err error) {
+	rebalanced := false
+
+	if checkPosition {
+		tooClose, err := positionIsTooClose(s, "tasks", "position", builder.Eq{"list_id": t.ListID}, t.ID, t.Position)
+		if err != nil {
+			return err
+		}
+		if tooClose {
+			if err := rebalanceTaskPositions(s, t.ListID); err != nil {
+				return err
+			}
+			rebalanced = true
+		}
+	}
+
+	if checkKanbanPosition && t.BucketID != 0 {
+		tooClose, err := positionIsTooClose(s, "tasks", "kanban_position", builder.Eq{"bucket_id": t.BucketID}, t.ID, t.KanbanPosition)
+		if err != nil {
+			return err
+		}
+		if tooClose {
+			if err := rebalanceTaskKanbanPositions(s, t.BucketID); err != nil {
+				return err
+			}
+			rebalanced = true
+		}
+	}
+
+	if !rebalanced {
+		return nil
+	}
+
+	positions := &Task{}
+	_, err = s.ID(t.ID).Cols("position", "kanban_position").Get(positions)
+	if err != nil {
+		return err
+	}
+	t.Position = positions.Position
+	t.KanbanPosition = positions.KanbanPosition
+	return nil
+}
+
+// ensureBucketPositionSpacing does the same as ensureTaskPositionSpacing for buckets
+func ensureBucketPositionSpacing(s *xorm.Session, b *Bucket) (err error) {
+	tooClose, err := positionIsTooClose(s, "buckets", "position", builder.Eq{"list_id": b.ListID}, b.ID, b.Position)
+	if err != nil || !tooClose {
+		return err
+	}
+
+	if err := rebalanceBucketPositions(s, b.ListID); err != nil {
+		return err
+	}
+
+	positions := &Bucket{}
+	_, err = s.ID(b.ID).Cols("position").Get(positions)
+	if err != nil {
+		return err
+	}
+	b.Position = positions.Position
+	return nil
+}
+
+// RepairPositions spreads out the positions of all tasks and buckets evenly, keeping their order.
+// It returns the number of lists and buckets whose positions were rebalanced.
+func RepairPositions(s *xorm.Session) (lists int, buckets int, err error) {
+	listIDs := []int64{}
+	err = s.Table("lists").Cols("id").Find(&listIDs)
+	if err != nil {
+		return
+	}
+
+	for _, id := range listIDs {
+		if err = rebalanceTaskPositions(s, id); err != nil {
+			return
+		}
+		if err = rebalanceBucketPositions(s, id); err != nil {
+			return
+		}
+	}
+
+	bucketIDs := []int64{}
+	err = s.Table("buckets").Cols("id").Find(&bucketIDs)
+	if err != nil {
+		return
+	}
+
+	for _, id := range bucketIDs {
+		if err = rebalanceTaskKanbanPositions(s, id); err != nil {
+			return
+		}
+	}
+
+	return len(listIDs), len(bucketIDs), nil
+}
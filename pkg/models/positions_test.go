@@ -0,0 +1,224 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func TestTask_Update_RebalancePositions(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("enough room", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Position: 3}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		assert.Equal(t, float64(3), task.Position)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       2,
+			"position": 4,
+		}, false)
+	})
+	t.Run("no room left", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Position: 4 + 1e-12}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+
+		task2 := &Task{}
+		_, err = s.ID(2).Get(task2)
+		assert.NoError(t, err)
+		assert.Equal(t, task2.Position+positionSpacing, task.Position)
+		assertPositionsAreSpread(t, s, "tasks", "position", "list_id", 1)
+	})
+	t.Run("tasks without a position keep their order", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Position: 4 + 1e-12}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+
+		// The tasks with a position come first because the default positions of the others are larger
+		for i, id := range []int64{2, 1, 3, 4, 5} {
+			db.AssertExists(t, "tasks", map[string]interface{}{
+				"id":       id,
+				"position": float64(i+1) * positionSpacing,
+			}, false)
+		}
+	})
+	t.Run("kanban position", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task2 := &Task{ID: 2, Title: "test", ListID: 1, KanbanPosition: 1}
+		err := task2.Update(s, u)
+		assert.NoError(t, err)
+		task := &Task{ID: 1, Title: "test", ListID: 1, KanbanPosition: 1 - 1e-13}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+
+		task2 = &Task{}
+		_, err = s.ID(2).Get(task2)
+		assert.NoError(t, err)
+		assert.Equal(t, task2.KanbanPosition-positionSpacing, task.KanbanPosition)
+		assertPositionsAreSpread(t, s, "tasks", "kanban_position", "bucket_id", 1)
+	})
+	t.Run("close but not too close for the range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Position: 4.001}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		assert.Equal(t, 4.001, task.Position)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       2,
+			"position": 4,
+		}, false)
+	})
+	t.Run("gap relative to a large range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task3 := &Task{ID: 3, Title: "test", ListID: 1, Position: 1e12}
+		err := task3.Update(s, u)
+		assert.NoError(t, err)
+		task := &Task{ID: 1, Title: "test", ListID: 1, Position: 4.5}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+
+		task2 := &Task{}
+		_, err = s.ID(2).Get(task2)
+		assert.NoError(t, err)
+		assert.Equal(t, task2.Position+positionSpacing, task.Position)
+		assertPositionsAreSpread(t, s, "tasks", "position", "list_id", 1)
+	})
+	t.Run("tasks without a position", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Table("tasks").Where("list_id = ? AND id != ?", 1, 2).Update(map[string]interface{}{"position": 0})
+		assert.NoError(t, err)
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Position: 0.001}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.001, task.Position)
+		task = &Task{ID: 1, Title: "test", ListID: 1, Position: 0}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), task.Position)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       2,
+			"position": 4,
+		}, false)
+	})
+}
+
+func TestRebalancePositions_Batches(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	for i := 0; i < rebalanceBatchSize+10; i++ {
+		_, err := s.Insert(&Task{Title: "batch", ListID: 2, CreatedByID: 1, Index: int64(1000 + i), Position: float64(rebalanceBatchSize + 10 - i)})
+		assert.NoError(t, err)
+	}
+
+	err := rebalanceTaskPositions(s, 2)
+	assert.NoError(t, err)
+	assertPositionsAreSpread(t, s, "tasks", "position", "list_id", 2)
+}
+
+// assertPositionsAreSpread checks all positions of a list or bucket are evenly spread out
+func assertPositionsAreSpread(t *testing.T, s *xorm.Session, table, column, parentColumn string, parentID int64) {
+	positions := []float64{}
+	err := s.Table(table).Where(parentColumn+" = ?", parentID).OrderBy(column).Cols(column).Find(&positions)
+	assert.NoError(t, err)
+	for i, p := range positions {
+		assert.Equal(t, float64(i+1)*positionSpacing, p)
+	}
+}
+
+func TestBucket_Update_RebalancePositions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	b := &Bucket{ID: 3, ListID: 1, Title: "testbucket3", Position: 2 + 1e-12}
+	err := b.Update(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3*positionSpacing, b.Position)
+	db.AssertExists(t, "buckets", map[string]interface{}{
+		"id":       1,
+		"position": positionSpacing,
+	}, false)
+	db.AssertExists(t, "buckets", map[string]interface{}{
+		"id":       2,
+		"position": 2 * positionSpacing,
+	}, false)
+}
+
+func TestRepairPositions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	lists, buckets, err := RepairPositions(s)
+	assert.NoError(t, err)
+	assert.NotZero(t, lists)
+	assert.NotZero(t, buckets)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	assertPositionsAreSpread(t, s, "tasks", "position", "list_id", 1)
+	assertPositionsAreSpread(t, s, "tasks", "kanban_position", "bucket_id", 1)
+	db.AssertExists(t, "buckets", map[string]interface{}{
+		"id":       3,
+		"position": 3 * positionSpacing,
+	}, false)
+}
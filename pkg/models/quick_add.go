@@ -0,0 +1,250 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/quickadd"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// QuickAddTasks creates tasks from lines of text with quick add magic
+type QuickAddTasks struct {
+	// The text to create tasks from. Every line which is not empty becomes a task.
+	Text string `json:"text" valid:"required"`
+	// The list tasks are created in if they don't mention one with +list. If 0, the default list of the user is used.
+	ListID int64 `json:"list_id"`
+
+	// The created tasks
+	Tasks []*Task `json:"tasks"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// quickAddContext caches everything looked up while creating multiple tasks at once
+type quickAddContext struct {
+	user    *user.User
+	options *quickadd.Options
+	lists   []*List
+	// All labels by their lowercase title
+	labels map[string]*Label
+	// All users by their lowercase username
+	users map[string]*user.User
+}
+
+// Create creates tasks from quick add magic
+// @Summary Create tasks with quick add magic
+// @Description Creates a task for every line of the text. Labels (*label), the list (+list), assignees (@user), the priority (!1 to !5), dates like "next monday at 15:00" and repetitions like "every week" are parsed from each line and removed from its title. Dates are relative to the timezone of the user and understood in the language of the user. Labels which don't exist yet are created.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param quickadd body models.QuickAddTasks true "The text to create tasks from."
+// @Success 201 {object} models.QuickAddTasks "The created tasks."
+// @Failure 400 {object} web.HTTPError "No list to create the tasks in."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to a list."
+// @Failure 404 {object} web.HTTPError "A list or user mentioned in the text does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/quick-add [put]
+func (qa *QuickAddTasks) Create(s *xorm.Session, a web.Auth) (err error) {
+	u, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return err
+	}
+
+	ctx := &quickAddContext{
+		user: u,
+		options: &quickadd.Options{
+			Now:       time.Now().In(u.GetTimezone()),
+			WeekStart: time.Weekday(u.WeekStart),
+			Language:  u.Language,
+		},
+		labels: make(map[string]*Label),
+		users:  make(map[string]*user.User),
+	}
+
+	if qa.ListID == 0 {
+		qa.ListID = u.DefaultListID
+	}
+
+	qa.Tasks = []*Task{}
+	for _, line := range strings.Split(qa.Text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		task, err := qa.createTask(s, a, ctx, line)
+		if err != nil {
+			return err
+		}
+		qa.Tasks = append(qa.Tasks, task)
+	}
+
+	return nil
+}
+
+func (qa *QuickAddTasks) createTask(s *xorm.Session, a web.Auth, ctx *quickAddContext, line string) (task *Task, err error) {
+	result := quickadd.Parse(line, ctx.options)
+
+	task = &Task{
+		Title:      result.Title,
+		ListID:     qa.ListID,
+		Priority:   result.Priority,
+		DueDate:    result.Date,
+		RepeatRule: result.RepeatRule,
+	}
+	// A line which only consists of magic is most likely meant as the title
+	if task.Title == "" {
+		task.Title = line
+	}
+
+	if result.List != "" {
+		list, err := ctx.getList(s, result.List)
+		if err != nil {
+			return nil, err
+		}
+		task.ListID = list.ID
+	}
+	if task.ListID == 0 {
+		return nil, &ErrQuickAddNoList{}
+	}
+
+	canWrite, err := (&List{ID: task.ListID}).CanWrite(s, a)
+	if err != nil {
+		return nil, err
+	}
+	if !canWrite {
+		return nil, ErrGenericForbidden{}
+	}
+
+	for _, username := range result.Assignees {
+		assignee, err := ctx.getUser(s, username)
+		if err != nil {
+			return nil, err
+		}
+		task.Assignees = append(task.Assignees, assignee)
+	}
+
+	err = createTask(s, task, a, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Labels) > 0 {
+		labels := make([]*Label, 0, len(result.Labels))
+		for _, title := range result.Labels {
+			label, err := ctx.getOrCreateLabel(s, a, title)
+			if err != nil {
+				return nil, err
+			}
+			labels = append(labels, label)
+		}
+		err = task.updateTaskLabels(s, a, labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	task = &Task{ID: task.ID}
+	err = task.ReadOne(s, a)
+	return task, err
+}
+
+// getList finds a list of the user by its identifier or its title
+func (ctx *quickAddContext) getList(s *xorm.Session, title string) (list *List, err error) {
+	if ctx.lists == nil {
+		ctx.lists, _, _, err = getRawListsForUser(s, &listOptions{
+			user: ctx.user,
+			page: -1,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, l := range ctx.lists {
+		if l.Identifier != "" && strings.EqualFold(l.Identifier, title) {
+			return l, nil
+		}
+	}
+	for _, l := range ctx.lists {
+		if strings.EqualFold(l.Title, title) {
+			return l, nil
+		}
+	}
+
+	return nil, &ErrQuickAddListDoesNotExist{List: title}
+}
+
+func (ctx *quickAddContext) getUser(s *xorm.Session, username string) (*user.User, error) {
+	key := strings.ToLower(username)
+	if u, has := ctx.users[key]; has {
+		return u, nil
+	}
+
+	u, err := user.GetUserByUsername(s, username)
+	if user.IsErrUserDoesNotExist(err) {
+		return nil, &ErrQuickAddUserDoesNotExist{Username: username}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.users[key] = u
+	return u, nil
+}
+
+// getOrCreateLabel finds a label the user has access to by its title and creates it if there is none
+func (ctx *quickAddContext) getOrCreateLabel(s *xorm.Session, a web.Auth, title string) (*Label, error) {
+	key := strings.ToLower(title)
+	if l, has := ctx.labels[key]; has {
+		return l, nil
+	}
+
+	labels, _, _, err := getLabelsByTaskIDs(s, &LabelByTaskIDsOptions{
+		User:                ctx.user,
+		Search:              title,
+		GetForUser:          ctx.user.ID,
+		GetUnusedLabels:     true,
+		GroupByLabelIDsOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range labels {
+		if strings.EqualFold(l.Title, title) {
+			label := l.Label
+			ctx.labels[key] = &label
+			return &label, nil
+		}
+	}
+
+	label := &Label{Title: title}
+	err = label.Create(s, a)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.labels[key] = label
+	return label, nil
+}
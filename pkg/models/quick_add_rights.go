@@ -0,0 +1,36 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can create tasks with quick add magic.
+// Write access to the lists the tasks end up in is checked when creating each of them.
+func (qa *QuickAddTasks) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Link shares neither have labels nor a default list
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	if qa.ListID != 0 {
+		return (&List{ID: qa.ListID}).CanWrite(s, a)
+	}
+
+	return true, nil
+}
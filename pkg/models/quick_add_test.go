@@ -0,0 +1,145 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuickAddTasks_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{
+			Text:   "Buy milk *'Label #1' *groceries @user1 !3 tomorrow every week\n\n  Call mom +Test7  \n",
+			ListID: 1,
+		}
+		can, err := qa.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = qa.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		assert.Len(t, qa.Tasks, 2)
+
+		task := qa.Tasks[0]
+		assert.Equal(t, "Buy milk", task.Title)
+		assert.Equal(t, int64(1), task.ListID)
+		assert.Equal(t, int64(3), task.Priority)
+		assert.Equal(t, "FREQ=WEEKLY", task.RepeatRule)
+		assert.False(t, task.DueDate.IsZero())
+		assert.Len(t, task.Assignees, 1)
+		assert.Equal(t, int64(1), task.Assignees[0].ID)
+		assert.Len(t, task.Labels, 2)
+		db.AssertExists(t, "label_tasks", map[string]interface{}{
+			"task_id":  task.ID,
+			"label_id": 1,
+		}, false)
+		db.AssertExists(t, "labels", map[string]interface{}{
+			"title":         "groceries",
+			"created_by_id": 1,
+		}, false)
+
+		assert.Equal(t, "Call mom", qa.Tasks[1].Title)
+		assert.Equal(t, int64(7), qa.Tasks[1].ListID)
+	})
+	t.Run("list by identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "Task +TEST7"}
+		err := qa.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), qa.Tasks[0].ListID)
+	})
+	t.Run("same new label twice", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "One *new\nTwo *New", ListID: 1}
+		err := qa.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, qa.Tasks[0].Labels[0].ID, qa.Tasks[1].Labels[0].ID)
+	})
+	t.Run("no list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "Task"}
+		err := qa.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrQuickAddNoList(err))
+	})
+	t.Run("list does not exist", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "Task +nonexistent", ListID: 1}
+		err := qa.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrQuickAddListDoesNotExist(err))
+	})
+	t.Run("user does not exist", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "Task @nonexistent", ListID: 1}
+		err := qa.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrQuickAddUserDoesNotExist(err))
+	})
+	t.Run("list with read access only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "Task +Test3", ListID: 1}
+		err := qa.Create(s, u)
+		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrGenericForbidden{})
+
+		qa = &QuickAddTasks{Text: "Task", ListID: 3}
+		can, err := qa.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		qa := &QuickAddTasks{Text: "Task", ListID: 1}
+		can, err := qa.CanCreate(s, &LinkSharing{ID: 1, ListID: 1, Right: RightWrite})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
@@ -0,0 +1,308 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/web"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// RealtimeEvent is an event which is streamed to all connected clients who are allowed to see it
+type RealtimeEvent struct {
+	// The unique, numeric id of this event. Pass it as last event id to resume a stream after a disconnect.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The name of the event, for example task.updated
+	EventName string `xorm:"varchar(250) not null" json:"event_name"`
+	// The task this event belongs to, if any.
+	TaskID int64 `xorm:"bigint null" json:"task_id"`
+	// The list this event belongs to, if any.
+	ListID int64 `xorm:"bigint null index" json:"list_id"`
+	// The namespace this event belongs to, if any.
+	NamespaceID int64 `xorm:"bigint null" json:"namespace_id"`
+	// The event itself, as it was dispatched.
+	Payload json.RawMessage `xorm:"longtext null" json:"payload"`
+	// A timestamp when this event happened.
+	Created time.Time `xorm:"created not null index" json:"created"`
+}
+
+// TableName returns the table name for realtime events
+func (*RealtimeEvent) TableName() string {
+	return "realtime_events"
+}
+
+const (
+	// Events are kept so clients can resume a stream after a short disconnect
+	realtimeEventRetention = 24 * time.Hour
+	// The maximum number of events returned at once
+	realtimeEventBatchSize = 100
+)
+
+// realtimeEvents holds all events which are streamed to clients
+var realtimeEvents = []string{
+	(&TaskCreatedEvent{}).Name(),
+	(&TaskUpdatedEvent{}).Name(),
+	(&TaskDeletedEvent{}).Name(),
+	(&TaskAssigneeCreatedEvent{}).Name(),
+	(&TaskCommentCreatedEvent{}).Name(),
+	(&TaskCommentUpdatedEvent{}).Name(),
+	(&ListCreatedEvent{}).Name(),
+	(&ListUpdatedEvent{}).Name(),
+	(&ListDeletedEvent{}).Name(),
+	(&NamespaceUpdatedEvent{}).Name(),
+	(&NamespaceDeletedEvent{}).Name(),
+}
+
+var (
+	realtimeNotifyLock sync.Mutex
+	realtimeNotify     = make(chan struct{})
+)
+
+// RealtimeEventsAvailable returns a channel which is closed as soon as a new realtime event was recorded on this instance.
+// Events recorded by other instances are only noticed when polling.
+func RealtimeEventsAvailable() <-chan struct{} {
+	realtimeNotifyLock.Lock()
+	defer realtimeNotifyLock.Unlock()
+	return realtimeNotify
+}
+
+func notifyRealtimeSubscribers() {
+	realtimeNotifyLock.Lock()
+	defer realtimeNotifyLock.Unlock()
+	close(realtimeNotify)
+	realtimeNotify = make(chan struct{})
+}
+
+// removeEmailsFromEventPayload makes sure no email addresses of users included in an event are sent to everyone
+// receiving the event, like realtime subscribers or webhooks
+// who can see the event.
+func removeEmailsFromEventPayload(payload []byte) ([]byte, error) {
+	var content interface{}
+	err := json.Unmarshal(payload, &content)
+	if err != nil {
+		return nil, err
+	}
+
+	var clean func(v interface{})
+	clean = func(v interface{}) {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			delete(val, "email")
+			for _, child := range val {
+				clean(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				clean(child)
+			}
+		}
+	}
+	clean(content)
+
+	return json.Marshal(content)
+}
+
+// RecordRealtimeEvent represents a listener
+type RecordRealtimeEvent struct {
+	EventName string
+}
+
+// Name defines the name for the RecordRealtimeEvent listener
+func (r *RecordRealtimeEvent) Name() string {
+	return "realtime.record"
+}
+
+// Handle is executed when the event RecordRealtimeEvent listens on is fired
+func (r *RecordRealtimeEvent) Handle(msg *message.Message) (err error) {
+	entities := &eventEntities{}
+	err = json.Unmarshal(msg.Payload, entities)
+	if err != nil {
+		return err
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	taskID, listID, namespaceID, err := entities.resolveIDs(s)
+	if err != nil {
+		return err
+	}
+
+	payload, err := removeEmailsFromEventPayload(msg.Payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(&RealtimeEvent{
+		EventName:   r.EventName,
+		TaskID:      taskID,
+		ListID:      listID,
+		NamespaceID: namespaceID,
+		Payload:     payload,
+	})
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+
+	err = s.Commit()
+	if err != nil {
+		return err
+	}
+
+	notifyRealtimeSubscribers()
+	return nil
+}
+
+// realtimeRightsCache caches the read rights of one user for lists and namespaces while checking a batch of events.
+type realtimeRightsCache struct {
+	s          *xorm.Session
+	a          web.Auth
+	lists      map[int64]bool
+	namespaces map[int64]bool
+}
+
+func (rc *realtimeRightsCache) canReadNamespace(namespaceID int64) (bool, error) {
+	if can, has := rc.namespaces[namespaceID]; has {
+		return can, nil
+	}
+
+	can, _, err := (&Namespace{ID: namespaceID}).CanRead(rc.s, rc.a)
+	if err != nil && !IsErrNamespaceDoesNotExist(err) {
+		return false, err
+	}
+	rc.namespaces[namespaceID] = can
+	return can, nil
+}
+
+func (rc *realtimeRightsCache) canReadList(listID int64) (bool, error) {
+	if can, has := rc.lists[listID]; has {
+		return can, nil
+	}
+
+	can, _, err := (&List{ID: listID}).CanRead(rc.s, rc.a)
+	if err != nil && !IsErrListDoesNotExist(err) {
+		return false, err
+	}
+	rc.lists[listID] = can
+	return can, nil
+}
+
+func (rc *realtimeRightsCache) canRead(e *RealtimeEvent) (bool, error) {
+	// Task rights are derived from the list, checking the list directly also works for deleted tasks.
+	if e.ListID != 0 {
+		can, err := rc.canReadList(e.ListID)
+		if err != nil || can {
+			return can, err
+		}
+
+		// The list might have been deleted, in that case everyone who could see the namespace should know about it.
+		if e.EventName != (&ListDeletedEvent{}).Name() {
+			return false, nil
+		}
+	}
+
+	if e.NamespaceID != 0 {
+		return rc.canReadNamespace(e.NamespaceID)
+	}
+
+	return false, nil
+}
+
+// GetRealtimeEventsSince returns all realtime events after the event with the provided id the user has read access to.
+// If list ids are provided, only events of these lists are returned.
+// Because events the user can't see are skipped, it also returns the id of the last event which was checked. That's
+// the id to continue from on the next call.
+func GetRealtimeEventsSince(s *xorm.Session, a web.Auth, lastEventID int64, listIDs []int64) (result []*RealtimeEvent, lastCheckedID int64, err error) {
+	cond := builder.NewCond().And(builder.Gt{"id": lastEventID})
+	if len(listIDs) > 0 {
+		cond = cond.And(builder.In("list_id", listIDs))
+	}
+
+	events := []*RealtimeEvent{}
+	err = s.
+		Where(cond).
+		OrderBy("id asc").
+		Limit(realtimeEventBatchSize).
+		Find(&events)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rc := &realtimeRightsCache{
+		s:          s,
+		a:          a,
+		lists:      make(map[int64]bool),
+		namespaces: make(map[int64]bool),
+	}
+
+	lastCheckedID = lastEventID
+	result = []*RealtimeEvent{}
+	for _, e := range events {
+		can, err := rc.canRead(e)
+		if err != nil {
+			return nil, 0, err
+		}
+		if can {
+			result = append(result, e)
+		}
+		lastCheckedID = e.ID
+	}
+
+	return
+}
+
+// GetLatestRealtimeEventID returns the id of the latest realtime event. Used to start a stream without replaying
+// older events.
+func GetLatestRealtimeEventID(s *xorm.Session) (id int64, err error) {
+	e := &RealtimeEvent{}
+	_, err = s.OrderBy("id desc").Get(e)
+	return e.ID, err
+}
+
+// RegisterRealtimeEventCleanupCron registers a cron function to remove old realtime events
+func RegisterRealtimeEventCleanupCron() {
+	const logPrefix = "[Realtime Event Cleanup Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		deleted, err := s.
+			Where("created < ?", time.Now().Add(-realtimeEventRetention)).
+			Delete(&RealtimeEvent{})
+		if err != nil {
+			log.Errorf(logPrefix+"Error removing old realtime events: %s", err)
+			return
+		}
+		if deleted > 0 {
+			log.Debugf(logPrefix+"Deleted %d old realtime events", deleted)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register realtime event cleanup cron: %s", err)
+	}
+}
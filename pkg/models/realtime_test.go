@@ -0,0 +1,106 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRealtimeEventsSince(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("all events", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		evts, lastID, err := GetRealtimeEventsSince(s, u, 0, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), lastID)
+		assert.Len(t, evts, 3)
+		assert.Equal(t, int64(1), evts[0].ID)
+		assert.Equal(t, int64(3), evts[1].ID)
+		assert.Equal(t, int64(4), evts[2].ID)
+	})
+	t.Run("resume from last event id", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		evts, _, err := GetRealtimeEventsSince(s, u, 3, nil)
+		assert.NoError(t, err)
+		assert.Len(t, evts, 1)
+		assert.Equal(t, int64(4), evts[0].ID)
+	})
+	t.Run("filtered by list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		evts, _, err := GetRealtimeEventsSince(s, u, 0, []int64{1})
+		assert.NoError(t, err)
+		assert.Len(t, evts, 2)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		evts, lastID, err := GetRealtimeEventsSince(s, u, 0, []int64{5})
+		assert.NoError(t, err)
+		assert.Len(t, evts, 0)
+		assert.Equal(t, int64(2), lastID)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		evts, _, err := GetRealtimeEventsSince(s, &LinkSharing{ID: 1, ListID: 1, Right: RightRead}, 0, nil)
+		assert.NoError(t, err)
+		assert.Len(t, evts, 2)
+	})
+}
+
+func TestRecordRealtimeEvent(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+
+	events.TestListener(t, &TaskUpdatedEvent{
+		Task: &Task{ID: 1, ListID: 1},
+		Doer: &user.User{ID: 1, Username: "user1", Email: "user1@example.com"},
+	}, &RecordRealtimeEvent{EventName: "task.updated"})
+
+	db.AssertExists(t, "realtime_events", map[string]interface{}{
+		"event_name":   "task.updated",
+		"task_id":      1,
+		"list_id":      1,
+		"namespace_id": 1,
+	}, false)
+
+	s := db.NewSession()
+	defer s.Close()
+	e := &RealtimeEvent{}
+	_, err := s.OrderBy("id desc").Get(e)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(e.Payload), "user1@example.com")
+}
@@ -0,0 +1,228 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskActivity represents a single change made to a task
+type TaskActivity struct {
+	// The unique, numeric id of this activity entry.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+	// The changed field. This is either the name of a task property, like `due_date`, or one of `assignees`, `labels`,
+	// `attachments`, `comments` or `related_tasks.<relation kind>` for changes of related entities.
+	Field string `xorm:"varchar(250) not null" json:"field"`
+	// The value before the change. Empty if something was added.
+	OldValue string `xorm:"longtext null" json:"old_value"`
+	// The value after the change. Empty if something was removed.
+	NewValue string `xorm:"longtext null" json:"new_value"`
+
+	// The user (or link share) who made the change.
+	Actor   *user.User `xorm:"-" json:"actor"`
+	ActorID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this change was made.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the task activity table
+func (*TaskActivity) TableName() string {
+	return "task_activities"
+}
+
+const (
+	taskActivityFieldAssignees   = "assignees"
+	taskActivityFieldLabels      = "labels"
+	taskActivityFieldAttachments = "attachments"
+	taskActivityFieldComments    = "comments"
+	taskActivityFieldRelations   = "related_tasks."
+)
+
+// Returns the id under which a change made by the auth is recorded. Link shares are stored with negative ids,
+// like everywhere else.
+func getActorID(a web.Auth) int64 {
+	if share, is := a.(*LinkSharing); is {
+		return share.getUserID()
+	}
+	if a == nil {
+		return 0
+	}
+	return a.GetID()
+}
+
+func recordTaskActivity(s *xorm.Session, a web.Auth, taskID int64, field, oldValue, newValue string) (err error) {
+	_, err = s.Insert(&TaskActivity{
+		TaskID:   taskID,
+		Field:    field,
+		OldValue: oldValue,
+		NewValue: newValue,
+		ActorID:  getActorID(a),
+	})
+	return
+}
+
+func formatActivityTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// The task properties recorded when a task is updated.
+// Positions and timestamps are left out on purpose, they change all the time and are not interesting for humans.
+var taskActivityFields = []struct {
+	field string
+	value func(t *Task) string
+}{
+	{"title", func(t *Task) string { return t.Title }},
+	{"description", func(t *Task) string { return t.Description }},
+	{"done", func(t *Task) string { return strconv.FormatBool(t.Done) }},
+	{"due_date", func(t *Task) string { return formatActivityTime(t.DueDate) }},
+	{"reminders", func(t *Task) string {
+		reminders := make([]string, 0, len(t.Reminders))
+		for _, r := range t.Reminders {
+			if r.RelativeTo != "" {
+				period := time.Duration(r.RelativePeriod) * time.Second
+				reminders = append(reminders, period.String()+" relative to "+string(r.RelativeTo))
+				continue
+			}
+			reminders = append(reminders, formatActivityTime(r.Reminder))
+		}
+		sort.Strings(reminders)
+		return strings.Join(reminders, ", ")
+	}},
+	{"repeat_after", func(t *Task) string { return strconv.FormatInt(t.RepeatAfter, 10) }},
+	{"repeat_mode", func(t *Task) string { return strconv.Itoa(int(t.RepeatMode)) }},
+	{"repeat_rule", func(t *Task) string { return t.RepeatRule }},
+	{"repeat_exceptions", func(t *Task) string {
+		exceptions := make([]string, 0, len(t.RepeatExceptions))
+		for _, e := range t.RepeatExceptions {
+			exceptions = append(exceptions, formatActivityTime(e))
+		}
+		sort.Strings(exceptions)
+		return strings.Join(exceptions, ", ")
+	}},
+	{"priority", func(t *Task) string { return strconv.FormatInt(t.Priority, 10) }},
+	{"start_date", func(t *Task) string { return formatActivityTime(t.StartDate) }},
+	{"end_date", func(t *Task) string { return formatActivityTime(t.EndDate) }},
+	{"hex_color", func(t *Task) string { return t.HexColor }},
+	{"percent_done", func(t *Task) string { return strconv.FormatFloat(t.PercentDone, 'f', -1, 64) }},
+	{"list_id", func(t *Task) string { return strconv.FormatInt(t.ListID, 10) }},
+	{"bucket_id", func(t *Task) string { return strconv.FormatInt(t.BucketID, 10) }},
+	{"cover_image_attachment_id", func(t *Task) string { return strconv.FormatInt(t.CoverImageAttachmentID, 10) }},
+}
+
+// getTaskActivityValues takes a snapshot of all recorded properties of a task. It is used to compare a task
+// before and after an update.
+func getTaskActivityValues(t *Task) map[string]string {
+	values := make(map[string]string, len(taskActivityFields))
+	for _, f := range taskActivityFields {
+		values[f.field] = f.value(t)
+	}
+	return values
+}
+
+func recordTaskUpdateActivity(s *xorm.Session, a web.Auth, taskID int64, oldValues, newValues map[string]string) error {
+	for _, f := range taskActivityFields {
+		if oldValues[f.field] == newValues[f.field] {
+			continue
+		}
+		err := recordTaskActivity(s, a, taskID, f.field, oldValues[f.field], newValues[f.field])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadAll returns the change history of a task
+// @Summary Get the activity of a task
+// @Description Returns all changes made to a task, oldest first.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskActivity "The task activity"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The task does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/activity [get]
+func (ta *TaskActivity) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := ta.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	activities := []*TaskActivity{}
+	query := s.
+		Where("task_id = ?", ta.TaskID).
+		OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&activities)
+	if err != nil {
+		return
+	}
+
+	err = addActorsToTaskActivities(s, activities)
+	if err != nil {
+		return
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", ta.TaskID).
+		Count(&TaskActivity{})
+	return activities, len(activities), numberOfTotalItems, err
+}
+
+func addActorsToTaskActivities(s *xorm.Session, activities []*TaskActivity) error {
+	actorIDs := make([]int64, 0, len(activities))
+	for _, activity := range activities {
+		actorIDs = append(actorIDs, activity.ActorID)
+	}
+
+	actors, err := getUsersOrLinkSharesFromIDs(s, actorIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, activity := range activities {
+		activity.Actor = actors[activity.ActorID]
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskActivity_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskActivity{TaskID: 1}
+		result, resultCount, total, err := ta.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, resultCount)
+		assert.Equal(t, int64(2), total)
+		activities := result.([]*TaskActivity)
+		assert.Equal(t, "due_date", activities[0].Field)
+		assert.Equal(t, "assignees", activities[1].Field)
+		assert.Equal(t, "user1", activities[1].NewValue)
+		assert.Equal(t, int64(1), activities[1].Actor.ID)
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ta := &TaskActivity{TaskID: 14}
+		_, _, _, err := ta.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTaskActivity_Record(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "changed title",
+			Description: "Lorem Ipsum",
+			Priority:    3,
+			ListID:      1,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "title",
+			"old_value": "task #1",
+			"new_value": "changed title",
+			"actor_id":  1,
+		}, false)
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "priority",
+			"old_value": "0",
+			"new_value": "3",
+		}, false)
+		db.AssertMissing(t, "task_activities", map[string]interface{}{
+			"task_id": 1,
+			"field":   "description",
+		})
+	})
+	t.Run("assignee", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		assignee := &TaskAssginee{TaskID: 2, UserID: 1}
+		err := assignee.Create(s, u)
+		assert.NoError(t, err)
+		err = assignee.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   2,
+			"field":     "assignees",
+			"old_value": "",
+			"new_value": "user1",
+		}, false)
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   2,
+			"field":     "assignees",
+			"old_value": "user1",
+			"new_value": "",
+		}, false)
+	})
+	t.Run("label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lt := &LabelTask{TaskID: 1, LabelID: 1}
+		err := lt.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "labels",
+			"new_value": "Label #1",
+		}, false)
+	})
+	t.Run("relation", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := &TaskRelation{TaskID: 1, OtherTaskID: 2, RelationKind: RelationKindSubtask}
+		err := rel.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "related_tasks.subtask",
+			"new_value": "2",
+		}, false)
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   2,
+			"field":     "related_tasks.parenttask",
+			"new_value": "1",
+		}, false)
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{ID: 1, TaskID: 1, Comment: "changed comment"}
+		err := tc.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_activities", map[string]interface{}{
+			"task_id":   1,
+			"field":     "comments",
+			"old_value": "Lorem Ipsum Dolor Sit Amet",
+			"new_value": "changed comment",
+		}, false)
+	})
+}
@@ -0,0 +1,139 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// getTimezoneForAuth returns the timezone dates are interpreted in for a user or link share
+func getTimezoneForAuth(s *xorm.Session, a web.Auth) (*time.Location, error) {
+	if _, is := a.(*LinkSharing); is || a == nil {
+		return config.GetTimeZone(), nil
+	}
+
+	u, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return nil, err
+	}
+	return u.GetTimezone(), nil
+}
+
+// toCalendarDate returns the date of a point in time in a timezone as midnight UTC of that date.
+func toCalendarDate(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseDateOnly returns midnight UTC of a date sent without a time of day, like "2022-11-04".
+func parseDateOnly(raw json.RawMessage) (date time.Time, is bool) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return
+	}
+	date, err := time.Parse("2006-01-02", value)
+	return date, err == nil
+}
+
+// SetDatesAsCalendarDates marks the due, start and end date of the task as calendar dates which were sent without a
+// time of day. They are saved as they are instead of being taken as dates in the timezone of the user.
+func (t *Task) SetDatesAsCalendarDates() {
+	t.dateOnlyFields = map[string]bool{
+		taskPropertyDueDate:   true,
+		taskPropertyStartDate: true,
+		taskPropertyEndDate:   true,
+	}
+}
+
+// getAllDayDates returns the dates of a task as they were before it is saved, see normalizeAllDayDates.
+func getAllDayDates(t *Task) *Task {
+	return &Task{
+		AllDay:    t.AllDay,
+		DueDate:   t.DueDate,
+		StartDate: t.StartDate,
+		EndDate:   t.EndDate,
+	}
+}
+
+// normalizeAllDayDates turns the dates of an all-day task into calendar dates in the timezone loc.
+// Dates which were sent as a date only are calendar dates already. The same goes for dates which did not change
+// compared to the task before it was saved, if that was an all-day task as well, and the dates a repeating task was
+// moved to. Otherwise saving a date again would move it to another day in a timezone with a negative offset.
+func (t *Task) normalizeAllDayDates(loc *time.Location, before *Task) {
+	if !t.AllDay {
+		return
+	}
+
+	previous := &Task{}
+	if before != nil && before.AllDay {
+		previous = before
+	}
+
+	normalize := func(field string, date, previousDate time.Time) time.Time {
+		if t.dateOnlyFields[field] || (!date.IsZero() && date.Equal(previousDate)) {
+			return date.UTC()
+		}
+		return toCalendarDate(date, loc)
+	}
+
+	t.DueDate = normalize(taskPropertyDueDate, t.DueDate, previous.DueDate)
+	t.StartDate = normalize(taskPropertyStartDate, t.StartDate, previous.StartDate)
+	t.EndDate = normalize(taskPropertyEndDate, t.EndDate, previous.EndDate)
+}
+
+func isAllDayDateField(field string) bool {
+	return field == taskPropertyDueDate || field == taskPropertyStartDate || field == taskPropertyEndDate
+}
+
+// getAllDayAwareFilterCond compares the dates of all-day tasks as calendar dates in the timezone of the user
+// and the ones of all other tasks as they are.
+func getAllDayAwareFilterCond(f *taskFilter, includeNulls bool, loc *time.Location) (cond builder.Cond, err error) {
+	value, is := f.value.(time.Time)
+	if !is {
+		return getFilterCond(f, includeNulls)
+	}
+
+	timeCond, err := getFilterCond(f, includeNulls)
+	if err != nil {
+		return nil, err
+	}
+
+	// All-day dates are stored as midnight UTC, the value is formatted to compare it as such in all databases
+	local := value.In(loc)
+	dateFilter := *f
+	dateFilter.value = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Format(dbTimeFormat)
+	dateCond, err := getFilterCond(&dateFilter, includeNulls)
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.Or(
+		builder.And(builder.Eq{"all_day": false}, timeCond),
+		builder.And(builder.Eq{"all_day": true}, dateCond),
+	), nil
+}
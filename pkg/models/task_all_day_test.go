@@ -0,0 +1,290 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func TestToCalendarDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+
+	t.Run("takes the date in the timezone", func(t *testing.T) {
+		date := toCalendarDate(time.Date(2022, 11, 4, 0, 30, 0, 0, berlin), berlin)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), date)
+	})
+	t.Run("negative offset", func(t *testing.T) {
+		newYork, err := time.LoadLocation("America/New_York")
+		assert.NoError(t, err)
+
+		// This is midnight utc of the next day
+		date := toCalendarDate(time.Date(2022, 11, 4, 20, 0, 0, 0, newYork), newYork)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), date)
+		date = toCalendarDate(time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), newYork)
+		assert.Equal(t, time.Date(2022, 11, 3, 0, 0, 0, 0, time.UTC), date)
+	})
+	t.Run("zero", func(t *testing.T) {
+		assert.True(t, toCalendarDate(time.Time{}, berlin).IsZero())
+	})
+}
+
+func setUserTimezone(t *testing.T, s *xorm.Session, id int64, timezone string) {
+	_, err := s.Where("id = ?", id).Cols("timezone").Update(&user.User{Timezone: timezone})
+	assert.NoError(t, err)
+}
+
+func TestTask_AllDay(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "Europe/Berlin")
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		assert.NoError(t, err)
+
+		task := &Task{
+			Title:   "Lorem",
+			ListID:  1,
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 0, 30, 0, 0, berlin),
+		}
+		err = task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), task.DueDate)
+	})
+	t.Run("create with negative offset", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "America/New_York")
+		newYork, err := time.LoadLocation("America/New_York")
+		assert.NoError(t, err)
+
+		task := &Task{
+			Title:   "Lorem",
+			ListID:  1,
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 20, 0, 0, 0, newYork),
+		}
+		err = task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), task.DueDate)
+	})
+	t.Run("create with a date only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "America/Los_Angeles")
+
+		task := &Task{}
+		err := json.Unmarshal([]byte(`{"title":"Lorem","list_id":1,"all_day":true,"due_date":"2022-11-04","start_date":"2022-11-02"}`), task)
+		assert.NoError(t, err)
+		err = task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), task.DueDate)
+		assert.Equal(t, time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC), task.StartDate)
+	})
+	t.Run("saving again with negative offset", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "America/Los_Angeles")
+		_, err := s.ID(1).Cols("all_day", "due_date").Update(&Task{
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+
+		// The client sends the date back as it got it
+		task := &Task{
+			ID:      1,
+			Title:   "task #1 updated",
+			ListID:  1,
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC),
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), task.DueDate)
+	})
+	t.Run("repeating with negative offset", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "America/Los_Angeles")
+		_, err := s.ID(28).Cols("all_day", "due_date", "repeat_after").Update(&Task{
+			AllDay:      true,
+			DueDate:     time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC),
+			RepeatAfter: 60 * 60 * 24,
+		})
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:          28,
+			Title:       "task #28",
+			ListID:      1,
+			AllDay:      true,
+			Done:        true,
+			DueDate:     time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC),
+			RepeatAfter: 60 * 60 * 24,
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.False(t, task.Done)
+		// The task moves to the next day after today, the date does not move by the offset of the timezone
+		tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		assert.Equal(t, tomorrow, task.DueDate.UTC())
+	})
+	t.Run("update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "Pacific/Auckland")
+
+		task := &Task{
+			ID:      1,
+			Title:   "task #1",
+			ListID:  1,
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 20, 0, 0, 0, time.UTC),
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 5, 0, 0, 0, 0, time.UTC), task.DueDate)
+
+		// Unsetting the flag keeps the date as it is
+		task = &Task{
+			ID:      1,
+			Title:   "task #1",
+			ListID:  1,
+			DueDate: time.Date(2022, 11, 5, 0, 0, 0, 0, time.UTC),
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.False(t, task.AllDay)
+		assert.Equal(t, time.Date(2022, 11, 5, 0, 0, 0, 0, time.UTC), task.DueDate)
+	})
+	t.Run("relative reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, "America/New_York")
+
+		task := &Task{
+			ID:      1,
+			Title:   "task #1",
+			ListID:  1,
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 5, 4, 0, 0, 0, time.UTC),
+			Reminders: []*TaskReminder{
+				{
+					RelativeTo:     ReminderRelationDueDate,
+					RelativePeriod: -3600,
+				},
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 5, 0, 0, 0, 0, time.UTC), task.DueDate)
+		assert.Len(t, task.Reminders, 1)
+		assert.Equal(t, time.Date(2022, 11, 4, 23, 0, 0, 0, time.UTC), task.Reminders[0].Reminder.UTC())
+
+		db.AssertExists(t, "task_reminders", map[string]interface{}{
+			"task_id":  1,
+			"reminder": "2022-11-04 23:00:00",
+		}, false)
+	})
+}
+
+func TestTaskCollection_ReadAll_AllDay(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	readAllDayTask := func(t *testing.T, timezone, comparator, value string) bool {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		setUserTimezone(t, s, 1, timezone)
+		_, err := s.ID(1).Cols("all_day", "due_date").Update(&Task{
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+
+		tc := &TaskCollection{
+			ListID:           1,
+			FilterBy:         []string{"due_date"},
+			FilterComparator: []string{comparator},
+			FilterValue:      []string{value},
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		assert.NoError(t, err)
+
+		for _, task := range result.([]*Task) {
+			if task.ID == 1 {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("earlier on the same day", func(t *testing.T) {
+		assert.False(t, readAllDayTask(t, "Europe/Berlin", "less", "2022-11-04T10:00:00+01:00"))
+		assert.True(t, readAllDayTask(t, "Europe/Berlin", "less_equals", "2022-11-04T10:00:00+01:00"))
+	})
+	t.Run("later on the same day", func(t *testing.T) {
+		assert.True(t, readAllDayTask(t, "Europe/Berlin", "greater_equals", "2022-11-04T23:30:00+01:00"))
+		assert.False(t, readAllDayTask(t, "Europe/Berlin", "greater", "2022-11-04T23:30:00+01:00"))
+	})
+	t.Run("next day in the timezone of the user", func(t *testing.T) {
+		assert.True(t, readAllDayTask(t, "Pacific/Auckland", "less", "2022-11-04T20:00:00Z"))
+		assert.False(t, readAllDayTask(t, "Europe/Berlin", "less", "2022-11-04T20:00:00Z"))
+	})
+	t.Run("returned as midnight utc", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(1).Cols("all_day", "due_date").Update(&Task{
+			AllDay:  true,
+			DueDate: time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+
+		task := &Task{ID: 1}
+		err = task.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 11, 4, 0, 0, 0, 0, time.UTC), task.DueDate)
+	})
+}
@@ -80,8 +80,17 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer
 	if len(assignees) == 0 && len(t.Assignees) > 0 {
 		_, err = s.Where("task_id = ?", t.ID).
 			Delete(TaskAssginee{})
+		if err != nil {
+			return err
+		}
+		for _, assignee := range t.Assignees {
+			err = recordTaskActivity(s, doer, t.ID, taskActivityFieldAssignees, assignee.Username, "")
+			if err != nil {
+				return err
+			}
+		}
 		t.setTaskAssignees(assignees)
-		return err
+		return nil
 	}
 
 	// If we didn't change anything (from 0 to zero) don't do anything.
@@ -108,6 +117,10 @@ func (t *Task) updateTaskAssignees(s *xorm.Session, assignees []*user.User, doer
 		// Put all assignees which are only on the old list to the trash
 		if !found {
 			assigneesToDelete = append(assigneesToDelete, oldAssignee.ID)
+			err = recordTaskActivity(s, doer, t.ID, taskActivityFieldAssignees, oldAssignee.Username, "")
+			if err != nil {
+				return err
+			}
 		}
 
 		oldAssignees[oldAssignee.ID] = oldAssignee
@@ -178,6 +191,15 @@ func (la *TaskAssginee) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	assignee, err := user.GetUserByID(s, la.UserID)
+	if err != nil {
+		return err
+	}
+	err = recordTaskActivity(s, a, la.TaskID, taskActivityFieldAssignees, assignee.Username, "")
+	if err != nil {
+		return err
+	}
+
 	err = updateListByTaskID(s, la.TaskID)
 	return
 }
@@ -242,6 +264,11 @@ func (t *Task) addNewAssigneeByID(s *xorm.Session, newAssigneeID int64, list *Li
 		return err
 	}
 
+	err = recordTaskActivity(s, auth, t.ID, taskActivityFieldAssignees, "", newAssignee.Username)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(auth)
 	err = events.Dispatch(&TaskAssigneeCreatedEvent{
 		Task:     t,
@@ -18,9 +18,11 @@ package models
 
 import (
 	"io"
+	"strconv"
 	"time"
 
 	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"xorm.io/xorm"
@@ -36,6 +38,8 @@ type TaskAttachment struct {
 	CreatedBy   *user.User `xorm:"-" json:"created_by"`
 
 	File *files.File `xorm:"-" json:"file"`
+	// If the attachment is an image, this contains its blur hash which can be shown as a placeholder while loading it.
+	BlurHash string `xorm:"varchar(50) null" json:"blur_hash"`
 
 	Created time.Time `xorm:"created" json:"created"`
 
@@ -68,7 +72,7 @@ func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realna
 	ta.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		// remove the  uploaded file if adding it to the db fails
-		if err2 := file.Delete(); err2 != nil {
+		if err2 := file.DeleteWithSession(s); err2 != nil {
 			return err2
 		}
 		return err
@@ -78,13 +82,19 @@ func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realna
 	_, err = s.Insert(ta)
 	if err != nil {
 		// remove the  uploaded file if adding it to the db fails
-		if err2 := file.Delete(); err2 != nil {
+		if err2 := file.DeleteWithSession(s); err2 != nil {
 			return err2
 		}
 		return err
 	}
 
-	return nil
+	// A failing preview should never prevent the upload of the attachment itself
+	err = ta.generatePreviews(s)
+	if err != nil {
+		log.Errorf("Could not generate previews for attachment %d: %s", ta.ID, err)
+	}
+
+	return recordTaskActivity(s, a, ta.TaskID, taskActivityFieldAttachments, "", file.Name)
 }
 
 // ReadOne returns a task attachment
@@ -203,8 +213,22 @@ func (ta *TaskAttachment) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	err = ta.deletePreviews(s)
+	if err != nil {
+		return err
+	}
+
+	oldValue := strconv.FormatInt(ta.ID, 10)
+	if ta.File != nil {
+		oldValue = ta.File.Name
+	}
+	err = recordTaskActivity(s, a, ta.TaskID, taskActivityFieldAttachments, oldValue, "")
+	if err != nil {
+		return err
+	}
+
 	// Delete the underlying file
-	err = ta.File.Delete()
+	err = ta.File.DeleteWithSession(s)
 	// If the file does not exist, we don't want to error out
 	if err != nil && files.IsErrFileDoesNotExist(err) {
 		return nil
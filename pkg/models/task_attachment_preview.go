@@ -0,0 +1,295 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif" // To make sure previews can be generated of gifs
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/bbrks/go-blurhash"
+	_ "golang.org/x/image/bmp" // To make sure previews can be generated of bmps
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff" // To make sure previews can be generated of tiffs
+	_ "golang.org/x/image/webp" // To make sure previews can be generated of webps
+	"xorm.io/xorm"
+)
+
+// PreviewSize is the size of a preview of an image attachment
+type PreviewSize string
+
+const (
+	PreviewSizeSmall      PreviewSize = "sm"
+	PreviewSizeMedium     PreviewSize = "md"
+	PreviewSizeLarge      PreviewSize = "lg"
+	PreviewSizeExtraLarge PreviewSize = "xl"
+)
+
+// previewSizes holds the maximum width and height of each preview size in pixels.
+// It is ordered from the largest to the smallest size because each preview is scaled down from the one before.
+var previewSizes = []struct {
+	size      PreviewSize
+	maxPixels int
+}{
+	{PreviewSizeExtraLarge, 800},
+	{PreviewSizeLarge, 400},
+	{PreviewSizeMedium, 200},
+	{PreviewSizeSmall, 100},
+}
+
+// Images with more pixels than this are not decoded to generate previews from them.
+// Previews are generated while uploading the attachment and decoding needs 4 bytes per pixel, this limits it to 64MB.
+const maxPreviewSourcePixels = 16 * 1000 * 1000
+
+// GetPreviewSizeFromString returns the preview size matching a string and false if there is none
+func GetPreviewSizeFromString(size string) (PreviewSize, bool) {
+	for _, s := range previewSizes {
+		if string(s.size) == size {
+			return s.size, true
+		}
+	}
+	return "", false
+}
+
+// TaskAttachmentPreview is a scaled down version of an image attachment
+type TaskAttachmentPreview struct {
+	ID               int64       `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskAttachmentID int64       `xorm:"bigint not null INDEX" json:"-"`
+	Size             PreviewSize `xorm:"varchar(2) not null" json:"-"`
+	FileID           int64       `xorm:"bigint not null" json:"-"`
+}
+
+// TableName returns the table name for task attachment previews
+func (TaskAttachmentPreview) TableName() string {
+	return "task_attachment_previews"
+}
+
+// GetPreview returns the preview file of an attachment with its content loaded.
+// If there is no preview in that size because the attachment is no image or smaller than the preview would be,
+// nil is returned.
+func (ta *TaskAttachment) GetPreview(s *xorm.Session, size PreviewSize) (file *files.File, err error) {
+	preview := &TaskAttachmentPreview{}
+	exists, err := s.
+		Where("task_attachment_id = ? AND size = ?", ta.ID, size).
+		Get(preview)
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	file = &files.File{ID: preview.FileID}
+	err = file.LoadFileMetaByID()
+	if err != nil {
+		return nil, err
+	}
+	err = file.LoadFileByID()
+	return
+}
+
+func (ta *TaskAttachment) deletePreviews(s *xorm.Session) error {
+	previews := []*TaskAttachmentPreview{}
+	err := s.Where("task_attachment_id = ?", ta.ID).Find(&previews)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range previews {
+		f := &files.File{ID: p.FileID}
+		err = f.DeleteWithSession(s)
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return err
+		}
+	}
+
+	_, err = s.Where("task_attachment_id = ?", ta.ID).Delete(&TaskAttachmentPreview{})
+	return err
+}
+
+func decodeAttachmentImage(fileID int64) (img image.Image, err error) {
+	f := &files.File{ID: fileID}
+	err = f.LoadFileByID()
+	if err != nil {
+		return nil, err
+	}
+	defer f.File.Close()
+
+	config, _, err := image.DecodeConfig(f.File)
+	if err != nil {
+		// Not an image
+		return nil, nil
+	}
+	if config.Width*config.Height > maxPreviewSourcePixels {
+		log.Debugf("Not generating previews of file %d with %dx%d pixels", fileID, config.Width, config.Height)
+		return nil, nil
+	}
+
+	if _, err = f.File.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	img, _, err = image.Decode(f.File)
+	if err != nil {
+		log.Debugf("Could not decode image file %d: %s", fileID, err)
+		return nil, nil
+	}
+	return img, nil
+}
+
+func scaleImage(src image.Image, maxPixels int) image.Image {
+	bounds := src.Bounds()
+	width, height := maxPixels, bounds.Dy()*maxPixels/bounds.Dx()
+	if bounds.Dy() > bounds.Dx() {
+		width, height = bounds.Dx()*maxPixels/bounds.Dy(), maxPixels
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Rect, src, bounds, draw.Over, nil)
+	return dst
+}
+
+// encodePreview encodes images without transparency as jpeg because that is a lot smaller for photos, all others as png.
+func encodePreview(img image.Image) (content []byte, extension string, mime string, err error) {
+	buf := &bytes.Buffer{}
+	if o, is := img.(interface{ Opaque() bool }); is && o.Opaque() {
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), ".jpg", "image/jpeg", err
+	}
+
+	err = png.Encode(buf, img)
+	return buf.Bytes(), ".png", "image/png", err
+}
+
+// generatePreviews creates the blur hash and all previews of an image attachment which is larger than them.
+// Existing previews are replaced. Attachments which are no images are left alone.
+// Previews count towards the storage quota of the user who uploaded the attachment like all of their files,
+// previews which would exceed it are not created.
+func (ta *TaskAttachment) generatePreviews(s *xorm.Session) (err error) {
+	img, err := decodeAttachmentImage(ta.FileID)
+	if err != nil || img == nil {
+		return err
+	}
+
+	err = ta.deletePreviews(s)
+	if err != nil {
+		return err
+	}
+
+	blur := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	draw.NearestNeighbor.Scale(blur, blur.Rect, img, img.Bounds(), draw.Over, nil)
+	ta.BlurHash, err = blurhash.Encode(4, 3, blur)
+	if err != nil {
+		return err
+	}
+	_, err = s.Where("id = ?", ta.ID).Cols("blur_hash").Update(ta)
+	if err != nil {
+		return err
+	}
+
+	baseName := ""
+	if ta.File != nil {
+		baseName = strings.TrimSuffix(ta.File.Name, filepath.Ext(ta.File.Name))
+	}
+
+	bounds := img.Bounds()
+	src := img
+	for _, ps := range previewSizes {
+		// Smaller images are used as they are
+		if bounds.Dx() <= ps.maxPixels && bounds.Dy() <= ps.maxPixels {
+			continue
+		}
+
+		src = scaleImage(src, ps.maxPixels)
+		content, extension, mime, err := encodePreview(src)
+		if err != nil {
+			return err
+		}
+
+		file, err := files.CreateWithMimeAndSession(
+			s,
+			bytes.NewReader(content),
+			baseName+"_"+string(ps.size)+extension,
+			uint64(len(content)),
+			&user.User{ID: ta.CreatedByID},
+			mime,
+			true,
+		)
+		if files.IsErrStorageQuotaExceeded(err) || files.IsErrInstanceStorageQuotaExceeded(err) || files.IsErrFileIsTooLarge(err) {
+			log.Debugf("Not generating the %s preview of attachment %d: %s", ps.size, ta.ID, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Insert(&TaskAttachmentPreview{
+			TaskAttachmentID: ta.ID,
+			Size:             ps.size,
+			FileID:           file.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GenerateMissingAttachmentPreviews creates the blur hash and previews of all image attachments which don't have
+// a blur hash yet, for example because they were uploaded before Vikunja generated previews.
+// It returns the number of attachments it generated previews for.
+func GenerateMissingAttachmentPreviews(s *xorm.Session) (count int, err error) {
+	attachments := []*TaskAttachment{}
+	err = s.
+		Where("blur_hash IS NULL OR blur_hash = ''").
+		OrderBy("id asc").
+		Find(&attachments)
+	if err != nil {
+		return
+	}
+
+	for _, ta := range attachments {
+		ta.File = &files.File{ID: ta.FileID}
+		err = ta.File.LoadFileMetaByID()
+		if err != nil {
+			log.Errorf("Could not load file %d of attachment %d: %s", ta.FileID, ta.ID, err)
+			continue
+		}
+
+		err = ta.generatePreviews(s)
+		if err != nil {
+			log.Errorf("Could not generate previews for attachment %d: %s", ta.ID, err)
+			continue
+		}
+
+		if ta.BlurHash != "" {
+			count++
+		}
+	}
+
+	return count, nil
+}
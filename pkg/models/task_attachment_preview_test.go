@@ -0,0 +1,174 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestImage(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestTaskAttachment_generatePreviews(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("large image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		content := createTestImage(t, 1000, 500)
+		ta := &TaskAttachment{TaskID: 1}
+		err := ta.NewAttachment(s, io.NopCloser(bytes.NewReader(content)), "image.png", uint64(len(content)), u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ta.BlurHash)
+
+		db.AssertExists(t, "task_attachments", map[string]interface{}{
+			"id":        ta.ID,
+			"blur_hash": ta.BlurHash,
+		}, false)
+
+		expectedWidths := map[PreviewSize]int{
+			PreviewSizeSmall:      100,
+			PreviewSizeMedium:     200,
+			PreviewSizeLarge:      400,
+			PreviewSizeExtraLarge: 800,
+		}
+		for size, width := range expectedWidths {
+			preview, err := ta.GetPreview(s, size)
+			assert.NoError(t, err)
+			if !assert.NotNil(t, preview, "no preview in size %s", size) {
+				continue
+			}
+			assert.Equal(t, "image_"+string(size)+".jpg", preview.Name)
+			assert.Equal(t, "image/jpeg", preview.Mime)
+
+			config, format, err := image.DecodeConfig(preview.File)
+			assert.NoError(t, err)
+			assert.Equal(t, "jpeg", format)
+			assert.Equal(t, width, config.Width)
+			assert.Equal(t, width/2, config.Height)
+		}
+	})
+	t.Run("small image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		content := createTestImage(t, 150, 150)
+		ta := &TaskAttachment{TaskID: 1}
+		err := ta.NewAttachment(s, io.NopCloser(bytes.NewReader(content)), "image.png", uint64(len(content)), u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ta.BlurHash)
+
+		preview, err := ta.GetPreview(s, PreviewSizeSmall)
+		assert.NoError(t, err)
+		assert.NotNil(t, preview)
+		preview, err = ta.GetPreview(s, PreviewSizeMedium)
+		assert.NoError(t, err)
+		assert.Nil(t, preview)
+	})
+	t.Run("storage quota", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		content := createTestImage(t, 1000, 500)
+		usage, err := files.GetStorageUsageForUser(s, u.ID)
+		assert.NoError(t, err)
+		// Leaves room for the attachment but not for its previews
+		err = (&user.User{ID: u.ID}).SetStorageQuota(s, int64(usage)+int64(len(content)))
+		assert.NoError(t, err)
+
+		ta := &TaskAttachment{TaskID: 1}
+		err = ta.NewAttachment(s, io.NopCloser(bytes.NewReader(content)), "image.png", uint64(len(content)), u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ta.BlurHash)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_attachments", map[string]interface{}{
+			"id": ta.ID,
+		}, false)
+		db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{
+			"task_attachment_id": ta.ID,
+		})
+	})
+	t.Run("no image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		content := []byte("not an image")
+		ta := &TaskAttachment{TaskID: 1}
+		err := ta.NewAttachment(s, io.NopCloser(bytes.NewReader(content)), "file.txt", uint64(len(content)), u)
+		assert.NoError(t, err)
+		assert.Empty(t, ta.BlurHash)
+		db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{
+			"task_attachment_id": ta.ID,
+		})
+	})
+}
+
+func TestGenerateMissingAttachmentPreviews(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	files.InitTestFileFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	content := createTestImage(t, 300, 300)
+	file, err := files.Create(bytes.NewReader(content), "image.png", uint64(len(content)), &user.User{ID: 1})
+	assert.NoError(t, err)
+	ta := &TaskAttachment{TaskID: 1, FileID: file.ID, CreatedByID: 1}
+	_, err = s.Insert(ta)
+	assert.NoError(t, err)
+
+	count, err := GenerateMissingAttachmentPreviews(s)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	db.AssertExists(t, "task_attachment_previews", map[string]interface{}{
+		"task_attachment_id": ta.ID,
+		"size":               PreviewSizeMedium,
+	}, false)
+	db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{
+		"task_attachment_id": ta.ID,
+		"size":               PreviewSizeLarge,
+	})
+}
@@ -19,7 +19,6 @@ package models
 import (
 	"io"
 	"os"
-	"strconv"
 	"testing"
 
 	"code.vikunja.io/api/pkg/config"
@@ -120,7 +119,9 @@ func TestTaskAttachment_NewAttachment(t *testing.T) {
 	err := ta.NewAttachment(s, tf, "testfile", 100, testuser)
 	assert.NoError(t, err)
 	assert.NotEqual(t, 0, ta.FileID)
-	_, err = files.FileStat("files/" + strconv.FormatInt(ta.FileID, 10))
+	// Files are stored by the hash of their content
+	assert.NotEmpty(t, ta.File.Hash)
+	_, err = files.FileStat("files/" + ta.File.Hash)
 	assert.NoError(t, err)
 	assert.False(t, os.IsNotExist(err))
 	assert.Equal(t, testuser.ID, ta.CreatedByID)
@@ -170,6 +171,12 @@ func TestTaskAttachment_Delete(t *testing.T) {
 		// Check if the file itself was deleted
 		_, err = files.FileStat("/1") // The new file has the id 2 since it's the second attachment
 		assert.True(t, os.IsNotExist(err))
+		// Check if its previews were deleted as well
+		db.AssertMissing(t, "task_attachment_previews", map[string]interface{}{
+			"task_attachment_id": 1,
+		})
+		_, err = files.FileStat("/2")
+		assert.True(t, os.IsNotExist(err))
 	})
 	t.Run("Nonexisting", func(t *testing.T) {
 		files.InitTestFileFixtures(t)
@@ -0,0 +1,495 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskChecklistItem is a single item of the checklist of a task
+type TaskChecklistItem struct {
+	// The unique, numeric id of this checklist item.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"checklistitem"`
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id" param:"task"`
+
+	// The text of the checklist item.
+	Text string `xorm:"varchar(250) not null" json:"text" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// Whether the item is checked.
+	Done bool `xorm:"INDEX null" json:"done"`
+	// The time when the item was checked.
+	DoneAt time.Time `xorm:"DATETIME null 'done_at'" json:"done_at"`
+	// The position of the item in the checklist. Items are sorted by this value, ascending.
+	Position float64 `xorm:"double null" json:"position"`
+
+	// The id of the user this item is assigned to. The user needs access to the list of the task.
+	AssigneeID int64 `xorm:"bigint null" json:"assignee_id"`
+	// The user this item is assigned to.
+	Assignee *user.User `xorm:"-" json:"assignee" valid:"-"`
+	// The date when this item should be done.
+	DueDate time.Time `xorm:"DATETIME null 'due_date'" json:"due_date"`
+
+	CreatedByID int64 `xorm:"bigint not null" json:"-"`
+	// The user who created this item.
+	CreatedBy *user.User `xorm:"-" json:"created_by" valid:"-"`
+
+	// A timestamp when this item was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this item was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the checklist items table
+func (*TaskChecklistItem) TableName() string {
+	return "task_checklist_items"
+}
+
+func getChecklistItem(s *xorm.Session, taskID, itemID int64) (item *TaskChecklistItem, err error) {
+	item = &TaskChecklistItem{}
+	exists, err := s.
+		Where("id = ? AND task_id = ?", itemID, taskID).
+		Get(item)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrChecklistItemDoesNotExist{ItemID: itemID, TaskID: taskID}
+	}
+	return
+}
+
+func addUsersToChecklistItems(s *xorm.Session, items []*TaskChecklistItem) error {
+	userIDs := make([]int64, 0, len(items)*2)
+	for _, item := range items {
+		userIDs = append(userIDs, item.CreatedByID)
+		if item.AssigneeID != 0 {
+			userIDs = append(userIDs, item.AssigneeID)
+		}
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		item.CreatedBy = users[item.CreatedByID]
+		item.Assignee = users[item.AssigneeID]
+	}
+	return nil
+}
+
+func addChecklistItemsToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	items := []*TaskChecklistItem{}
+	err := s.
+		In("task_id", taskIDs).
+		OrderBy("position asc, id asc").
+		Find(&items)
+	if err != nil {
+		return err
+	}
+
+	err = addUsersToChecklistItems(s, items)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if task, has := taskMap[item.TaskID]; has {
+			task.ChecklistItems = append(task.ChecklistItems, item)
+		}
+	}
+	return nil
+}
+
+// checkAssignee makes sure the assignee of an item exists and can see the task
+func (item *TaskChecklistItem) checkAssignee(s *xorm.Session) error {
+	if item.AssigneeID == 0 {
+		return nil
+	}
+
+	assignee, err := user.GetUserByID(s, item.AssigneeID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, item.TaskID)
+	if err != nil {
+		return err
+	}
+
+	canRead, _, err := (&List{ID: task.ListID}).CanRead(s, assignee)
+	if err != nil {
+		return err
+	}
+	if !canRead {
+		return ErrUserDoesNotHaveAccessToList{ListID: task.ListID, UserID: assignee.ID}
+	}
+	return nil
+}
+
+func (item *TaskChecklistItem) setDefaultPosition(s *xorm.Session) error {
+	if item.Position != 0 {
+		return nil
+	}
+
+	last := &TaskChecklistItem{}
+	_, err := s.
+		Where("task_id = ?", item.TaskID).
+		OrderBy("position desc").
+		Get(last)
+	if err != nil {
+		return err
+	}
+
+	item.Position = last.Position + math.Pow(2, 16)
+	return nil
+}
+
+func (item *TaskChecklistItem) insert(s *xorm.Session, a web.Auth) (err error) {
+	item.ID = 0
+	if item.Done && item.DoneAt.IsZero() {
+		item.DoneAt = time.Now()
+	}
+	if !item.Done {
+		item.DoneAt = time.Time{}
+	}
+
+	err = item.setDefaultPosition(s)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedBy, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+	item.CreatedByID = item.CreatedBy.ID
+
+	_, err = s.Insert(item)
+	return err
+}
+
+// getChecklistPercentDone returns the share of checked items of the checklist of a task.
+// If the task does not have a checklist, hasChecklist is false and the percent done of the task is up to the user.
+func getChecklistPercentDone(s *xorm.Session, taskID int64) (percentDone float64, hasChecklist bool, err error) {
+	total, err := s.
+		Where("task_id = ?", taskID).
+		Count(&TaskChecklistItem{})
+	if err != nil || total == 0 {
+		return 0, false, err
+	}
+
+	done, err := s.
+		Where("task_id = ? AND done = ?", taskID, true).
+		Count(&TaskChecklistItem{})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return float64(done) / float64(total), true, nil
+}
+
+// updateTaskPercentDone sets the percent done of a task from its checklist after the checklist changed.
+// When the last item of the checklist was removed, it is reset to 0.
+func updateTaskPercentDone(s *xorm.Session, taskID int64) error {
+	percentDone, _, err := getChecklistPercentDone(s, taskID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", taskID).
+		Cols("percent_done").
+		Update(&Task{PercentDone: percentDone})
+	return err
+}
+
+// updateTaskFromChecklist updates the percent done and the updated timestamp of a task after an item of its
+// checklist was created, changed or deleted and lets everyone listening for task changes know about it.
+func updateTaskFromChecklist(s *xorm.Session, a web.Auth, taskID int64) error {
+	err := updateTaskPercentDone(s, taskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskUpdatedEvent{
+		Task: &task,
+		Doer: doer,
+	})
+	if err != nil {
+		return err
+	}
+
+	return updateListLastUpdated(s, &List{ID: task.ListID})
+}
+
+// createChecklistItems creates the checklist of a new task. If checkAssignees is false, assignees without
+// access to the list of the task are dropped instead of failing.
+func (t *Task) createChecklistItems(s *xorm.Session, a web.Auth, items []*TaskChecklistItem, checkAssignees bool) (err error) {
+	t.ChecklistItems = nil
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		item.TaskID = t.ID
+		err = item.checkAssignee(s)
+		if err != nil {
+			if checkAssignees || !IsErrUserDoesNotHaveAccessToList(err) {
+				return err
+			}
+			item.AssigneeID = 0
+		}
+
+		err = item.insert(s, a)
+		if err != nil {
+			return err
+		}
+		t.ChecklistItems = append(t.ChecklistItems, item)
+	}
+
+	if len(t.ChecklistItems) == 0 {
+		return nil
+	}
+
+	err = addUsersToChecklistItems(s, t.ChecklistItems)
+	if err != nil {
+		return err
+	}
+
+	err = updateTaskPercentDone(s, t.ID)
+	if err != nil {
+		return err
+	}
+	t.PercentDone, _, err = getChecklistPercentDone(s, t.ID)
+	return err
+}
+
+// Create adds a new item to the checklist of a task
+// @Summary Add a checklist item
+// @Description Adds a new item to the checklist of a task. The percent done of the task is updated from the checked items.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param item body models.TaskChecklistItem true "The checklist item"
+// @Success 201 {object} models.TaskChecklistItem "The created checklist item."
+// @Failure 400 {object} web.HTTPError "Invalid checklist item object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist-items [put]
+func (item *TaskChecklistItem) Create(s *xorm.Session, a web.Auth) (err error) {
+	err = item.checkAssignee(s)
+	if err != nil {
+		return err
+	}
+
+	err = item.insert(s, a)
+	if err != nil {
+		return err
+	}
+
+	err = updateTaskFromChecklist(s, a, item.TaskID)
+	if err != nil {
+		return err
+	}
+
+	return addUsersToChecklistItems(s, []*TaskChecklistItem{item})
+}
+
+// ReadOne returns a single checklist item
+// @Summary Get one checklist item
+// @Description Returns one item of the checklist of a task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param checklistitem path int true "Checklist item ID"
+// @Success 200 {object} models.TaskChecklistItem "The checklist item."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist-items/{checklistitem} [get]
+func (item *TaskChecklistItem) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	saved, err := getChecklistItem(s, item.TaskID, item.ID)
+	if err != nil {
+		return err
+	}
+
+	*item = *saved
+	return addUsersToChecklistItems(s, []*TaskChecklistItem{item})
+}
+
+// ReadAll returns the checklist of a task
+// @Summary Get the checklist of a task
+// @Description Returns all checklist items of a task, sorted by their position.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskChecklistItem "The checklist items"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist-items [get]
+func (item *TaskChecklistItem) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := item.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	items := []*TaskChecklistItem{}
+	query := s.
+		Where("task_id = ?", item.TaskID).
+		OrderBy("position asc, id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&items)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addUsersToChecklistItems(s, items)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", item.TaskID).
+		Count(&TaskChecklistItem{})
+	return items, len(items), numberOfTotalItems, err
+}
+
+// Update changes a checklist item
+// @Summary Update a checklist item
+// @Description Changes the text, done state, position, assignee or due date of a checklist item. The percent done of the task is updated from the checked items.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param checklistitem path int true "Checklist item ID"
+// @Param item body models.TaskChecklistItem true "The checklist item"
+// @Success 200 {object} models.TaskChecklistItem "The updated checklist item."
+// @Failure 400 {object} web.HTTPError "Invalid checklist item object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist-items/{checklistitem} [post]
+func (item *TaskChecklistItem) Update(s *xorm.Session, a web.Auth) (err error) {
+	saved, err := getChecklistItem(s, item.TaskID, item.ID)
+	if err != nil {
+		return err
+	}
+
+	err = item.checkAssignee(s)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case item.Done && !saved.Done:
+		item.DoneAt = time.Now()
+	case item.Done:
+		item.DoneAt = saved.DoneAt
+	default:
+		item.DoneAt = time.Time{}
+	}
+
+	if item.Position == 0 {
+		item.Position = saved.Position
+	}
+
+	_, err = s.
+		ID(item.ID).
+		Cols(
+			"text",
+			"done",
+			"done_at",
+			"position",
+			"assignee_id",
+			"due_date",
+		).
+		Update(item)
+	if err != nil {
+		return err
+	}
+
+	err = updateTaskFromChecklist(s, a, item.TaskID)
+	if err != nil {
+		return err
+	}
+
+	item.CreatedByID = saved.CreatedByID
+	item.Created = saved.Created
+	return addUsersToChecklistItems(s, []*TaskChecklistItem{item})
+}
+
+// Delete removes a checklist item
+// @Summary Delete a checklist item
+// @Description Removes an item from the checklist of a task. The percent done of the task is updated from the remaining items, it is reset to 0 when the last item is removed.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param checklistitem path int true "Checklist item ID"
+// @Success 200 {object} models.Message "The checklist item was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The checklist item does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/checklist-items/{checklistitem} [delete]
+func (item *TaskChecklistItem) Delete(s *xorm.Session, a web.Auth) (err error) {
+	_, err = getChecklistItem(s, item.TaskID, item.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ? AND task_id = ?", item.ID, item.TaskID).
+		Delete(&TaskChecklistItem{})
+	if err != nil {
+		return err
+	}
+
+	return updateTaskFromChecklist(s, a, item.TaskID)
+}
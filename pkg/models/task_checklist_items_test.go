@@ -0,0 +1,315 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskChecklistItem_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{
+			TaskID: 1,
+			Text:   "Release it",
+			Done:   true,
+		}
+		can, err := item.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = item.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(196608), item.Position)
+		assert.False(t, item.DoneAt.IsZero())
+		assert.Equal(t, int64(1), item.CreatedBy.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":            item.ID,
+			"task_id":       1,
+			"text":          "Release it",
+			"done":          true,
+			"created_by_id": 1,
+		}, false)
+
+		task, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		assert.InDelta(t, 1.0/3.0, task.PercentDone, 0.0001)
+	})
+	t.Run("assignee without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{
+			TaskID:     1,
+			Text:       "Release it",
+			AssigneeID: 2,
+		}
+		err := item.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrUserDoesNotHaveAccessToList(err))
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{
+			TaskID: 14,
+			Text:   "Release it",
+		}
+		can, err := item.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskChecklistItem_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{TaskID: 1}
+		result, _, total, err := item.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		items := result.([]*TaskChecklistItem)
+		assert.Len(t, items, 2)
+		assert.Equal(t, int64(1), items[0].ID)
+		assert.Equal(t, int64(2), items[1].ID)
+		assert.Equal(t, int64(1), items[1].Assignee.ID)
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{TaskID: 14}
+		can, _, err := item.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskChecklistItem_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("check and uncheck", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{
+			ID:     1,
+			TaskID: 1,
+			Text:   "Write the tests",
+			Done:   true,
+		}
+		can, err := item.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = item.Update(s, u)
+		assert.NoError(t, err)
+		assert.False(t, item.DoneAt.IsZero())
+		assert.Equal(t, float64(65536), item.Position)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 0.5,
+		}, false)
+		events.AssertDispatched(t, &TaskUpdatedEvent{})
+
+		item.Done = false
+		err = item.Update(s, u)
+		assert.NoError(t, err)
+		assert.True(t, item.DoneAt.IsZero())
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"id":   1,
+			"done": false,
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 0,
+		}, false)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		item := &TaskChecklistItem{
+			ID:     1,
+			TaskID: 2,
+			Text:   "Write the tests",
+		}
+		err := item.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrChecklistItemDoesNotExist(err))
+	})
+}
+
+func TestTaskChecklistItem_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("done").Update(&TaskChecklistItem{Done: true})
+		assert.NoError(t, err)
+
+		item := &TaskChecklistItem{ID: 2, TaskID: 1}
+		can, err := item.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = item.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_checklist_items", map[string]interface{}{
+			"id": 2,
+		})
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 1,
+		}, false)
+	})
+	t.Run("last item", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+		s := db.NewSession()
+		defer s.Close()
+
+		before, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+
+		_, err = s.Where("id = ?", 1).Cols("done").Update(&TaskChecklistItem{Done: true})
+		assert.NoError(t, err)
+		err = (&TaskChecklistItem{ID: 2, TaskID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&TaskChecklistItem{ID: 1, TaskID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		// Without a checklist, the percent done is up to the user again
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 0,
+		}, false)
+		events.AssertDispatched(t, &TaskUpdatedEvent{})
+
+		after, err := GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+		assert.True(t, after.Updated.After(before.Updated))
+	})
+}
+
+func TestTask_ChecklistItems(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create task with checklist", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:  "Task with a checklist",
+			ListID: 1,
+			ChecklistItems: []*TaskChecklistItem{
+				{Text: "First", Done: true},
+				{Text: "Second"},
+				{Text: "Third"},
+				{Text: "Fourth"},
+			},
+		}
+		err := task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.ChecklistItems, 4)
+		assert.Equal(t, 0.25, task.PercentDone)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_checklist_items", map[string]interface{}{
+			"task_id": task.ID,
+			"text":    "Fourth",
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           task.ID,
+			"percent_done": 0.25,
+		}, false)
+	})
+	t.Run("percent done is computed from the checklist", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "task #1",
+			ListID:      1,
+			PercentDone: 0.8,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), task.PercentDone)
+		assert.Len(t, task.ChecklistItems, 2)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"percent_done": 0,
+		}, false)
+	})
+	t.Run("delete task permanently", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1}
+		err := deleteTaskPermanently(s, task, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_checklist_items", map[string]interface{}{
+			"task_id": 1,
+		})
+	})
+}
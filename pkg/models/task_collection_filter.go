@@ -211,6 +211,14 @@ func getValueForField(field reflect.StructField, rawValue string) (value interfa
 			}
 		}
 	case reflect.Slice:
+		// Reminders are filtered by their (computed) reminder time.
+		// There are probably better ways to do this - please let me know if you have one.
+		if field.Type.Elem().String() == "time.Time" || field.Type.Elem() == reflect.TypeOf(&TaskReminder{}) {
+			value, err = time.Parse(time.RFC3339, rawValue)
+			value = value.(time.Time).In(config.GetTimeZone())
+			return
+		}
+
 		// If this is a slice of pointers we're dealing with some property which is a relation
 		// In that case we don't really care about what the actual type is, we just cast the value to an
 		// int64 since we need the id - yes, this assumes we only ever have int64 IDs, but this is fine.
@@ -218,13 +226,6 @@ func getValueForField(field reflect.StructField, rawValue string) (value interfa
 			value, err = strconv.ParseInt(rawValue, 10, 64)
 			return
 		}
-
-		// There are probably better ways to do this - please let me know if you have one.
-		if field.Type.Elem().String() == "time.Time" {
-			value, err = time.Parse(time.RFC3339, rawValue)
-			value = value.(time.Time).In(config.GetTimeZone())
-			return
-		}
 		fallthrough
 	default:
 		panic(fmt.Errorf("unrecognized filter type %s for field %s, value %s", field.Type.String(), field.Name, value))
@@ -235,6 +236,14 @@ func getValueForField(field reflect.StructField, rawValue string) (value interfa
 
 func getNativeValueForTaskField(fieldName string, comparator taskFilterComparator, value string) (reflectField *reflect.StructField, nativeValue interface{}, err error) {
 
+	// The type of a custom field is only known once it was loaded from the db, which is why its value is converted later.
+	if _, is := getCustomFieldIDFromTaskField(fieldName); is {
+		if comparator == taskFilterComparatorIn {
+			return nil, strings.Split(value, ","), nil
+		}
+		return nil, value, nil
+	}
+
 	realFieldName := strings.ReplaceAll(strcase.ToCamel(fieldName), "Id", "ID")
 
 	if realFieldName == "Namespace" {
@@ -35,6 +35,7 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Username:                     "user1",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -46,6 +47,7 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Username:                     "user2",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -57,6 +59,7 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Username:                     "user6",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -97,6 +100,36 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Labels: []*Label{
 			label4,
 		},
+		CustomFields: map[int64]interface{}{
+			1: "ACME",
+			2: float64(5),
+			4: []string{"web", "ios"},
+		},
+		ChecklistItems: []*TaskChecklistItem{
+			{
+				ID:          1,
+				TaskID:      1,
+				Text:        "Write the tests",
+				Position:    65536,
+				CreatedByID: 1,
+				CreatedBy:   user1,
+				Created:     time.Date(2022, 10, 20, 10, 0, 0, 0, time.UTC).In(loc),
+				Updated:     time.Date(2022, 10, 20, 10, 0, 0, 0, time.UTC).In(loc),
+			},
+			{
+				ID:          2,
+				TaskID:      1,
+				Text:        "Update the docs",
+				Position:    131072,
+				AssigneeID:  1,
+				Assignee:    user1,
+				DueDate:     time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC).In(loc),
+				CreatedByID: 1,
+				CreatedBy:   user1,
+				Created:     time.Date(2022, 10, 20, 10, 0, 0, 0, time.UTC).In(loc),
+				Updated:     time.Date(2022, 10, 20, 10, 0, 0, 0, time.UTC).In(loc),
+			},
+		},
 		RelatedTasks: map[RelationKind][]*Task{
 			RelationKindSubtask: {
 				{
@@ -168,21 +201,35 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Labels: []*Label{
 			label4,
 		},
+		CustomFields: map[int64]interface{}{
+			2: float64(3),
+			3: "staging",
+			5: time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC).In(loc),
+		},
 		RelatedTasks: map[RelationKind][]*Task{},
-		Reminders: []time.Time{
-			time.Unix(1543626824, 0).In(loc),
+		Reminders: []*TaskReminder{
+			{
+				ID:       3,
+				TaskID:   2,
+				Reminder: time.Unix(1543626824, 0).In(loc),
+				Created:  time.Unix(1543626724, 0).In(loc),
+			},
 		},
-		Created: time.Unix(1543626724, 0).In(loc),
-		Updated: time.Unix(1543626724, 0).In(loc),
+		ReminderDates: []time.Time{time.Unix(1543626824, 0).In(loc)},
+		Created:       time.Unix(1543626724, 0).In(loc),
+		Updated:       time.Unix(1543626724, 0).In(loc),
 	}
 	task3 := &Task{
-		ID:           3,
-		Title:        "task #3 high prio",
-		Identifier:   "test1-3",
-		Index:        3,
-		CreatedByID:  1,
-		CreatedBy:    user1,
-		ListID:       1,
+		ID:          3,
+		Title:       "task #3 high prio",
+		Identifier:  "test1-3",
+		Index:       3,
+		CreatedByID: 1,
+		CreatedBy:   user1,
+		ListID:      1,
+		CustomFields: map[int64]interface{}{
+			2: float64(8),
+		},
 		RelatedTasks: map[RelationKind][]*Task{},
 		Created:      time.Unix(1543626724, 0).In(loc),
 		Updated:      time.Unix(1543626724, 0).In(loc),
@@ -477,15 +524,26 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Index:       12,
 		CreatedByID: 1,
 		CreatedBy:   user1,
-		Reminders: []time.Time{
-			time.Unix(1543626724, 0).In(loc),
-			time.Unix(1543626824, 0).In(loc),
+		Reminders: []*TaskReminder{
+			{
+				ID:       1,
+				TaskID:   27,
+				Reminder: time.Unix(1543626724, 0).In(loc),
+				Created:  time.Unix(1543626724, 0).In(loc),
+			},
+			{
+				ID:       2,
+				TaskID:   27,
+				Reminder: time.Unix(1543626824, 0).In(loc),
+				Created:  time.Unix(1543626724, 0).In(loc),
+			},
 		},
-		ListID:       1,
-		BucketID:     1,
-		RelatedTasks: map[RelationKind][]*Task{},
-		Created:      time.Unix(1543626724, 0).In(loc),
-		Updated:      time.Unix(1543626724, 0).In(loc),
+		ReminderDates: []time.Time{time.Unix(1543626724, 0).In(loc), time.Unix(1543626824, 0).In(loc)},
+		ListID:        1,
+		BucketID:      1,
+		RelatedTasks:  map[RelationKind][]*Task{},
+		Created:       time.Unix(1543626724, 0).In(loc),
+		Updated:       time.Unix(1543626724, 0).In(loc),
 	}
 	task28 := &Task{
 		ID:           28,
@@ -498,8 +556,37 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		RelatedTasks: map[RelationKind][]*Task{},
 		RepeatAfter:  3600,
 		BucketID:     1,
-		Created:      time.Unix(1543626724, 0).In(loc),
-		Updated:      time.Unix(1543626724, 0).In(loc),
+		Completions: []*TaskCompletion{
+			{
+				ID:       3,
+				TaskID:   28,
+				DueDate:  time.Date(2018, 12, 1, 6, 12, 4, 0, time.UTC).In(loc),
+				DoneAt:   time.Date(2018, 12, 1, 6, 0, 0, 0, time.UTC).In(loc),
+				DoneByID: 1,
+				DoneBy:   user1,
+			},
+			{
+				ID:                2,
+				TaskID:            28,
+				DueDate:           time.Date(2018, 12, 1, 3, 12, 4, 0, time.UTC).In(loc),
+				DoneAt:            time.Date(2018, 12, 1, 5, 30, 0, 0, time.UTC).In(loc),
+				DoneByID:          1,
+				DoneBy:            user1,
+				MissedOccurrences: 2,
+			},
+			{
+				ID:       1,
+				TaskID:   28,
+				DueDate:  time.Date(2018, 12, 1, 2, 12, 4, 0, time.UTC).In(loc),
+				DoneAt:   time.Date(2018, 12, 1, 2, 0, 0, 0, time.UTC).In(loc),
+				DoneByID: 1,
+				DoneBy:   user1,
+			},
+		},
+		CompletionStreak:  2,
+		MissedOccurrences: 2,
+		Created:           time.Unix(1543626724, 0).In(loc),
+		Updated:           time.Unix(1543626724, 0).In(loc),
 	}
 	task29 := &Task{
 		ID:          29,
@@ -80,6 +80,11 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	err = recordTaskActivity(s, a, tc.TaskID, taskActivityFieldComments, "", tc.Comment)
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskCommentCreatedEvent{
 		Task:    &task,
 		Comment: tc,
@@ -102,6 +107,12 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [delete]
 func (tc *TaskComment) Delete(s *xorm.Session, a web.Auth) error {
+	savedComment := &TaskComment{}
+	_, err := s.ID(tc.ID).NoAutoCondition().Get(savedComment)
+	if err != nil {
+		return err
+	}
+
 	deleted, err := s.
 		ID(tc.ID).
 		NoAutoCondition().
@@ -109,7 +120,11 @@ func (tc *TaskComment) Delete(s *xorm.Session, a web.Auth) error {
 	if deleted == 0 {
 		return ErrTaskCommentDoesNotExist{ID: tc.ID}
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	return recordTaskActivity(s, a, savedComment.TaskID, taskActivityFieldComments, savedComment.Comment, "")
 }
 
 // Update updates a task text by its ID
@@ -127,6 +142,12 @@ func (tc *TaskComment) Delete(s *xorm.Session, a web.Auth) error {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/{taskID}/comments/{commentID} [post]
 func (tc *TaskComment) Update(s *xorm.Session, a web.Auth) error {
+	savedComment := &TaskComment{}
+	_, err := s.ID(tc.ID).NoAutoCondition().Get(savedComment)
+	if err != nil {
+		return err
+	}
+
 	updated, err := s.
 		ID(tc.ID).
 		Cols("comment").
@@ -139,6 +160,13 @@ func (tc *TaskComment) Update(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	if savedComment.Comment != tc.Comment {
+		err = recordTaskActivity(s, a, savedComment.TaskID, taskActivityFieldComments, savedComment.Comment, tc.Comment)
+		if err != nil {
+			return err
+		}
+	}
+
 	task, err := GetTaskSimple(s, &Task{ID: tc.TaskID})
 	if err != nil {
 		return err
@@ -0,0 +1,162 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/rrule"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskCompletion is one occurrence of a repeating task which was marked done
+type TaskCompletion struct {
+	// The unique, numeric id of this completion.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The task this completion belongs to.
+	TaskID int64 `xorm:"bigint not null INDEX" json:"task_id"`
+	// The due date of the occurrence which was done.
+	DueDate time.Time `xorm:"DATETIME null 'due_date'" json:"due_date"`
+	// When the occurrence was marked done.
+	DoneAt time.Time `xorm:"DATETIME not null 'done_at'" json:"done_at"`
+
+	DoneByID int64 `xorm:"bigint not null" json:"-"`
+	// The user who marked the occurrence done.
+	DoneBy *user.User `xorm:"-" json:"done_by"`
+
+	// How many occurrences before this one passed without being marked done.
+	MissedOccurrences int64 `xorm:"bigint not null default 0" json:"missed_occurrences"`
+}
+
+// TableName holds the table name for the task completions table
+func (*TaskCompletion) TableName() string {
+	return "task_completions"
+}
+
+// recordTaskCompletion saves that the current occurrence of a repeating task was marked done.
+// oldTask holds the dates of the occurrence which was done, newTask the ones of the next occurrence.
+func recordTaskCompletion(s *xorm.Session, a web.Auth, oldTask, newTask *Task) error {
+	completion := &TaskCompletion{
+		TaskID:   oldTask.ID,
+		DueDate:  oldTask.DueDate,
+		DoneAt:   time.Now(),
+		DoneByID: getActorID(a),
+	}
+
+	// If the recurrence has ended the task stays done and there is no next occurrence to skip to
+	if !newTask.Done {
+		completion.MissedOccurrences = countMissedOccurrences(oldTask, newTask)
+	}
+
+	_, err := s.Insert(completion)
+	return err
+}
+
+// getRepeatReference returns the date the occurrences of a task are computed from
+func getRepeatReference(t *Task) time.Time {
+	switch {
+	case !t.DueDate.IsZero():
+		return t.DueDate
+	case !t.StartDate.IsZero():
+		return t.StartDate
+	default:
+		return t.EndDate
+	}
+}
+
+// countMissedOccurrences returns how many occurrences were skipped when a repeating task was moved from the
+// dates of oldTask to the ones of newTask because they were already in the past.
+func countMissedOccurrences(oldTask, newTask *Task) int64 {
+	from := getRepeatReference(oldTask)
+	to := getRepeatReference(newTask)
+	if from.IsZero() || to.IsZero() || !to.After(from) || oldTask.RepeatMode != TaskRepeatModeDefault {
+		// Tasks repeating from the current date or monthly never skip an occurrence
+		return 0
+	}
+
+	if oldTask.RepeatRule == "" {
+		if oldTask.RepeatAfter <= 0 {
+			return 0
+		}
+		return int64(to.Sub(from)/(time.Duration(oldTask.RepeatAfter)*time.Second)) - 1
+	}
+
+	rule, err := rrule.Parse(oldTask.RepeatRule)
+	if err != nil {
+		log.Errorf("Could not parse repeat rule of task %d: %s", oldTask.ID, err)
+		return 0
+	}
+
+	var missed int64
+	// The iterator starts after the occurrence which was just done
+	it := rule.Iterate(from)
+	for {
+		occurrence, exists := it.Next()
+		if !exists || !occurrence.Before(to) {
+			break
+		}
+		if !isRepeatException(oldTask.RepeatExceptions, occurrence) {
+			missed++
+		}
+	}
+
+	return missed
+}
+
+func addCompletionsToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) error {
+	completions := []*TaskCompletion{}
+	err := s.
+		In("task_id", taskIDs).
+		OrderBy("done_at desc, id desc").
+		Find(&completions)
+	if err != nil {
+		return err
+	}
+
+	userIDs := make([]int64, 0, len(completions))
+	for _, c := range completions {
+		userIDs = append(userIDs, c.DoneByID)
+	}
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	// Tasks which were never done in a row have a streak of 0
+	streakEnded := make(map[int64]bool, len(taskMap))
+	for _, c := range completions {
+		task, has := taskMap[c.TaskID]
+		if !has {
+			continue
+		}
+
+		c.DoneBy = users[c.DoneByID]
+		task.Completions = append(task.Completions, c)
+		task.MissedOccurrences += c.MissedOccurrences
+
+		// Completions are sorted newest first, the streak goes back until the last time an occurrence was missed
+		if !streakEnded[c.TaskID] {
+			task.CompletionStreak++
+			streakEnded[c.TaskID] = c.MissedOccurrences > 0
+		}
+	}
+
+	return nil
+}
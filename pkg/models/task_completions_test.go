@@ -0,0 +1,123 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskCompletion(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("history on the task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 28}
+		err := task.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.Completions, 3)
+		assert.Equal(t, int64(3), task.Completions[0].ID)
+		assert.Equal(t, int64(1), task.Completions[0].DoneBy.ID)
+		assert.Equal(t, int64(2), task.CompletionStreak)
+		assert.Equal(t, int64(2), task.MissedOccurrences)
+	})
+	t.Run("marking a repeating task done", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		dueDate := time.Now().Add(-210 * time.Minute).Truncate(time.Second)
+		task := &Task{ID: 28, Title: "test", ListID: 1, RepeatAfter: 3600, DueDate: dueDate}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+
+		task = &Task{ID: 28, Title: "test", ListID: 1, RepeatAfter: 3600, DueDate: dueDate, Done: true}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.False(t, task.Done)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		// The occurrences 2.5, 1.5 and 0.5 hours ago were skipped
+		db.AssertExists(t, "task_completions", map[string]interface{}{
+			"task_id":            28,
+			"done_by_id":         1,
+			"missed_occurrences": 3,
+		}, false)
+	})
+	t.Run("marking a repeating task done in bulk", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		bt := &BulkTask{IDs: []int64{28}, Task: Task{Done: true}}
+		can, err := bt.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = bt.Update(s, u)
+		assert.NoError(t, err)
+
+		count, err := s.Where("task_id = ?", 28).Count(&TaskCompletion{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), count)
+	})
+	t.Run("marking a task done which does not repeat", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "test", ListID: 1, Done: true}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_completions", map[string]interface{}{
+			"task_id": 1,
+		})
+	})
+}
+
+func TestCountMissedOccurrences(t *testing.T) {
+	due := time.Date(2022, 11, 7, 9, 0, 0, 0, time.UTC)
+
+	t.Run("repeat rule", func(t *testing.T) {
+		oldTask := &Task{
+			DueDate:          due,
+			RepeatRule:       "FREQ=DAILY",
+			RepeatExceptions: []time.Time{due.AddDate(0, 0, 2)},
+		}
+		newTask := &Task{DueDate: due.AddDate(0, 0, 5)}
+		// Four occurrences in between, one of them is an exception
+		assert.Equal(t, int64(3), countMissedOccurrences(oldTask, newTask))
+	})
+	t.Run("repeating from the current date", func(t *testing.T) {
+		oldTask := &Task{DueDate: due, RepeatAfter: 3600, RepeatMode: TaskRepeatModeFromCurrentDate}
+		newTask := &Task{DueDate: due.Add(72 * time.Hour)}
+		assert.Equal(t, int64(0), countMissedOccurrences(oldTask, newTask))
+	})
+	t.Run("without dates", func(t *testing.T) {
+		assert.Equal(t, int64(0), countMissedOccurrences(&Task{RepeatAfter: 3600}, &Task{}))
+	})
+}
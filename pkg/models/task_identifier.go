@@ -0,0 +1,165 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskIdentifierRedirect keeps a task reachable through an identifier it had in the past,
+// after it was moved to another list or the identifier of its list changed.
+type TaskIdentifierRedirect struct {
+	ID             int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	ListIdentifier string    `xorm:"varchar(10) not null INDEX(list_identifier_task_index)" json:"list_identifier"`
+	TaskIndex      int64     `xorm:"bigint not null INDEX(list_identifier_task_index)" json:"task_index"`
+	TaskID         int64     `xorm:"bigint not null INDEX" json:"task_id"`
+	Created        time.Time `xorm:"created not null" json:"created"`
+}
+
+// TableName holds the table name for task identifier redirects
+func (TaskIdentifierRedirect) TableName() string {
+	return "task_identifier_redirects"
+}
+
+// A list identifier is up to 10 characters, it may contain dashes itself which is why the index is matched at the end.
+var taskIdentifierRegex = regexp.MustCompile(`(?:^|\s)(\S{1,10})-([0-9]+)(?:$|\s)`)
+
+// parseTaskIdentifier splits an identifier like PROJ-42 into the identifier of the list and the index of the task.
+func parseTaskIdentifier(identifier string) (listIdentifier string, index int64, err error) {
+	pos := strings.LastIndex(identifier, "-")
+	if pos < 1 {
+		return "", 0, &ErrInvalidTaskIdentifier{Identifier: identifier}
+	}
+
+	listIdentifier = identifier[:pos]
+	index, err = strconv.ParseInt(identifier[pos+1:], 10, 64)
+	if err != nil || index < 1 || len([]rune(listIdentifier)) > 10 {
+		return "", 0, &ErrInvalidTaskIdentifier{Identifier: identifier}
+	}
+
+	return listIdentifier, index, nil
+}
+
+// getTaskIdentifierFromSearchString returns the first task identifier in a search string.
+func getTaskIdentifierFromSearchString(search string) (listIdentifier string, index int64) {
+	matches := taskIdentifierRegex.FindStringSubmatch(search)
+	if len(matches) != 3 {
+		return "", 0
+	}
+
+	index, _ = strconv.ParseInt(matches[2], 10, 64)
+	return matches[1], index
+}
+
+// getTaskIDsByIdentifier returns the ids of all tasks which have or had an identifier.
+// The task which currently has the identifier comes first, followed by the ones which had it, most recent first.
+func getTaskIDsByIdentifier(s *xorm.Session, listIdentifier string, index int64) (taskIDs []int64, err error) {
+	taskIDs = []int64{}
+	err = s.
+		Table("tasks").
+		Cols("id").
+		Where(builder.And(
+			builder.Eq{"`index`": index},
+			builder.IsNull{"deleted"},
+			builder.In("list_id", builder.Select("id").From("lists").Where(builder.Eq{"identifier": listIdentifier})),
+		)).
+		Find(&taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	redirects := []*TaskIdentifierRedirect{}
+	err = s.
+		Where("list_identifier = ? AND task_index = ?", listIdentifier, index).
+		OrderBy("id desc").
+		Find(&redirects)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range redirects {
+		taskIDs = append(taskIDs, r.TaskID)
+	}
+
+	return taskIDs, nil
+}
+
+// addTaskIdentifierRedirects remembers the current identifiers of tasks in a list before they change.
+// Tasks in lists without an identifier don't have one which could be referenced, those are skipped.
+func addTaskIdentifierRedirects(s *xorm.Session, listIdentifier string, tasks []*Task) (err error) {
+	if listIdentifier == "" || len(tasks) == 0 {
+		return nil
+	}
+
+	redirects := make([]*TaskIdentifierRedirect, 0, len(tasks))
+	for _, t := range tasks {
+		redirects = append(redirects, &TaskIdentifierRedirect{
+			ListIdentifier: listIdentifier,
+			TaskIndex:      t.Index,
+			TaskID:         t.ID,
+		})
+	}
+
+	_, err = s.Insert(&redirects)
+	return
+}
+
+// GetTaskByIdentifier returns the task which has an identifier like PROJ-42 or had it before it was moved to
+// another list or the identifier of its list was changed.
+func GetTaskByIdentifier(s *xorm.Session, a web.Auth, identifier string) (task *Task, err error) {
+	listIdentifier, index, err := parseTaskIdentifier(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs, err := getTaskIDsByIdentifier(s, listIdentifier, index)
+	if err != nil {
+		return nil, err
+	}
+
+	var forbidden bool
+	for _, id := range taskIDs {
+		task = &Task{ID: id}
+		canRead, _, err := task.CanRead(s, a)
+		// Tasks which are in the trash or in a list in the trash can't be found this way
+		if IsErrTaskDoesNotExist(err) || IsErrListDoesNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			forbidden = true
+			continue
+		}
+
+		err = task.ReadOne(s, a)
+		return task, err
+	}
+
+	if forbidden {
+		return nil, ErrGenericForbidden{}
+	}
+
+	return nil, &ErrTaskIdentifierDoesNotExist{Identifier: identifier}
+}
@@ -0,0 +1,187 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTaskIdentifier(t *testing.T) {
+	tests := []struct {
+		identifier     string
+		listIdentifier string
+		index          int64
+		valid          bool
+	}{
+		{"PROJ-42", "PROJ", 42, true},
+		{"MY-PROJ-7", "MY-PROJ", 7, true},
+		{"PROJ", "", 0, false},
+		{"-42", "", 0, false},
+		{"PROJ-", "", 0, false},
+		{"PROJ-0", "", 0, false},
+		{"TOOLONGIDENT-1", "", 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.identifier, func(t *testing.T) {
+			listIdentifier, index, err := parseTaskIdentifier(test.identifier)
+			if !test.valid {
+				assert.Error(t, err)
+				assert.True(t, IsErrInvalidTaskIdentifier(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.listIdentifier, listIdentifier)
+			assert.Equal(t, test.index, index)
+		})
+	}
+}
+
+func TestGetTaskIdentifierFromSearchString(t *testing.T) {
+	listIdentifier, index := getTaskIdentifierFromSearchString("PROJ-42")
+	assert.Equal(t, "PROJ", listIdentifier)
+	assert.Equal(t, int64(42), index)
+
+	listIdentifier, index = getTaskIdentifierFromSearchString("fixed in MY-PROJ-7 yesterday")
+	assert.Equal(t, "MY-PROJ", listIdentifier)
+	assert.Equal(t, int64(7), index)
+
+	_, index = getTaskIdentifierFromSearchString("some task #42")
+	assert.Equal(t, int64(0), index)
+}
+
+func TestGetTaskByIdentifier(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("current identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task, err := GetTaskByIdentifier(s, u, "test1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), task.ID)
+		assert.Equal(t, "test1-1", task.Identifier)
+	})
+	t.Run("old identifier", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task, err := GetTaskByIdentifier(s, u, "old1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), task.ID)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTaskByIdentifier(s, u, "test1")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskIdentifier(err))
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTaskByIdentifier(s, u, "test1-9999")
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIdentifierDoesNotExist(err))
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTaskByIdentifier(s, &user.User{ID: 2}, "test1-1")
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("task in the trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		_, err = GetTaskByIdentifier(s, u, "test1-1")
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskIdentifierDoesNotExist(err))
+	})
+	t.Run("moved to another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 1, Title: "task #1", ListID: 2}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_identifier_redirects", map[string]interface{}{
+			"list_identifier": "test1",
+			"task_index":      1,
+			"task_id":         1,
+		}, false)
+
+		moved, err := GetTaskByIdentifier(s, u, "test1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), moved.ID)
+		assert.Equal(t, int64(2), moved.ListID)
+		assert.NotEqual(t, "test1-1", moved.Identifier)
+	})
+	t.Run("list identifier changed", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list := &List{ID: 1, Title: "Test1", Identifier: "new1", NamespaceID: 1}
+		err := list.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		task, err := GetTaskByIdentifier(s, u, "test1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), task.ID)
+		assert.Equal(t, "new1-1", task.Identifier)
+
+		task, err = GetTaskByIdentifier(s, u, "new1-1")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), task.ID)
+	})
+	t.Run("search", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{}
+		res, _, _, err := tc.ReadAll(s, u, "old1-1", 0, 50)
+		assert.NoError(t, err)
+		tasks := res.([]*Task)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(1), tasks[0].ID)
+	})
+}
@@ -41,6 +41,7 @@ func getUndoneOverdueTasks(s *xorm.Session, now time.Time) (usersWithTasks map[i
 		Join("LEFT", "lists", "lists.id = tasks.list_id").
 		Join("LEFT", "namespaces", "lists.namespace_id = namespaces.id").
 		And("done = false").
+		And("tasks.deleted IS NULL AND lists.deleted IS NULL AND namespaces.deleted IS NULL").
 		Find(&tasks)
 	if err != nil {
 		return
@@ -89,6 +90,11 @@ func getUndoneOverdueTasks(s *xorm.Session, now time.Time) (usersWithTasks map[i
 		isTimeForReminder := overdueMailTime.After(now) || overdueMailTime.Equal(now.In(tz))
 		wasTimeForReminder := overdueMailTime.Before(nextMinute)
 		taskIsOverdueInUserTimezone := overdueMailTime.After(t.Task.DueDate.In(tz))
+		if t.Task.AllDay {
+			// All-day tasks are due the whole day and only overdue from the next day on
+			today := time.Date(overdueMailTime.Year(), overdueMailTime.Month(), overdueMailTime.Day(), 0, 0, 0, 0, time.UTC)
+			taskIsOverdueInUserTimezone = t.Task.DueDate.Before(today)
+		}
 		if isTimeForReminder && wasTimeForReminder && taskIsOverdueInUserTimezone {
 			_, exists := uts[t.User.ID]
 			if !exists {
@@ -61,6 +61,44 @@ func TestGetUndoneOverDueTasks(t *testing.T) {
 		assert.Truef(t, task5Present, "expected task 5 to be present but was not")
 		assert.Truef(t, task6Present, "expected task 6 to be present but was not")
 	})
+	t.Run("all-day task due today", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(5).Cols("all_day", "due_date").Update(&Task{
+			AllDay:  true,
+			DueDate: time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339Nano, "2018-12-01T09:00:00Z")
+		assert.NoError(t, err)
+		uts, err := getUndoneOverdueTasks(s, now)
+		assert.NoError(t, err)
+		assert.Len(t, uts, 1)
+		assert.Len(t, uts[1].tasks, 1)
+		assert.Contains(t, uts[1].tasks, int64(6))
+	})
+	t.Run("all-day task due yesterday", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.ID(5).Cols("all_day", "due_date").Update(&Task{
+			AllDay:  true,
+			DueDate: time.Date(2018, 11, 30, 0, 0, 0, 0, time.UTC),
+		})
+		assert.NoError(t, err)
+
+		now, err := time.Parse(time.RFC3339Nano, "2018-12-01T09:00:00Z")
+		assert.NoError(t, err)
+		uts, err := getUndoneOverdueTasks(s, now)
+		assert.NoError(t, err)
+		assert.Len(t, uts, 1)
+		assert.Len(t, uts[1].tasks, 2)
+		assert.Contains(t, uts[1].tasks, int64(5))
+	})
 	t.Run("done overdue", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"xorm.io/builder"
@@ -145,6 +146,11 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		}
 	}
 
+	err = checkRelationCreatesCycle(s, rel)
+	if err != nil {
+		return err
+	}
+
 	rel.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return err
@@ -190,7 +196,97 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		rel,
 		otherRelation,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskActivity(s, a, rel.TaskID, taskActivityFieldRelations+string(rel.RelationKind), "", strconv.FormatInt(rel.OtherTaskID, 10))
+	if err != nil {
+		return err
+	}
+	return recordTaskActivity(s, a, otherRelation.TaskID, taskActivityFieldRelations+string(otherRelation.RelationKind), "", strconv.FormatInt(otherRelation.OtherTaskID, 10))
+}
+
+// checkRelationCreatesCycle makes sure a new parent/subtask or precedes/follows relation does not result
+// in a cycle, like a task becoming its own grandparent.
+func checkRelationCreatesCycle(s *xorm.Session, rel *TaskRelation) error {
+	// Each relation is checked in the "forward" direction: from the parent to its subtasks
+	// and from a task to the tasks following it.
+	var from, to int64
+	var kind RelationKind
+	switch rel.RelationKind {
+	case RelationKindSubtask:
+		from, to, kind = rel.TaskID, rel.OtherTaskID, RelationKindSubtask
+	case RelationKindParenttask:
+		from, to, kind = rel.OtherTaskID, rel.TaskID, RelationKindSubtask
+	case RelationKindPreceeds:
+		from, to, kind = rel.TaskID, rel.OtherTaskID, RelationKindPreceeds
+	case RelationKindFollows:
+		from, to, kind = rel.OtherTaskID, rel.TaskID, RelationKindPreceeds
+	default:
+		return nil
+	}
+
+	// The new relation creates a cycle if "from" can already be reached from "to".
+	seen := map[int64]bool{to: true}
+	current := []int64{to}
+	for len(current) > 0 {
+		next := []int64{}
+		err := s.
+			Table("task_relations").
+			In("task_id", current).
+			And("relation_kind = ?", kind).
+			Cols("other_task_id").
+			Find(&next)
+		if err != nil {
+			return err
+		}
+
+		current = []int64{}
+		for _, id := range next {
+			if id == from {
+				return &ErrRelationWouldCreateCycle{
+					TaskID:      rel.TaskID,
+					OtherTaskID: rel.OtherTaskID,
+					Kind:        rel.RelationKind,
+				}
+			}
+			if !seen[id] {
+				seen[id] = true
+				current = append(current, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkTaskIsNotBlocked returns an error if the list of the task enforces blocking relations
+// and any of the tasks blocking it is not done yet.
+func checkTaskIsNotBlocked(s *xorm.Session, task *Task) error {
+	list, err := GetListSimpleByID(s, task.ListID)
+	if err != nil {
+		return err
+	}
+	if !list.EnforceBlockingRelations {
+		return nil
+	}
+
+	blocking := &Task{}
+	has, err := s.
+		Where(builder.In("id", builder.
+			Select("other_task_id").
+			From("task_relations").
+			Where(builder.Eq{"task_id": task.ID, "relation_kind": RelationKindBlocked}))).
+		And("done = ?", false).
+		Get(blocking)
+	if err != nil {
+		return err
+	}
+	if has {
+		return &ErrTaskIsBlocked{TaskID: task.ID, BlockingTaskID: blocking.ID}
+	}
+	return nil
 }
 
 // Delete removes a task relation
@@ -241,5 +337,9 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 	_, err = s.
 		Where(cond).
 		Delete(&TaskRelation{})
-	return err
+	if err != nil {
+		return err
+	}
+
+	return recordTaskActivity(s, a, rel.TaskID, taskActivityFieldRelations+string(rel.RelationKind), strconv.FormatInt(rel.OtherTaskID, 10), "")
 }
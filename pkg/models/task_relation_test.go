@@ -94,6 +94,86 @@ func TestTaskRelation_Create(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, IsErrRelationTasksCannotBeTheSame(err))
 	})
+	t.Run("Subtask cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// Task 29 is a subtask of task 1
+		rel := TaskRelation{
+			TaskID:       1,
+			OtherTaskID:  29,
+			RelationKind: RelationKindParenttask,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrRelationWouldCreateCycle(err))
+	})
+	t.Run("Own grandparent", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := TaskRelation{
+			TaskID:       29,
+			OtherTaskID:  2,
+			RelationKind: RelationKindSubtask,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+
+		rel = TaskRelation{
+			TaskID:       2,
+			OtherTaskID:  1,
+			RelationKind: RelationKindSubtask,
+		}
+		err = rel.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrRelationWouldCreateCycle(err))
+	})
+	t.Run("Precedes cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := TaskRelation{
+			TaskID:       1,
+			OtherTaskID:  2,
+			RelationKind: RelationKindPreceeds,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+
+		rel = TaskRelation{
+			TaskID:       3,
+			OtherTaskID:  2,
+			RelationKind: RelationKindFollows,
+		}
+		err = rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+
+		rel = TaskRelation{
+			TaskID:       1,
+			OtherTaskID:  3,
+			RelationKind: RelationKindFollows,
+		}
+		err = rel.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrRelationWouldCreateCycle(err))
+	})
+	t.Run("Related tasks may form a cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := TaskRelation{
+			TaskID:       29,
+			OtherTaskID:  1,
+			RelationKind: RelationKindRelated,
+		}
+		err := rel.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+	})
 }
 
 func TestTaskRelation_Delete(t *testing.T) {
@@ -33,12 +33,37 @@ import (
 	"code.vikunja.io/api/pkg/user"
 )
 
-// TaskReminder holds a reminder on a task
+// ReminderRelation represents the task date a relative reminder is based on
+type ReminderRelation string
+
+// All task dates a reminder can be relative to
+const (
+	ReminderRelationDueDate   ReminderRelation = `due_date`
+	ReminderRelationStartDate ReminderRelation = `start_date`
+	ReminderRelationEndDate   ReminderRelation = `end_date`
+)
+
+func (r ReminderRelation) isValid() bool {
+	switch r {
+	case ReminderRelationDueDate, ReminderRelationStartDate, ReminderRelationEndDate:
+		return true
+	}
+	return false
+}
+
+// TaskReminder holds a reminder on a task.
+// If RelativeTo is set, the reminder is computed from that date of the task and
+// moves with it whenever it changes. Otherwise, Reminder is an absolute time.
 type TaskReminder struct {
-	ID       int64     `xorm:"bigint autoincr not null unique pk"`
-	TaskID   int64     `xorm:"bigint not null INDEX"`
-	Reminder time.Time `xorm:"DATETIME not null INDEX 'reminder'"`
-	Created  time.Time `xorm:"created not null"`
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The absolute time when the user wants to be reminded of the task. For relative reminders this is computed from the task date it is relative to.
+	Reminder time.Time `xorm:"DATETIME not null INDEX 'reminder'" json:"reminder"`
+	// A period in seconds relative to the date in relative_to. Negative values mean the reminder triggers before the date.
+	RelativePeriod int64 `xorm:"bigint null" json:"relative_period"`
+	// The name of the task date this reminder is relative to. Can be one of due_date, start_date or end_date. Leave empty for an absolute reminder.
+	RelativeTo ReminderRelation `xorm:"varchar(50) null" json:"relative_to"`
+	Created    time.Time        `xorm:"created not null" json:"-"`
 }
 
 // TableName returns a pretty table name
@@ -46,6 +71,87 @@ func (TaskReminder) TableName() string {
 	return "task_reminders"
 }
 
+// remindersFromDates returns absolute reminders for the times of the deprecated reminder_dates
+// together with all relative reminders in existing.
+func remindersFromDates(dates []time.Time, existing []*TaskReminder) []*TaskReminder {
+	reminders := make([]*TaskReminder, 0, len(dates)+len(existing))
+	for _, r := range existing {
+		if r.RelativeTo != "" {
+			reminders = append(reminders, r)
+		}
+	}
+	for _, date := range dates {
+		reminders = append(reminders, &TaskReminder{Reminder: date})
+	}
+	return reminders
+}
+
+// setReminderDates sets the deprecated reminder_dates from the absolute reminders of a task
+func (t *Task) setReminderDates() {
+	t.ReminderDates = nil
+	for _, r := range t.Reminders {
+		if r.RelativeTo == "" {
+			t.ReminderDates = append(t.ReminderDates, r.Reminder)
+		}
+	}
+}
+
+func (t *Task) getReminderRelatedDate(relation ReminderRelation) time.Time {
+	switch relation {
+	case ReminderRelationDueDate:
+		return t.DueDate
+	case ReminderRelationStartDate:
+		return t.StartDate
+	case ReminderRelationEndDate:
+		return t.EndDate
+	}
+	return time.Time{}
+}
+
+// computeReminder sets the absolute reminder time of a relative reminder based on the dates of the task.
+// Absolute reminders are left untouched.
+func (t *Task) computeReminder(r *TaskReminder) error {
+	if r.RelativeTo == "" {
+		return nil
+	}
+
+	if !r.RelativeTo.isValid() {
+		return &ErrInvalidReminderRelation{RelativeTo: r.RelativeTo}
+	}
+
+	date := t.getReminderRelatedDate(r.RelativeTo)
+	if date.IsZero() {
+		return &ErrReminderRelativeToMissing{TaskID: t.ID, RelativeTo: r.RelativeTo}
+	}
+
+	r.Reminder = date.Add(time.Duration(r.RelativePeriod) * time.Second)
+	return nil
+}
+
+// updateRelativeReminders recomputes all relative reminders of a task from its current dates.
+// This needs to be called every time the dates of a task change without going through updateReminders.
+func (t *Task) updateRelativeReminders(s *xorm.Session) (err error) {
+	reminders := []*TaskReminder{}
+	err = s.
+		Where("task_id = ? AND relative_to != ''", t.ID).
+		Find(&reminders)
+	if err != nil {
+		return
+	}
+
+	for _, r := range reminders {
+		if err = t.computeReminder(r); err != nil {
+			return
+		}
+		_, err = s.ID(r.ID).Cols("reminder").Update(r)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 type taskUser struct {
 	Task *Task      `xorm:"extends"`
 	User *user.User `xorm:"extends"`
@@ -110,6 +216,8 @@ func getTaskUsersForTasks(s *xorm.Session, taskIDs []int64, cond builder.Cond) (
 	return
 }
 
+// Relative reminders are recomputed every time the dates of their task change, which means we can rely on
+// the reminder column for both absolute and relative reminders here.
 func getTasksWithRemindersDueAndTheirUsers(s *xorm.Session, now time.Time) (reminderNotifications []*ReminderDueNotification, err error) {
 	now = utils.GetTimeWithoutNanoSeconds(now)
 	reminderNotifications = []*ReminderDueNotification{}
@@ -124,6 +232,8 @@ func getTasksWithRemindersDueAndTheirUsers(s *xorm.Session, now time.Time) (remi
 		// All reminders from -12h to +14h to include all time zones
 		Where("reminder >= ? and reminder < ?", now.Add(time.Hour*-12).Format(dbTimeFormat), nextMinute.Add(time.Hour*14).Format(dbTimeFormat)).
 		And("tasks.done = false").
+		And(builder.IsNull{"tasks.deleted"}).
+		And(builder.NotIn("tasks.list_id", getTrashedListIDsQuery())).
 		Find(&reminders)
 	if err != nil {
 		return
@@ -17,10 +17,12 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,3 +51,85 @@ func TestReminderGetTasksInTheNextMinute(t *testing.T) {
 		assert.Len(t, taskIDs, 0)
 	})
 }
+
+func TestTask_ReminderDates(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{}
+		err := json.Unmarshal([]byte(`{"title":"Lorem","list_id":1,"reminder_dates":["2022-11-04T10:00:00Z"]}`), task)
+		assert.NoError(t, err)
+		err = task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.Reminders, 1)
+		assert.Equal(t, time.Date(2022, 11, 4, 10, 0, 0, 0, time.UTC), task.Reminders[0].Reminder)
+		assert.Len(t, task.ReminderDates, 1)
+
+		db.AssertExists(t, "task_reminders", map[string]interface{}{
+			"task_id":  task.ID,
+			"reminder": "2022-11-04 10:00:00",
+		}, false)
+	})
+	t.Run("update keeps relative reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		dueDate := time.Date(2022, 11, 4, 12, 0, 0, 0, time.UTC)
+		task := &Task{
+			ID:      1,
+			Title:   "task #1",
+			ListID:  1,
+			DueDate: dueDate,
+			Reminders: []*TaskReminder{
+				{Reminder: time.Date(2022, 11, 1, 10, 0, 0, 0, time.UTC)},
+				{RelativeTo: ReminderRelationDueDate, RelativePeriod: -3600},
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+
+		// An older client only sends the absolute reminders
+		task = &Task{
+			ID:            1,
+			Title:         "task #1",
+			ListID:        1,
+			DueDate:       dueDate,
+			ReminderDates: []time.Time{time.Date(2022, 11, 2, 10, 0, 0, 0, time.UTC)},
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.Reminders, 2)
+		assert.Equal(t, []time.Time{time.Date(2022, 11, 2, 10, 0, 0, 0, time.UTC)}, task.ReminderDates)
+
+		err = s.Commit()
+		assert.NoError(t, err)
+		db.AssertExists(t, "task_reminders", map[string]interface{}{
+			"task_id":         1,
+			"relative_to":     ReminderRelationDueDate,
+			"relative_period": -3600,
+		}, false)
+		db.AssertMissing(t, "task_reminders", map[string]interface{}{
+			"task_id":  1,
+			"reminder": "2022-11-01 10:00:00",
+		})
+	})
+	t.Run("returned when reading", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 27}
+		err := task.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Len(t, task.ReminderDates, 2)
+
+		j, err := json.Marshal(task)
+		assert.NoError(t, err)
+		assert.Contains(t, string(j), `"reminder_dates":["`)
+	})
+}
@@ -0,0 +1,161 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/rrule"
+)
+
+// UnmarshalJSON decodes a task and remembers whether the repeat rule was sent at all.
+// Clients which don't know about repeat rules only send repeat_after and repeat_mode,
+// updates from them must not remove an existing rule.
+// The due, start and end date can also be sent as a date only, for all-day tasks.
+func (t *Task) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	_, t.repeatRuleSent = fields["repeat_rule"]
+
+	t.dateOnlyFields = nil
+	for _, field := range []string{taskPropertyDueDate, taskPropertyStartDate, taskPropertyEndDate} {
+		date, is := parseDateOnly(fields[field])
+		if !is {
+			continue
+		}
+		if t.dateOnlyFields == nil {
+			t.dateOnlyFields = make(map[string]bool)
+		}
+		t.dateOnlyFields[field] = true
+		fields[field], _ = json.Marshal(date)
+	}
+	if t.dateOnlyFields != nil {
+		var err error
+		data, err = json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	type task Task
+	return json.Unmarshal(data, (*task)(t))
+}
+
+// UnmarshalJSON decodes a bulk task. It is needed because the method of the embedded task would otherwise
+// be promoted and ignore the task ids.
+func (bt *BulkTask) UnmarshalJSON(data []byte) error {
+	if err := bt.Task.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	ids := struct {
+		IDs []int64 `json:"task_ids"`
+	}{}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	bt.IDs = ids.IDs
+	return nil
+}
+
+// UnmarshalJSON decodes a task with its comments, activity and time entries. It is needed because the method
+// of the embedded task would otherwise be promoted and ignore everything else.
+func (t *TaskWithComments) UnmarshalJSON(data []byte) error {
+	if err := t.Task.UnmarshalJSON(data); err != nil {
+		return err
+	}
+
+	rest := struct {
+		Comments    []*TaskComment  `json:"comments"`
+		Activity    []*TaskActivity `json:"activity"`
+		TimeEntries []*TimeEntry    `json:"time_entries"`
+	}{}
+	if err := json.Unmarshal(data, &rest); err != nil {
+		return err
+	}
+	t.Comments = rest.Comments
+	t.Activity = rest.Activity
+	t.TimeEntries = rest.TimeEntries
+	return nil
+}
+
+// repeatRuleFromLegacyFields returns the repeat rule which describes the same recurrence as repeat_after and repeat_mode.
+func repeatRuleFromLegacyFields(repeatAfter int64, repeatMode TaskRepeatMode) string {
+	if repeatMode == TaskRepeatModeMonth {
+		return "FREQ=MONTHLY"
+	}
+
+	if repeatAfter <= 0 {
+		return ""
+	}
+
+	frequencies := []struct {
+		freq    string
+		seconds int64
+	}{
+		{"WEEKLY", 60 * 60 * 24 * 7},
+		{"DAILY", 60 * 60 * 24},
+		{"HOURLY", 60 * 60},
+		{"MINUTELY", 60},
+		{"SECONDLY", 1},
+	}
+
+	for _, f := range frequencies {
+		if repeatAfter%f.seconds != 0 {
+			continue
+		}
+		interval := repeatAfter / f.seconds
+		if interval == 1 {
+			return "FREQ=" + f.freq
+		}
+		return "FREQ=" + f.freq + ";INTERVAL=" + strconv.FormatInt(interval, 10)
+	}
+
+	return ""
+}
+
+// setLegacyRepeatFields sets repeat_after and repeat_mode from the repeat rule of the task, as far as they are able
+// to describe it. This way clients which don't know about repeat rules still show the recurrence of a task.
+func (t *Task) setLegacyRepeatFields(rule *rrule.Rule) {
+	t.RepeatAfter = 0
+	if t.RepeatMode == TaskRepeatModeMonth {
+		t.RepeatMode = TaskRepeatModeDefault
+	}
+
+	if rule == nil || !rule.IsSimple() {
+		return
+	}
+
+	seconds := map[rrule.Frequency]int64{
+		rrule.Secondly: 1,
+		rrule.Minutely: 60,
+		rrule.Hourly:   60 * 60,
+		rrule.Daily:    60 * 60 * 24,
+		rrule.Weekly:   60 * 60 * 24 * 7,
+	}
+
+	if s, has := seconds[rule.Freq]; has {
+		t.RepeatAfter = s * int64(rule.Interval)
+		return
+	}
+
+	if rule.Freq == rrule.Monthly && rule.Interval == 1 && t.RepeatMode == TaskRepeatModeDefault {
+		t.RepeatMode = TaskRepeatModeMonth
+	}
+}
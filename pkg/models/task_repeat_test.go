@@ -0,0 +1,181 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTask_LegacyRepeatFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("filled from simple rules", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{Title: "test", ListID: 1, RepeatRule: "FREQ=DAILY;INTERVAL=2"}
+		err := task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(60*60*24*2), task.RepeatAfter)
+		assert.Equal(t, TaskRepeatModeDefault, task.RepeatMode)
+
+		task = &Task{Title: "test", ListID: 1, RepeatRule: "FREQ=MONTHLY"}
+		err = task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), task.RepeatAfter)
+		assert.Equal(t, TaskRepeatModeMonth, task.RepeatMode)
+	})
+	t.Run("empty for rules they can't describe", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{Title: "test", ListID: 1, RepeatRule: "FREQ=WEEKLY;BYDAY=MO,TH"}
+		err := task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), task.RepeatAfter)
+		assert.Equal(t, TaskRepeatModeDefault, task.RepeatMode)
+	})
+	t.Run("rule from legacy fields on create", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{Title: "test", ListID: 1, RepeatAfter: 60 * 60 * 24 * 7}
+		err := task.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY", task.RepeatRule)
+		assert.Equal(t, int64(60*60*24*7), task.RepeatAfter)
+	})
+}
+
+func TestTask_UpdateFromLegacyClients(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	// Task 28 repeats hourly. After the migration to repeat rules it has a rule and still its repeat_after.
+	setUpMigratedTask := func(t *testing.T, rule string) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		_, err := s.Where("id = ?", 28).Cols("repeat_rule").Update(&Task{RepeatRule: rule})
+		assert.NoError(t, err)
+		assert.NoError(t, s.Commit())
+	}
+
+	t.Run("marking a migrated task done", func(t *testing.T) {
+		setUpMigratedTask(t, "FREQ=HOURLY")
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 28, Title: "test", ListID: 1, RepeatAfter: 3600, Done: true}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		assert.False(t, task.Done)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           28,
+			"repeat_rule":  "FREQ=HOURLY",
+			"repeat_after": 3600,
+		}, false)
+	})
+	t.Run("keeps a rule the legacy fields can't describe", func(t *testing.T) {
+		setUpMigratedTask(t, "FREQ=WEEKLY;BYDAY=MO,TH")
+		s := db.NewSession()
+		defer s.Close()
+
+		// A legacy client sends back repeat_after as it got it
+		task := &Task{ID: 28, Title: "test", ListID: 1, RepeatAfter: 3600}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          28,
+			"repeat_rule": "FREQ=WEEKLY;BYDAY=MO,TH",
+		}, false)
+	})
+	t.Run("changing repeat_after", func(t *testing.T) {
+		setUpMigratedTask(t, "FREQ=HOURLY")
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 28, Title: "test", ListID: 1, RepeatAfter: 60 * 60 * 24}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           28,
+			"repeat_rule":  "FREQ=DAILY",
+			"repeat_after": 60 * 60 * 24,
+		}, false)
+	})
+	t.Run("removing the recurrence", func(t *testing.T) {
+		setUpMigratedTask(t, "FREQ=HOURLY")
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{ID: 28, Title: "test", ListID: 1}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           28,
+			"repeat_rule":  "",
+			"repeat_after": 0,
+		}, false)
+	})
+	t.Run("removing the rule explicitly", func(t *testing.T) {
+		setUpMigratedTask(t, "FREQ=WEEKLY;BYDAY=MO,TH")
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{}
+		err := json.Unmarshal([]byte(`{"id":28,"title":"test","list_id":1,"repeat_rule":""}`), task)
+		assert.NoError(t, err)
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          28,
+			"repeat_rule": "",
+		}, false)
+	})
+}
+
+func TestBulkTask_UnmarshalJSON(t *testing.T) {
+	bt := &BulkTask{}
+	err := json.Unmarshal([]byte(`{"task_ids":[1,2],"title":"test","repeat_rule":"FREQ=DAILY"}`), bt)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, bt.IDs)
+	assert.Equal(t, "test", bt.Title)
+	assert.True(t, bt.repeatRuleSent)
+}
@@ -0,0 +1,372 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"xorm.io/xorm"
+)
+
+// TaskRevision holds the title and description of a task at one point in time
+type TaskRevision struct {
+	// The unique, numeric id of this revision.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"revision"`
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+
+	Title       string `xorm:"text not null" json:"title"`
+	Description string `xorm:"longtext null" json:"description"`
+
+	// The user (or link share) who made the change resulting in this revision.
+	CreatedBy   *user.User `xorm:"-" json:"created_by"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this revision was created.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the task revisions table
+func (*TaskRevision) TableName() string {
+	return "task_revisions"
+}
+
+// recordTaskRevision stores the title and description of a task as a new revision if one of them changed.
+// Tasks created before revisions existed don't have any, which is why the previous state is stored first
+// in that case. Otherwise it would not be possible to go back to it.
+func recordTaskRevision(s *xorm.Session, a web.Auth, t *Task, oldTitle, oldDescription string) error {
+	if t.Title == oldTitle && t.Description == oldDescription {
+		return nil
+	}
+
+	exists, err := s.Where("task_id = ?", t.ID).Exist(&TaskRevision{})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err = s.Insert(&TaskRevision{
+			TaskID:      t.ID,
+			Title:       oldTitle,
+			Description: oldDescription,
+			CreatedByID: t.CreatedByID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Insert(&TaskRevision{
+		TaskID:      t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		CreatedByID: getActorID(a),
+	})
+	return err
+}
+
+func getTaskRevision(s *xorm.Session, taskID, revisionID int64) (revision *TaskRevision, err error) {
+	revision = &TaskRevision{}
+	exists, err := s.
+		Where("id = ? AND task_id = ?", revisionID, taskID).
+		Get(revision)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTaskRevisionDoesNotExist{TaskID: taskID, RevisionID: revisionID}
+	}
+	return
+}
+
+func addCreatorsToTaskRevisions(s *xorm.Session, revisions []*TaskRevision) error {
+	creatorIDs := make([]int64, 0, len(revisions))
+	for _, r := range revisions {
+		creatorIDs = append(creatorIDs, r.CreatedByID)
+	}
+
+	creators, err := getUsersOrLinkSharesFromIDs(s, creatorIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range revisions {
+		r.CreatedBy = creators[r.CreatedByID]
+	}
+	return nil
+}
+
+// ReadAll returns all revisions of a task
+// @Summary Get all revisions of a task
+// @Description Returns all title and description revisions of a task, newest first.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskRevision "The revisions"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/revisions [get]
+func (tr *TaskRevision) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := tr.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	revisions := []*TaskRevision{}
+	query := s.
+		Where("task_id = ?", tr.TaskID).
+		OrderBy("id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&revisions)
+	if err != nil {
+		return
+	}
+
+	err = addCreatorsToTaskRevisions(s, revisions)
+	if err != nil {
+		return
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", tr.TaskID).
+		Count(&TaskRevision{})
+	return revisions, len(revisions), numberOfTotalItems, err
+}
+
+// ReadOne returns a single revision of a task
+// @Summary Get one revision of a task
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param revision path int true "Revision ID"
+// @Success 200 {object} models.TaskRevision "The revision"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The revision does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/revisions/{revision} [get]
+func (tr *TaskRevision) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	revision, err := getTaskRevision(s, tr.TaskID, tr.ID)
+	if err != nil {
+		return err
+	}
+	*tr = *revision
+
+	return addCreatorsToTaskRevisions(s, []*TaskRevision{tr})
+}
+
+// TaskRevisionDiff holds the differences between two revisions of a task
+type TaskRevisionDiff struct {
+	TaskID int64 `json:"-" param:"task"`
+	// The id of the newer revision.
+	RevisionID int64 `json:"-" param:"revision"`
+	// The id of the revision to compare with. If not provided, the revision before is used.
+	CompareToID int64 `json:"-" query:"compare_to"`
+
+	// The older revision. Null if there is no revision before.
+	From *TaskRevision `json:"from"`
+	// The newer revision.
+	To *TaskRevision `json:"to"`
+
+	// A unified diff of the title.
+	TitleDiff string `json:"title_diff"`
+	// A unified diff of the description.
+	DescriptionDiff string `json:"description_diff"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+func diffTaskRevisionText(from, to string) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(to),
+		FromFile: "from",
+		ToFile:   "to",
+		Context:  3,
+	})
+}
+
+// ReadOne compares two revisions of a task
+// @Summary Compare two revisions of a task
+// @Description Returns unified diffs of the title and description between two revisions of a task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param revision path int true "Revision ID"
+// @Param compare_to query int false "The revision id to compare with. Defaults to the revision before."
+// @Success 200 {object} models.TaskRevisionDiff "The differences"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "One of the revisions does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/revisions/{revision}/diff [get]
+func (trd *TaskRevisionDiff) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	trd.To, err = getTaskRevision(s, trd.TaskID, trd.RevisionID)
+	if err != nil {
+		return err
+	}
+
+	if trd.CompareToID != 0 {
+		trd.From, err = getTaskRevision(s, trd.TaskID, trd.CompareToID)
+		if err != nil {
+			return err
+		}
+	} else {
+		previous := &TaskRevision{}
+		exists, err := s.
+			Where("task_id = ? AND id < ?", trd.TaskID, trd.RevisionID).
+			OrderBy("id desc").
+			Get(previous)
+		if err != nil {
+			return err
+		}
+		if exists {
+			trd.From = previous
+		}
+	}
+
+	from := &TaskRevision{}
+	if trd.From != nil {
+		from = trd.From
+		err = addCreatorsToTaskRevisions(s, []*TaskRevision{trd.From, trd.To})
+	} else {
+		err = addCreatorsToTaskRevisions(s, []*TaskRevision{trd.To})
+	}
+	if err != nil {
+		return err
+	}
+
+	trd.TitleDiff, err = diffTaskRevisionText(from.Title, trd.To.Title)
+	if err != nil {
+		return err
+	}
+	trd.DescriptionDiff, err = diffTaskRevisionText(from.Description, trd.To.Description)
+	return err
+}
+
+// TaskRevisionRestore restores the title and description of a task from a revision
+type TaskRevisionRestore struct {
+	TaskID     int64 `json:"-" param:"task"`
+	RevisionID int64 `json:"-" param:"revision"`
+
+	// The newly created revision.
+	Revision *TaskRevision `json:"revision"`
+	// The task with the restored title and description.
+	Task *Task `json:"task"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// Create restores a task revision
+// @Summary Restore a task revision
+// @Description Sets the title and description of the task to the ones of the revision. This creates a new revision.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param revision path int true "Revision ID"
+// @Success 201 {object} models.TaskRevisionRestore "The restored task and the new revision."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The revision does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/revisions/{revision}/restore [put]
+func (trr *TaskRevisionRestore) Create(s *xorm.Session, a web.Auth) (err error) {
+	revision, err := getTaskRevision(s, trr.TaskID, trr.RevisionID)
+	if err != nil {
+		return err
+	}
+
+	task, err := GetTaskByIDSimple(s, trr.TaskID)
+	if err != nil {
+		return err
+	}
+
+	oldTitle, oldDescription := task.Title, task.Description
+	task.Title = revision.Title
+	task.Description = revision.Description
+
+	_, err = s.
+		ID(task.ID).
+		Cols("title", "description").
+		Update(&task)
+	if err != nil {
+		return err
+	}
+
+	if task.Title != oldTitle {
+		err = recordTaskActivity(s, a, task.ID, "title", oldTitle, task.Title)
+		if err != nil {
+			return err
+		}
+	}
+	if task.Description != oldDescription {
+		err = recordTaskActivity(s, a, task.ID, "description", oldDescription, task.Description)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Restoring always creates a new revision, even if nothing changed, to make the restore visible
+	trr.Revision = &TaskRevision{
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		CreatedByID: getActorID(a),
+	}
+	_, err = s.Insert(trr.Revision)
+	if err != nil {
+		return err
+	}
+	err = addCreatorsToTaskRevisions(s, []*TaskRevision{trr.Revision})
+	if err != nil {
+		return err
+	}
+
+	trr.Task = &task
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskUpdatedEvent{
+		Task: trr.Task,
+		Doer: doer,
+	})
+	if err != nil {
+		return err
+	}
+
+	return updateListLastUpdated(s, &List{ID: task.ListID})
+}
@@ -0,0 +1,179 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskRevision_Record(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task with revisions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "task #1",
+			Description: "Lorem Ipsum Dolor",
+			ListID:      1,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id":       1,
+			"description":   "Lorem Ipsum Dolor",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("task without revisions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          2,
+			Title:       "new title",
+			Description: "Lorem Ipsum",
+			ListID:      1,
+			Done:        true,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id": 2,
+			"title":   "task #2 done",
+		}, false)
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"task_id": 2,
+			"title":   "new title",
+		}, false)
+	})
+	t.Run("unchanged title and description", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "task #1",
+			Description: "Lorem Ipsum",
+			ListID:      1,
+			Priority:    2,
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		count, err := s.Where("task_id = ?", 1).Count(&TaskRevision{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+}
+
+func TestTaskRevision_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tr := &TaskRevision{TaskID: 1}
+	result, resultCount, total, err := tr.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resultCount)
+	assert.Equal(t, int64(2), total)
+	revisions := result.([]*TaskRevision)
+	assert.Equal(t, int64(2), revisions[0].ID)
+	assert.Equal(t, int64(1), revisions[0].CreatedBy.ID)
+}
+
+func TestTaskRevisionDiff_ReadOne(t *testing.T) {
+	t.Run("with the revision before", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		trd := &TaskRevisionDiff{TaskID: 1, RevisionID: 2}
+		err := trd.ReadOne(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), trd.From.ID)
+		assert.Equal(t, "", trd.TitleDiff)
+		assert.Contains(t, trd.DescriptionDiff, "-Lorem\n")
+		assert.Contains(t, trd.DescriptionDiff, "+Lorem Ipsum\n")
+	})
+	t.Run("nonexisting revision", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		trd := &TaskRevisionDiff{TaskID: 1, RevisionID: 2, CompareToID: 9999}
+		err := trd.ReadOne(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskRevisionDoesNotExist(err))
+	})
+}
+
+func TestTaskRevisionRestore_Create(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		trr := &TaskRevisionRestore{TaskID: 1, RevisionID: 1}
+		err := trr.Create(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Lorem", trr.Task.Description)
+		assert.NotZero(t, trr.Revision.ID)
+		events.AssertDispatched(t, &TaskUpdatedEvent{})
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          1,
+			"description": "Lorem",
+		}, false)
+		db.AssertExists(t, "task_revisions", map[string]interface{}{
+			"id":          trr.Revision.ID,
+			"task_id":     1,
+			"description": "Lorem",
+		}, false)
+	})
+	t.Run("revision of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		trr := &TaskRevisionRestore{TaskID: 2, RevisionID: 1}
+		err := trr.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskRevisionDoesNotExist(err))
+	})
+}
@@ -0,0 +1,376 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// ListSchedule holds the schedule of all undone tasks of a list or saved filter, computed from their
+// start and end dates and the precedes/follows relations between them.
+type ListSchedule struct {
+	// The list or saved filter the schedule was computed for.
+	ListID int64 `json:"list_id" param:"list"`
+	// The earliest start date of all tasks in the schedule.
+	Start time.Time `json:"start"`
+	// The date when the last task of the schedule ends at the earliest.
+	End time.Time `json:"end"`
+	// All tasks of the schedule, ordered by their earliest start.
+	Tasks []*TaskSchedule `json:"tasks"`
+	// The ids of all tasks without any slack, in the order they need to be done.
+	// Any delay of one of these tasks delays the end of the whole schedule.
+	CriticalPath []int64 `json:"critical_path"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// SavedFilterSchedule holds the schedule of all undone tasks matching a saved filter.
+type SavedFilterSchedule struct {
+	// The saved filter the schedule is computed for.
+	FilterID int64 `json:"-" param:"filter"`
+	ListSchedule
+}
+
+// TaskSchedule holds the computed schedule of a single task.
+type TaskSchedule struct {
+	TaskID int64 `json:"task_id"`
+	// The duration of the task in seconds, computed from its start and end date.
+	Duration int64 `json:"duration"`
+	// The ids of all tasks in the schedule which precede this task.
+	Predecessors []int64 `json:"predecessors"`
+
+	EarliestStart time.Time `json:"earliest_start"`
+	EarliestEnd   time.Time `json:"earliest_end"`
+	LatestStart   time.Time `json:"latest_start"`
+	LatestEnd     time.Time `json:"latest_end"`
+	// How many seconds the task can be delayed without delaying the end of the schedule.
+	Slack int64 `json:"slack"`
+	// Whether the task is on the critical path.
+	Critical bool `json:"critical"`
+
+	task       *Task
+	successors []*TaskSchedule
+}
+
+func (ts *TaskSchedule) duration() time.Duration {
+	return time.Duration(ts.Duration) * time.Second
+}
+
+// CanRead checks if the user can read the list the schedule is computed for
+func (ls *ListSchedule) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	return (&List{ID: ls.ListID}).CanRead(s, a)
+}
+
+// CanRead checks if the user can read the saved filter the schedule is computed for
+func (sfs *SavedFilterSchedule) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	return (&SavedFilter{ID: sfs.FilterID}).CanRead(s, a)
+}
+
+func getTaskDuration(t *Task) int64 {
+	if t.StartDate.IsZero() || !t.EndDate.After(t.StartDate) {
+		return 0
+	}
+	return int64(t.EndDate.Sub(t.StartDate).Seconds())
+}
+
+// ReadOne computes the schedule of a list
+// @Summary Get the schedule of a list
+// @Description Computes the earliest and latest start of all undone tasks in a list from their start and end dates and the precedes/follows relations between them. The duration of a task is the time between its start and end date, tasks without both are treated as milestones.
+// @tags list
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Success 200 {object} models.ListSchedule "The schedule of the list."
+// @Failure 400 {object} web.HTTPError "The relations between the tasks form a cycle."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/schedule [get]
+func (ls *ListSchedule) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	result, _, _, err := (&TaskCollection{ListID: ls.ListID}).ReadAll(s, a, "", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	schedules := make(map[int64]*TaskSchedule)
+	ls.Tasks = []*TaskSchedule{}
+	for _, t := range result.([]*Task) {
+		if t.Done {
+			continue
+		}
+		ts := &TaskSchedule{
+			TaskID:       t.ID,
+			Duration:     getTaskDuration(t),
+			Predecessors: []int64{},
+			task:         t,
+		}
+		schedules[t.ID] = ts
+		ls.Tasks = append(ls.Tasks, ts)
+	}
+
+	// Build the graph, only relations between tasks in this schedule are taken into account
+	for _, ts := range ls.Tasks {
+		seen := make(map[int64]bool)
+		for _, other := range ts.task.RelatedTasks[RelationKindPreceeds] {
+			successor, exists := schedules[other.ID]
+			if !exists || seen[other.ID] {
+				continue
+			}
+			seen[other.ID] = true
+			ts.successors = append(ts.successors, successor)
+			successor.Predecessors = append(successor.Predecessors, ts.TaskID)
+		}
+	}
+
+	sorted, ok := sortTaskSchedulesTopologically(ls.Tasks)
+	if !ok {
+		return &ErrTaskDependencyCycle{ListID: ls.ListID}
+	}
+
+	ls.CriticalPath = []int64{}
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	// Tasks without a start date start with the whole schedule at the earliest
+	for _, ts := range sorted {
+		if !ts.task.StartDate.IsZero() && (ls.Start.IsZero() || ts.task.StartDate.Before(ls.Start)) {
+			ls.Start = ts.task.StartDate
+		}
+	}
+	if ls.Start.IsZero() {
+		ls.Start = time.Now().Truncate(time.Second)
+	}
+
+	// Forward pass: A task can start once all its predecessors are done, but not before its own start date
+	for _, ts := range sorted {
+		if ts.EarliestStart.IsZero() {
+			ts.EarliestStart = ls.Start
+		}
+		if ts.task.StartDate.After(ts.EarliestStart) {
+			ts.EarliestStart = ts.task.StartDate
+		}
+		ts.EarliestEnd = ts.EarliestStart.Add(ts.duration())
+		if ts.EarliestEnd.After(ls.End) {
+			ls.End = ts.EarliestEnd
+		}
+		for _, successor := range ts.successors {
+			if ts.EarliestEnd.After(successor.EarliestStart) {
+				successor.EarliestStart = ts.EarliestEnd
+			}
+		}
+	}
+
+	// Backward pass: A task must end before the first of its successors needs to start
+	for i := len(sorted) - 1; i >= 0; i-- {
+		ts := sorted[i]
+		ts.LatestEnd = ls.End
+		for _, successor := range ts.successors {
+			if successor.LatestStart.Before(ts.LatestEnd) {
+				ts.LatestEnd = successor.LatestStart
+			}
+		}
+		ts.LatestStart = ts.LatestEnd.Add(-ts.duration())
+		ts.Slack = int64(ts.LatestStart.Sub(ts.EarliestStart).Seconds())
+		ts.Critical = ts.Slack == 0
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].EarliestStart.Equal(sorted[j].EarliestStart) {
+			return sorted[i].TaskID < sorted[j].TaskID
+		}
+		return sorted[i].EarliestStart.Before(sorted[j].EarliestStart)
+	})
+	ls.Tasks = sorted
+	for _, ts := range ls.Tasks {
+		if ts.Critical {
+			ls.CriticalPath = append(ls.CriticalPath, ts.TaskID)
+		}
+	}
+
+	return nil
+}
+
+// ReadOne computes the schedule of a saved filter
+// @Summary Get the schedule of a saved filter
+// @Description Computes the earliest and latest start of all undone tasks matching a saved filter from their start and end dates and the precedes/follows relations between them, the same way as the schedule of a list.
+// @tags filter
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param filter path int true "Saved filter ID"
+// @Success 200 {object} models.SavedFilterSchedule "The schedule of the saved filter."
+// @Failure 400 {object} web.HTTPError "The relations between the tasks form a cycle."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the saved filter."
+// @Failure 404 {object} web.HTTPError "The saved filter does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /filters/{filter}/schedule [get]
+func (sfs *SavedFilterSchedule) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	sfs.ListID = getListIDFromSavedFilterID(sfs.FilterID)
+	return sfs.ListSchedule.ReadOne(s, a)
+}
+
+// sortTaskSchedulesTopologically sorts all tasks so that each task comes after all its predecessors.
+// It returns false if the tasks depend on each other in a cycle.
+func sortTaskSchedulesTopologically(tasks []*TaskSchedule) (sorted []*TaskSchedule, ok bool) {
+	incoming := make(map[int64]int, len(tasks))
+	queue := []*TaskSchedule{}
+	for _, ts := range tasks {
+		incoming[ts.TaskID] = len(ts.Predecessors)
+		if len(ts.Predecessors) == 0 {
+			queue = append(queue, ts)
+		}
+	}
+
+	sorted = make([]*TaskSchedule, 0, len(tasks))
+	for len(queue) > 0 {
+		ts := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, ts)
+		for _, successor := range ts.successors {
+			incoming[successor.TaskID]--
+			if incoming[successor.TaskID] == 0 {
+				queue = append(queue, successor)
+			}
+		}
+	}
+
+	return sorted, len(sorted) == len(tasks)
+}
+
+// shiftFollowingTasks moves the dates of all tasks following a task forward if they would start before the
+// task ends. This continues through the whole chain of following tasks in the same list.
+// It only does something if the list of the task has the option enabled.
+func shiftFollowingTasks(s *xorm.Session, a web.Auth, task *Task) error {
+	list, err := GetListSimpleByID(s, task.ListID)
+	if err != nil {
+		return err
+	}
+	if !list.ShiftFollowingTasks {
+		return nil
+	}
+
+	// Collect all tasks following the task, directly or through other tasks
+	tasks := map[int64]*Task{task.ID: task}
+	successors := make(map[int64][]int64)
+	incoming := make(map[int64]int)
+	current := []int64{task.ID}
+	for len(current) > 0 {
+		relations := []*TaskRelation{}
+		err = s.
+			In("task_id", current).
+			And("relation_kind = ?", RelationKindPreceeds).
+			Find(&relations)
+		if err != nil {
+			return err
+		}
+		if len(relations) == 0 {
+			break
+		}
+
+		otherTaskIDs := make([]int64, 0, len(relations))
+		for _, r := range relations {
+			otherTaskIDs = append(otherTaskIDs, r.OtherTaskID)
+		}
+		following := make(map[int64]*Task)
+		err = s.
+			In("id", otherTaskIDs).
+			And("list_id = ?", task.ListID).
+			Find(&following)
+		if err != nil {
+			return err
+		}
+
+		current = []int64{}
+		for _, r := range relations {
+			f, exists := following[r.OtherTaskID]
+			if !exists {
+				continue
+			}
+			successors[r.TaskID] = append(successors[r.TaskID], f.ID)
+			incoming[f.ID]++
+			if _, seen := tasks[f.ID]; !seen {
+				tasks[f.ID] = f
+				current = append(current, f.ID)
+			}
+		}
+	}
+
+	// Go through the tasks so that every task comes after all tasks preceding it.
+	// Tasks in a cycle are never reached and therefore not shifted.
+	doer, _ := user.GetFromAuth(a)
+	queue := []int64{task.ID}
+	for len(queue) > 0 {
+		t := tasks[queue[0]]
+		queue = queue[1:]
+
+		end := t.EndDate
+		if end.IsZero() {
+			end = t.StartDate
+		}
+
+		for _, id := range successors[t.ID] {
+			incoming[id]--
+			if incoming[id] == 0 {
+				queue = append(queue, id)
+			}
+
+			f := tasks[id]
+			if end.IsZero() || f.StartDate.IsZero() || !f.StartDate.Before(end) {
+				continue
+			}
+
+			oldActivityValues := getTaskActivityValues(f)
+			shift := end.Sub(f.StartDate)
+			f.StartDate = f.StartDate.Add(shift)
+			if !f.EndDate.IsZero() {
+				f.EndDate = f.EndDate.Add(shift)
+			}
+			if !f.DueDate.IsZero() {
+				f.DueDate = f.DueDate.Add(shift)
+			}
+
+			_, err = s.ID(f.ID).Cols("start_date", "end_date", "due_date").Update(f)
+			if err != nil {
+				return err
+			}
+			err = f.updateRelativeReminders(s)
+			if err != nil {
+				return err
+			}
+			err = recordTaskUpdateActivity(s, a, f.ID, oldActivityValues, getTaskActivityValues(f))
+			if err != nil {
+				return err
+			}
+			err = events.Dispatch(&TaskUpdatedEvent{
+				Task: f,
+				Doer: doer,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+// createScheduleTestTasks creates a new list with four tasks where A precedes B and C, and both precede D.
+func createScheduleTestTasks(t *testing.T, s *xorm.Session, u *user.User, base time.Time) (list *List, tasks map[string]*Task) {
+	list = &List{
+		Title:       "Project",
+		NamespaceID: 1,
+	}
+	err := list.Create(s, u)
+	assert.NoError(t, err)
+
+	day := 24 * time.Hour
+	tasks = map[string]*Task{
+		"A": {Title: "A", ListID: list.ID, StartDate: base, EndDate: base.Add(2 * day)},
+		"B": {Title: "B", ListID: list.ID, StartDate: base.Add(2 * day), EndDate: base.Add(5 * day)},
+		"C": {Title: "C", ListID: list.ID, StartDate: base.Add(2 * day), EndDate: base.Add(3 * day)},
+		"D": {Title: "D", ListID: list.ID, StartDate: base.Add(5 * day), EndDate: base.Add(6 * day)},
+	}
+	for _, title := range []string{"A", "B", "C", "D"} {
+		err = tasks[title].Create(s, u)
+		assert.NoError(t, err)
+	}
+
+	for _, r := range [][2]string{{"A", "B"}, {"A", "C"}, {"B", "D"}, {"C", "D"}} {
+		rel := &TaskRelation{
+			TaskID:       tasks[r[0]].ID,
+			OtherTaskID:  tasks[r[1]].ID,
+			RelationKind: RelationKindPreceeds,
+		}
+		err = rel.Create(s, u)
+		assert.NoError(t, err)
+	}
+
+	return
+}
+
+func TestListSchedule_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+	base := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list, tasks := createScheduleTestTasks(t, s, u, base)
+
+		ls := &ListSchedule{ListID: list.ID}
+		can, _, err := ls.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ls.ReadOne(s, u)
+		assert.NoError(t, err)
+
+		assert.True(t, ls.Start.Equal(base))
+		assert.True(t, ls.End.Equal(base.Add(6*day)))
+		assert.Equal(t, []int64{tasks["A"].ID, tasks["B"].ID, tasks["D"].ID}, ls.CriticalPath)
+
+		assert.Len(t, ls.Tasks, 4)
+		c := ls.Tasks[2]
+		assert.Equal(t, tasks["C"].ID, c.TaskID)
+		assert.Equal(t, int64(day.Seconds()), c.Duration)
+		assert.Equal(t, []int64{tasks["A"].ID}, c.Predecessors)
+		assert.True(t, c.EarliestStart.Equal(base.Add(2*day)))
+		assert.True(t, c.LatestStart.Equal(base.Add(4*day)))
+		assert.True(t, c.LatestEnd.Equal(base.Add(5*day)))
+		assert.Equal(t, int64((2 * day).Seconds()), c.Slack)
+		assert.False(t, c.Critical)
+
+		d := ls.Tasks[3]
+		assert.Equal(t, tasks["D"].ID, d.TaskID)
+		assert.ElementsMatch(t, []int64{tasks["B"].ID, tasks["C"].ID}, d.Predecessors)
+		assert.Equal(t, int64(0), d.Slack)
+		assert.True(t, d.Critical)
+	})
+	t.Run("predecessor ends late", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list, tasks := createScheduleTestTasks(t, s, u, base)
+		_, err := s.ID(tasks["C"].ID).Cols("end_date").Update(&Task{EndDate: base.Add(7 * day)})
+		assert.NoError(t, err)
+
+		ls := &ListSchedule{ListID: list.ID}
+		err = ls.ReadOne(s, u)
+		assert.NoError(t, err)
+
+		assert.True(t, ls.End.Equal(base.Add(8*day)))
+		assert.Equal(t, []int64{tasks["A"].ID, tasks["C"].ID, tasks["D"].ID}, ls.CriticalPath)
+	})
+	t.Run("done tasks are not part of the schedule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list, tasks := createScheduleTestTasks(t, s, u, base)
+		_, err := s.ID(tasks["A"].ID).Cols("done").Update(&Task{Done: true})
+		assert.NoError(t, err)
+
+		ls := &ListSchedule{ListID: list.ID}
+		err = ls.ReadOne(s, u)
+		assert.NoError(t, err)
+
+		assert.Len(t, ls.Tasks, 3)
+		assert.True(t, ls.Start.Equal(base.Add(2*day)))
+		assert.Empty(t, ls.Tasks[0].Predecessors)
+	})
+	t.Run("cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list, tasks := createScheduleTestTasks(t, s, u, base)
+		// Creating the relation through the api is not possible
+		_, err := s.Insert(&TaskRelation{
+			TaskID:       tasks["D"].ID,
+			OtherTaskID:  tasks["A"].ID,
+			RelationKind: RelationKindPreceeds,
+			CreatedByID:  1,
+		})
+		assert.NoError(t, err)
+
+		ls := &ListSchedule{ListID: list.ID}
+		err = ls.ReadOne(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDependencyCycle(err))
+	})
+	t.Run("saved filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ls := &ListSchedule{ListID: getListIDFromSavedFilterID(1)}
+		can, _, err := ls.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = ls.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ls.Tasks)
+	})
+	t.Run("saved filter by its id", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		sfs := &SavedFilterSchedule{FilterID: 1}
+		can, _, err := sfs.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = sfs.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, getListIDFromSavedFilterID(1), sfs.ListID)
+		assert.NotEmpty(t, sfs.Tasks)
+
+		can, _, err = (&SavedFilterSchedule{FilterID: 1}).CanRead(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ls := &ListSchedule{ListID: 20}
+		can, _, err := ls.CanRead(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTask_Update_ShiftFollowingTasks(t *testing.T) {
+	u := &user.User{ID: 1}
+	base := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	t.Run("shift", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list, tasks := createScheduleTestTasks(t, s, u, base)
+		list.ShiftFollowingTasks = true
+		err := list.Update(s, u)
+		assert.NoError(t, err)
+
+		a := tasks["A"]
+		a.EndDate = base.Add(4 * day)
+		err = a.Update(s, u)
+		assert.NoError(t, err)
+
+		b, err := GetTaskByIDSimple(s, tasks["B"].ID)
+		assert.NoError(t, err)
+		assert.True(t, b.StartDate.Equal(base.Add(4*day)))
+		assert.True(t, b.EndDate.Equal(base.Add(7*day)))
+
+		c, err := GetTaskByIDSimple(s, tasks["C"].ID)
+		assert.NoError(t, err)
+		assert.True(t, c.StartDate.Equal(base.Add(4*day)))
+		assert.True(t, c.EndDate.Equal(base.Add(5*day)))
+
+		// D follows both B and C and needs to wait for B
+		d, err := GetTaskByIDSimple(s, tasks["D"].ID)
+		assert.NoError(t, err)
+		assert.True(t, d.StartDate.Equal(base.Add(7*day)))
+		assert.True(t, d.EndDate.Equal(base.Add(8*day)))
+	})
+	t.Run("bulk update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		list, tasks := createScheduleTestTasks(t, s, u, base)
+		list.ShiftFollowingTasks = true
+		err := list.Update(s, u)
+		assert.NoError(t, err)
+
+		bt := &BulkTask{
+			IDs:  []int64{tasks["A"].ID},
+			Task: Task{EndDate: base.Add(4 * day)},
+		}
+		allowed, err := bt.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+		err = bt.Update(s, u)
+		assert.NoError(t, err)
+
+		b, err := GetTaskByIDSimple(s, tasks["B"].ID)
+		assert.NoError(t, err)
+		assert.True(t, b.StartDate.Equal(base.Add(4*day)))
+		assert.True(t, b.EndDate.Equal(base.Add(7*day)))
+
+		d, err := GetTaskByIDSimple(s, tasks["D"].ID)
+		assert.NoError(t, err)
+		assert.True(t, d.StartDate.Equal(base.Add(7*day)))
+	})
+	t.Run("not enabled", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, tasks := createScheduleTestTasks(t, s, u, base)
+
+		a := tasks["A"]
+		a.EndDate = base.Add(4 * day)
+		err := a.Update(s, u)
+		assert.NoError(t, err)
+
+		b, err := GetTaskByIDSimple(s, tasks["B"].ID)
+		assert.NoError(t, err)
+		assert.True(t, b.StartDate.Equal(base.Add(2*day)))
+	})
+}
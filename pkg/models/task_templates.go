@@ -0,0 +1,442 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskTemplateTask holds everything needed to create one task from a template. The subtasks of a template are stored
+// with the same struct, which makes it possible to create a whole tree of tasks from one template.
+type TaskTemplateTask struct {
+	// The title of the created task.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The description of the created task.
+	Description string `xorm:"longtext null" json:"description"`
+	// The priority of the created task.
+	Priority int64 `xorm:"bigint null" json:"priority"`
+	// The ids of all labels which will be added to the created task.
+	LabelIDs []int64 `xorm:"JSON null 'label_ids'" json:"label_ids"`
+	// The ids of all users which will be assigned to the created task.
+	AssigneeIDs []int64 `xorm:"JSON null 'assignee_ids'" json:"assignee_ids"`
+	// The due date of the created task in seconds after the moment the task was created. 0 means the task won't have a due date.
+	DueDateOffset int64 `xorm:"bigint null" json:"due_date_offset"`
+	// The start date of the created task in seconds after the moment the task was created. 0 means the task won't have a start date.
+	StartDateOffset int64 `xorm:"bigint null" json:"start_date_offset"`
+	// The end date of the created task in seconds after the moment the task was created. 0 means the task won't have an end date.
+	EndDateOffset int64 `xorm:"bigint null" json:"end_date_offset"`
+	// The reminders of the created task. They must all be relative to a date of the task which has an offset.
+	Reminders []*TaskReminder `xorm:"JSON null" json:"reminders"`
+	// The tasks which will be created as subtasks of the created task.
+	Subtasks []*TaskTemplateTask `xorm:"JSON null" json:"subtasks"`
+}
+
+// TaskTemplate is a template to create the same task and its subtasks over and over again.
+// A template either belongs to the user who created it or to a list, in which case everyone with access to the list can use it.
+type TaskTemplate struct {
+	// The unique, numeric id of this template.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"tasktemplate"`
+
+	TaskTemplateTask `xorm:"extends"`
+
+	// The list this template belongs to. If 0, the template only belongs to the user who created it.
+	ListID int64 `xorm:"bigint null INDEX" json:"list_id"`
+
+	OwnerID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The user who created this template.
+	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
+
+	// A timestamp when this template was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this template was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task templates
+func (*TaskTemplate) TableName() string {
+	return "task_templates"
+}
+
+func getTaskTemplateByID(s *xorm.Session, id int64) (template *TaskTemplate, err error) {
+	template = &TaskTemplate{}
+	exists, err := s.Where("id = ?", id).Get(template)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTaskTemplateDoesNotExist{TemplateID: id}
+	}
+	return template, nil
+}
+
+func (tt *TaskTemplateTask) validate(templateID int64) error {
+	if tt.Title == "" {
+		return ErrTaskCannotBeEmpty{}
+	}
+
+	for _, r := range tt.Reminders {
+		if r == nil {
+			continue
+		}
+		if !r.RelativeTo.isValid() {
+			return &ErrInvalidTaskTemplateReminder{TemplateID: templateID}
+		}
+		if (r.RelativeTo == ReminderRelationDueDate && tt.DueDateOffset == 0) ||
+			(r.RelativeTo == ReminderRelationStartDate && tt.StartDateOffset == 0) ||
+			(r.RelativeTo == ReminderRelationEndDate && tt.EndDateOffset == 0) {
+			return &ErrInvalidTaskTemplateReminder{TemplateID: templateID}
+		}
+	}
+
+	for _, subtask := range tt.Subtasks {
+		if subtask == nil {
+			continue
+		}
+		if err := subtask.validate(templateID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addOwnersToTaskTemplates(s *xorm.Session, templates []*TaskTemplate) error {
+	ownerIDs := make([]int64, 0, len(templates))
+	for _, t := range templates {
+		ownerIDs = append(ownerIDs, t.OwnerID)
+	}
+
+	owners, err := user.GetUsersByIDs(s, ownerIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range templates {
+		t.Owner = owners[t.OwnerID]
+	}
+	return nil
+}
+
+// Create creates a new task template
+// @Summary Create a task template
+// @Description Creates a new task template. If a list id is provided, the template belongs to the list and the user needs write access to it.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template body models.TaskTemplate true "The task template"
+// @Success 201 {object} models.TaskTemplate "The created task template."
+// @Failure 400 {object} web.HTTPError "Invalid task template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates [put]
+func (tt *TaskTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	tt.ID = 0
+	err = tt.validate(0)
+	if err != nil {
+		return err
+	}
+
+	tt.OwnerID = a.GetID()
+	_, err = s.Insert(tt)
+	if err != nil {
+		return err
+	}
+
+	return addOwnersToTaskTemplates(s, []*TaskTemplate{tt})
+}
+
+// ReadOne returns one task template
+// @Summary Get one task template
+// @Description Returns one task template.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Success 200 {object} models.TaskTemplate "The task template."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate} [get]
+func (tt *TaskTemplate) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	template, err := getTaskTemplateByID(s, tt.ID)
+	if err != nil {
+		return err
+	}
+
+	*tt = *template
+	return addOwnersToTaskTemplates(s, []*TaskTemplate{tt})
+}
+
+// ReadAll returns all task templates a user has access to
+// @Summary Get all task templates
+// @Description Returns all task templates created by the user and all templates of lists the user has access to.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search templates by title."
+// @Success 200 {array} models.TaskTemplate "The task templates"
+// @Failure 403 {object} web.HTTPError "Link shares can't use task templates."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates [get]
+func (tt *TaskTemplate) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := builder.Or(
+		builder.And(
+			builder.Eq{"owner_id": a.GetID()},
+			builder.Or(builder.IsNull{"list_id"}, builder.Eq{"list_id": 0}),
+		),
+		builder.In("list_id", getUserListsStatement(a.GetID()).Select("l.id")),
+	)
+	if search != "" {
+		cond = builder.And(cond, db.ILIKE("title", search))
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	templates := []*TaskTemplate{}
+	query := s.
+		Where(cond).
+		OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&templates)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addOwnersToTaskTemplates(s, templates)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where(cond).
+		Count(&TaskTemplate{})
+	return templates, len(templates), numberOfTotalItems, err
+}
+
+// Update changes a task template
+// @Summary Update a task template
+// @Description Changes a task template. The list a template belongs to can't be changed.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Param template body models.TaskTemplate true "The task template"
+// @Success 200 {object} models.TaskTemplate "The updated task template."
+// @Failure 400 {object} web.HTTPError "Invalid task template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate} [post]
+func (tt *TaskTemplate) Update(s *xorm.Session, _ web.Auth) (err error) {
+	saved, err := getTaskTemplateByID(s, tt.ID)
+	if err != nil {
+		return err
+	}
+
+	err = tt.validate(tt.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.
+		ID(tt.ID).
+		Cols(
+			"title",
+			"description",
+			"priority",
+			"label_ids",
+			"assignee_ids",
+			"due_date_offset",
+			"start_date_offset",
+			"end_date_offset",
+			"reminders",
+			"subtasks",
+		).
+		Update(tt)
+	if err != nil {
+		return err
+	}
+
+	tt.ListID = saved.ListID
+	tt.OwnerID = saved.OwnerID
+	tt.Created = saved.Created
+	return addOwnersToTaskTemplates(s, []*TaskTemplate{tt})
+}
+
+// Delete removes a task template
+// @Summary Delete a task template
+// @Description Removes a task template. Tasks created from it are not affected.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Success 200 {object} models.Message "The task template was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate} [delete]
+func (tt *TaskTemplate) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", tt.ID).Delete(&TaskTemplate{})
+	return err
+}
+
+// TaskFromTemplate holds everything needed to create a task and its subtasks from a template
+type TaskFromTemplate struct {
+	// The id of the template to create the task from
+	TemplateID int64 `json:"-" param:"tasktemplate"`
+	// The list all tasks will be created in.
+	ListID int64 `json:"list_id" valid:"required"`
+	// The kanban bucket all tasks will be created in. If 0, they will be put in the default bucket of the list.
+	BucketID int64 `json:"bucket_id"`
+	// If provided, overrides the title of the template for the created task. Subtasks always keep their title.
+	Title string `json:"title"`
+
+	// The created task with all its subtasks
+	Task *Task `json:"task,omitempty"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// Create creates a task and its subtasks from a template
+// @Summary Create a task from a template
+// @Description Creates the task of a template with all its labels, assignees, reminders and subtasks in one go. The dates of the tasks are computed from the offsets in the template relative to now. The user needs read access to the template and write access to the target list.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Param target body models.TaskFromTemplate true "The target list and bucket of the new task."
+// @Success 201 {object} models.TaskFromTemplate "The created task."
+// @Failure 400 {object} web.HTTPError "Invalid target provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the template or the target list."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate}/tasks [put]
+func (tft *TaskFromTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	template, err := getTaskTemplateByID(s, tft.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	if tft.Title != "" {
+		template.Title = tft.Title
+	}
+
+	task, err := tft.createTaskTree(s, a, &template.TaskTemplateTask, time.Now())
+	if err != nil {
+		return err
+	}
+
+	tft.Task = &Task{ID: task.ID}
+	return tft.Task.ReadOne(s, a)
+}
+
+func (tft *TaskFromTemplate) createTaskTree(s *xorm.Session, a web.Auth, tt *TaskTemplateTask, now time.Time) (task *Task, err error) {
+	task = &Task{
+		Title:       tt.Title,
+		Description: tt.Description,
+		Priority:    tt.Priority,
+		ListID:      tft.ListID,
+		BucketID:    tft.BucketID,
+	}
+
+	if tt.DueDateOffset != 0 {
+		task.DueDate = now.Add(time.Duration(tt.DueDateOffset) * time.Second)
+	}
+	if tt.StartDateOffset != 0 {
+		task.StartDate = now.Add(time.Duration(tt.StartDateOffset) * time.Second)
+	}
+	if tt.EndDateOffset != 0 {
+		task.EndDate = now.Add(time.Duration(tt.EndDateOffset) * time.Second)
+	}
+
+	for _, r := range tt.Reminders {
+		if r == nil {
+			continue
+		}
+		task.Reminders = append(task.Reminders, &TaskReminder{
+			RelativePeriod: r.RelativePeriod,
+			RelativeTo:     r.RelativeTo,
+		})
+	}
+
+	for _, id := range tt.AssigneeIDs {
+		task.Assignees = append(task.Assignees, &user.User{ID: id})
+	}
+
+	err = createTask(s, task, a, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tt.LabelIDs) > 0 {
+		labels := make([]*Label, 0, len(tt.LabelIDs))
+		for _, id := range tt.LabelIDs {
+			labels = append(labels, &Label{ID: id})
+		}
+		err = task.updateTaskLabels(s, a, labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, st := range tt.Subtasks {
+		if st == nil {
+			continue
+		}
+
+		subtask, err := tft.createTaskTree(s, a, st, now)
+		if err != nil {
+			return nil, err
+		}
+
+		rel := &TaskRelation{
+			TaskID:       task.ID,
+			OtherTaskID:  subtask.ID,
+			RelationKind: RelationKindSubtask,
+		}
+		err = rel.Create(s, a)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return task, nil
+}
@@ -0,0 +1,90 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user has the right to read a task template
+func (tt *TaskTemplate) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	return tt.canDoTemplate(s, a, false)
+}
+
+// CanCreate checks if a user has the right to create a task template
+func (tt *TaskTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	// Templates of a list can be created by everyone who can create tasks in it
+	if tt.ListID != 0 {
+		return (&List{ID: tt.ListID}).CanWrite(s, a)
+	}
+
+	return true, nil
+}
+
+// CanUpdate checks if a user has the right to update a task template
+func (tt *TaskTemplate) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Checking on a new struct to not override the values we want to update
+	can, _, err := (&TaskTemplate{ID: tt.ID}).canDoTemplate(s, a, true)
+	return can, err
+}
+
+// CanDelete checks if a user has the right to delete a task template
+func (tt *TaskTemplate) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	can, _, err := tt.canDoTemplate(s, a, true)
+	return can, err
+}
+
+// canDoTemplate checks the rights of a template: Templates of a list inherit the rights of the list,
+// all other templates are only available to the user who created them.
+func (tt *TaskTemplate) canDoTemplate(s *xorm.Session, a web.Auth, write bool) (bool, int, error) {
+	// Link shares can't use templates
+	if _, is := a.(*LinkSharing); is {
+		return false, 0, nil
+	}
+
+	template, err := getTaskTemplateByID(s, tt.ID)
+	if err != nil {
+		return false, 0, err
+	}
+	*tt = *template
+
+	if tt.ListID == 0 {
+		return tt.OwnerID == a.GetID(), int(RightAdmin), nil
+	}
+
+	l := &List{ID: tt.ListID}
+	if write {
+		can, err := l.CanWrite(s, a)
+		return can, int(RightWrite), err
+	}
+	return l.CanRead(s, a)
+}
+
+// CanCreate checks if a user has the right to create a task from a template
+func (tft *TaskFromTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	canRead, _, err := (&TaskTemplate{ID: tft.TemplateID}).CanRead(s, a)
+	if err != nil || !canRead {
+		return canRead, err
+	}
+
+	return (&List{ID: tft.ListID}).CanWrite(s, a)
+}
@@ -0,0 +1,342 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			TaskTemplateTask: TaskTemplateTask{
+				Title:         "Weekly report",
+				DueDateOffset: 3600,
+				Reminders: []*TaskReminder{
+					{RelativePeriod: -600, RelativeTo: ReminderRelationDueDate},
+				},
+				Subtasks: []*TaskTemplateTask{
+					{Title: "Collect numbers"},
+				},
+			},
+		}
+		can, err := tt.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), tt.Owner.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_templates", map[string]interface{}{
+			"id":              tt.ID,
+			"title":           "Weekly report",
+			"due_date_offset": 3600,
+			"owner_id":        1,
+		}, false)
+	})
+	t.Run("in a list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			TaskTemplateTask: TaskTemplateTask{Title: "Bug report"},
+			ListID:           1,
+		}
+		can, err := tt.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("in a list with read access only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			TaskTemplateTask: TaskTemplateTask{Title: "Bug report"},
+			ListID:           3,
+		}
+		can, err := tt.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("reminder relative to a date without offset", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			TaskTemplateTask: TaskTemplateTask{
+				Title: "Weekly report",
+				Reminders: []*TaskReminder{
+					{RelativePeriod: -600, RelativeTo: ReminderRelationStartDate},
+				},
+			},
+		}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTemplateReminder(err))
+	})
+	t.Run("absolute reminder", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			TaskTemplateTask: TaskTemplateTask{
+				Title: "Weekly report",
+				Reminders: []*TaskReminder{
+					{Reminder: time.Now()},
+				},
+			},
+		}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTemplateReminder(err))
+	})
+	t.Run("subtask without title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			TaskTemplateTask: TaskTemplateTask{
+				Title:    "Weekly report",
+				Subtasks: []*TaskTemplateTask{{}},
+			},
+		}
+		err := tt.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskCannotBeEmpty(err))
+	})
+}
+
+func TestTaskTemplate_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{}
+	result, _, total, err := tt.ReadAll(s, u, "", 0, 50)
+	assert.NoError(t, err)
+	templates := result.([]*TaskTemplate)
+	assert.Len(t, templates, 3)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, int64(1), templates[0].ID)
+	assert.Equal(t, int64(2), templates[1].ID)
+	assert.Equal(t, int64(4), templates[2].ID)
+	assert.Len(t, templates[1].Subtasks, 2)
+	assert.Equal(t, "Publish binaries", templates[1].Subtasks[1].Subtasks[0].Title)
+}
+
+func TestTaskTemplate_Rights(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("own template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, err := (&TaskTemplate{ID: 1}).CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("template of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, _, err := (&TaskTemplate{ID: 3}).CanRead(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("template in a list with read access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		can, _, err := (&TaskTemplate{ID: 4}).CanRead(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		can, err = (&TaskTemplate{ID: 4}).CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, _, err := (&TaskTemplate{ID: 9999}).CanRead(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskTemplateDoesNotExist(err))
+	})
+}
+
+func TestTaskTemplate_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{
+		ID: 2,
+		TaskTemplateTask: TaskTemplateTask{
+			Title:    "Release",
+			Subtasks: []*TaskTemplateTask{{Title: "Tag release"}},
+		},
+		ListID: 3,
+	}
+	err := tt.Update(s, u)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tt.ListID)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertExists(t, "task_templates", map[string]interface{}{
+		"id":      2,
+		"title":   "Release",
+		"list_id": 1,
+	}, false)
+}
+
+func TestTaskTemplate_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{ID: 1}
+	err := tt.Delete(s, u)
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "task_templates", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestTaskFromTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("with subtasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{
+			TemplateID: 2,
+			ListID:     1,
+			BucketID:   1,
+		}
+		can, err := tft.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = tft.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Release checklist", tft.Task.Title)
+		assert.Equal(t, int64(3), tft.Task.Priority)
+		assert.Equal(t, int64(1), tft.Task.BucketID)
+		assert.Len(t, tft.Task.RelatedTasks[RelationKindSubtask], 2)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		var tagRelease *Task
+		for _, st := range tft.Task.RelatedTasks[RelationKindSubtask] {
+			if st.Title == "Tag release" {
+				tagRelease = st
+			}
+		}
+		assert.NotNil(t, tagRelease)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"title":     "Publish binaries",
+			"list_id":   1,
+			"bucket_id": 1,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       tagRelease.ID,
+			"relation_kind": RelationKindSubtask,
+		}, false)
+	})
+	t.Run("with dates, labels, assignees and reminders", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{
+			TemplateID: 1,
+			ListID:     1,
+			Title:      "Onboarding Jane",
+		}
+		before := time.Now()
+		err := tft.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, "Onboarding Jane", tft.Task.Title)
+		assert.WithinDuration(t, before.Add(7*24*time.Hour), tft.Task.DueDate, time.Minute)
+		assert.Len(t, tft.Task.Labels, 1)
+		assert.Equal(t, int64(1), tft.Task.Labels[0].ID)
+		assert.Len(t, tft.Task.Assignees, 1)
+		assert.Len(t, tft.Task.Reminders, 1)
+		assert.Equal(t, tft.Task.DueDate.Add(-24*time.Hour), tft.Task.Reminders[0].Reminder)
+		assert.Len(t, tft.Task.RelatedTasks[RelationKindSubtask], 2)
+	})
+	t.Run("template of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{
+			TemplateID: 3,
+			ListID:     1,
+		}
+		can, err := tft.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("list with read access only", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{
+			TemplateID: 4,
+			ListID:     3,
+		}
+		can, err := tft.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
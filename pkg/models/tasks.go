@@ -28,6 +28,7 @@ import (
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/rrule"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 
@@ -61,28 +62,54 @@ type Task struct {
 	DoneAt time.Time `xorm:"INDEX null 'done_at'" json:"done_at"`
 	// The time when the task is due.
 	DueDate time.Time `xorm:"DATETIME INDEX null 'due_date'" json:"due_date"`
-	// An array of datetimes when the user wants to be reminded of the task.
-	Reminders []time.Time `xorm:"-" json:"reminder_dates"`
+	// An array of reminders that are associated with this task. A reminder is either an absolute time or relative to the due, start or end date of the task.
+	Reminders []*TaskReminder `xorm:"-" json:"reminders"`
+	// The times of all absolute reminders of this task. This only exists for compatibility with older clients, use reminders instead.
+	// When a task is saved with reminder_dates but without reminders, its absolute reminders are replaced with them.
+	ReminderDates []time.Time `xorm:"-" json:"reminder_dates"`
 	// The list this task belongs to.
 	ListID int64 `xorm:"bigint INDEX not null" json:"list_id" param:"list"`
-	// An amount in seconds this task repeats itself. If this is set, when marking the task as done, it will mark itself as "undone" and then increase all remindes and the due date by its amount.
+	// An amount in seconds this task repeats itself. If this is set, when marking the task as done, it will mark itself as "undone" and then increase all remindes and the due date by its amount. It is set from repeat_rule whenever the rule is a plain interval.
 	RepeatAfter int64 `xorm:"bigint INDEX null" json:"repeat_after" valid:"range(0|9223372036854775807)"`
 	// Can have three possible values which will trigger when the task is marked as done: 0 = repeats after the amount specified in repeat_after, 1 = repeats all dates each months (ignoring repeat_after), 3 = repeats from the current date rather than the last set date.
 	RepeatMode TaskRepeatMode `xorm:"not null default 0" json:"repeat_mode"`
+	// A recurrence rule as defined in RFC 5545, for example "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH". If this is set, it takes precedence over repeat_after: When marking the task as done, it will mark itself as "undone" and move all dates to the next occurrence of the rule. With repeat_mode 2, the next occurrence is calculated from the current date.
+	RepeatRule string `xorm:"text null" json:"repeat_rule"`
+	// Occurrences of the repeat rule which should be skipped.
+	RepeatExceptions []time.Time `xorm:"JSON null" json:"repeat_exceptions"`
 	// The task priority. Can be anything you want, it is possible to sort by this later.
 	Priority int64 `xorm:"bigint null" json:"priority"`
 	// When this task starts.
 	StartDate time.Time `xorm:"DATETIME INDEX null 'start_date'" json:"start_date" query:"-"`
 	// When this task ends.
 	EndDate time.Time `xorm:"DATETIME INDEX null 'end_date'" json:"end_date" query:"-"`
+	// If true, the due, start and end date of this task are calendar dates without a time of day. They are taken as
+	// dates in the timezone of the user who saves them and returned as midnight UTC of that date. They can also be
+	// sent as a date only, like "2022-11-04".
+	AllDay bool `xorm:"not null default false" json:"all_day"`
 	// An array of users who are assigned to this task
 	Assignees []*user.User `xorm:"-" json:"assignees"`
 	// An array of labels which are associated with this task.
 	Labels []*Label `xorm:"-" json:"labels"`
 	// The task color in hex
 	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
-	// Determines how far a task is left from being done
+	// Determines how far a task is left from being done. If the task has a checklist, this is computed from the
+	// checked items and can't be changed directly.
 	PercentDone float64 `xorm:"DOUBLE null" json:"percent_done"`
+	// The checklist of this task. When creating a task, all items passed here are created with it.
+	// To change the items of an existing task, use the checklist item endpoints.
+	ChecklistItems []*TaskChecklistItem `xorm:"-" json:"checklist_items"`
+	// All occurrences of a repeating task which were marked done, newest first.
+	Completions []*TaskCompletion `xorm:"-" json:"completions"`
+	// The number of occurrences of a repeating task done in a row since the last time one was missed.
+	CompletionStreak int64 `xorm:"-" json:"completion_streak"`
+	// The number of occurrences of a repeating task which passed without being marked done.
+	MissedOccurrences int64 `xorm:"-" json:"missed_occurrences"`
+	// The values of the custom fields of the task's list, with the id of the field as key. Depending on the type of
+	// the field, the value is a string (text and select), a number (number and the id of the user for user fields),
+	// a date or an array of strings (multiselect). When updating a task, only the fields in this object are changed,
+	// setting a field to null removes its value.
+	CustomFields map[int64]interface{} `xorm:"-" json:"custom_fields"`
 
 	// The task identifier, based on the list identifier and the task's index
 	Identifier string `xorm:"-" json:"identifier"`
@@ -113,6 +140,10 @@ type Task struct {
 	// A timestamp when this task was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
 
+	// When this task was moved to the trash and who did it. Items in the trash are only returned by the trash endpoint.
+	Deleted     time.Time `xorm:"deleted null INDEX" json:"-"`
+	DeletedByID int64     `xorm:"bigint null" json:"-"`
+
 	// BucketID is the ID of the kanban bucket this task belongs to.
 	BucketID int64 `xorm:"bigint null" json:"bucket_id"`
 
@@ -130,13 +161,20 @@ type Task struct {
 	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
 	CreatedByID int64      `xorm:"bigint not null" json:"-"` // ID of the user who put that task on the list
 
+	// Whether the repeat rule was part of the json this task was decoded from
+	repeatRuleSent bool
+	// The dates which were sent without a time of day in the json this task was decoded from
+	dateOnlyFields map[string]bool
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
 
 type TaskWithComments struct {
 	Task
-	Comments []*TaskComment `xorm:"-" json:"comments"`
+	Comments    []*TaskComment  `xorm:"-" json:"comments"`
+	Activity    []*TaskActivity `xorm:"-" json:"activity"`
+	TimeEntries []*TimeEntry    `xorm:"-" json:"time_entries"`
 }
 
 // TableName returns the table name for listtasks
@@ -305,14 +343,22 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 			return nil, 0, 0, err
 		}
 
+		column := "`" + param.sortBy + "`"
+		if fieldID, is := getCustomFieldIDFromTaskField(param.sortBy); is {
+			column, err = getCustomFieldSortColumn(s, fieldID)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+		}
+
 		// Mysql sorts columns with null values before ones without null value.
 		// Because it does not have support for NULLS FIRST or NULLS LAST we work around this by
 		// first sorting for null (or not null) values and then the order we actually want to.
 		if db.Type() == schemas.MYSQL {
-			orderby += "`" + param.sortBy + "` IS NULL, "
+			orderby += column + " IS NULL, "
 		}
 
-		orderby += "`" + param.sortBy + "` " + param.orderBy.String()
+		orderby += column + " " + param.orderBy.String()
 
 		// Postgres and sqlite allow us to control how columns with null values are sorted.
 		// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
@@ -332,8 +378,19 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 	namespaceFilters := []builder.Cond{}
 
 	var filters = make([]builder.Cond, 0, len(opts.filters))
+	// The timezone of the user, only loaded when filtering by a date
+	var loc *time.Location
 	// To still find tasks with nil values, we exclude 0s when comparing with >/< values.
 	for _, f := range opts.filters {
+		if fieldID, is := getCustomFieldIDFromTaskField(f.field); is {
+			filter, err := getCustomFieldFilterCond(s, fieldID, f, opts.filterIncludeNulls)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
 		if f.field == "reminders" {
 			f.field = "reminder" // This is the name in the db
 			filter, err := getFilterCond(f, opts.filterIncludeNulls)
@@ -377,6 +434,21 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 			continue
 		}
 
+		if isAllDayDateField(f.field) {
+			if loc == nil {
+				loc, err = getTimezoneForAuth(s, a)
+				if err != nil {
+					return nil, 0, 0, err
+				}
+			}
+			filter, err := getAllDayAwareFilterCond(f, opts.filterIncludeNulls, loc)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
 		filter, err := getFilterCond(f, opts.filterIncludeNulls)
 		if err != nil {
 			return nil, 0, 0, err
@@ -394,6 +466,17 @@ func getRawTasksForLists(s *xorm.Session, lists []*List, a web.Auth, opts *taskO
 		if searchIndex > 0 {
 			where = builder.Or(where, builder.Eq{"`index`": searchIndex})
 		}
+
+		listIdentifier, identifierIndex := getTaskIdentifierFromSearchString(opts.search)
+		if identifierIndex > 0 {
+			identifierTaskIDs, err := getTaskIDsByIdentifier(s, listIdentifier, identifierIndex)
+			if err != nil {
+				return nil, 0, 0, err
+			}
+			if len(identifierTaskIDs) > 0 {
+				where = builder.Or(where, builder.In("id", identifierTaskIDs))
+			}
+		}
 	}
 
 	var listIDCond builder.Cond
@@ -641,8 +724,8 @@ func addAttachmentsToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*
 	return
 }
 
-func getTaskReminderMap(s *xorm.Session, taskIDs []int64) (taskReminders map[int64][]time.Time, err error) {
-	taskReminders = make(map[int64][]time.Time)
+func getTaskReminderMap(s *xorm.Session, taskIDs []int64) (taskReminders map[int64][]*TaskReminder, err error) {
+	taskReminders = make(map[int64][]*TaskReminder)
 
 	// Get all reminders and put them in a map to have it easier later
 	reminders, err := getRemindersForTasks(s, taskIDs)
@@ -651,7 +734,7 @@ func getTaskReminderMap(s *xorm.Session, taskIDs []int64) (taskReminders map[int
 	}
 
 	for _, r := range reminders {
-		taskReminders[r.TaskID] = append(taskReminders[r.TaskID], r.Reminder)
+		taskReminders[r.TaskID] = append(taskReminders[r.TaskID], r)
 	}
 
 	return
@@ -745,6 +828,21 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		return
 	}
 
+	err = addCustomFieldValuesToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = addChecklistItemsToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
+	err = addCompletionsToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
 	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
 	if err != nil {
 		return
@@ -774,6 +872,10 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 
 		// Add the reminders
 		task.Reminders = taskReminders[task.ID]
+		task.setReminderDates()
+
+		// All-day dates are returned as midnight UTC, regardless of the timezone of the server
+		task.normalizeAllDayDates(time.UTC, nil)
 
 		// Prepare the subtasks
 		task.RelatedTasks = make(RelatedTaskMap)
@@ -881,7 +983,9 @@ func calculateDefaultPosition(entityID int64, position float64) float64 {
 
 func getNextTaskIndex(s *xorm.Session, listID int64) (nextIndex int64, err error) {
 	latestTask := &Task{}
+	// Tasks in the trash still hold on to their index so they don't clash with new tasks once restored
 	_, err = s.
+		Unscoped().
 		Where("list_id = ?", listID).
 		OrderBy("`index` desc").
 		Get(latestTask)
@@ -919,6 +1023,13 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return ErrTaskCannotBeEmpty{}
 	}
 
+	if !t.repeatRuleSent && t.RepeatRule == "" {
+		t.RepeatRule = repeatRuleFromLegacyFields(t.RepeatAfter, t.RepeatMode)
+	}
+	if err := t.validateRepeatRule(); err != nil {
+		return err
+	}
+
 	// Check if the list exists
 	l, err := GetListSimpleByID(s, t.ListID)
 	if err != nil {
@@ -951,10 +1062,23 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 	// If no position was supplied, set a default one
 	t.Position = calculateDefaultPosition(t.Index, t.Position)
 	t.KanbanPosition = calculateDefaultPosition(t.Index, t.KanbanPosition)
+
+	if t.AllDay {
+		loc, err := getTimezoneForAuth(s, a)
+		if err != nil {
+			return err
+		}
+		t.normalizeAllDayDates(loc, nil)
+	}
+
 	if _, err = s.Insert(t); err != nil {
 		return err
 	}
 
+	if err := ensureTaskPositionSpacing(s, t, true, true); err != nil {
+		return err
+	}
+
 	t.CreatedBy = createdBy
 
 	// Update the assignees
@@ -965,10 +1089,24 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 	}
 
 	// Update the reminders
+	if t.Reminders == nil && t.ReminderDates != nil {
+		t.Reminders = remindersFromDates(t.ReminderDates, nil)
+	}
 	if err := t.updateReminders(s, t.Reminders); err != nil {
 		return err
 	}
 
+	if err := t.updateCustomFieldValues(s, t.CustomFields); err != nil {
+		return err
+	}
+	if err := addCustomFieldValuesToTasks(s, []int64{t.ID}, map[int64]*Task{t.ID: t}); err != nil {
+		return err
+	}
+
+	if err := t.createChecklistItems(s, a, t.ChecklistItems, updateAssignees); err != nil {
+		return err
+	}
+
 	t.setIdentifier(l)
 
 	if t.IsFavorite {
@@ -1017,17 +1155,39 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		t.ListID = ot.ListID
 	}
 
+	// Clients which don't know about repeat rules only send repeat_after and repeat_mode.
+	// As long as they did not change them, the task keeps its rule.
+	if !t.repeatRuleSent && t.RepeatRule == "" {
+		t.RepeatRule = repeatRuleFromLegacyFields(t.RepeatAfter, t.RepeatMode)
+		if ot.RepeatRule != "" && t.RepeatAfter == ot.RepeatAfter && t.RepeatMode == ot.RepeatMode {
+			t.RepeatRule = ot.RepeatRule
+		}
+	}
+	if err := t.validateRepeatRule(); err != nil {
+		return err
+	}
+
 	// Get the reminders
 	reminders, err := getRemindersForTasks(s, []int64{t.ID})
 	if err != nil {
 		return
 	}
 
-	ot.Reminders = make([]time.Time, len(reminders))
-	for i, r := range reminders {
-		ot.Reminders[i] = r.Reminder
+	ot.Reminders = reminders
+
+	// Clients which only know reminder_dates keep the relative reminders of the task
+	if t.Reminders == nil && t.ReminderDates != nil {
+		t.Reminders = remindersFromDates(t.ReminderDates, reminders)
 	}
 
+	oldActivityValues := getTaskActivityValues(&ot)
+	oldTitle, oldDescription := ot.Title, ot.Description
+	oldListID := ot.ListID
+	oldEndDate := ot.EndDate
+	allDayDatesBefore := getAllDayDates(&ot)
+	oldPosition, oldKanbanPosition, oldBucketID := ot.Position, ot.KanbanPosition, ot.BucketID
+	customFields := t.CustomFields
+
 	targetBucket, err := setTaskBucket(s, t, &ot, t.BucketID != 0 && t.BucketID != ot.BucketID)
 	if err != nil {
 		return err
@@ -1035,21 +1195,40 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 
 	// If the task was moved into the done bucket and the task has a repeating cycle we should not update
 	// the bucket.
-	if targetBucket.IsDoneBucket && t.RepeatAfter > 0 {
+	var doneBucketID int64
+	if targetBucket.IsDoneBucket && t.isRepeating() {
 		t.Done = true // This will trigger the correct re-scheduling of the task (happening in updateDone later)
+		doneBucketID = targetBucket.ID
 		t.BucketID = ot.BucketID
 	}
 
+	if t.Done && !ot.Done {
+		if err := checkTaskIsNotBlocked(s, t); err != nil {
+			return err
+		}
+	}
+
 	// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
+	wasCompleted := t.Done && !ot.Done
 	updateDone(&ot, t)
+	// Repeating tasks are not done anymore afterwards, unless their recurrence has ended
+	if wasCompleted && (!t.Done || ot.isRepeating()) {
+		if err := recordTaskCompletion(s, a, &ot, t); err != nil {
+			return err
+		}
+	}
+	if wasCompleted && !t.Done {
+		allDayDatesBefore = getAllDayDates(t)
+		allDayDatesBefore.AllDay = ot.AllDay
+	}
 
-	// Update the assignees
-	if err := ot.updateTaskAssignees(s, t.Assignees, a); err != nil {
-		return err
+	// If the recurrence of the task has ended, it stays done and belongs in the done bucket after all
+	if doneBucketID != 0 && t.Done {
+		t.BucketID = doneBucketID
 	}
 
-	// Update the reminders
-	if err := ot.updateReminders(s, t.Reminders); err != nil {
+	// Update the assignees
+	if err := ot.updateTaskAssignees(s, t.Assignees, a); err != nil {
 		return err
 	}
 
@@ -1070,12 +1249,25 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		"bucket_id",
 		"position",
 		"repeat_mode",
+		"repeat_rule",
+		"repeat_exceptions",
 		"kanban_position",
 		"cover_image_attachment_id",
+		"all_day",
 	}
 
 	// If the task is being moved between lists, make sure to move the bucket + index as well
 	if t.ListID != 0 && ot.ListID != t.ListID {
+		// The old identifier should still point to this task after the move
+		oldList, err := GetListSimpleByID(s, ot.ListID)
+		if err != nil {
+			return err
+		}
+		err = addTaskIdentifierRedirects(s, oldList.Identifier, []*Task{&ot})
+		if err != nil {
+			return err
+		}
+
 		t.Index, err = getNextTaskIndex(s, t.ListID)
 		if err != nil {
 			return err
@@ -1180,6 +1372,13 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.PercentDone == 0 {
 		ot.PercentDone = 0
 	}
+	percentDone, hasChecklist, err := getChecklistPercentDone(s, t.ID)
+	if err != nil {
+		return err
+	}
+	if hasChecklist {
+		ot.PercentDone = percentDone
+	}
 	// Position
 	if t.Position == 0 {
 		ot.Position = 0
@@ -1191,6 +1390,13 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.RepeatMode == TaskRepeatModeDefault {
 		ot.RepeatMode = TaskRepeatModeDefault
 	}
+	// Repeat rule
+	if t.RepeatRule == "" {
+		ot.RepeatRule = ""
+	}
+	if len(t.RepeatExceptions) == 0 {
+		ot.RepeatExceptions = nil
+	}
 	// Is Favorite
 	if !t.IsFavorite {
 		ot.IsFavorite = false
@@ -1199,6 +1405,24 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.CoverImageAttachmentID == 0 {
 		ot.CoverImageAttachmentID = 0
 	}
+	// All day
+	if !t.AllDay {
+		ot.AllDay = false
+	}
+	if ot.AllDay {
+		loc, err := getTimezoneForAuth(s, a)
+		if err != nil {
+			return err
+		}
+		ot.dateOnlyFields = t.dateOnlyFields
+		ot.normalizeAllDayDates(loc, allDayDatesBefore)
+	}
+
+	// Update the reminders. Relative reminders are computed from the new dates of the task,
+	// after the dates of all-day tasks were turned into calendar dates.
+	if err := ot.updateReminders(s, t.Reminders); err != nil {
+		return err
+	}
 
 	_, err = s.ID(t.ID).
 		Cols(colsToUpdate...).
@@ -1207,6 +1431,16 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if err != nil {
 		return err
 	}
+
+	err = ensureTaskPositionSpacing(
+		s,
+		t,
+		t.Position != oldPosition || t.ListID != oldListID,
+		t.KanbanPosition != oldKanbanPosition || t.BucketID != oldBucketID,
+	)
+	if err != nil {
+		return err
+	}
 	// Get the task updated timestamp in a new struct - if we'd just try to put it into t which we already have, it
 	// would still contain the old updated date.
 	nt := &Task{}
@@ -1216,6 +1450,34 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	}
 	t.Updated = nt.Updated
 
+	// Values of custom fields only make sense in the list the fields belong to
+	if t.ListID != oldListID {
+		if err := t.removeCustomFieldValuesOfOtherLists(s); err != nil {
+			return err
+		}
+	}
+	if err := t.updateCustomFieldValues(s, customFields); err != nil {
+		return err
+	}
+	if err := addCustomFieldValuesToTasks(s, []int64{t.ID}, map[int64]*Task{t.ID: t}); err != nil {
+		return err
+	}
+
+	t.ChecklistItems = nil
+	if err := addChecklistItemsToTasks(s, []int64{t.ID}, map[int64]*Task{t.ID: t}); err != nil {
+		return err
+	}
+
+	err = recordTaskUpdateActivity(s, a, t.ID, oldActivityValues, getTaskActivityValues(t))
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskRevision(s, a, t, oldTitle, oldDescription)
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	err = events.Dispatch(&TaskUpdatedEvent{
 		Task: t,
@@ -1225,6 +1487,13 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	if t.EndDate.After(oldEndDate) {
+		err = shiftFollowingTasks(s, a, t)
+		if err != nil {
+			return err
+		}
+	}
+
 	return updateListLastUpdated(s, &List{ID: t.ListID})
 }
 
@@ -1254,14 +1523,14 @@ func setTaskDatesDefault(oldTask, newTask *Task) {
 	}
 
 	newTask.Reminders = oldTask.Reminders
-	// When repeating from the current date, all reminders should keep their difference to each other.
-	// To make this easier, we sort them first because we can then rely on the fact the first is the smallest
-	if len(oldTask.Reminders) > 0 {
-		for in, r := range oldTask.Reminders {
-			newTask.Reminders[in] = r.Add(repeatDuration)
-			for !newTask.Reminders[in].After(now) {
-				newTask.Reminders[in] = newTask.Reminders[in].Add(repeatDuration)
-			}
+	for _, r := range newTask.Reminders {
+		// Relative reminders will be recomputed from the new dates
+		if r.RelativeTo != "" {
+			continue
+		}
+		r.Reminder = r.Reminder.Add(repeatDuration)
+		for !r.Reminder.After(now) {
+			r.Reminder = r.Reminder.Add(repeatDuration)
 		}
 	}
 
@@ -1289,10 +1558,11 @@ func setTaskDatesMonthRepeat(oldTask, newTask *Task) {
 	}
 
 	newTask.Reminders = oldTask.Reminders
-	if len(oldTask.Reminders) > 0 {
-		for in, r := range oldTask.Reminders {
-			newTask.Reminders[in] = addOneMonthToDate(r)
+	for _, r := range newTask.Reminders {
+		if r.RelativeTo != "" {
+			continue
 		}
+		r.Reminder = addOneMonthToDate(r.Reminder)
 	}
 
 	if !oldTask.StartDate.IsZero() && !oldTask.EndDate.IsZero() {
@@ -1328,16 +1598,23 @@ func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
 	}
 
 	newTask.Reminders = oldTask.Reminders
-	// When repeating from the current date, all reminders should keep their difference to each other.
-	// To make this easier, we sort them first because we can then rely on the fact the first is the smallest
-	if len(oldTask.Reminders) > 0 {
-		sort.Slice(oldTask.Reminders, func(i, j int) bool {
-			return oldTask.Reminders[i].Unix() < oldTask.Reminders[j].Unix()
+	// When repeating from the current date, all absolute reminders should keep their difference to each other.
+	// To make this easier, we sort them first because we can then rely on the fact the first is the smallest.
+	// Relative reminders will be recomputed from the new dates.
+	absoluteReminders := []*TaskReminder{}
+	for _, r := range newTask.Reminders {
+		if r.RelativeTo == "" {
+			absoluteReminders = append(absoluteReminders, r)
+		}
+	}
+	if len(absoluteReminders) > 0 {
+		sort.Slice(absoluteReminders, func(i, j int) bool {
+			return absoluteReminders[i].Reminder.Unix() < absoluteReminders[j].Reminder.Unix()
 		})
-		first := oldTask.Reminders[0]
-		for in, r := range oldTask.Reminders {
-			diff := r.Sub(first)
-			newTask.Reminders[in] = now.Add(repeatDuration + diff)
+		first := absoluteReminders[0].Reminder
+		for _, r := range absoluteReminders {
+			diff := r.Reminder.Sub(first)
+			r.Reminder = now.Add(repeatDuration + diff)
 		}
 	}
 
@@ -1380,19 +1657,138 @@ func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
 	newTask.Done = false
 }
 
+func isRepeatException(exceptions []time.Time, occurrence time.Time) bool {
+	for _, e := range exceptions {
+		if e.Equal(occurrence) {
+			return true
+		}
+	}
+	return false
+}
+
+func setTaskDatesFromRepeatRule(oldTask, newTask *Task) {
+	rule, err := rrule.Parse(oldTask.RepeatRule)
+	if err != nil {
+		// Repeat rules are validated when saving a task so this should never happen
+		log.Errorf("Could not parse repeat rule of task %d: %s", oldTask.ID, err)
+		return
+	}
+
+	// Current time in an extra variable to base all calculations on the same time
+	now := time.Now().In(config.GetTimeZone())
+
+	// The occurrences of the rule are based on the due date, or the start or end date if the task has no due date
+	reference := oldTask.DueDate
+	if reference.IsZero() {
+		reference = oldTask.StartDate
+	}
+	if reference.IsZero() {
+		reference = oldTask.EndDate
+	}
+
+	start := reference
+	if start.IsZero() || oldTask.RepeatMode == TaskRepeatModeFromCurrentDate {
+		start = now
+	}
+	if reference.IsZero() {
+		reference = start
+	}
+
+	// Skip all occurrences which are excluded or already in the past.
+	// Each of them counts towards the total count of the rule.
+	it := rule.Iterate(start)
+	consumed := 0
+	var next time.Time
+	for {
+		occurrence, exists := it.Next()
+		if !exists {
+			// The recurrence has ended, which means the task stays done
+			return
+		}
+		consumed++
+
+		if occurrence.After(now) && !isRepeatException(oldTask.RepeatExceptions, occurrence) {
+			next = occurrence
+			break
+		}
+	}
+
+	// All dates keep their difference to each other
+	diff := next.Sub(reference)
+	if !oldTask.DueDate.IsZero() {
+		newTask.DueDate = oldTask.DueDate.Add(diff)
+	}
+	if !oldTask.StartDate.IsZero() {
+		newTask.StartDate = oldTask.StartDate.Add(diff)
+	}
+	if !oldTask.EndDate.IsZero() {
+		newTask.EndDate = oldTask.EndDate.Add(diff)
+	}
+
+	newTask.Reminders = oldTask.Reminders
+	for _, r := range newTask.Reminders {
+		// Relative reminders will be recomputed from the new dates
+		if r.RelativeTo != "" {
+			continue
+		}
+		r.Reminder = r.Reminder.Add(diff)
+	}
+
+	newTask.RepeatRule = oldTask.RepeatRule
+	if rule.Count > 0 {
+		rule.Count -= consumed
+		newTask.RepeatRule = rule.String()
+	}
+
+	// Exceptions before the new occurrence are not needed anymore
+	newTask.RepeatExceptions = nil
+	for _, e := range oldTask.RepeatExceptions {
+		if e.After(next) {
+			newTask.RepeatExceptions = append(newTask.RepeatExceptions, e)
+		}
+	}
+
+	newTask.Done = false
+}
+
+func (t *Task) isRepeating() bool {
+	return t.RepeatAfter > 0 || t.RepeatRule != ""
+}
+
+// validateRepeatRule makes sure the repeat rule of a task is valid and brings it into its canonical form.
+// It also keeps repeat_after and repeat_mode in sync with the rule.
+func (t *Task) validateRepeatRule() error {
+	if t.RepeatRule == "" {
+		t.setLegacyRepeatFields(nil)
+		return nil
+	}
+
+	rule, err := rrule.Parse(t.RepeatRule)
+	if err != nil {
+		return &ErrInvalidRepeatRule{RepeatRule: t.RepeatRule, Reason: err.Error()}
+	}
+
+	t.RepeatRule = rule.String()
+	t.setLegacyRepeatFields(rule)
+	return nil
+}
+
 // This helper function updates the reminders, doneAt, start and end dates of the *old* task
 // and saves the new values in the newTask object.
 // We make a few assumtions here:
-//  1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatAfter has a value > 0
+//  1. Everything in oldTask is the truth - we figure out if we update anything at all if oldTask.RepeatRule is set
+//     or oldTask.RepeatAfter has a value > 0
 //  2. Because of 1., this functions should not be used to update values other than Done in the same go
 func updateDone(oldTask *Task, newTask *Task) {
 	if !oldTask.Done && newTask.Done {
-		switch oldTask.RepeatMode {
-		case TaskRepeatModeMonth:
+		switch {
+		case oldTask.RepeatRule != "":
+			setTaskDatesFromRepeatRule(oldTask, newTask)
+		case oldTask.RepeatMode == TaskRepeatModeMonth:
 			setTaskDatesMonthRepeat(oldTask, newTask)
-		case TaskRepeatModeFromCurrentDate:
+		case oldTask.RepeatMode == TaskRepeatModeFromCurrentDate:
 			setTaskDatesFromCurrentDateRepeat(oldTask, newTask)
-		case TaskRepeatModeDefault:
+		case oldTask.RepeatMode == TaskRepeatModeDefault:
 			setTaskDatesDefault(oldTask, newTask)
 		}
 
@@ -1408,8 +1804,8 @@ func updateDone(oldTask *Task, newTask *Task) {
 // Removes all old reminders and adds the new ones. This is a lot easier and less buggy than
 // trying to figure out which reminders changed and then only re-add those needed. And since it does
 // not make a performance difference we'll just do that.
-// The parameter is a slice with unix dates which holds the new reminders.
-func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err error) {
+// The parameter is a slice which holds the new reminders. Relative reminders are computed from the dates of t.
+func (t *Task) updateReminders(s *xorm.Session, reminders []*TaskReminder) (err error) {
 
 	_, err = s.
 		Where("task_id = ?", t.ID).
@@ -1418,24 +1814,45 @@ func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err erro
 		return
 	}
 
-	// Resolve duplicates and sort them
-	reminderMap := make(map[int64]time.Time, len(reminders))
-	for _, reminder := range reminders {
-		reminderMap[reminder.UTC().Unix()] = reminder
-	}
+	// Resolve duplicates while keeping the order
+	seen := make(map[string]bool, len(reminders))
+	newReminders := make([]*TaskReminder, 0, len(reminders))
+	for _, r := range reminders {
+		if r == nil {
+			continue
+		}
+
+		if err := t.computeReminder(r); err != nil {
+			return err
+		}
+
+		key := string(r.RelativeTo) + strconv.FormatInt(r.RelativePeriod, 10)
+		if r.RelativeTo == "" {
+			key = strconv.FormatInt(r.Reminder.UTC().Unix(), 10)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
 
-	// Loop through all reminders and add them
-	for _, r := range reminderMap {
-		_, err = s.Insert(&TaskReminder{TaskID: t.ID, Reminder: r})
+		newReminder := &TaskReminder{
+			TaskID:         t.ID,
+			Reminder:       r.Reminder,
+			RelativePeriod: r.RelativePeriod,
+			RelativeTo:     r.RelativeTo,
+		}
+		_, err = s.Insert(newReminder)
 		if err != nil {
 			return err
 		}
+		newReminders = append(newReminders, newReminder)
 	}
 
-	t.Reminders = reminders
-	if len(reminders) == 0 {
+	t.Reminders = newReminders
+	if len(newReminders) == 0 {
 		t.Reminders = nil
 	}
+	t.setReminderDates()
 
 	err = updateListLastUpdated(s, &List{ID: t.ListID})
 	return
@@ -1443,7 +1860,7 @@ func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err erro
 
 // Delete implements the delete method for listTask
 // @Summary Delete a task
-// @Description Deletes a task from a list. This does not mean "mark it done".
+// @Description Moves a task to the trash. This does not mean "mark it done". Tasks in the trash can be restored until they are removed permanently after the configured retention period.
 // @tags task
 // @Produce json
 // @Security JWTKeyAuth
@@ -1455,7 +1872,30 @@ func (t *Task) updateReminders(s *xorm.Session, reminders []time.Time) (err erro
 // @Router /tasks/{id} [delete]
 func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 
-	if _, err = s.ID(t.ID).Delete(Task{}); err != nil {
+	err = moveToTrash(s, a, t.ID, &Task{})
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	err = events.Dispatch(&TaskDeletedEvent{
+		Task: t,
+		Doer: doer,
+	})
+	if err != nil {
+		return
+	}
+
+	err = updateListLastUpdated(s, &List{ID: t.ListID})
+	return
+}
+
+// deleteTaskPermanently removes a task with everything associated to it.
+// The task deleted event is only dispatched if the task was not in the trash, otherwise that already happened
+// when it was moved there.
+func deleteTaskPermanently(s *xorm.Session, t *Task, a web.Auth) (err error) {
+
+	if _, err = s.Unscoped().ID(t.ID).Delete(&Task{}); err != nil {
 		return err
 	}
 
@@ -1465,7 +1905,7 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 	}
 
 	// Delete Favorites
-	err = removeFromFavorite(s, t.ID, a, FavoriteKindTask)
+	_, err = s.Where("entity_id = ? AND kind = ?", t.ID, FavoriteKindTask).Delete(&Favorite{})
 	if err != nil {
 		return
 	}
@@ -1507,6 +1947,52 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	// Delete the activity
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskActivity{})
+	if err != nil {
+		return
+	}
+
+	// Delete all revisions
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskRevision{})
+	if err != nil {
+		return
+	}
+
+	// Delete all time entries
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TimeEntry{})
+	if err != nil {
+		return
+	}
+
+	// Delete all custom field values
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
+	// Delete the checklist
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskChecklistItem{})
+	if err != nil {
+		return
+	}
+
+	// Delete old identifiers
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskIdentifierRedirect{})
+	if err != nil {
+		return
+	}
+
+	// Delete the completion history
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskCompletion{})
+	if err != nil {
+		return
+	}
+
+	if !t.Deleted.IsZero() {
+		return nil
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	err = events.Dispatch(&TaskDeletedEvent{
 		Task: t,
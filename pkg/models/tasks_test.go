@@ -309,6 +309,63 @@ func TestTask_Update(t *testing.T) {
 			"bucket_id": 3,
 		}, false)
 	})
+	t.Run("blocked task", func(t *testing.T) {
+		t.Run("enforced", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			_, err := s.Where("id = ?", 1).Cols("enforce_blocking_relations").Update(&List{EnforceBlockingRelations: true})
+			assert.NoError(t, err)
+			rel := &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked}
+			err = rel.Create(s, u)
+			assert.NoError(t, err)
+
+			task := &Task{
+				ID:   1,
+				Done: true,
+			}
+			err = task.Update(s, u)
+			assert.Error(t, err)
+			assert.True(t, IsErrTaskIsBlocked(err))
+		})
+		t.Run("enforced, but blocking task is done", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			_, err := s.Where("id = ?", 1).Cols("enforce_blocking_relations").Update(&List{EnforceBlockingRelations: true})
+			assert.NoError(t, err)
+			rel := &TaskRelation{TaskID: 2, OtherTaskID: 1, RelationKind: RelationKindBlocking}
+			err = rel.Create(s, u)
+			assert.NoError(t, err)
+
+			task := &Task{
+				ID:   1,
+				Done: true,
+			}
+			err = task.Update(s, u)
+			assert.NoError(t, err)
+			assert.True(t, task.Done)
+		})
+		t.Run("not enforced", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			rel := &TaskRelation{TaskID: 1, OtherTaskID: 3, RelationKind: RelationKindBlocked}
+			err := rel.Create(s, u)
+			assert.NoError(t, err)
+
+			task := &Task{
+				ID:   1,
+				Done: true,
+			}
+			err = task.Update(s, u)
+			assert.NoError(t, err)
+			assert.True(t, task.Done)
+		})
+	})
 	t.Run("move task to another list", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -352,6 +409,67 @@ func TestTask_Update(t *testing.T) {
 			"bucket_id": 1,
 		}, false)
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		t.Run("invalid", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			task := &Task{
+				ID:         1,
+				Title:      "test",
+				ListID:     1,
+				RepeatRule: "FREQ=FORTNIGHTLY",
+			}
+			err := task.Update(s, u)
+			assert.Error(t, err)
+			assert.True(t, IsErrInvalidRepeatRule(err))
+		})
+		t.Run("normalized", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			task := &Task{
+				ID:         1,
+				Title:      "test",
+				ListID:     1,
+				RepeatRule: "RRULE:freq=weekly;interval=2;byday=mo,th",
+			}
+			err := task.Update(s, u)
+			assert.NoError(t, err)
+			err = s.Commit()
+			assert.NoError(t, err)
+			assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", task.RepeatRule)
+
+			db.AssertExists(t, "tasks", map[string]interface{}{
+				"id":          1,
+				"repeat_rule": "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			}, false)
+		})
+		t.Run("ended recurrence should move the task to the done bucket", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			task := &Task{
+				ID:         28,
+				Title:      "test",
+				ListID:     1,
+				RepeatRule: "FREQ=DAILY;COUNT=1",
+			}
+			err := task.Update(s, u)
+			assert.NoError(t, err)
+
+			task.Done = true
+			err = task.Update(s, u)
+			assert.NoError(t, err)
+			err = s.Commit()
+			assert.NoError(t, err)
+			assert.True(t, task.Done)
+			assert.Equal(t, int64(3), task.BucketID)
+		})
+	})
 	t.Run("moving a task between lists should give it a correct index", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -367,6 +485,89 @@ func TestTask_Update(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, int64(3), task.Index)
 	})
+	t.Run("relative reminders", func(t *testing.T) {
+		t.Run("computed from the task date", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			task := &Task{
+				ID:      1,
+				Title:   "test",
+				ListID:  1,
+				DueDate: time.Unix(1550000000, 0),
+				Reminders: []*TaskReminder{
+					{
+						RelativeTo:     ReminderRelationDueDate,
+						RelativePeriod: -3600,
+					},
+					{
+						Reminder: time.Unix(1540000000, 0),
+					},
+				},
+			}
+			err := task.Update(s, u)
+			assert.NoError(t, err)
+			assert.Len(t, task.Reminders, 2)
+			assert.Equal(t, time.Unix(1549996400, 0).Unix(), task.Reminders[0].Reminder.Unix())
+			assert.Equal(t, time.Unix(1540000000, 0).Unix(), task.Reminders[1].Reminder.Unix())
+
+			// Moving the due date should move the relative reminder but not the absolute one
+			task.DueDate = time.Unix(1560000000, 0)
+			err = task.Update(s, u)
+			assert.NoError(t, err)
+			err = s.Commit()
+			assert.NoError(t, err)
+
+			reminders, err := getRemindersForTasks(s, []int64{1})
+			assert.NoError(t, err)
+			assert.Len(t, reminders, 2)
+			assert.Equal(t, time.Unix(1540000000, 0).Unix(), reminders[0].Reminder.Unix())
+			assert.Equal(t, time.Unix(1559996400, 0).Unix(), reminders[1].Reminder.Unix())
+			assert.Equal(t, ReminderRelationDueDate, reminders[1].RelativeTo)
+			assert.Equal(t, int64(-3600), reminders[1].RelativePeriod)
+		})
+		t.Run("date not set", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			task := &Task{
+				ID:     1,
+				Title:  "test",
+				ListID: 1,
+				Reminders: []*TaskReminder{
+					{
+						RelativeTo:     ReminderRelationStartDate,
+						RelativePeriod: -3600,
+					},
+				},
+			}
+			err := task.Update(s, u)
+			assert.Error(t, err)
+			assert.True(t, IsErrReminderRelativeToMissing(err))
+		})
+		t.Run("invalid relation", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			task := &Task{
+				ID:      1,
+				Title:   "test",
+				ListID:  1,
+				DueDate: time.Unix(1550000000, 0),
+				Reminders: []*TaskReminder{
+					{
+						RelativeTo: "created",
+					},
+				},
+			}
+			err := task.Update(s, u)
+			assert.Error(t, err)
+			assert.True(t, IsErrInvalidReminderRelation(err))
+		})
+	})
 }
 
 func TestTask_Delete(t *testing.T) {
@@ -383,9 +584,37 @@ func TestTask_Delete(t *testing.T) {
 		err = s.Commit()
 		assert.NoError(t, err)
 
+		// The task is only moved to the trash
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":            1,
+			"deleted_by_id": 1,
+		}, false)
+		_, err = GetTaskByIDSimple(s, 1)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+		}, false)
+	})
+	t.Run("permanently", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID: 1,
+		}
+		err := deleteTaskPermanently(s, task, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
 		db.AssertMissing(t, "tasks", map[string]interface{}{
 			"id": 1,
 		})
+		db.AssertMissing(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+		})
 	})
 }
 
@@ -465,9 +694,9 @@ func TestUpdateDone(t *testing.T) {
 			oldTask := &Task{
 				Done:        false,
 				RepeatAfter: 8600,
-				Reminders: []time.Time{
-					time.Unix(1550000000, 0),
-					time.Unix(1555000000, 0),
+				Reminders: []*TaskReminder{
+					{Reminder: time.Unix(1550000000, 0)},
+					{Reminder: time.Unix(1555000000, 0)},
 				},
 			}
 			newTask := &Task{
@@ -485,10 +714,34 @@ func TestUpdateDone(t *testing.T) {
 			}
 
 			assert.Len(t, newTask.Reminders, 2)
-			assert.Equal(t, expected1, newTask.Reminders[0])
-			assert.Equal(t, expected2, newTask.Reminders[1])
+			assert.Equal(t, expected1, newTask.Reminders[0].Reminder)
+			assert.Equal(t, expected2, newTask.Reminders[1].Reminder)
 			assert.False(t, newTask.Done)
 		})
+		t.Run("keep relative reminders", func(t *testing.T) {
+			oldTask := &Task{
+				Done:        false,
+				RepeatAfter: 8600,
+				DueDate:     time.Unix(1550000000, 0),
+				Reminders: []*TaskReminder{
+					{
+						Reminder:       time.Unix(1549996400, 0),
+						RelativeTo:     ReminderRelationDueDate,
+						RelativePeriod: -3600,
+					},
+				},
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			// Relative reminders are only recomputed when saving the task
+			assert.Len(t, newTask.Reminders, 1)
+			assert.Equal(t, time.Unix(1549996400, 0), newTask.Reminders[0].Reminder)
+			assert.Equal(t, ReminderRelationDueDate, newTask.Reminders[0].RelativeTo)
+			assert.Equal(t, int64(-3600), newTask.Reminders[0].RelativePeriod)
+		})
 		t.Run("update start date", func(t *testing.T) {
 			oldTask := &Task{
 				Done:        false,
@@ -563,9 +816,9 @@ func TestUpdateDone(t *testing.T) {
 					Done:        false,
 					RepeatAfter: 8600,
 					RepeatMode:  TaskRepeatModeFromCurrentDate,
-					Reminders: []time.Time{
-						time.Unix(1550000000, 0),
-						time.Unix(1555000000, 0),
+					Reminders: []*TaskReminder{
+						{Reminder: time.Unix(1550000000, 0)},
+						{Reminder: time.Unix(1555000000, 0)},
 					},
 				}
 				newTask := &Task{
@@ -573,12 +826,12 @@ func TestUpdateDone(t *testing.T) {
 				}
 				updateDone(oldTask, newTask)
 
-				diff := oldTask.Reminders[1].Sub(oldTask.Reminders[0])
+				diff := oldTask.Reminders[1].Reminder.Sub(oldTask.Reminders[0].Reminder)
 
 				assert.Len(t, newTask.Reminders, 2)
 				// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
-				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.Reminders[0].Unix())
-				assert.Equal(t, time.Now().Add(diff+time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.Reminders[1].Unix())
+				assert.Equal(t, time.Now().Add(time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.Reminders[0].Reminder.Unix())
+				assert.Equal(t, time.Now().Add(diff+time.Duration(oldTask.RepeatAfter)*time.Second).Unix(), newTask.Reminders[1].Reminder.Unix())
 				assert.False(t, newTask.Done)
 			})
 			t.Run("start date", func(t *testing.T) {
@@ -656,23 +909,25 @@ func TestUpdateDone(t *testing.T) {
 				oldTask := &Task{
 					Done:       false,
 					RepeatMode: TaskRepeatModeMonth,
-					Reminders: []time.Time{
-						time.Unix(1550000000, 0),
-						time.Unix(1555000000, 0),
+					Reminders: []*TaskReminder{
+						{Reminder: time.Unix(1550000000, 0)},
+						{Reminder: time.Unix(1555000000, 0)},
 					},
 				}
 				newTask := &Task{
 					Done: true,
 				}
 				oldReminders := make([]time.Time, len(oldTask.Reminders))
-				copy(oldReminders, oldTask.Reminders)
+				for i, r := range oldTask.Reminders {
+					oldReminders[i] = r.Reminder
+				}
 
 				updateDone(oldTask, newTask)
 
 				assert.Len(t, newTask.Reminders, len(oldReminders))
 				for i, r := range newTask.Reminders {
-					assert.True(t, r.After(oldReminders[i]))
-					assert.NotEqual(t, oldReminders[i].Month(), r.Month())
+					assert.True(t, r.Reminder.After(oldReminders[i]))
+					assert.NotEqual(t, oldReminders[i].Month(), r.Reminder.Month())
 				}
 				assert.False(t, newTask.Done)
 			})
@@ -735,6 +990,112 @@ func TestUpdateDone(t *testing.T) {
 			})
 		})
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		tomorrow := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+
+		t.Run("weekly", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=WEEKLY",
+				DueDate:    tomorrow,
+				StartDate:  tomorrow.Add(-time.Hour),
+				Reminders: []*TaskReminder{
+					{Reminder: tomorrow.Add(-2 * time.Hour)},
+				},
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, tomorrow.AddDate(0, 0, 7).Unix(), newTask.DueDate.Unix())
+			assert.Equal(t, tomorrow.AddDate(0, 0, 7).Add(-time.Hour).Unix(), newTask.StartDate.Unix())
+			assert.Equal(t, tomorrow.AddDate(0, 0, 7).Add(-2*time.Hour).Unix(), newTask.Reminders[0].Reminder.Unix())
+			assert.Equal(t, "FREQ=WEEKLY", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("due date in the past", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY",
+				DueDate:    tomorrow.AddDate(0, 0, -10),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, tomorrow.Unix(), newTask.DueDate.Unix())
+			assert.False(t, newTask.Done)
+		})
+		t.Run("count", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;COUNT=3",
+				DueDate:    tomorrow,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, tomorrow.AddDate(0, 0, 1).Unix(), newTask.DueDate.Unix())
+			assert.Equal(t, "FREQ=DAILY;COUNT=2", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("ended", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;UNTIL=20200101T000000Z",
+				DueDate:    tomorrow,
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+			assert.False(t, newTask.DoneAt.IsZero())
+		})
+		t.Run("exceptions", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY",
+				DueDate:    tomorrow,
+				RepeatExceptions: []time.Time{
+					tomorrow.AddDate(0, 0, 1),
+					tomorrow.AddDate(0, 0, 5),
+				},
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, tomorrow.AddDate(0, 0, 2).Unix(), newTask.DueDate.Unix())
+			assert.Equal(t, []time.Time{tomorrow.AddDate(0, 0, 5)}, newTask.RepeatExceptions)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("from current date", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;INTERVAL=2",
+				RepeatMode: TaskRepeatModeFromCurrentDate,
+				DueDate:    tomorrow.AddDate(0, 0, -10),
+				StartDate:  tomorrow.AddDate(0, 0, -11),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+			updateDone(oldTask, newTask)
+
+			// Only comparing unix timestamps because time.Time use nanoseconds which can't ever possibly have the same value
+			assert.Equal(t, time.Now().AddDate(0, 0, 2).Unix(), newTask.DueDate.Unix())
+			assert.Equal(t, time.Now().AddDate(0, 0, 1).Unix(), newTask.StartDate.Unix())
+			assert.False(t, newTask.Done)
+		})
+	})
 }
 
 func TestTask_ReadOne(t *testing.T) {
@@ -0,0 +1,413 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TimeEntry represents time a user spent working on a task
+type TimeEntry struct {
+	// The unique, numeric id of this time entry.
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"timeentry"`
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+
+	// The user (or link share) who tracked this time.
+	User   *user.User `xorm:"-" json:"user"`
+	UserID int64      `xorm:"bigint not null index" json:"-"`
+
+	// When the work started.
+	Start time.Time `xorm:"DATETIME not null 'start_time'" json:"start"`
+	// When the work ended. A time entry without an end is a running timer.
+	End time.Time `xorm:"DATETIME null 'end_time'" json:"end"`
+	// The tracked time in seconds. If you create an entry without an end, the end is calculated from this.
+	// Otherwise it is calculated from start and end.
+	Duration int64 `xorm:"bigint not null default 0" json:"duration"`
+	// A note about what was done during this time.
+	Note string `xorm:"text null" json:"note"`
+
+	// A timestamp when this time entry was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this time entry was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the time entries table
+func (*TimeEntry) TableName() string {
+	return "time_entries"
+}
+
+// setPeriod makes sure a finished time entry has a valid start, end and duration.
+func (te *TimeEntry) setPeriod() error {
+	if te.End.IsZero() && te.Duration > 0 {
+		te.End = te.Start.Add(time.Duration(te.Duration) * time.Second)
+	}
+
+	if te.Start.IsZero() || te.End.IsZero() || !te.End.After(te.Start) {
+		return &ErrInvalidTimeEntryPeriod{Start: te.Start, End: te.End}
+	}
+
+	te.Duration = int64(te.End.Sub(te.Start).Seconds())
+	return nil
+}
+
+func (te *TimeEntry) isRunning() bool {
+	return te.End.IsZero()
+}
+
+func getTimeEntry(s *xorm.Session, taskID, timeEntryID int64) (te *TimeEntry, err error) {
+	te = &TimeEntry{}
+	exists, err := s.
+		Where("id = ? AND task_id = ?", timeEntryID, taskID).
+		Get(te)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTimeEntryDoesNotExist{TaskID: taskID, TimeEntryID: timeEntryID}
+	}
+	return
+}
+
+func addUsersToTimeEntries(s *xorm.Session, entries []*TimeEntry) error {
+	userIDs := make([]int64, 0, len(entries))
+	for _, te := range entries {
+		userIDs = append(userIDs, te.UserID)
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, te := range entries {
+		te.User = users[te.UserID]
+	}
+	return nil
+}
+
+// Create adds a new time entry to a task
+// @Summary Track time on a task
+// @Description Adds a finished time entry to a task. Either an end or a duration must be provided. To track time while working on a task, use the timer instead.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param entry body models.TimeEntry true "The time entry"
+// @Success 201 {object} models.TimeEntry "The created time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries [put]
+func (te *TimeEntry) Create(s *xorm.Session, a web.Auth) (err error) {
+	te.ID = 0
+	err = te.setPeriod()
+	if err != nil {
+		return err
+	}
+
+	te.User, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+	te.UserID = te.User.ID
+
+	_, err = s.Insert(te)
+	return err
+}
+
+// ReadOne returns a single time entry
+// @Summary Get one time entry
+// @Description Returns one time entry of a task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timeentry path int true "Time entry ID"
+// @Success 200 {object} models.TimeEntry "The time entry."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries/{timeentry} [get]
+func (te *TimeEntry) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	entry, err := getTimeEntry(s, te.TaskID, te.ID)
+	if err != nil {
+		return err
+	}
+
+	*te = *entry
+	return addUsersToTimeEntries(s, []*TimeEntry{te})
+}
+
+// ReadAll returns all time entries of a task
+// @Summary Get all time entries of a task
+// @Description Returns all time tracked on a task, including running timers, oldest first.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TimeEntry "The time entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries [get]
+func (te *TimeEntry) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := te.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	entries := []*TimeEntry{}
+	query := s.
+		Where("task_id = ?", te.TaskID).
+		OrderBy("start_time asc, id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addUsersToTimeEntries(s, entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where("task_id = ?", te.TaskID).
+		Count(&TimeEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+// Update changes a time entry
+// @Summary Update a time entry
+// @Description Changes the start, end, duration or note of a time entry. Only the user who tracked the time can change it. Running timers stay running unless an end or duration is provided.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timeentry path int true "Time entry ID"
+// @Param entry body models.TimeEntry true "The time entry"
+// @Success 200 {object} models.TimeEntry "The updated time entry."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries/{timeentry} [post]
+func (te *TimeEntry) Update(s *xorm.Session, _ web.Auth) (err error) {
+	saved, err := getTimeEntry(s, te.TaskID, te.ID)
+	if err != nil {
+		return err
+	}
+
+	if saved.isRunning() && te.End.IsZero() && te.Duration <= 0 {
+		if te.Start.IsZero() {
+			return &ErrInvalidTimeEntryPeriod{Start: te.Start, End: te.End}
+		}
+		te.Duration = 0
+	} else {
+		err = te.setPeriod()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.
+		ID(te.ID).
+		Cols("start_time", "end_time", "duration", "note").
+		Update(te)
+	if err != nil {
+		return err
+	}
+
+	te.UserID = saved.UserID
+	te.Created = saved.Created
+	return addUsersToTimeEntries(s, []*TimeEntry{te})
+}
+
+// Delete removes a time entry
+// @Summary Delete a time entry
+// @Description Removes a time entry from a task. Only the user who tracked the time can delete it.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timeentry path int true "Time entry ID"
+// @Success 200 {object} models.Message "The time entry was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/time-entries/{timeentry} [delete]
+func (te *TimeEntry) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		Delete(&TimeEntry{})
+	return
+}
+
+// TaskTimer starts and stops tracking time on a task. Every user can only have one running timer at a time.
+type TaskTimer struct {
+	TaskID int64 `json:"-" param:"task"`
+
+	// A note which will be saved with the time entry of the timer.
+	Note string `json:"note"`
+	// The time entry of the timer. It does not have an end while the timer is running.
+	TimeEntry *TimeEntry `json:"time_entry"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+func getRunningTimeEntry(s *xorm.Session, userID int64) (te *TimeEntry, exists bool, err error) {
+	te = &TimeEntry{}
+	exists, err = s.
+		Where("user_id = ? AND end_time IS NULL", userID).
+		Get(te)
+	return
+}
+
+// ReadOne returns the running timer of the current user on a task
+// @Summary Get the running timer
+// @Description Returns the running timer of the current user on a task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 200 {object} models.TaskTimer "The running timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "The user does not have a running timer on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/timer [get]
+func (tt *TaskTimer) ReadOne(s *xorm.Session, a web.Auth) (err error) {
+	userID := getActorID(a)
+	running, exists, err := getRunningTimeEntry(s, userID)
+	if err != nil {
+		return err
+	}
+	if !exists || running.TaskID != tt.TaskID {
+		return &ErrNoRunningTimer{UserID: userID, TaskID: tt.TaskID}
+	}
+
+	tt.TimeEntry = running
+	tt.Note = running.Note
+	return addUsersToTimeEntries(s, []*TimeEntry{running})
+}
+
+// Create starts a timer
+// @Summary Start a timer
+// @Description Starts tracking time on a task. Fails if the user already has a running timer, on this or any other task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timer body models.TaskTimer false "An optional note for the time entry"
+// @Success 201 {object} models.TaskTimer "The started timer."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 409 {object} web.HTTPError "The user already has a running timer."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/timer [put]
+func (tt *TaskTimer) Create(s *xorm.Session, a web.Auth) (err error) {
+	doer, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	running, exists, err := getRunningTimeEntry(s, doer.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return &ErrTimerAlreadyRunning{UserID: doer.ID, TaskID: running.TaskID}
+	}
+
+	tt.TimeEntry = &TimeEntry{
+		TaskID: tt.TaskID,
+		UserID: doer.ID,
+		User:   doer,
+		Start:  time.Now().Truncate(time.Second),
+		Note:   tt.Note,
+	}
+	_, err = s.Insert(tt.TimeEntry)
+	return err
+}
+
+// Update stops a timer
+// @Summary Stop a timer
+// @Description Stops the running timer of the current user on a task. The time entry of the timer is kept with the current time as its end.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Param timer body models.TaskTimer false "An optional note for the time entry. If empty, the note the timer was started with is kept."
+// @Success 200 {object} models.TaskTimer "The stopped timer."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the task."
+// @Failure 404 {object} web.HTTPError "The user does not have a running timer on this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/timer [post]
+func (tt *TaskTimer) Update(s *xorm.Session, a web.Auth) (err error) {
+	userID := getActorID(a)
+	running, exists, err := getRunningTimeEntry(s, userID)
+	if err != nil {
+		return err
+	}
+	if !exists || running.TaskID != tt.TaskID {
+		return &ErrNoRunningTimer{UserID: userID, TaskID: tt.TaskID}
+	}
+
+	running.End = time.Now().Truncate(time.Second)
+	if !running.End.After(running.Start) {
+		running.End = running.Start.Add(time.Second)
+	}
+	running.Duration = int64(running.End.Sub(running.Start).Seconds())
+	if tt.Note != "" {
+		running.Note = tt.Note
+	}
+
+	_, err = s.
+		ID(running.ID).
+		Cols("end_time", "duration", "note").
+		Update(running)
+	if err != nil {
+		return err
+	}
+
+	tt.TimeEntry = running
+	tt.Note = running.Note
+	return addUsersToTimeEntries(s, []*TimeEntry{running})
+}
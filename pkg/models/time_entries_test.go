@@ -0,0 +1,403 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeEntry_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	start := time.Date(2022, 10, 23, 9, 0, 0, 0, time.UTC)
+
+	t.Run("with end", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(90 * time.Minute),
+			Note:   "Meeting",
+		}
+		err := te.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5400), te.Duration)
+		assert.Equal(t, int64(1), te.User.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "time_entries", map[string]interface{}{
+			"task_id":  1,
+			"user_id":  1,
+			"duration": 5400,
+			"note":     "Meeting",
+		}, false)
+	})
+	t.Run("with duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			TaskID:   1,
+			Start:    start,
+			Duration: 600,
+		}
+		err := te.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(10*time.Minute), te.End)
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(-time.Hour),
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntryPeriod(err))
+	})
+	t.Run("without end or duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			TaskID: 1,
+			Start:  start,
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeEntryPeriod(err))
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{TaskID: 14}
+		can, err := te.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTimeEntry_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{TaskID: 1}
+		result, resultCount, total, err := te.ReadAll(s, u, "", 0, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, resultCount)
+		assert.Equal(t, int64(2), total)
+		entries := result.([]*TimeEntry)
+		assert.Equal(t, int64(1), entries[0].ID)
+		assert.Equal(t, int64(2), entries[1].ID)
+		assert.Equal(t, "user1", entries[0].User.Username)
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{TaskID: 14}
+		_, _, _, err := te.ReadAll(s, u, "", 0, -1)
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
+
+func TestTimeEntry_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			ID:     1,
+			TaskID: 1,
+			Start:  time.Date(2022, 10, 20, 9, 0, 0, 0, time.UTC),
+			End:    time.Date(2022, 10, 20, 9, 45, 0, 0, time.UTC),
+			Note:   "Less research",
+		}
+		can, err := te.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = te.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "time_entries", map[string]interface{}{
+			"id":       1,
+			"duration": 2700,
+			"note":     "Less research",
+		}, false)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			ID:     9999,
+			TaskID: 1,
+		}
+		_, err := te.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+	t.Run("entry of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			ID:     3,
+			TaskID: 1,
+		}
+		_, err := te.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimeEntryDoesNotExist(err))
+	})
+}
+
+func TestTimeEntry_Delete(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		te := &TimeEntry{
+			ID:     1,
+			TaskID: 1,
+		}
+		can, err := te.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = te.Delete(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "time_entries", map[string]interface{}{
+			"id": 1,
+		})
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TimeEntry{
+			ID:     4,
+			TaskID: 14,
+		}
+		can, err := te.CanDelete(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTimer(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("start and stop", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		timer := &TaskTimer{TaskID: 1, Note: "Working"}
+		err := timer.Create(s, u)
+		assert.NoError(t, err)
+		assert.True(t, timer.TimeEntry.End.IsZero())
+
+		running := &TaskTimer{TaskID: 1}
+		err = running.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, timer.TimeEntry.ID, running.TimeEntry.ID)
+
+		stop := &TaskTimer{TaskID: 1}
+		err = stop.Update(s, u)
+		assert.NoError(t, err)
+		assert.False(t, stop.TimeEntry.End.IsZero())
+		assert.True(t, stop.TimeEntry.Duration > 0)
+		assert.Equal(t, "Working", stop.TimeEntry.Note)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "time_entries", map[string]interface{}{
+			"id":      stop.TimeEntry.ID,
+			"task_id": 1,
+			"user_id": 1,
+			"note":    "Working",
+		}, false)
+	})
+	t.Run("only one running timer per user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		timer := &TaskTimer{TaskID: 1}
+		err := timer.Create(s, u)
+		assert.NoError(t, err)
+
+		timer = &TaskTimer{TaskID: 3}
+		err = timer.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTimerAlreadyRunning(err))
+	})
+	t.Run("stop without running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		timer := &TaskTimer{TaskID: 1}
+		err := timer.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+	t.Run("stop timer of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		timer := &TaskTimer{TaskID: 1}
+		err := timer.Create(s, u)
+		assert.NoError(t, err)
+
+		timer = &TaskTimer{TaskID: 3}
+		err = timer.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTimer(err))
+	})
+}
+
+func TestGetTimeReport(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("all", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(13500), report.TotalDuration)
+		assert.Equal(t, []*TimeReportItem{{ID: 1, Title: "Test1", Duration: 13500, Entries: 4}}, report.Lists)
+		assert.Equal(t, []*TimeReportItem{{ID: 4, Title: "Label #4 - visible via other task", Duration: 12600, Entries: 3}}, report.Labels)
+		assert.Equal(t, []*TimeReportItem{{ID: 1, Title: "user1", Duration: 13500, Entries: 4}}, report.Users)
+	})
+	t.Run("date range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{From: "2022-10-21", To: "2022-10-21"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9000), report.TotalDuration)
+	})
+	t.Run("label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{LabelID: 4})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12600), report.TotalDuration)
+	})
+	t.Run("user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{UserID: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), report.TotalDuration)
+		assert.Empty(t, report.Users)
+	})
+	t.Run("list without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTimeReport(s, u, &TimeReportOptions{ListID: 5})
+		assert.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("running timers are not included", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, &user.User{ID: 6}, &TimeReportOptions{ListID: 6})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), report.TotalDuration)
+	})
+	t.Run("invalid range", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetTimeReport(s, u, &TimeReportOptions{From: "2022-10-22", To: "2022-10-21"})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeReportRange(err))
+
+		_, err = GetTimeReport(s, u, &TimeReportOptions{From: "yesterday"})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTimeReportRange(err))
+	})
+	t.Run("csv", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		report, err := GetTimeReport(s, u, &TimeReportOptions{})
+		assert.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		err = report.WriteCSV(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, `type,id,title,entries,duration_seconds,duration_hours
+list,1,Test1,4,13500,3.75
+label,4,Label #4 - visible via other task,3,12600,3.50
+user,1,user1,4,13500,3.75
+total,,,,13500,3.75
+`, buf.String())
+	})
+}
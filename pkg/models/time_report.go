@@ -0,0 +1,335 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TimeReportOptions holds the filters for a time report
+type TimeReportOptions struct {
+	// Only include time tracked on tasks in this list.
+	ListID int64
+	// Only include time tracked on tasks with this label.
+	LabelID int64
+	// Only include time tracked by this user.
+	UserID int64
+	// Only include time entries which started on or after this date.
+	From string
+	// Only include time entries which started on or before this date.
+	To string
+}
+
+// TimeReportItem is the tracked time of one list, label or user
+type TimeReportItem struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	// The tracked time in seconds.
+	Duration int64 `json:"duration"`
+	// The number of time entries the duration consists of.
+	Entries int64 `json:"entries"`
+}
+
+// TimeReport holds the tracked time aggregated by list, label and user
+type TimeReport struct {
+	// The total tracked time in seconds.
+	TotalDuration int64 `json:"total_duration"`
+
+	Lists  []*TimeReportItem `json:"lists"`
+	Labels []*TimeReportItem `json:"labels"`
+	Users  []*TimeReportItem `json:"users"`
+}
+
+// parseTimeReportDate parses a date of a time report. Dates without time cover the whole day in the configured
+// time zone, which is why the end of a date only range is moved to the start of the next day.
+func parseTimeReportDate(value string, isEnd bool) (t time.Time, isDate bool, err error) {
+	t, err = time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, false, nil
+	}
+
+	t, err = time.ParseInLocation("2006-01-02", value, config.GetTimeZone())
+	if err != nil {
+		return
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true, nil
+}
+
+func (opts *TimeReportOptions) getDateCond() (cond builder.Cond, err error) {
+	cond = builder.NotNull{"time_entries.end_time"}
+
+	var from, to time.Time
+	var toIsDate bool
+	if opts.From != "" {
+		from, _, err = parseTimeReportDate(opts.From, false)
+		if err != nil {
+			return nil, &ErrInvalidTimeReportRange{From: opts.From, To: opts.To}
+		}
+		cond = builder.And(cond, builder.Gte{"time_entries.start_time": from})
+	}
+	if opts.To != "" {
+		to, toIsDate, err = parseTimeReportDate(opts.To, true)
+		if err != nil {
+			return nil, &ErrInvalidTimeReportRange{From: opts.From, To: opts.To}
+		}
+		if toIsDate {
+			cond = builder.And(cond, builder.Lt{"time_entries.start_time": to})
+		} else {
+			cond = builder.And(cond, builder.Lte{"time_entries.start_time": to})
+		}
+	}
+
+	if !from.IsZero() && !to.IsZero() && (from.After(to) || toIsDate && from.Equal(to)) {
+		return nil, &ErrInvalidTimeReportRange{From: opts.From, To: opts.To}
+	}
+
+	return cond, nil
+}
+
+func getListIDsForTimeReport(s *xorm.Session, a web.Auth, listID int64) (listIDs []int64, err error) {
+	if listID != 0 {
+		l := &List{ID: listID}
+		canRead, _, err := l.CanRead(s, a)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			return nil, ErrGenericForbidden{}
+		}
+		return []int64{listID}, nil
+	}
+
+	if share, is := a.(*LinkSharing); is {
+		return []int64{share.ListID}, nil
+	}
+
+	lists, _, _, err := getRawListsForUser(s, &listOptions{
+		user:       &user.User{ID: a.GetID()},
+		page:       -1,
+		isArchived: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listIDs = make([]int64, 0, len(lists))
+	for _, l := range lists {
+		listIDs = append(listIDs, l.ID)
+	}
+	return
+}
+
+// GetTimeReport aggregates the time tracked on all tasks the user has access to.
+// Running timers are not included.
+func GetTimeReport(s *xorm.Session, a web.Auth, opts *TimeReportOptions) (report *TimeReport, err error) {
+	cond, err := opts.getDateCond()
+	if err != nil {
+		return nil, err
+	}
+
+	listIDs, err := getListIDsForTimeReport(s, a, opts.ListID)
+	if err != nil {
+		return nil, err
+	}
+
+	report = &TimeReport{
+		Lists:  []*TimeReportItem{},
+		Labels: []*TimeReportItem{},
+		Users:  []*TimeReportItem{},
+	}
+	if len(listIDs) == 0 {
+		return report, nil
+	}
+
+	cond = builder.And(cond, builder.In("tasks.list_id", listIDs), builder.IsNull{"tasks.deleted"})
+	if opts.UserID != 0 {
+		cond = builder.And(cond, builder.Eq{"time_entries.user_id": opts.UserID})
+	}
+	if opts.LabelID != 0 {
+		cond = builder.And(cond, builder.In("time_entries.task_id",
+			builder.Select("task_id").From("label_tasks").Where(builder.Eq{"label_id": opts.LabelID}),
+		))
+	}
+
+	entries := []*TimeEntry{}
+	err = s.
+		Join("INNER", "tasks", "tasks.id = time_entries.task_id").
+		Where(cond).
+		Find(&entries)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return report, nil
+	}
+
+	taskIDs := make([]int64, 0, len(entries))
+	userIDs := make([]int64, 0, len(entries))
+	for _, te := range entries {
+		taskIDs = append(taskIDs, te.TaskID)
+		userIDs = append(userIDs, te.UserID)
+	}
+
+	tasks := make(map[int64]*Task)
+	err = s.In("id", taskIDs).Find(&tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	taskListIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskListIDs = append(taskListIDs, t.ListID)
+	}
+	lists, err := GetListsByIDs(s, taskListIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	labelTasks := []*LabelTask{}
+	err = s.In("task_id", taskIDs).Find(&labelTasks)
+	if err != nil {
+		return nil, err
+	}
+	labelIDs := make([]int64, 0, len(labelTasks))
+	taskLabels := make(map[int64][]int64)
+	for _, lt := range labelTasks {
+		labelIDs = append(labelIDs, lt.LabelID)
+		taskLabels[lt.TaskID] = append(taskLabels[lt.TaskID], lt.LabelID)
+	}
+	labels := make(map[int64]*Label)
+	if len(labelIDs) > 0 {
+		err = s.In("id", labelIDs).Find(&labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	listItems := make(map[int64]*TimeReportItem)
+	labelItems := make(map[int64]*TimeReportItem)
+	userItems := make(map[int64]*TimeReportItem)
+	addToItem := func(items map[int64]*TimeReportItem, id int64, title string, duration int64) {
+		if _, exists := items[id]; !exists {
+			items[id] = &TimeReportItem{ID: id, Title: title}
+		}
+		items[id].Duration += duration
+		items[id].Entries++
+	}
+
+	for _, te := range entries {
+		report.TotalDuration += te.Duration
+
+		task := tasks[te.TaskID]
+		if l, exists := lists[task.ListID]; exists {
+			addToItem(listItems, l.ID, l.Title, te.Duration)
+		}
+		for _, labelID := range taskLabels[te.TaskID] {
+			if l, exists := labels[labelID]; exists {
+				addToItem(labelItems, l.ID, l.Title, te.Duration)
+			}
+		}
+		var userName string
+		if u, exists := users[te.UserID]; exists {
+			userName = u.GetName()
+		}
+		addToItem(userItems, te.UserID, userName, te.Duration)
+	}
+
+	report.Lists = sortTimeReportItems(listItems)
+	report.Labels = sortTimeReportItems(labelItems)
+	report.Users = sortTimeReportItems(userItems)
+	return report, nil
+}
+
+// sortTimeReportItems returns the items with the most tracked time first
+func sortTimeReportItems(items map[int64]*TimeReportItem) []*TimeReportItem {
+	sorted := make([]*TimeReportItem, 0, len(items))
+	for _, item := range items {
+		sorted = append(sorted, item)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Duration == sorted[j].Duration {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return sorted[i].Duration > sorted[j].Duration
+	})
+	return sorted
+}
+
+// WriteCSV writes the report as csv with one row per list, label and user, followed by the total.
+func (report *TimeReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	formatHours := func(seconds int64) string {
+		return strconv.FormatFloat(float64(seconds)/3600, 'f', 2, 64)
+	}
+
+	err := cw.Write([]string{"type", "id", "title", "entries", "duration_seconds", "duration_hours"})
+	if err != nil {
+		return err
+	}
+
+	groups := []struct {
+		kind  string
+		items []*TimeReportItem
+	}{
+		{kind: "list", items: report.Lists},
+		{kind: "label", items: report.Labels},
+		{kind: "user", items: report.Users},
+	}
+	for _, group := range groups {
+		for _, item := range group.items {
+			err = cw.Write([]string{
+				group.kind,
+				strconv.FormatInt(item.ID, 10),
+				item.Title,
+				strconv.FormatInt(item.Entries, 10),
+				strconv.FormatInt(item.Duration, 10),
+				formatHours(item.Duration),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = cw.Write([]string{"total", "", "", "", strconv.FormatInt(report.TotalDuration, 10), formatHours(report.TotalDuration)})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,567 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// The kinds of items which can end up in the trash
+const (
+	TrashItemKindTask      = "task"
+	TrashItemKindList      = "list"
+	TrashItemKindNamespace = "namespace"
+)
+
+// TrashItem is a task, list or namespace which was moved to the trash.
+type TrashItem struct {
+	// The kind of the item, either `task`, `list` or `namespace`.
+	Kind string `json:"kind"`
+	// The unique, numeric id of the item.
+	ID int64 `json:"id"`
+	// The title of the item.
+	Title string `json:"title"`
+	// The id of the list of a task or the namespace of a list. Always 0 for namespaces.
+	ParentID int64 `json:"parent_id"`
+	// When this item was moved to the trash.
+	Deleted time.Time `json:"deleted"`
+	// The user who moved the item to the trash.
+	DeletedBy *user.User `json:"deleted_by"`
+
+	deletedByID int64
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// moveToTrash soft-deletes an item and remembers who did it.
+func moveToTrash(s *xorm.Session, a web.Auth, id int64, bean interface{}) (err error) {
+	_, err = s.
+		Table(bean).
+		ID(id).
+		Update(map[string]interface{}{"deleted_by_id": getActorID(a)})
+	if err != nil {
+		return
+	}
+
+	_, err = s.ID(id).Delete(bean)
+	return
+}
+
+// restoreFromTrash clears the soft-delete flag of an item.
+func restoreFromTrash(s *xorm.Session, id int64, bean interface{}) (err error) {
+	_, err = s.
+		Unscoped().
+		Table(bean).
+		ID(id).
+		Update(map[string]interface{}{"deleted": nil, "deleted_by_id": 0})
+	return
+}
+
+// getTrashedListIDsQuery returns a query selecting the ids of all lists which are hidden because either they or
+// their namespace are in the trash.
+func getTrashedListIDsQuery() *builder.Builder {
+	return builder.
+		Select("id").
+		From("lists").
+		Where(builder.Or(
+			builder.NotNull{"deleted"},
+			builder.In("namespace_id", getTrashedNamespaceIDsQuery()),
+		))
+}
+
+func getTrashedNamespaceIDsQuery() *builder.Builder {
+	return builder.
+		Select("id").
+		From("namespaces").
+		Where(builder.NotNull{"deleted"})
+}
+
+func getTrashedTask(s *xorm.Session, taskID int64) (task *Task, err error) {
+	task = &Task{}
+	exists, err := s.
+		Unscoped().
+		Where("id = ? AND deleted IS NOT NULL", taskID).
+		Get(task)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTrashItemDoesNotExist{Kind: TrashItemKindTask, ID: taskID}
+	}
+	return
+}
+
+func getTrashedList(s *xorm.Session, listID int64) (list *List, err error) {
+	list = &List{}
+	exists, err := s.
+		Unscoped().
+		Where("id = ? AND deleted IS NOT NULL", listID).
+		Get(list)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTrashItemDoesNotExist{Kind: TrashItemKindList, ID: listID}
+	}
+	return
+}
+
+func getTrashedNamespace(s *xorm.Session, namespaceID int64) (namespace *Namespace, err error) {
+	namespace = &Namespace{}
+	exists, err := s.
+		Unscoped().
+		Where("id = ? AND deleted IS NOT NULL", namespaceID).
+		Get(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTrashItemDoesNotExist{Kind: TrashItemKindNamespace, ID: namespaceID}
+	}
+	return
+}
+
+func getTrashItemsForUser(s *xorm.Session, u *user.User) (items []*TrashItem, err error) {
+	items = []*TrashItem{}
+
+	namespaces := []*Namespace{}
+	err = s.
+		Unscoped().
+		Where(builder.And(
+			builder.NotNull{"deleted"},
+			builder.Or(
+				builder.Eq{"deleted_by_id": u.ID},
+				builder.Eq{"owner_id": u.ID},
+			),
+		)).
+		Find(&namespaces)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range namespaces {
+		items = append(items, &TrashItem{
+			Kind:        TrashItemKindNamespace,
+			ID:          n.ID,
+			Title:       n.Title,
+			Deleted:     n.Deleted,
+			deletedByID: n.DeletedByID,
+		})
+	}
+
+	// Lists in a namespace which is in the trash come back with the namespace, they are not shown on their own.
+	lists := []*List{}
+	err = s.
+		Unscoped().
+		Where(builder.And(
+			builder.NotNull{"deleted"},
+			builder.NotIn("namespace_id", getTrashedNamespaceIDsQuery()),
+			builder.Or(
+				builder.Eq{"deleted_by_id": u.ID},
+				builder.Eq{"owner_id": u.ID},
+				builder.In("namespace_id", builder.Select("id").From("namespaces").Where(builder.Eq{"owner_id": u.ID})),
+			),
+		)).
+		Find(&lists)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		items = append(items, &TrashItem{
+			Kind:        TrashItemKindList,
+			ID:          l.ID,
+			Title:       l.Title,
+			ParentID:    l.NamespaceID,
+			Deleted:     l.Deleted,
+			deletedByID: l.DeletedByID,
+		})
+	}
+
+	// The same goes for tasks, only those in lists which are not in the trash are shown.
+	userLists, _, _, err := getRawListsForUser(s, &listOptions{user: u, page: -1, isArchived: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(userLists) == 0 {
+		return items, nil
+	}
+
+	listIDs := make([]int64, 0, len(userLists))
+	for _, l := range userLists {
+		listIDs = append(listIDs, l.ID)
+	}
+
+	tasks := []*Task{}
+	err = s.
+		Unscoped().
+		Where(builder.NotNull{"deleted"}).
+		In("list_id", listIDs).
+		Find(&tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	canWriteList := make(map[int64]bool)
+	for _, t := range tasks {
+		if t.DeletedByID != u.ID {
+			canWrite, has := canWriteList[t.ListID]
+			if !has {
+				canWrite, err = (&List{ID: t.ListID}).CanWrite(s, u)
+				if err != nil && !IsErrListIsArchived(err) && !IsErrNamespaceIsArchived(err) {
+					return nil, err
+				}
+				canWriteList[t.ListID] = canWrite
+			}
+			if !canWrite {
+				continue
+			}
+		}
+
+		items = append(items, &TrashItem{
+			Kind:        TrashItemKindTask,
+			ID:          t.ID,
+			Title:       t.Title,
+			ParentID:    t.ListID,
+			Deleted:     t.Deleted,
+			deletedByID: t.DeletedByID,
+		})
+	}
+
+	return items, nil
+}
+
+// ReadAll returns everything the user moved to the trash or is allowed to restore
+// @Summary Get the trash
+// @Description Returns all tasks, lists and namespaces in the trash which the current user has deleted or is allowed to restore. Newest first. Lists and tasks which are in the trash only because their namespace or list is are not included, they are restored together with it.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search items by title."
+// @Success 200 {array} models.TrashItem "The items in the trash."
+// @Failure 403 {object} web.HTTPError "Link shares don't have a trash."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /trash [get]
+func (ti *TrashItem) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	all, err := getTrashItemsForUser(s, &user.User{ID: a.GetID()})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	items := make([]*TrashItem, 0, len(all))
+	search = strings.ToLower(search)
+	for _, item := range all {
+		if search != "" && !strings.Contains(strings.ToLower(item.Title), search) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Deleted.Equal(items[j].Deleted) {
+			return items[i].Deleted.After(items[j].Deleted)
+		}
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].ID < items[j].ID
+	})
+
+	numberOfTotalItems = int64(len(items))
+	limit, start := getLimitFromPageIndex(page, perPage)
+	if limit > 0 {
+		if start > len(items) {
+			start = len(items)
+		}
+		end := start + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		items = items[start:end]
+	}
+
+	userIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		userIDs = append(userIDs, item.deletedByID)
+	}
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	for _, item := range items {
+		item.DeletedBy = users[item.deletedByID]
+	}
+
+	return items, len(items), numberOfTotalItems, nil
+}
+
+// TaskRestore restores a task from the trash
+type TaskRestore struct {
+	TaskID int64 `json:"-" param:"task"`
+
+	// The restored task.
+	Task *Task `json:"task"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// Create restores a task from the trash
+// @Summary Restore a task from the trash
+// @Description Restores a task with all of its labels, assignees, relations, attachments and comments. If the kanban bucket the task was in does not exist anymore, it is put into the default bucket of its list.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param task path int true "Task ID"
+// @Success 201 {object} models.TaskRestore "The restored task."
+// @Failure 403 {object} web.HTTPError "The user did not delete the task and does not have write access to its list."
+// @Failure 404 {object} web.HTTPError "The task is not in the trash."
+// @Failure 412 {object} web.HTTPError "The list of the task is in the trash as well."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{task}/restore [put]
+func (tr *TaskRestore) Create(s *xorm.Session, a web.Auth) (err error) {
+	task, err := getTrashedTask(s, tr.TaskID)
+	if err != nil {
+		return err
+	}
+
+	err = restoreFromTrash(s, task.ID, &Task{})
+	if err != nil {
+		return err
+	}
+
+	_, err = getBucketByID(s, task.BucketID)
+	if IsErrBucketDoesNotExist(err) {
+		var bucket *Bucket
+		bucket, err = getDefaultBucket(s, task.ListID)
+		if err != nil {
+			return err
+		}
+		task.BucketID = bucket.ID
+		_, err = s.ID(task.ID).Cols("bucket_id").NoAutoTime().Update(task)
+	}
+	if err != nil {
+		return err
+	}
+
+	restored, err := GetTaskByIDSimple(s, task.ID)
+	if err != nil {
+		return err
+	}
+	tr.Task = &restored
+
+	return updateListLastUpdated(s, &List{ID: task.ListID})
+}
+
+// ListRestore restores a list from the trash
+type ListRestore struct {
+	ListID int64 `json:"-" param:"list"`
+
+	// The restored list.
+	List *List `json:"list"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// Create restores a list from the trash
+// @Summary Restore a list from the trash
+// @Description Restores a list with all of its tasks, buckets, shares and custom fields. Tasks which were moved to the trash on their own before the list stay there.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Success 201 {object} models.ListRestore "The restored list."
+// @Failure 400 {object} web.HTTPError "Another list has the same identifier."
+// @Failure 403 {object} web.HTTPError "The user did not delete the list and is neither its owner nor admin of its namespace."
+// @Failure 404 {object} web.HTTPError "The list is not in the trash."
+// @Failure 412 {object} web.HTTPError "The namespace of the list is in the trash as well."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/restore [put]
+func (lr *ListRestore) Create(s *xorm.Session, a web.Auth) (err error) {
+	list, err := getTrashedList(s, lr.ListID)
+	if err != nil {
+		return err
+	}
+
+	// Another list could have taken the identifier while this one was in the trash
+	if list.Identifier != "" {
+		exists, err := s.
+			Where("identifier = ?", list.Identifier).
+			And("id != ?", list.ID).
+			Exist(&List{})
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrListIdentifierIsNotUnique{Identifier: list.Identifier}
+		}
+	}
+
+	err = restoreFromTrash(s, list.ID, &List{})
+	if err != nil {
+		return err
+	}
+
+	lr.List, err = GetListSimpleByID(s, list.ID)
+	return err
+}
+
+// NamespaceRestore restores a namespace from the trash
+type NamespaceRestore struct {
+	NamespaceID int64 `json:"-" param:"namespace"`
+
+	// The restored namespace.
+	Namespace *Namespace `json:"namespace"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// Create restores a namespace from the trash
+// @Summary Restore a namespace from the trash
+// @Description Restores a namespace with all of its lists. Lists which were moved to the trash on their own before the namespace stay there.
+// @tags trash
+// @Produce json
+// @Security JWTKeyAuth
+// @Param namespace path int true "Namespace ID"
+// @Success 201 {object} models.NamespaceRestore "The restored namespace."
+// @Failure 403 {object} web.HTTPError "The user did not delete the namespace and is not its owner."
+// @Failure 404 {object} web.HTTPError "The namespace is not in the trash."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /namespaces/{namespace}/restore [put]
+func (nr *NamespaceRestore) Create(s *xorm.Session, a web.Auth) (err error) {
+	namespace, err := getTrashedNamespace(s, nr.NamespaceID)
+	if err != nil {
+		return err
+	}
+
+	err = restoreFromTrash(s, namespace.ID, &Namespace{})
+	if err != nil {
+		return err
+	}
+
+	nr.Namespace, err = GetNamespaceByID(s, namespace.ID)
+	return err
+}
+
+func purgeTrash(s *xorm.Session, olderThan time.Time) (purged int, err error) {
+	namespaces := []*Namespace{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND deleted < ?", olderThan).
+		Find(&namespaces)
+	if err != nil {
+		return
+	}
+	for _, n := range namespaces {
+		err = deleteNamespacePermanently(s, n, nil, true)
+		if err != nil {
+			return
+		}
+	}
+
+	lists := []*List{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND deleted < ?", olderThan).
+		Find(&lists)
+	if err != nil {
+		return
+	}
+	for _, l := range lists {
+		err = deleteListPermanently(s, l, nil)
+		if err != nil {
+			return
+		}
+	}
+
+	tasks := []*Task{}
+	err = s.
+		Unscoped().
+		Where("deleted IS NOT NULL AND deleted < ?", olderThan).
+		Find(&tasks)
+	if err != nil {
+		return
+	}
+	for _, t := range tasks {
+		err = deleteTaskPermanently(s, t, nil)
+		if err != nil {
+			return
+		}
+	}
+
+	return len(namespaces) + len(lists) + len(tasks), nil
+}
+
+// RegisterTrashPurgeCron registers a cron function which permanently removes everything that has been in the
+// trash for longer than the configured retention period.
+func RegisterTrashPurgeCron() {
+	const logPrefix = "[Trash Purge Cron] "
+
+	retentionDays := config.ServiceTrashRetentionDays.GetInt()
+	if retentionDays <= 0 {
+		log.Debugf(logPrefix + "Trash retention is disabled, not purging anything")
+		return
+	}
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		err := s.Begin()
+		if err != nil {
+			log.Errorf(logPrefix+"Could not start transaction: %s", err)
+			return
+		}
+
+		purged, err := purgeTrash(s, time.Now().AddDate(0, 0, -retentionDays))
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf(logPrefix+"Error purging the trash: %s", err)
+			return
+		}
+
+		if err := s.Commit(); err != nil {
+			_ = s.Rollback()
+			log.Errorf(logPrefix+"Error purging the trash: %s", err)
+			return
+		}
+
+		if purged > 0 {
+			log.Debugf(logPrefix+"Permanently deleted %d items from the trash", purged)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register trash purge cron: %s", err)
+	}
+}
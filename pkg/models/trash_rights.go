@@ -0,0 +1,96 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can restore a task from the trash.
+// This is the user who deleted it or everyone with write access to its list.
+func (tr *TaskRestore) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	task, err := getTrashedTask(s, tr.TaskID)
+	if err != nil {
+		return false, err
+	}
+
+	list, err := GetListSimpleByID(s, task.ListID)
+	if IsErrListDoesNotExist(err) {
+		return false, &ErrTrashItemParentIsDeleted{
+			Kind:       TrashItemKindTask,
+			ID:         task.ID,
+			ParentKind: TrashItemKindList,
+			ParentID:   task.ListID,
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if task.DeletedByID == getActorID(a) {
+		return true, list.CheckIsArchived(s)
+	}
+
+	return list.CanWrite(s, a)
+}
+
+// CanCreate checks if a user can restore a list from the trash.
+// This is the user who deleted it, its owner or an admin of its namespace.
+func (lr *ListRestore) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	list, err := getTrashedList(s, lr.ListID)
+	if err != nil {
+		return false, err
+	}
+
+	namespace, err := GetNamespaceByID(s, list.NamespaceID)
+	if IsErrNamespaceDoesNotExist(err) {
+		return false, &ErrTrashItemParentIsDeleted{
+			Kind:       TrashItemKindList,
+			ID:         list.ID,
+			ParentKind: TrashItemKindNamespace,
+			ParentID:   list.NamespaceID,
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if list.DeletedByID == a.GetID() || list.OwnerID == a.GetID() {
+		return true, nil
+	}
+
+	return namespace.IsAdmin(s, a)
+}
+
+// CanCreate checks if a user can restore a namespace from the trash.
+// This is the user who deleted it or its owner.
+func (nr *NamespaceRestore) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	namespace, err := getTrashedNamespace(s, nr.NamespaceID)
+	if err != nil {
+		return false, err
+	}
+
+	return namespace.DeletedByID == a.GetID() || namespace.OwnerID == a.GetID(), nil
+}
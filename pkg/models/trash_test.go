@@ -0,0 +1,369 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/xorm"
+)
+
+func setTrashDate(t *testing.T, s *xorm.Session, bean interface{}, id int64, deleted time.Time) {
+	_, err := s.Unscoped().Table(bean).ID(id).Update(map[string]interface{}{"deleted": deleted})
+	assert.NoError(t, err)
+}
+
+func TestTrashItem_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+		setTrashDate(t, s, &Task{}, 1, time.Now().Add(-time.Hour))
+
+		ti := &TrashItem{}
+		res, count, total, err := ti.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(2), total)
+		items := res.([]*TrashItem)
+		// Newest first
+		assert.Equal(t, TrashItemKindList, items[0].Kind)
+		assert.Equal(t, int64(2), items[0].ID)
+		assert.Equal(t, int64(1), items[0].ParentID)
+		assert.Equal(t, int64(1), items[0].DeletedBy.ID)
+		assert.Equal(t, TrashItemKindTask, items[1].Kind)
+		assert.Equal(t, int64(1), items[1].ID)
+		assert.Equal(t, "task #1", items[1].Title)
+	})
+	t.Run("owner of a list deleted by someone else", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{}
+		res, _, _, err := ti.ReadAll(s, &user.User{ID: 3}, "", 0, 50)
+		assert.NoError(t, err)
+		items := res.([]*TrashItem)
+		assert.Len(t, items, 1)
+		assert.Equal(t, int64(2), items[0].ID)
+	})
+	t.Run("lists of a deleted namespace are not shown", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&Namespace{ID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{}
+		res, _, _, err := ti.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		items := res.([]*TrashItem)
+		assert.Len(t, items, 1)
+		assert.Equal(t, TrashItemKindNamespace, items[0].Kind)
+		assert.Equal(t, int64(1), items[0].ID)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{}
+		res, _, _, err := ti.ReadAll(s, &user.User{ID: 2}, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+	})
+	t.Run("search", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+
+		ti := &TrashItem{}
+		res, _, _, err := ti.ReadAll(s, u, "TASK", 0, 50)
+		assert.NoError(t, err)
+		items := res.([]*TrashItem)
+		assert.Len(t, items, 1)
+		assert.Equal(t, int64(1), items[0].ID)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		ti := &TrashItem{}
+		_, _, _, err := ti.ReadAll(s, &LinkSharing{ID: 1, ListID: 1}, "", 0, 50)
+		assert.Error(t, err)
+	})
+}
+
+func TestTaskRestore(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		tr := &TaskRestore{TaskID: 1}
+		can, err := tr.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = tr.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), tr.Task.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":            1,
+			"deleted_by_id": 0,
+			"bucket_id":     1,
+		}, false)
+		db.AssertExists(t, "label_tasks", map[string]interface{}{
+			"task_id":  1,
+			"label_id": 4,
+		}, false)
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+		}, false)
+		_, err = GetTaskByIDSimple(s, 1)
+		assert.NoError(t, err)
+	})
+	t.Run("bucket does not exist anymore", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		_, err = s.Where("id = ?", 1).Delete(&Bucket{})
+		assert.NoError(t, err)
+
+		tr := &TaskRestore{TaskID: 1}
+		err = tr.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), tr.Task.BucketID)
+	})
+	t.Run("not in the trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tr := &TaskRestore{TaskID: 1}
+		_, err := tr.CanCreate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTrashItemDoesNotExist(err))
+	})
+	t.Run("list is in the trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&List{ID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		tr := &TaskRestore{TaskID: 1}
+		_, err = tr.CanCreate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTrashItemParentIsDeleted(err))
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		tr := &TaskRestore{TaskID: 1}
+		can, err := tr.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestListRestore(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+
+		// The owner of the list can restore it as well
+		lr := &ListRestore{ListID: 2}
+		can, err := lr.CanCreate(s, &user.User{ID: 3})
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = lr.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), lr.List.ID)
+
+		_, err = GetListSimpleByID(s, 2)
+		assert.NoError(t, err)
+	})
+	t.Run("namespace is in the trash", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&Namespace{ID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		lr := &ListRestore{ListID: 2}
+		_, err = lr.CanCreate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTrashItemParentIsDeleted(err))
+	})
+	t.Run("identifier was taken", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&List{ID: 2}).Delete(s, u)
+		assert.NoError(t, err)
+		err = (&List{Title: "new list", Identifier: "test2", NamespaceID: 1}).Create(s, u)
+		assert.NoError(t, err)
+
+		lr := &ListRestore{ListID: 2}
+		err = lr.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrListIdentifierIsNotUnique(err))
+
+		_, err = getTrashedList(s, 2)
+		assert.NoError(t, err)
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&List{ID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		lr := &ListRestore{ListID: 1}
+		can, err := lr.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestNamespaceRestore(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Namespace{ID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+		_, err = GetListSimpleByID(s, 1)
+		assert.True(t, IsErrListDoesNotExist(err))
+
+		nr := &NamespaceRestore{NamespaceID: 1}
+		can, err := nr.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+		err = nr.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), nr.Namespace.ID)
+
+		// The lists come back with the namespace
+		_, err = GetListSimpleByID(s, 1)
+		assert.NoError(t, err)
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := (&Namespace{ID: 1}).Delete(s, u)
+		assert.NoError(t, err)
+
+		nr := &NamespaceRestore{NamespaceID: 1}
+		can, err := nr.CanCreate(s, &user.User{ID: 2})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestPurgeTrash(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+	u := &user.User{ID: 1}
+
+	err := (&Task{ID: 1, ListID: 1}).Delete(s, u)
+	assert.NoError(t, err)
+	err = (&Task{ID: 2, ListID: 1}).Delete(s, u)
+	assert.NoError(t, err)
+	err = (&List{ID: 2}).Delete(s, u)
+	assert.NoError(t, err)
+	_, err = s.Insert(&Webhook{TargetURL: "https://example.com/trashed", Events: []string{"task.created"}, ListID: 2, CreatedByID: 1})
+	assert.NoError(t, err)
+	setTrashDate(t, s, &Task{}, 1, time.Now().AddDate(0, 0, -40))
+	setTrashDate(t, s, &List{}, 2, time.Now().AddDate(0, 0, -40))
+
+	purged, err := purgeTrash(s, time.Now().AddDate(0, 0, -30))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "tasks", map[string]interface{}{"id": 1})
+	db.AssertMissing(t, "task_comments", map[string]interface{}{"task_id": 1})
+	db.AssertMissing(t, "lists", map[string]interface{}{"id": 2})
+	db.AssertMissing(t, "tasks", map[string]interface{}{"list_id": 2})
+	db.AssertMissing(t, "webhooks", map[string]interface{}{"list_id": 2})
+	// Deleted recently enough to stay in the trash
+	db.AssertExists(t, "tasks", map[string]interface{}{"id": 2}, false)
+}
@@ -51,6 +51,7 @@ func SetupTests() {
 		"namespaces",
 		"task_assignees",
 		"task_attachments",
+		"task_attachment_previews",
 		"task_comments",
 		"task_relations",
 		"task_reminders",
@@ -67,6 +68,19 @@ func SetupTests() {
 		"saved_filters",
 		"subscriptions",
 		"favorites",
+		"webhooks",
+		"webhook_deliveries",
+		"api_tokens",
+		"realtime_events",
+		"task_activities",
+		"task_revisions",
+		"time_entries",
+		"custom_fields",
+		"task_custom_field_values",
+		"task_templates",
+		"task_checklist_items",
+		"task_identifier_redirects",
+		"task_completions",
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -182,19 +182,64 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 
 	// Delete everything not shared with anybody else
 	for _, n := range namespacesToDelete {
-		err = deleteNamespace(s, n, u, false)
+		err = deleteNamespacePermanently(s, n, u, false)
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, l := range listsToDelete {
-		err = l.Delete(s, u)
+		err = deleteListPermanently(s, l, u)
 		if err != nil {
 			return err
 		}
 	}
 
+	// Whatever the user owns and has already moved to the trash goes away with them
+	trashedNamespaces := []*Namespace{}
+	err = s.Unscoped().
+		Where("owner_id = ? AND deleted IS NOT NULL", u.ID).
+		Find(&trashedNamespaces)
+	if err != nil {
+		return err
+	}
+	for _, n := range trashedNamespaces {
+		err = deleteNamespacePermanently(s, n, u, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	trashedLists := []*List{}
+	err = s.Unscoped().
+		Where("owner_id = ? AND deleted IS NOT NULL", u.ID).
+		Find(&trashedLists)
+	if err != nil {
+		return err
+	}
+	for _, l := range trashedLists {
+		err = deleteListPermanently(s, l, u)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&APIToken{})
+	if err != nil {
+		return err
+	}
+
+	// Templates of lists stay with the list, only the user's own templates are deleted
+	_, err = s.
+		Where(builder.And(
+			builder.Eq{"owner_id": u.ID},
+			builder.Or(builder.IsNull{"list_id"}, builder.Eq{"list_id": 0}),
+		)).
+		Delete(&TaskTemplate{})
+	if err != nil {
+		return err
+	}
+
 	_, err = s.Where("id = ?", u.ID).Delete(&user.User{})
 	if err != nil {
 		return err
@@ -30,6 +30,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user1",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -41,6 +42,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user2",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -52,6 +54,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user3",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -64,6 +67,7 @@ func TestListUsersFromList(t *testing.T) {
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Status:                       user.StatusEmailConfirmationRequired,
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -76,6 +80,7 @@ func TestListUsersFromList(t *testing.T) {
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Status:                       user.StatusEmailConfirmationRequired,
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -87,6 +92,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user6",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -98,6 +104,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user7",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		DiscoverableByEmail:          true,
 		OverdueTasksRemindersEnabled: true,
@@ -110,6 +117,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user8",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -121,6 +129,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user9",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -132,6 +141,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user10",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -144,6 +154,7 @@ func TestListUsersFromList(t *testing.T) {
 		Name:                         "Some one else",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -156,6 +167,7 @@ func TestListUsersFromList(t *testing.T) {
 		Name:                         "Name with spaces",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		DiscoverableByName:           true,
 		OverdueTasksRemindersEnabled: true,
@@ -168,6 +180,7 @@ func TestListUsersFromList(t *testing.T) {
 		Username:                     "user13",
 		Password:                     "$2a$14$dcadBoMBL9jQoOcZK8Fju.cy0Ptx2oZECkKLnaa8ekRoTFe1w7To.",
 		Issuer:                       "local",
+		StorageQuota:                 user.DefaultStorageQuota,
 		EmailRemindersEnabled:        true,
 		OverdueTasksRemindersEnabled: true,
 		OverdueTasksRemindersTime:    "09:00",
@@ -0,0 +1,714 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+	"code.vikunja.io/api/pkg/version"
+	"code.vikunja.io/web"
+
+	"github.com/ThreeDotsLabs/watermill/message"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// Webhook represents a webhook target which receives events of a list or namespace
+type Webhook struct {
+	// The unique, numeric id of this webhook.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"webhook"`
+	// The url events will be sent to via http POST.
+	TargetURL string `xorm:"text not null" json:"target_url" valid:"required,url"`
+	// The events this webhook subscribes to. See /webhooks/events for all available events.
+	Events []string `xorm:"JSON not null" json:"events"`
+	// The secret used to sign all requests with HMAC-SHA256. If none is provided, a random one will be generated.
+	// It is only returned once, when creating the webhook.
+	Secret string `xorm:"text null" json:"secret"`
+
+	// The list this webhook belongs to. Either this or the namespace id is set.
+	ListID int64 `xorm:"bigint null index" json:"list_id" param:"list"`
+	// The namespace this webhook belongs to. Webhooks of a namespace receive events of all lists in that namespace.
+	NamespaceID int64 `xorm:"bigint null index" json:"namespace_id" param:"namespace"`
+
+	// The user who initially created the webhook.
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this webhook was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this webhook was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhooks
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDelivery holds a single attempt to deliver an event to a webhook target
+type WebhookDelivery struct {
+	// The unique, numeric id of this delivery.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The webhook this delivery was made for.
+	WebhookID int64 `xorm:"bigint not null index" json:"webhook_id" param:"webhook"`
+	// The id of the event message. All retries of the same event share the same message id.
+	MessageID string `xorm:"varchar(50) not null index" json:"message_id"`
+	// The name of the event which was delivered.
+	EventName string `xorm:"varchar(250) not null" json:"event_name"`
+	// The attempt number of this delivery, starting at 1.
+	Attempt int `xorm:"not null default 1" json:"attempt"`
+	// The full payload which was sent to the target.
+	Payload string `xorm:"longtext null" json:"payload"`
+	// The http status code the target responded with. 0 if the request failed before a response was received.
+	StatusCode int `xorm:"null" json:"status_code"`
+	// The (truncated) response body of the target or the error message if the request failed.
+	Response string `xorm:"text null" json:"response"`
+	// Whether the target accepted the delivery.
+	Success bool `xorm:"not null default false" json:"success"`
+
+	ListID      int64 `xorm:"-" json:"-" param:"list"`
+	NamespaceID int64 `xorm:"-" json:"-" param:"namespace"`
+
+	// A timestamp when this delivery was made.
+	Created time.Time `xorm:"created not null" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhook deliveries
+func (wd *WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+const (
+	webhookSecretLength          = 32
+	webhookResponseMaxLength     = 2048
+	webhookSignatureHeader       = "X-Vikunja-Signature"
+	webhookEventHeader           = "X-Vikunja-Event"
+	webhookDeliveryContentType   = "application/json"
+	webhookDeliveryUserAgentBase = "Vikunja/"
+)
+
+// webhookEvents holds all events a webhook can subscribe to
+var webhookEvents = []string{
+	(&TaskCreatedEvent{}).Name(),
+	(&TaskUpdatedEvent{}).Name(),
+	(&TaskDeletedEvent{}).Name(),
+	(&TaskAssigneeCreatedEvent{}).Name(),
+	(&TaskCommentCreatedEvent{}).Name(),
+	(&TaskCommentUpdatedEvent{}).Name(),
+	(&ListCreatedEvent{}).Name(),
+	(&ListUpdatedEvent{}).Name(),
+	(&ListDeletedEvent{}).Name(),
+	(&ListSharedWithUserEvent{}).Name(),
+	(&ListSharedWithTeamEvent{}).Name(),
+	(&NamespaceUpdatedEvent{}).Name(),
+	(&NamespaceDeletedEvent{}).Name(),
+	(&NamespaceSharedWithUserEvent{}).Name(),
+	(&NamespaceSharedWithTeamEvent{}).Name(),
+}
+
+// GetAvailableWebhookEvents returns all events webhooks can subscribe to
+func GetAvailableWebhookEvents() []string {
+	return webhookEvents
+}
+
+func isValidWebhookEvent(name string) bool {
+	for _, e := range webhookEvents {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Webhook) validateEvents() error {
+	if len(w.Events) == 0 {
+		return &ErrInvalidWebhookEvent{}
+	}
+
+	for _, e := range w.Events {
+		if !isValidWebhookEvent(e) {
+			return &ErrInvalidWebhookEvent{EventName: e}
+		}
+	}
+
+	return nil
+}
+
+func (w *Webhook) subscribesTo(eventName string) bool {
+	for _, e := range w.Events {
+		if e == eventName {
+			return true
+		}
+	}
+	return false
+}
+
+func getWebhookByID(s *xorm.Session, id int64) (webhook *Webhook, err error) {
+	webhook = &Webhook{}
+	exists, err := s.Where("id = ?", id).Get(webhook)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrWebhookDoesNotExist{WebhookID: id}
+	}
+	return
+}
+
+// Create creates a new webhook
+// @Summary Create a webhook for a list
+// @Description Creates a new webhook target which will receive all subscribed events of the list. The user needs to have admin rights on the list.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook body models.Webhook true "The webhook object"
+// @Success 201 {object} models.Webhook "The created webhook object, including its secret."
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin rights on the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks [put]
+func (w *Webhook) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := w.validateEvents(); err != nil {
+		return err
+	}
+
+	if w.Secret == "" {
+		w.Secret = utils.MakeRandomString(webhookSecretLength)
+	}
+
+	// A webhook belongs either to a list or a namespace, never both
+	if w.ListID != 0 {
+		w.NamespaceID = 0
+	}
+
+	w.ID = 0
+	w.CreatedBy, err = user.GetFromAuth(a)
+	if err != nil {
+		return err
+	}
+	w.CreatedByID = w.CreatedBy.ID
+
+	_, err = s.Insert(w)
+	return
+}
+
+// ReadAll returns all webhooks of a list or namespace
+// @Summary Get all webhooks of a list
+// @Description Returns all webhooks of a list. The secrets of the webhooks are not returned.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Param list path int true "List ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.Webhook "The webhooks"
+// @Failure 403 {object} web.HTTPError "The user does not have admin rights on the list."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks [get]
+func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	can, err := w.canDoWebhook(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	cond := w.getParentCond()
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	query := s.Where(cond).OrderBy("id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	webhooks := []*Webhook{}
+	err = query.Find(&webhooks)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	userIDs := make([]int64, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		userIDs = append(userIDs, webhook.CreatedByID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+		webhook.CreatedBy = users[webhook.CreatedByID]
+	}
+
+	totalItems, err = s.Where(cond).Count(&Webhook{})
+	return webhooks, len(webhooks), totalItems, err
+}
+
+// Update updates the target url and subscribed events of a webhook
+// @Summary Update a webhook of a list
+// @Description Changes the target url and the subscribed events of a webhook. The secret cannot be changed.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook path int true "Webhook ID"
+// @Param webhook body models.Webhook true "The webhook object"
+// @Success 200 {object} models.Webhook "The updated webhook object."
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin rights on the list."
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks/{webhook} [post]
+func (w *Webhook) Update(s *xorm.Session, _ web.Auth) (err error) {
+	if err := w.validateEvents(); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", w.ID).
+		Cols("target_url", "events").
+		Update(w)
+	if err != nil {
+		return err
+	}
+
+	updated, err := getWebhookByID(s, w.ID)
+	if err != nil {
+		return err
+	}
+	*w = *updated
+	w.Secret = ""
+	return
+}
+
+// Delete removes a webhook and all of its deliveries
+// @Summary Delete a webhook of a list
+// @Description Deletes a webhook. Events which are currently being delivered will not be sent anymore.
+// @tags webhooks
+// @Produce json
+// @Security JWTKeyAuth
+// @Param list path int true "List ID"
+// @Param webhook path int true "Webhook ID"
+// @Success 200 {object} models.Message "The webhook was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have admin rights on the list."
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks/{webhook} [delete]
+func (w *Webhook) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("webhook_id = ?", w.ID).Delete(&WebhookDelivery{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ?", w.ID).Delete(&Webhook{})
+	return
+}
+
+// deleteWebhooksOf removes all webhooks of a list or namespace together with their delivery log.
+func deleteWebhooksOf(s *xorm.Session, parentCond builder.Cond) (err error) {
+	webhookIDs := []int64{}
+	err = s.Table("webhooks").Where(parentCond).Cols("id").Find(&webhookIDs)
+	if err != nil || len(webhookIDs) == 0 {
+		return
+	}
+
+	_, err = s.In("webhook_id", webhookIDs).Delete(&WebhookDelivery{})
+	if err != nil {
+		return
+	}
+
+	_, err = s.In("id", webhookIDs).Delete(&Webhook{})
+	return
+}
+
+func (w *Webhook) getParentCond() builder.Cond {
+	if w.ListID != 0 {
+		return builder.Eq{"list_id": w.ListID}
+	}
+	return builder.Eq{"namespace_id": w.NamespaceID}
+}
+
+// ReadAll returns all deliveries of a webhook
+// @Summary Get the delivery log of a webhook
+// @Description Returns all delivery attempts of a webhook, newest first, including the payload and the response status code.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Param list path int true "List ID"
+// @Param webhook path int true "Webhook ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Security JWTKeyAuth
+// @Success 200 {array} models.WebhookDelivery "The deliveries"
+// @Failure 403 {object} web.HTTPError "The user does not have admin rights on the list."
+// @Failure 404 {object} web.HTTPError "The webhook does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /lists/{list}/webhooks/{webhook}/deliveries [get]
+func (wd *WebhookDelivery) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
+	w := &Webhook{
+		ID:          wd.WebhookID,
+		ListID:      wd.ListID,
+		NamespaceID: wd.NamespaceID,
+	}
+	can, err := w.CanUpdate(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	query := s.
+		Where("webhook_id = ?", wd.WebhookID).
+		OrderBy("id desc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+
+	deliveries := []*WebhookDelivery{}
+	err = query.Find(&deliveries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	totalItems, err = s.
+		Where("webhook_id = ?", wd.WebhookID).
+		Count(&WebhookDelivery{})
+	return deliveries, len(deliveries), totalItems, err
+}
+
+// webhookPayload is what gets sent to the webhook target
+type webhookPayload struct {
+	EventName string          `json:"event_name"`
+	Time      time.Time       `json:"time"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// signWebhookPayload returns the hex encoded HMAC-SHA256 signature of a payload
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// eventEntities contains all entities of an event we need to figure out which lists or namespaces it belongs to.
+type eventEntities struct {
+	Task      *Task      `json:"Task"`
+	List      *List      `json:"List"`
+	Namespace *Namespace `json:"Namespace"`
+}
+
+// resolveIDs returns the ids of the task, list and namespace an event belongs to.
+// If the event only contains a task or list, the namespace is looked up.
+func (e *eventEntities) resolveIDs(s *xorm.Session) (taskID, listID, namespaceID int64, err error) {
+	if e.Task != nil {
+		taskID = e.Task.ID
+		listID = e.Task.ListID
+	}
+	if e.List != nil {
+		listID = e.List.ID
+		namespaceID = e.List.NamespaceID
+	}
+	if e.Namespace != nil {
+		namespaceID = e.Namespace.ID
+	}
+
+	if listID != 0 && namespaceID == 0 {
+		l, err := GetListSimpleByID(s, listID)
+		if err != nil && !IsErrListDoesNotExist(err) {
+			return 0, 0, 0, err
+		}
+		if l != nil {
+			namespaceID = l.NamespaceID
+		}
+	}
+
+	return
+}
+
+func getWebhooksForEvent(s *xorm.Session, eventName string, payload []byte) (webhooks []*Webhook, err error) {
+	entities := &eventEntities{}
+	err = json.Unmarshal(payload, entities)
+	if err != nil {
+		return nil, err
+	}
+
+	_, listID, namespaceID, err := entities.resolveIDs(s)
+	if err != nil {
+		return nil, err
+	}
+
+	conds := []builder.Cond{}
+	if listID > 0 {
+		conds = append(conds, builder.Eq{"list_id": listID})
+	}
+	if namespaceID > 0 {
+		conds = append(conds, builder.Eq{"namespace_id": namespaceID})
+	}
+	if len(conds) == 0 {
+		return
+	}
+
+	all := []*Webhook{}
+	err = s.Where(builder.Or(conds...)).Find(&all)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range all {
+		if w.subscribesTo(eventName) {
+			webhooks = append(webhooks, w)
+		}
+	}
+
+	return
+}
+
+// WebhookListener dispatches a delivery for every webhook subscribed to the event it listens on
+type WebhookListener struct {
+	EventName string
+}
+
+// Name defines the name for the WebhookListener listener
+func (wl *WebhookListener) Name() string {
+	return "webhook.listener"
+}
+
+// Handle is executed when the event WebhookListener listens on is fired
+func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	webhooks, err := getWebhooksForEvent(s, wl.EventName, msg.Payload)
+	if err != nil {
+		return err
+	}
+
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	log.Debugf("Dispatching %d webhook deliveries for event %s", len(webhooks), wl.EventName)
+
+	// Webhooks send the payload to external urls, those should never see the email addresses of users
+	payload, err := removeEmailsFromEventPayload(msg.Payload)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range webhooks {
+		err = events.Dispatch(&WebhookDeliveryRequestedEvent{
+			WebhookID: w.ID,
+			EventName: wl.EventName,
+			Payload:   json.RawMessage(payload),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SendWebhookDelivery represents a listener
+type SendWebhookDelivery struct {
+}
+
+// Name defines the name for the SendWebhookDelivery listener
+func (sd *SendWebhookDelivery) Name() string {
+	return "webhook.delivery.send"
+}
+
+// Handle is executed when the event SendWebhookDelivery listens on is fired.
+// If the delivery fails, an error is returned which will make the event router retry it with exponential backoff.
+func (sd *SendWebhookDelivery) Handle(msg *message.Message) (err error) {
+	event := &WebhookDeliveryRequestedEvent{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	w, err := getWebhookByID(s, event.WebhookID)
+	if err != nil {
+		if IsErrWebhookDoesNotExist(err) {
+			// The webhook was deleted in the meantime, nothing left to do
+			return nil
+		}
+		return err
+	}
+
+	body, err := json.Marshal(&webhookPayload{
+		EventName: event.EventName,
+		Time:      time.Now(),
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	previousAttempts, err := s.
+		Where("webhook_id = ? AND message_id = ?", w.ID, msg.UUID).
+		Count(&WebhookDelivery{})
+	if err != nil {
+		return err
+	}
+
+	delivery := &WebhookDelivery{
+		WebhookID: w.ID,
+		MessageID: msg.UUID,
+		EventName: event.EventName,
+		Attempt:   int(previousAttempts) + 1,
+		Payload:   string(body),
+	}
+
+	deliveryErr := sendWebhookRequest(w, event.EventName, body, delivery)
+
+	_, err = s.Insert(delivery)
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+	err = s.Commit()
+	if err != nil {
+		return err
+	}
+
+	if deliveryErr != nil {
+		log.Debugf("Delivery of event %s to webhook %d failed (attempt %d): %s", event.EventName, w.ID, delivery.Attempt, deliveryErr)
+	}
+
+	return deliveryErr
+}
+
+// webhookHTTPClient returns the client used to send webhooks. Unless internal targets are allowed, it refuses to
+// connect to addresses which are not reachable from the internet. The check happens when connecting, after the target
+// was resolved, so a host name pointing to such an address is refused as well, even if it changes in the meantime.
+func webhookHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	if !config.WebhooksAllowInternalTargets.GetBool() {
+		dialer.Control = checkWebhookTargetAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+func checkWebhookTargetAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return &ErrWebhookTargetNotAllowed{Address: host}
+	}
+	return nil
+}
+
+// isPublicIP checks if an ip address is reachable from the internet
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return false
+	}
+
+	// Shared address space for carrier-grade NAT, 100.64.0.0/10
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64 {
+		return false
+	}
+
+	return true
+}
+
+func sendWebhookRequest(w *Webhook, eventName string, body []byte, delivery *WebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.WebhooksTimeoutSeconds.GetInt())*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Response = err.Error()
+		return err
+	}
+
+	req.Header.Set("Content-Type", webhookDeliveryContentType)
+	req.Header.Set("User-Agent", webhookDeliveryUserAgentBase+version.Version)
+	req.Header.Set(webhookEventHeader, eventName)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(w.Secret, body))
+
+	hc := webhookHTTPClient()
+	defer hc.CloseIdleConnections()
+	resp, err := hc.Do(req)
+	if err != nil {
+		delivery.Response = err.Error()
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxLength))
+	delivery.StatusCode = resp.StatusCode
+	delivery.Response = string(respBody)
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	if !delivery.Success {
+		return &ErrWebhookDeliveryFailed{WebhookID: w.ID, StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
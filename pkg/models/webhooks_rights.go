@@ -0,0 +1,75 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can create a webhook for a list or namespace
+func (w *Webhook) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+// CanUpdate checks if a user can update a webhook
+func (w *Webhook) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoExistingWebhook(s, a)
+}
+
+// CanDelete checks if a user can delete a webhook
+func (w *Webhook) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoExistingWebhook(s, a)
+}
+
+func (w *Webhook) canDoExistingWebhook(s *xorm.Session, a web.Auth) (bool, error) {
+	original, err := getWebhookByID(s, w.ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Make sure the webhook belongs to the list or namespace from the route
+	if w.ListID != 0 && original.ListID != w.ListID {
+		return false, nil
+	}
+	if w.NamespaceID != 0 && original.NamespaceID != w.NamespaceID {
+		return false, nil
+	}
+
+	w.ListID = original.ListID
+	w.NamespaceID = original.NamespaceID
+	return w.canDoWebhook(s, a)
+}
+
+// Only admins of a list or namespace can manage its webhooks since they expose all data of it.
+func (w *Webhook) canDoWebhook(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	if w.ListID != 0 {
+		l := &List{ID: w.ListID}
+		return l.IsAdmin(s, a)
+	}
+
+	if w.NamespaceID != 0 {
+		n := &Namespace{ID: w.NamespaceID}
+		return n.IsAdmin(s, a)
+	}
+
+	return false, nil
+}
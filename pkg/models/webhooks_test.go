@@ -0,0 +1,379 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/ThreeDotsLabs/watermill"
+	"github.com/ThreeDotsLabs/watermill/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/new",
+			Events:    []string{"task.created"},
+			ListID:    1,
+		}
+		can, err := w.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		err = w.Create(s, u)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, w.Secret)
+		assert.Equal(t, int64(1), w.CreatedBy.ID)
+
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":            w.ID,
+			"target_url":    "https://example.com/new",
+			"list_id":       1,
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("namespace", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL:   "https://example.com/new",
+			Events:      []string{"list.updated"},
+			NamespaceID: 1,
+		}
+		can, err := w.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		err = w.Create(s, u)
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":           w.ID,
+			"namespace_id": 1,
+		}, false)
+	})
+	t.Run("invalid event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/new",
+			Events:    []string{"task.created", "something.invalid"},
+			ListID:    1,
+		}
+		err := w.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebhookEvent(err))
+	})
+	t.Run("no events", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			TargetURL: "https://example.com/new",
+			ListID:    1,
+		}
+		err := w.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebhookEvent(err))
+	})
+	t.Run("forbidden without admin rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ListID: 5}
+		can, err := w.CanCreate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("forbidden for link shares", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ListID: 1}
+		can, err := w.CanCreate(s, &LinkSharing{ID: 1, ListID: 1, Right: RightAdmin})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestWebhook_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ListID: 1}
+		result, count, total, err := w.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(1), total)
+		webhooks := result.([]*Webhook)
+		assert.Equal(t, int64(1), webhooks[0].ID)
+		assert.Empty(t, webhooks[0].Secret)
+		assert.Equal(t, int64(1), webhooks[0].CreatedBy.ID)
+	})
+	t.Run("forbidden", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ListID: 5}
+		_, _, _, err := w.ReadAll(s, u, "", 0, 50)
+		assert.Error(t, err)
+	})
+}
+
+func TestWebhook_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{
+			ID:        1,
+			ListID:    1,
+			TargetURL: "https://example.com/changed",
+			Events:    []string{"task.deleted"},
+			Secret:    "should not change",
+		}
+		can, err := w.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+
+		err = w.Update(s, u)
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":         1,
+			"target_url": "https://example.com/changed",
+			"secret":     "webhooksecret1",
+		}, false)
+	})
+	t.Run("webhook of another list", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ID: 3, ListID: 1}
+		can, err := w.CanUpdate(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		w := &Webhook{ID: 9999, ListID: 1}
+		_, err := w.CanUpdate(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+}
+
+func TestWebhook_Delete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	w := &Webhook{ID: 1, ListID: 1}
+	can, err := w.CanDelete(s, u)
+	assert.NoError(t, err)
+	assert.True(t, can)
+
+	err = w.Delete(s, u)
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "webhooks", map[string]interface{}{"id": 1})
+	db.AssertMissing(t, "webhook_deliveries", map[string]interface{}{"webhook_id": 1})
+}
+
+func TestWebhookDelivery_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	wd := &WebhookDelivery{WebhookID: 1, ListID: 1}
+	result, count, _, err := wd.ReadAll(s, u, "", 0, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	deliveries := result.([]*WebhookDelivery)
+	assert.Equal(t, 200, deliveries[0].StatusCode)
+}
+
+func TestWebhookListener(t *testing.T) {
+	t.Run("dispatches deliveries for subscribed webhooks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		events.TestListener(t, &TaskCreatedEvent{Task: &Task{ID: 1, ListID: 1}}, &WebhookListener{EventName: "task.created"})
+		events.AssertDispatched(t, &WebhookDeliveryRequestedEvent{})
+	})
+	t.Run("does not include email addresses", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		events.Fake()
+
+		doer := &user.User{ID: 1, Username: "user1", Email: "user1@example.com"}
+		events.TestListener(t, &TaskCreatedEvent{Task: &Task{ID: 1, ListID: 1, CreatedBy: doer}, Doer: doer}, &WebhookListener{EventName: "task.created"})
+
+		dispatched := events.DispatchedTestEvents()
+		if assert.Len(t, dispatched, 1) {
+			delivery := dispatched[0].(*WebhookDeliveryRequestedEvent)
+			assert.Contains(t, string(delivery.Payload), `"username":"user1"`)
+			assert.NotContains(t, string(delivery.Payload), "user1@example.com")
+		}
+	})
+	t.Run("namespace webhook receives list events", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "list.updated", []byte(`{"List":{"id":1,"namespace_id":1}}`))
+		assert.NoError(t, err)
+		assert.Len(t, webhooks, 1)
+		assert.Equal(t, int64(2), webhooks[0].ID)
+	})
+	t.Run("unsubscribed event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		webhooks, err := getWebhooksForEvent(s, "task.deleted", []byte(`{"Task":{"id":1,"list_id":1}}`))
+		assert.NoError(t, err)
+		assert.Len(t, webhooks, 0)
+	})
+}
+
+func TestSendWebhookDelivery(t *testing.T) {
+	var receivedSignature string
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedSignature = r.Header.Get(webhookSignatureHeader)
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	setTarget := func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		_, err := s.Where("id = ?", 1).Cols("target_url").Update(&Webhook{TargetURL: server.URL})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		receivedSignature = ""
+		receivedBody = nil
+	}
+
+	t.Run("normal", func(t *testing.T) {
+		setTarget(t)
+		// The test server only listens on localhost
+		config.WebhooksAllowInternalTargets.Set(true)
+		defer config.WebhooksAllowInternalTargets.Set(false)
+
+		events.TestListener(t, &WebhookDeliveryRequestedEvent{
+			WebhookID: 1,
+			EventName: "task.created",
+			Payload:   []byte(`{"Task":{"id":1}}`),
+		}, &SendWebhookDelivery{})
+
+		assert.Equal(t, signWebhookPayload("webhooksecret1", receivedBody), receivedSignature)
+		assert.Contains(t, string(receivedBody), `"event_name":"task.created"`)
+		db.AssertExists(t, "webhook_deliveries", map[string]interface{}{
+			"webhook_id":  1,
+			"event_name":  "task.created",
+			"status_code": 200,
+			"attempt":     1,
+		}, false)
+	})
+	t.Run("internal target", func(t *testing.T) {
+		setTarget(t)
+
+		s := db.NewSession()
+		defer s.Close()
+		content, err := json.Marshal(&WebhookDeliveryRequestedEvent{
+			WebhookID: 1,
+			EventName: "task.created",
+			Payload:   []byte(`{"Task":{"id":1}}`),
+		})
+		assert.NoError(t, err)
+		err = (&SendWebhookDelivery{}).Handle(message.NewMessage(watermill.NewUUID(), content))
+		assert.Error(t, err)
+
+		assert.Nil(t, receivedBody)
+		delivery := &WebhookDelivery{}
+		has, err := s.Where("webhook_id = ?", 1).Desc("id").Get(delivery)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		assert.False(t, delivery.Success)
+		assert.Contains(t, delivery.Response, "Webhook target address is not allowed [Address: 127.0.0.1]")
+	})
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"1.1.1.1":             true,
+		"2606:4700::1111":     true,
+		"127.0.0.1":           false,
+		"::1":                 false,
+		"10.1.2.3":            false,
+		"172.16.0.1":          false,
+		"192.168.1.1":         false,
+		"169.254.169.254":     false,
+		"100.64.0.1":          false,
+		"0.0.0.0":             false,
+		"fe80::1":             false,
+		"fd00::1":             false,
+		"::ffff:127.0.0.1":    false,
+		"::ffff:192.168.1.10": false,
+	} {
+		assert.Equal(t, public, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
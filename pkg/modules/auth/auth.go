@@ -62,7 +62,13 @@ func NewUserJWTAuthtoken(u *user.User, long bool) (token string, err error) {
 	var exp = time.Now().Add(time.Second * ttl).Unix()
 
 	// Set claims
-	claims := t.Claims.(jwt.MapClaims)
+	setUserClaims(t.Claims.(jwt.MapClaims), u, exp, long)
+
+	// Generate encoded token and send it as response.
+	return t.SignedString([]byte(config.ServiceJWTSecret.GetString()))
+}
+
+func setUserClaims(claims jwt.MapClaims, u *user.User, exp int64, long bool) {
 	claims["type"] = AuthTypeUser
 	claims["id"] = u.ID
 	claims["username"] = u.Username
@@ -72,9 +78,20 @@ func NewUserJWTAuthtoken(u *user.User, long bool) (token string, err error) {
 	claims["emailRemindersEnabled"] = u.EmailRemindersEnabled
 	claims["isLocalUser"] = u.Issuer == user.IssuerLocal
 	claims["long"] = long
+}
 
-	// Generate encoded token and send it as response.
-	return t.SignedString([]byte(config.ServiceJWTSecret.GetString()))
+// NewUserJWTFromAPIToken creates an unsigned jwt token for the owner of an api token.
+// It is put into the request context after the api token was validated so all handlers can get
+// the current user the same way they do for regular jwt authentication.
+func NewUserJWTFromAPIToken(u *user.User, token *models.APIToken) *jwt.Token {
+	t := jwt.New(jwt.SigningMethodHS256)
+	claims := t.Claims.(jwt.MapClaims)
+	setUserClaims(claims, u, token.ExpiresAt.Unix(), false)
+	// Parsed jwt claims contain all numbers as float64, everything reading the claims relies on that.
+	claims["type"] = float64(AuthTypeUser)
+	claims["id"] = float64(u.ID)
+	t.Valid = true
+	return t
 }
 
 // NewLinkShareJWTAuthtoken creates a new jwt token from a link share
@@ -283,7 +283,11 @@ func (p *Provider) Set(s *xorm.Session, image *background.Image, list *models.Li
 	log.Debugf("Pinged unsplash download endpoint for photo %s", image.ID)
 
 	// Save it as a file in vikunja
-	file, err := files.Create(resp.Body, "", 0, auth)
+	var size uint64
+	if resp.ContentLength > 0 {
+		size = uint64(resp.ContentLength)
+	}
+	file, err := files.Create(resp.Body, "", size, auth)
 	if err != nil {
 		return
 	}
@@ -291,7 +295,7 @@ func (p *Provider) Set(s *xorm.Session, image *background.Image, list *models.Li
 	// Remove the old background if one exists
 	if list.BackgroundFileID != 0 {
 		file := files.File{ID: list.BackgroundFileID}
-		if err := file.Delete(); err != nil {
+		if err := file.DeleteWithSession(s); err != nil {
 			return err
 		}
 
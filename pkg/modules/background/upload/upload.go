@@ -56,7 +56,7 @@ func (p *Provider) Set(s *xorm.Session, img *background.Image, list *models.List
 	// Remove the old background if one exists
 	if list.BackgroundFileID != 0 {
 		file := files.File{ID: list.BackgroundFileID}
-		if err := file.Delete(); err != nil {
+		if err := file.DeleteWithSession(s); err != nil {
 			return err
 		}
 	}
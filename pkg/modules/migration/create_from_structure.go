@@ -83,6 +83,7 @@ func insertFromStructure(s *xorm.Session, str []*models.NamespaceWithListsAndTas
 			// to be able to still loop over them aftere the list was created.
 			tasks := l.Tasks
 			originalBuckets := l.Buckets
+			originalCustomFields := l.CustomFields
 			originalBackgroundInformation := l.BackgroundInformation
 			needsDefaultBucket := false
 
@@ -138,6 +139,20 @@ func insertFromStructure(s *xorm.Session, str []*models.NamespaceWithListsAndTas
 				log.Debugf("[creating structure] Created bucket %d, old ID was %d", bucket.ID, oldID)
 			}
 
+			// Create all custom fields
+			customFields := make(map[int64]*models.CustomField) // old custom field id is the key
+			for _, field := range originalCustomFields {
+				oldID := field.ID
+				field.ID = 0
+				field.ListID = l.ID
+				err = field.Create(s, user)
+				if err != nil {
+					return
+				}
+				customFields[oldID] = field
+				log.Debugf("[creating structure] Created custom field %d, old ID was %d", field.ID, oldID)
+			}
+
 			log.Debugf("[creating structure] Creating %d tasks", len(tasks))
 
 			setBucketOrDefault := func(task *models.Task) {
@@ -157,6 +172,23 @@ func insertFromStructure(s *xorm.Session, str []*models.NamespaceWithListsAndTas
 			for _, t := range tasks {
 				setBucketOrDefault(&t.Task)
 
+				// Map the values to the newly created custom fields. User ids are not the same across instances,
+				// which is why values of user fields are not imported.
+				customFieldValues := make(map[int64]interface{}, len(t.CustomFields))
+				for oldID, value := range t.CustomFields {
+					field, exists := customFields[oldID]
+					if !exists || field.Type == models.CustomFieldTypeUser {
+						continue
+					}
+					customFieldValues[field.ID] = value
+				}
+				t.CustomFields = customFieldValues
+
+				// Same for the assignees of checklist items
+				for _, item := range t.ChecklistItems {
+					item.AssigneeID = 0
+				}
+
 				t.ListID = l.ID
 				err = t.Create(s, user)
 				if err != nil {
@@ -180,6 +212,9 @@ func insertFromStructure(s *xorm.Session, str []*models.NamespaceWithListsAndTas
 						if rt.ID == 0 {
 							setBucketOrDefault(rt)
 							rt.ListID = t.ListID
+							for _, item := range rt.ChecklistItems {
+								item.AssigneeID = 0
+							}
 							err = rt.Create(s, user)
 							if err != nil {
 								return
@@ -62,6 +62,13 @@ type task struct {
 	Recurrence           *recurrence       `json:"recurrence"`
 	ReminderDateTime     *dateTimeTimeZone `json:"reminderDateTime"`
 	CompletedDateTime    *dateTimeTimeZone `json:"completedDateTime"`
+	ChecklistItems       []*checklistItem  `json:"checklistItems"`
+}
+type checklistItem struct {
+	ID              string    `json:"id"`
+	DisplayName     string    `json:"displayName"`
+	IsChecked       bool      `json:"isChecked"`
+	CheckedDateTime time.Time `json:"checkedDateTime"`
 }
 type dateTimeTimeZone struct {
 	DateTime string `json:"dateTime"`
@@ -227,7 +234,7 @@ func getMicrosoftTodoData(token string) (microsoftTodoData []*list, err error) {
 	log.Debugf("[Microsoft Todo Migration] Got %d lists", len(lists.Value))
 
 	for _, list := range lists.Value {
-		link := "lists/" + list.ID + "/tasks"
+		link := "lists/" + list.ID + "/tasks?$expand=checklistItems"
 		list.Tasks = []*task{}
 
 		// Microsoft's Graph API has pagination, so we're going through all pages to get all tasks
@@ -329,7 +336,7 @@ func convertMicrosoftTodoData(todoData []*list) (vikunjsStructure []*models.Name
 					return nil, err
 				}
 
-				task.Reminders = []time.Time{reminder}
+				task.Reminders = []*models.TaskReminder{{Reminder: reminder}}
 			}
 
 			// Due Date
@@ -358,6 +365,18 @@ func convertMicrosoftTodoData(todoData []*list) (vikunjsStructure []*models.Name
 				}
 			}
 
+			// Checklist items
+			for _, item := range t.ChecklistItems {
+				checklistItem := &models.TaskChecklistItem{
+					Text: item.DisplayName,
+					Done: item.IsChecked,
+				}
+				if item.IsChecked {
+					checklistItem.DoneAt = item.CheckedDateTime
+				}
+				task.ChecklistItems = append(task.ChecklistItems, checklistItem)
+			}
+
 			list.Tasks = append(list.Tasks, &models.TaskWithComments{Task: *task})
 			log.Debugf("[Microsoft Todo Migration] Done converted %d tasks", len(l.Tasks))
 		}
@@ -371,7 +390,7 @@ func convertMicrosoftTodoData(todoData []*list) (vikunjsStructure []*models.Name
 
 // Migrate gets all tasks from Microsoft Todo for a user and puts them into vikunja
 // @Summary Migrate all lists, tasks etc. from Microsoft Todo
-// @Description Migrates all tasklinsts, tasks, notes and reminders from Microsoft Todo to Vikunja.
+// @Description Migrates all tasklinsts, tasks, notes, reminders and checklist items from Microsoft Todo to Vikunja.
 // @tags migration
 // @Accept json
 // @Produce json
@@ -46,6 +46,16 @@ func TestConverting(t *testing.T) {
 						Content:     "This is a description",
 						ContentType: "text",
 					},
+					ChecklistItems: []*checklistItem{
+						{
+							DisplayName: "Step 1",
+						},
+						{
+							DisplayName:     "Step 2",
+							IsChecked:       true,
+							CheckedDateTime: testtimeTime,
+						},
+					},
 				},
 				{
 					Title:             "Task 2",
@@ -117,6 +127,16 @@ func TestConverting(t *testing.T) {
 							Task: models.Task{
 								Title:       "Task 1",
 								Description: "This is a description",
+								ChecklistItems: []*models.TaskChecklistItem{
+									{
+										Text: "Step 1",
+									},
+									{
+										Text:   "Step 2",
+										Done:   true,
+										DoneAt: testtimeTime,
+									},
+								},
 							},
 						},
 						{
@@ -141,8 +161,8 @@ func TestConverting(t *testing.T) {
 						{
 							Task: models.Task{
 								Title: "Task 5",
-								Reminders: []time.Time{
-									testtimeTime,
+								Reminders: []*models.TaskReminder{
+									{Reminder: testtimeTime},
 								},
 							},
 						},
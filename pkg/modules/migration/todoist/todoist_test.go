@@ -141,6 +141,22 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 				Checked:    false,
 				DateAdded:  time1,
 			},
+			{
+				ID:         "400000120",
+				UserID:     "1855589",
+				ProjectID:  "396936926",
+				Content:    "Task with parent and due date",
+				Priority:   1,
+				ParentID:   "400000006",
+				ChildOrder: 2,
+				Checked:    false,
+				DateAdded:  time1,
+				Due: &dueDate{
+					Date:        "2020-05-31",
+					Timezone:    nil,
+					IsRecurring: false,
+				},
+			},
 			{
 				ID:            "400000106",
 				UserID:        "1855589",
@@ -398,9 +414,9 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 								Description: "Lorem Ipsum dolor sit amet",
 								Done:        false,
 								Created:     time1,
-								Reminders: []time.Time{
-									time.Date(2020, time.June, 15, 23, 59, 0, 0, time.UTC).In(config.GetTimeZone()),
-									time.Date(2020, time.June, 16, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone()),
+								Reminders: []*models.TaskReminder{
+									{Reminder: time.Date(2020, time.June, 15, 23, 59, 0, 0, time.UTC).In(config.GetTimeZone())},
+									{Reminder: time.Date(2020, time.June, 16, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone())},
 								},
 							},
 						},
@@ -417,8 +433,8 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 								Title:   "Task400000002",
 								Done:    false,
 								Created: time1,
-								Reminders: []time.Time{
-									time.Date(2020, time.July, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone()),
+								Reminders: []*models.TaskReminder{
+									{Reminder: time.Date(2020, time.July, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone())},
 								},
 							},
 						},
@@ -431,8 +447,8 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 								Created:     time1,
 								DoneAt:      time3,
 								Labels:      vikunjaLabels,
-								Reminders: []time.Time{
-									time.Date(2020, time.June, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone()),
+								Reminders: []*models.TaskReminder{
+									{Reminder: time.Date(2020, time.June, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone())},
 								},
 							},
 						},
@@ -451,8 +467,8 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 								DueDate: dueTime,
 								Created: time1,
 								DoneAt:  time3,
-								Reminders: []time.Time{
-									time.Date(2020, time.June, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone()),
+								Reminders: []*models.TaskReminder{
+									{Reminder: time.Date(2020, time.June, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone())},
 								},
 							},
 						},
@@ -472,6 +488,13 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 											Created:  time1,
 											DoneAt:   nilTime,
 										},
+										{
+											Title:   "Task with parent and due date",
+											Done:    false,
+											DueDate: dueTime,
+											Created: time1,
+											DoneAt:  nilTime,
+										},
 									},
 								},
 							},
@@ -541,8 +564,8 @@ func TestConvertTodoistToVikunja(t *testing.T) {
 								Title:   "Task400000009",
 								Done:    false,
 								Created: time1,
-								Reminders: []time.Time{
-									time.Date(2020, time.June, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone()),
+								Reminders: []*models.TaskReminder{
+									{Reminder: time.Date(2020, time.June, 15, 7, 0, 0, 0, time.UTC).In(config.GetTimeZone())},
 								},
 							},
 						},
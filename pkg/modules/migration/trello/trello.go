@@ -208,18 +208,19 @@ func convertTrelloDataToVikunja(trelloData []*trello.Board, token string) (fullV
 					task.DueDate = *card.Due
 				}
 
-				// Checklists (as markdown in description)
+				// Checklists
+				// Items of cards with more than one checklist are prefixed with the name of their checklist
+				// to keep them distinguishable.
 				for _, checklist := range card.Checklists {
-					task.Description += "\n\n## " + checklist.Name + "\n"
-
 					for _, item := range checklist.CheckItems {
-						task.Description += "\n* "
-						if item.State == "completed" {
-							task.Description += "[x]"
-						} else {
-							task.Description += "[ ]"
+						text := item.Name
+						if len(card.Checklists) > 1 {
+							text = checklist.Name + ": " + text
 						}
-						task.Description += " " + item.Name
+						task.ChecklistItems = append(task.ChecklistItems, &models.TaskChecklistItem{
+							Text: text,
+							Done: item.State == "completed",
+						})
 					}
 				}
 				if len(card.Checklists) > 0 {
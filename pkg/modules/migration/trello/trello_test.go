@@ -241,20 +241,24 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 						},
 						{
 							Task: models.Task{
-								Title: "Test Card 2",
-								Description: `
-
-## Checklist 1
-
-* [ ] Pending Task
-* [x] Completed Task
-
-## Checklist 2
-
-* [ ] Pending Task
-* [ ] Another Pending Task`,
+								Title:          "Test Card 2",
 								BucketID:       1,
 								KanbanPosition: 124,
+								ChecklistItems: []*models.TaskChecklistItem{
+									{
+										Text: "Checklist 1: Pending Task",
+									},
+									{
+										Text: "Checklist 1: Completed Task",
+										Done: true,
+									},
+									{
+										Text: "Checklist 2: Pending Task",
+									},
+									{
+										Text: "Checklist 2: Another Pending Task",
+									},
+								},
 							},
 						},
 						{
@@ -0,0 +1,297 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package quickadd
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// locale holds the words of one language the parser understands.
+// All fields are regular expression alternatives, matched case-insensitive.
+type locale struct {
+	today            string
+	tomorrow         string
+	dayAfterTomorrow string
+	next             string
+	endOf            string
+	weekend          string
+	in               string
+	one              string
+	on               string
+	every            string
+	other            string
+
+	// Times, all of them need to capture the hour, the minutes and optionally am or pm in that order
+	times []string
+
+	units    map[unit]string
+	repeats  map[unit]string
+	weekdays [7]string // Indexed by time.Weekday
+	months   [12]string
+
+	// A regular expression for numeric dates without a year which captures day and month and an optional year,
+	// in that order when dayFirst is true, month first otherwise.
+	numericDate string
+	dayFirst    bool
+	// Dates with a month name, capturing day, month and an optional year. If monthFirst is true the month comes first.
+	namedDates []namedDate
+}
+
+type namedDate struct {
+	pattern    string
+	monthFirst bool
+}
+
+type unit int
+
+const (
+	unitHour unit = iota
+	unitDay
+	unitWeek
+	unitMonth
+	unitYear
+)
+
+var locales = map[string]*locale{
+	"en": {
+		today:            `today`,
+		tomorrow:         `tomorrow`,
+		dayAfterTomorrow: `the day after tomorrow|day after tomorrow`,
+		next:             `next`,
+		endOf:            `(?:the )?end of(?: the)?`,
+		weekend:          `(?:this |on the |at the )?weekend`,
+		in:               `in`,
+		one:              `a|an|one`,
+		on:               `on`,
+		every:            `every`,
+		other:            `other`,
+		times: []string{
+			`at\s+(\d{1,2})(?::(\d{2}))?\s*(am|pm)?`,
+			`(\d{1,2})(?::(\d{2}))?\s*(am|pm)`,
+		},
+		units: map[unit]string{
+			unitHour:  `hours?`,
+			unitDay:   `days?`,
+			unitWeek:  `weeks?`,
+			unitMonth: `months?`,
+			unitYear:  `years?`,
+		},
+		repeats: map[unit]string{
+			unitDay:   `daily`,
+			unitWeek:  `weekly`,
+			unitMonth: `monthly`,
+			unitYear:  `yearly|annually`,
+		},
+		weekdays: [7]string{`sunday`, `monday`, `tuesday`, `wednesday`, `thursday`, `friday`, `saturday`},
+		months: [12]string{
+			`january|jan`, `february|feb`, `march|mar`, `april|apr`, `may`, `june|jun`,
+			`july|jul`, `august|aug`, `september|sept|sep`, `october|oct`, `november|nov`, `december|dec`,
+		},
+		numericDate: `(\d{1,2})/(\d{1,2})(?:/(\d{4}))?`,
+		namedDates: []namedDate{
+			{pattern: `(?:the )?(\d{1,2})(?:st|nd|rd|th)?(?: of)? (MONTHS)(?:,? (\d{4}))?`},
+			{pattern: `(MONTHS) (\d{1,2})(?:st|nd|rd|th)?(?:,? (\d{4}))?`, monthFirst: true},
+		},
+	},
+	"de": {
+		today:            `heute`,
+		tomorrow:         `morgen`,
+		dayAfterTomorrow: `übermorgen`,
+		next:             `nächsten|nächste|nächster|nächstes|kommenden|kommende|kommender|kommendes`,
+		endOf:            `(?:am )?ende (?:der|des|dieser|dieses)`,
+		weekend:          `(?:dieses |am |zum )?wochenende`,
+		in:               `in`,
+		one:              `einem|einer|ein|eine`,
+		on:               `am`,
+		every:            `jeden|jede|jedes|alle`,
+		other:            `zwei`,
+		times: []string{
+			`um\s+(\d{1,2})(?::(\d{2}))?(?:\s*uhr)?()`,
+			`(\d{1,2})(?::(\d{2}))?\s*uhr()`,
+		},
+		units: map[unit]string{
+			unitHour:  `stunden|stunde`,
+			unitDay:   `tagen|tage|tag`,
+			unitWeek:  `wochen|woche`,
+			unitMonth: `monaten|monate|monats|monat`,
+			unitYear:  `jahren|jahre|jahres|jahr`,
+		},
+		repeats: map[unit]string{
+			unitDay:   `täglich`,
+			unitWeek:  `wöchentlich`,
+			unitMonth: `monatlich`,
+			unitYear:  `jährlich`,
+		},
+		weekdays: [7]string{`sonntag`, `montag`, `dienstag`, `mittwoch`, `donnerstag`, `freitag`, `samstag`},
+		months: [12]string{
+			`januar|jan`, `februar|feb`, `märz|mär`, `april|apr`, `mai`, `juni|jun`,
+			`juli|jul`, `august|aug`, `september|sept|sep`, `oktober|okt`, `november|nov`, `dezember|dez`,
+		},
+		numericDate: `(\d{1,2})\.(\d{1,2})\.(\d{4})?`,
+		dayFirst:    true,
+		namedDates: []namedDate{
+			{pattern: `(\d{1,2})\.? (MONTHS)(?: (\d{4}))?`},
+		},
+	},
+}
+
+// Everything the parser needs to know about a language, compiled once.
+type parser struct {
+	locale *locale
+
+	repeatEvery       *regexp.Regexp
+	repeatEveryDay    *regexp.Regexp
+	repeatWord        *regexp.Regexp
+	inUnits           *regexp.Regexp
+	dayAfterTomorrow  *regexp.Regexp
+	today             *regexp.Regexp
+	tomorrow          *regexp.Regexp
+	nextWeekday       *regexp.Regexp
+	nextUnit          *regexp.Regexp
+	endOfUnit         *regexp.Regexp
+	weekend           *regexp.Regexp
+	weekday           *regexp.Regexp
+	isoDate           *regexp.Regexp
+	numericDate       *regexp.Regexp
+	namedDates        []*regexp.Regexp
+	times             []*regexp.Regexp
+	unitMatchers      map[unit]*regexp.Regexp
+	weekdayMatchers   [7]*regexp.Regexp
+	monthMatchers     [12]*regexp.Regexp
+	repeatUnitMatcher map[unit]*regexp.Regexp
+	otherMatcher      *regexp.Regexp
+}
+
+var parsers = map[string]*parser{}
+
+func init() {
+	for lang, l := range locales {
+		parsers[lang] = newParser(l)
+	}
+}
+
+// wrap makes sure an expression only matches whole words.
+func wrap(expr string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|\s)(?:` + expr + `)(?:$|[\s,;.!?])`)
+}
+
+func anchored(expr string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)^(?:` + expr + `)$`)
+}
+
+func alternatives(words []string) string {
+	return strings.Join(words, "|")
+}
+
+func newParser(l *locale) *parser {
+	p := &parser{
+		locale:            l,
+		otherMatcher:      anchored(l.other),
+		unitMatchers:      make(map[unit]*regexp.Regexp, len(l.units)),
+		repeatUnitMatcher: make(map[unit]*regexp.Regexp, len(l.repeats)),
+	}
+
+	units := []string{}
+	dateUnits := []string{}
+	repeats := []string{}
+	for u := unitHour; u <= unitYear; u++ {
+		if expr, has := l.units[u]; has {
+			p.unitMatchers[u] = anchored(expr)
+			units = append(units, expr)
+			if u != unitHour {
+				dateUnits = append(dateUnits, expr)
+			}
+		}
+		if expr, has := l.repeats[u]; has {
+			p.repeatUnitMatcher[u] = anchored(expr)
+			repeats = append(repeats, expr)
+		}
+	}
+	weekdays := l.weekdays[:]
+	for i, expr := range l.weekdays {
+		p.weekdayMatchers[i] = anchored(expr)
+	}
+	months := l.months[:]
+	for i, expr := range l.months {
+		p.monthMatchers[i] = anchored(expr)
+	}
+
+	p.repeatEvery = wrap(`(?:` + l.every + `)\s+(?:(\d+|` + l.other + `)\s+)?(` + alternatives(dateUnits) + `)`)
+	p.repeatEveryDay = wrap(`(?:` + l.every + `)\s+(` + alternatives(weekdays) + `)`)
+	p.repeatWord = wrap(`(` + alternatives(repeats) + `)`)
+	p.inUnits = wrap(`(?:` + l.in + `)\s+(\d+|` + l.one + `)\s+(` + alternatives(units) + `)`)
+	p.dayAfterTomorrow = wrap(l.dayAfterTomorrow)
+	p.today = wrap(l.today)
+	p.tomorrow = wrap(l.tomorrow)
+	p.nextWeekday = wrap(`(?:(?:` + l.on + `)\s+)?(?:` + l.next + `)\s+(` + alternatives(weekdays) + `)`)
+	p.nextUnit = wrap(`(?:` + l.next + `)\s+(` + alternatives(dateUnits) + `)`)
+	p.endOfUnit = wrap(`(?:` + l.endOf + `)\s+(` + alternatives(dateUnits) + `)`)
+	p.weekend = wrap(l.weekend)
+	p.weekday = wrap(`(?:(?:` + l.on + `)\s+)?(` + alternatives(weekdays) + `)`)
+	p.isoDate = wrap(`(\d{4})-(\d{1,2})-(\d{1,2})`)
+	p.numericDate = wrap(`(?:(?:` + l.on + `)\s+)?` + l.numericDate)
+	for _, nd := range l.namedDates {
+		p.namedDates = append(p.namedDates, wrap(`(?:(?:`+l.on+`)\s+)?`+strings.ReplaceAll(nd.pattern, "MONTHS", alternatives(months))))
+	}
+	for _, expr := range l.times {
+		p.times = append(p.times, wrap(expr))
+	}
+
+	return p
+}
+
+// getParser returns the parser for a language like "de" or "de-DE", falling back to English.
+func getParser(language string) *parser {
+	language = strings.ToLower(language)
+	if p, has := parsers[language]; has {
+		return p
+	}
+	if pos := strings.IndexAny(language, "-_"); pos > 0 {
+		if p, has := parsers[language[:pos]]; has {
+			return p
+		}
+	}
+	return parsers["en"]
+}
+
+func (p *parser) matchUnit(word string) unit {
+	for u, re := range p.unitMatchers {
+		if re.MatchString(word) {
+			return u
+		}
+	}
+	return unitDay
+}
+
+func (p *parser) matchWeekday(word string) time.Weekday {
+	for i, re := range p.weekdayMatchers {
+		if re.MatchString(word) {
+			return time.Weekday(i)
+		}
+	}
+	return time.Sunday
+}
+
+func (p *parser) matchMonth(word string) time.Month {
+	for i, re := range p.monthMatchers {
+		if re.MatchString(word) {
+			return time.Month(i + 1)
+		}
+	}
+	return time.January
+}
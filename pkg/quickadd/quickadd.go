@@ -0,0 +1,439 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+// Package quickadd parses the "quick add magic" of a line of text into the properties of a task.
+// A line like "Buy milk *groceries +Home @john !3 tomorrow at 17:00" results in a task "Buy milk" with the
+// label "groceries" in the list "Home", assigned to john with priority 3 and due tomorrow at five.
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Labels, lists and users can be put in quotes to allow spaces in their names
+var (
+	labelRegex    = regexp.MustCompile(`(?:^|\s)\*("[^"]+"|'[^']+'|[^\s"']\S*)`)
+	listRegex     = regexp.MustCompile(`(?:^|\s)\+("[^"]+"|'[^']+'|[^\s"']\S*)`)
+	assigneeRegex = regexp.MustCompile(`(?:^|\s)@("[^"]+"|'[^']+'|[^\s"']\S*)`)
+	priorityRegex = regexp.MustCompile(`(?:^|\s)!([1-5])(?:$|\s)`)
+)
+
+// The hour dates without a time are set to. Using noon instead of midnight makes sure the date does not
+// change when it is looked at from a neighbouring timezone.
+const defaultHour = 12
+
+var rruleWeekdays = [7]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// Options control how dates are parsed.
+type Options struct {
+	// The point in time relative dates like "tomorrow" are computed from, in the timezone of the user.
+	// Defaults to the current time.
+	Now time.Time
+	// The day a week starts for the user, used for things like "next week".
+	WeekStart time.Weekday
+	// The language of the user, for example "de" or "de-DE". Unsupported languages fall back to English.
+	Language string
+}
+
+// Result is everything parsed from a line of text.
+type Result struct {
+	// The text with everything that was parsed out of it removed.
+	Title string
+	// The titles of all labels, prefixed with * in the text.
+	Labels []string
+	// The title or identifier of the list, prefixed with + in the text. Only the first one is used.
+	List string
+	// The usernames of all assignees, prefixed with @ in the text.
+	Assignees []string
+	// The priority, prefixed with ! in the text. 0 if there was none.
+	Priority int64
+	// The date in the text. Zero if there was none.
+	Date time.Time
+	// An RFC 5545 recurrence rule if the text contained a repetition like "every week".
+	RepeatRule string
+}
+
+// Parse parses a line of text.
+func Parse(text string, opts *Options) (result *Result) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	p := getParser(opts.Language)
+
+	result = &Result{}
+
+	var matches [][]string
+	matches, text = extract(labelRegex, text, -1)
+	for _, m := range matches {
+		result.Labels = append(result.Labels, unquote(m[1]))
+	}
+
+	matches, text = extract(listRegex, text, 1)
+	if len(matches) > 0 {
+		result.List = unquote(matches[0][1])
+	}
+
+	matches, text = extract(assigneeRegex, text, -1)
+	for _, m := range matches {
+		result.Assignees = append(result.Assignees, unquote(m[1]))
+	}
+
+	matches, text = extract(priorityRegex, text, 1)
+	if len(matches) > 0 {
+		result.Priority, _ = strconv.ParseInt(matches[0][1], 10, 64)
+	}
+
+	text = p.parseRepeat(text, result, now)
+	text = p.parseDate(text, result, now, opts.WeekStart)
+
+	result.Title = strings.Join(strings.Fields(text), " ")
+	return
+}
+
+// extract returns the submatches of the first n matches of an expression and the text with these matches removed.
+func extract(re *regexp.Regexp, text string, n int) (matches [][]string, rest string) {
+	indexes := re.FindAllStringSubmatchIndex(text, n)
+	if len(indexes) == 0 {
+		return nil, text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, idx := range indexes {
+		b.WriteString(text[last:idx[0]])
+		b.WriteString(" ")
+		last = idx[1]
+
+		m := make([]string, len(idx)/2)
+		for i := range m {
+			if idx[2*i] >= 0 {
+				m[i] = text[idx[2*i]:idx[2*i+1]]
+			}
+		}
+		matches = append(matches, m)
+	}
+	b.WriteString(text[last:])
+
+	return matches, b.String()
+}
+
+func unquote(value string) string {
+	if len(value) > 1 && (value[0] == '"' || value[0] == '\'') {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func (p *parser) parseNumber(value string) int {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		// Only words for one or "other" are matched besides numbers
+		if p.otherMatcher.MatchString(value) {
+			return 2
+		}
+		return 1
+	}
+	return n
+}
+
+func (p *parser) parseRepeat(text string, result *Result, now time.Time) string {
+	var matches [][]string
+
+	matches, text = extract(p.repeatEveryDay, text, 1)
+	if len(matches) > 0 {
+		weekday := p.matchWeekday(matches[0][1])
+		result.RepeatRule = "FREQ=WEEKLY;BYDAY=" + rruleWeekdays[weekday]
+		// The task is due on the next of these days unless the text says otherwise
+		today := startOfDay(now)
+		result.Date = today.AddDate(0, 0, (int(weekday)-int(today.Weekday())+7)%7).Add(defaultHour * time.Hour)
+		return text
+	}
+
+	interval := 1
+	var u unit
+	matches, text = extract(p.repeatEvery, text, 1)
+	if len(matches) > 0 {
+		if matches[0][1] != "" {
+			interval = p.parseNumber(matches[0][1])
+		}
+		u = p.matchUnit(matches[0][2])
+	} else {
+		matches, text = extract(p.repeatWord, text, 1)
+		if len(matches) == 0 {
+			return text
+		}
+		for ru, re := range p.repeatUnitMatcher {
+			if re.MatchString(matches[0][1]) {
+				u = ru
+			}
+		}
+	}
+
+	if interval < 1 {
+		return text
+	}
+
+	switch u {
+	case unitDay:
+		result.RepeatRule = "FREQ=DAILY"
+	case unitWeek:
+		result.RepeatRule = "FREQ=WEEKLY"
+	case unitMonth:
+		result.RepeatRule = "FREQ=MONTHLY"
+	case unitYear:
+		result.RepeatRule = "FREQ=YEARLY"
+	}
+	if interval > 1 {
+		result.RepeatRule += ";INTERVAL=" + strconv.Itoa(interval)
+	}
+
+	return text
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func startOfWeek(day time.Time, weekStart time.Weekday) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(weekStart) + 7) % 7))
+}
+
+// dateFromParts returns a date if it exists, dates without a year are the next time this day comes around.
+func dateFromParts(today time.Time, year string, month time.Month, day int) (time.Time, bool) {
+	y := today.Year()
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+	}
+
+	date := time.Date(y, month, day, 0, 0, 0, 0, today.Location())
+	if date.Month() != month || date.Day() != day {
+		return time.Time{}, false
+	}
+
+	if year == "" && date.Before(today) {
+		date = date.AddDate(1, 0, 0)
+	}
+
+	return date, true
+}
+
+type dateMatcher struct {
+	re   *regexp.Regexp
+	date func(m []string) (date time.Time, hasTime bool, ok bool)
+}
+
+//nolint:gocyclo
+func (p *parser) dateMatchers(now time.Time, weekStart time.Weekday) []*dateMatcher {
+	today := startOfDay(now)
+	week := startOfWeek(today, weekStart)
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+
+	matchers := []*dateMatcher{
+		{p.inUnits, func(m []string) (time.Time, bool, bool) {
+			n := p.parseNumber(m[1])
+			switch p.matchUnit(m[2]) {
+			case unitHour:
+				return now.Add(time.Duration(n) * time.Hour).Truncate(time.Minute), true, true
+			case unitWeek:
+				return today.AddDate(0, 0, 7*n), false, true
+			case unitMonth:
+				return today.AddDate(0, n, 0), false, true
+			case unitYear:
+				return today.AddDate(n, 0, 0), false, true
+			default:
+				return today.AddDate(0, 0, n), false, true
+			}
+		}},
+		{p.dayAfterTomorrow, func(m []string) (time.Time, bool, bool) {
+			return today.AddDate(0, 0, 2), false, true
+		}},
+		{p.today, func(m []string) (time.Time, bool, bool) {
+			return today, false, true
+		}},
+		{p.tomorrow, func(m []string) (time.Time, bool, bool) {
+			return today.AddDate(0, 0, 1), false, true
+		}},
+		{p.nextWeekday, func(m []string) (time.Time, bool, bool) {
+			weekday := p.matchWeekday(m[1])
+			return week.AddDate(0, 0, 7+(int(weekday)-int(weekStart)+7)%7), false, true
+		}},
+		{p.nextUnit, func(m []string) (time.Time, bool, bool) {
+			switch p.matchUnit(m[1]) {
+			case unitWeek:
+				return week.AddDate(0, 0, 7), false, true
+			case unitMonth:
+				return firstOfMonth.AddDate(0, 1, 0), false, true
+			case unitYear:
+				return time.Date(today.Year()+1, time.January, 1, 0, 0, 0, 0, today.Location()), false, true
+			default:
+				return today.AddDate(0, 0, 1), false, true
+			}
+		}},
+		{p.endOfUnit, func(m []string) (time.Time, bool, bool) {
+			switch p.matchUnit(m[1]) {
+			case unitWeek:
+				return week.AddDate(0, 0, 6), false, true
+			case unitMonth:
+				return firstOfMonth.AddDate(0, 1, -1), false, true
+			case unitYear:
+				return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()), false, true
+			default:
+				return today, false, true
+			}
+		}},
+		{p.weekend, func(m []string) (time.Time, bool, bool) {
+			if today.Weekday() == time.Sunday {
+				return today, false, true
+			}
+			return today.AddDate(0, 0, int(time.Saturday-today.Weekday())), false, true
+		}},
+		{p.isoDate, func(m []string) (time.Time, bool, bool) {
+			month, _ := strconv.Atoi(m[2])
+			day, _ := strconv.Atoi(m[3])
+			date, ok := dateFromParts(today, m[1], time.Month(month), day)
+			return date, false, ok
+		}},
+		{p.numericDate, func(m []string) (time.Time, bool, bool) {
+			first, _ := strconv.Atoi(m[1])
+			second, _ := strconv.Atoi(m[2])
+			day, month := second, first
+			if p.locale.dayFirst {
+				day, month = first, second
+			}
+			date, ok := dateFromParts(today, m[3], time.Month(month), day)
+			return date, false, ok
+		}},
+	}
+
+	for i, re := range p.namedDates {
+		monthFirst := p.locale.namedDates[i].monthFirst
+		matchers = append(matchers, &dateMatcher{re, func(m []string) (time.Time, bool, bool) {
+			dayPart, monthPart := m[1], m[2]
+			if monthFirst {
+				dayPart, monthPart = m[2], m[1]
+			}
+			day, _ := strconv.Atoi(dayPart)
+			date, ok := dateFromParts(today, m[3], p.matchMonth(monthPart), day)
+			return date, false, ok
+		}})
+	}
+
+	// A weekday on its own is the next one, a week from today if it is that day today
+	matchers = append(matchers, &dateMatcher{p.weekday, func(m []string) (time.Time, bool, bool) {
+		days := (int(p.matchWeekday(m[1])) - int(today.Weekday()) + 7) % 7
+		if days == 0 {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), false, true
+	}})
+
+	return matchers
+}
+
+func (p *parser) parseDate(text string, result *Result, now time.Time, weekStart time.Weekday) string {
+	var (
+		date    time.Time
+		hasTime bool
+	)
+
+	for _, matcher := range p.dateMatchers(now, weekStart) {
+		idx := matcher.re.FindStringSubmatchIndex(text)
+		if idx == nil {
+			continue
+		}
+		m := make([]string, len(idx)/2)
+		for i := range m {
+			if idx[2*i] >= 0 {
+				m[i] = text[idx[2*i]:idx[2*i+1]]
+			}
+		}
+		d, t, ok := matcher.date(m)
+		if !ok {
+			continue
+		}
+
+		date, hasTime = d, t
+		text = text[:idx[0]] + " " + text[idx[1]:]
+		break
+	}
+
+	if !hasTime {
+		var hour, minute int
+		var found bool
+		hour, minute, found, text = p.parseTime(text)
+		if found {
+			if date.IsZero() {
+				date = result.Date
+			}
+			if date.IsZero() {
+				date = startOfDay(now)
+			}
+			date = startOfDay(date).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+			hasTime = true
+		}
+	}
+
+	if date.IsZero() {
+		return text
+	}
+
+	if !hasTime {
+		date = date.Add(defaultHour * time.Hour)
+	}
+	result.Date = date
+
+	return text
+}
+
+func (p *parser) parseTime(text string) (hour, minute int, found bool, rest string) {
+	for _, re := range p.times {
+		idx := re.FindStringSubmatchIndex(text)
+		if idx == nil {
+			continue
+		}
+
+		hour, _ = strconv.Atoi(text[idx[2]:idx[3]])
+		if idx[4] >= 0 {
+			minute, _ = strconv.Atoi(text[idx[4]:idx[5]])
+		}
+		if idx[6] >= 0 {
+			switch strings.ToLower(text[idx[6]:idx[7]]) {
+			case "pm":
+				if hour < 12 {
+					hour += 12
+				}
+			case "am":
+				if hour == 12 {
+					hour = 0
+				}
+			}
+		}
+
+		if hour > 23 || minute > 59 {
+			continue
+		}
+
+		return hour, minute, true, text[:idx[0]] + " " + text[idx[1]:]
+	}
+
+	return 0, 0, false, text
+}
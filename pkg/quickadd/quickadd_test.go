@@ -0,0 +1,174 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2022, 11, 2, 10, 30, 0, 0, time.UTC)
+	noon := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("prefixes", func(t *testing.T) {
+		r := Parse(`Buy milk *groceries *"to buy" +Home @john @'jane doe' !3`, &Options{Now: now})
+		assert.Equal(t, "Buy milk", r.Title)
+		assert.Equal(t, []string{"groceries", "to buy"}, r.Labels)
+		assert.Equal(t, "Home", r.List)
+		assert.Equal(t, []string{"john", "jane doe"}, r.Assignees)
+		assert.Equal(t, int64(3), r.Priority)
+		assert.True(t, r.Date.IsZero())
+		assert.Empty(t, r.RepeatRule)
+	})
+	t.Run("only the first list", func(t *testing.T) {
+		r := Parse("Task +one +two", &Options{Now: now})
+		assert.Equal(t, "one", r.List)
+		assert.Equal(t, "Task +two", r.Title)
+	})
+	t.Run("no prefixes in words", func(t *testing.T) {
+		r := Parse("Send mail to me@example.com 1+1 !important", &Options{Now: now})
+		assert.Equal(t, "Send mail to me@example.com 1+1 !important", r.Title)
+		assert.Empty(t, r.Assignees)
+		assert.Empty(t, r.List)
+		assert.Equal(t, int64(0), r.Priority)
+	})
+
+	t.Run("english dates", func(t *testing.T) {
+		tests := map[string]time.Time{
+			"today":                  noon(2022, 11, 2),
+			"tomorrow":               noon(2022, 11, 3),
+			"the day after tomorrow": noon(2022, 11, 4),
+			"in 3 days":              noon(2022, 11, 5),
+			"in a week":              noon(2022, 11, 9),
+			"in 2 months":            noon(2023, 1, 2),
+			"in 2 hours":             time.Date(2022, 11, 2, 12, 30, 0, 0, time.UTC),
+			"next monday":            noon(2022, 11, 7),
+			"next friday":            noon(2022, 11, 11),
+			"next week":              noon(2022, 11, 7),
+			"next month":             noon(2022, 12, 1),
+			"next year":              noon(2023, 1, 1),
+			"end of the month":       noon(2022, 11, 30),
+			"end of week":            noon(2022, 11, 6),
+			"this weekend":           noon(2022, 11, 5),
+			"friday":                 noon(2022, 11, 4),
+			"on wednesday":           noon(2022, 11, 9),
+			"2022-12-24":             noon(2022, 12, 24),
+			"12/24":                  noon(2022, 12, 24),
+			"1/15":                   noon(2023, 1, 15),
+			"on 3/1/2024":            noon(2024, 3, 1),
+			"24th of december":       noon(2022, 12, 24),
+			"Jan 5":                  noon(2023, 1, 5),
+			"March 3rd, 2024":        noon(2024, 3, 3),
+			"tomorrow at 17:00":      time.Date(2022, 11, 3, 17, 0, 0, 0, time.UTC),
+			"friday 3pm":             time.Date(2022, 11, 4, 15, 0, 0, 0, time.UTC),
+			"at 9:15 am":             time.Date(2022, 11, 2, 9, 15, 0, 0, time.UTC),
+			"at 12am":                time.Date(2022, 11, 2, 0, 0, 0, 0, time.UTC),
+		}
+		for text, expected := range tests {
+			r := Parse("Do the thing "+text, &Options{Now: now, WeekStart: time.Monday})
+			assert.Equal(t, "Do the thing", r.Title, text)
+			assert.Equal(t, expected, r.Date, text)
+		}
+	})
+	t.Run("week start", func(t *testing.T) {
+		r := Parse("Task next week", &Options{Now: now, WeekStart: time.Sunday})
+		assert.Equal(t, noon(2022, 11, 6), r.Date)
+	})
+	t.Run("timezone", func(t *testing.T) {
+		loc, err := time.LoadLocation("Europe/Berlin")
+		assert.NoError(t, err)
+		r := Parse("Task tomorrow", &Options{Now: time.Date(2022, 11, 2, 23, 30, 0, 0, loc)})
+		assert.Equal(t, time.Date(2022, 11, 3, 12, 0, 0, 0, loc), r.Date)
+	})
+	t.Run("invalid date", func(t *testing.T) {
+		r := Parse("Task 2/30", &Options{Now: now})
+		assert.Equal(t, "Task 2/30", r.Title)
+		assert.True(t, r.Date.IsZero())
+	})
+	t.Run("invalid time", func(t *testing.T) {
+		r := Parse("Task at 25:00", &Options{Now: now})
+		assert.Equal(t, "Task at 25:00", r.Title)
+		assert.True(t, r.Date.IsZero())
+	})
+
+	t.Run("english repeats", func(t *testing.T) {
+		tests := map[string]string{
+			"every day":        "FREQ=DAILY",
+			"every 3 days":     "FREQ=DAILY;INTERVAL=3",
+			"every other week": "FREQ=WEEKLY;INTERVAL=2",
+			"every month":      "FREQ=MONTHLY",
+			"weekly":           "FREQ=WEEKLY",
+			"annually":         "FREQ=YEARLY",
+			"every monday":     "FREQ=WEEKLY;BYDAY=MO",
+		}
+		for text, expected := range tests {
+			r := Parse("Water plants "+text, &Options{Now: now})
+			assert.Equal(t, "Water plants", r.Title, text)
+			assert.Equal(t, expected, r.RepeatRule, text)
+		}
+	})
+	t.Run("repeat on a weekday", func(t *testing.T) {
+		r := Parse("Standup every friday at 9:30", &Options{Now: now})
+		assert.Equal(t, "Standup", r.Title)
+		assert.Equal(t, "FREQ=WEEKLY;BYDAY=FR", r.RepeatRule)
+		assert.Equal(t, time.Date(2022, 11, 4, 9, 30, 0, 0, time.UTC), r.Date)
+	})
+	t.Run("repeat with a date", func(t *testing.T) {
+		r := Parse("Pay rent every month next month", &Options{Now: now})
+		assert.Equal(t, "Pay rent", r.Title)
+		assert.Equal(t, "FREQ=MONTHLY", r.RepeatRule)
+		assert.Equal(t, noon(2022, 12, 1), r.Date)
+	})
+
+	t.Run("german", func(t *testing.T) {
+		tests := map[string]time.Time{
+			"heute":            noon(2022, 11, 2),
+			"morgen":           noon(2022, 11, 3),
+			"übermorgen":       noon(2022, 11, 4),
+			"in 3 Tagen":       noon(2022, 11, 5),
+			"in einer Woche":   noon(2022, 11, 9),
+			"nächsten Montag":  noon(2022, 11, 7),
+			"nächste Woche":    noon(2022, 11, 7),
+			"Ende des Monats":  noon(2022, 11, 30),
+			"am Wochenende":    noon(2022, 11, 5),
+			"am Freitag":       noon(2022, 11, 4),
+			"24.12.":           noon(2022, 12, 24),
+			"1.3.2024":         noon(2024, 3, 1),
+			"5. Januar":        noon(2023, 1, 5),
+			"morgen um 17 Uhr": time.Date(2022, 11, 3, 17, 0, 0, 0, time.UTC),
+			"Freitag 9:30 Uhr": time.Date(2022, 11, 4, 9, 30, 0, 0, time.UTC),
+		}
+		for text, expected := range tests {
+			r := Parse("Einkaufen "+text, &Options{Now: now, WeekStart: time.Monday, Language: "de-DE"})
+			assert.Equal(t, "Einkaufen", r.Title, text)
+			assert.Equal(t, expected, r.Date, text)
+		}
+
+		r := Parse("Blumen gießen alle zwei Wochen", &Options{Now: now, Language: "de"})
+		assert.Equal(t, "Blumen gießen", r.Title)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2", r.RepeatRule)
+	})
+	t.Run("unknown language", func(t *testing.T) {
+		r := Parse("Task tomorrow", &Options{Now: now, Language: "xx"})
+		assert.Equal(t, noon(2022, 11, 3), r.Date)
+	})
+}
@@ -0,0 +1,37 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetAvailableAPITokenScopes returns all scopes an api token can have
+// @Summary Get all available api token scopes
+// @Description Returns all scopes an api token can be created with. A write scope includes the read scope of the same resource, an admin scope includes both.
+// @tags user
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} string "The scopes"
+// @Router /user/settings/token/scopes [get]
+func GetAvailableAPITokenScopes(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.GetAvailableAPITokenScopes())
+}
@@ -0,0 +1,133 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+	"xorm.io/xorm"
+)
+
+// Feed urls end with .ics to make calendar apps recognize them, echo passes that as part of the param.
+func parseFeedID(c echo.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(c.Param(name), ".ics"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid "+name+" id.")
+	}
+	return id, nil
+}
+
+func renderFeed(c echo.Context, getFeed func(s *xorm.Session, u *user.User) (*models.TaskFeed, error)) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	u, err := user.GetUserByFeedToken(s, c.Param("token"))
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	feed, err := getFeed(s, u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	var calendar string
+	if c.QueryParam("type") == "todos" {
+		tasks := make([]*models.TaskWithComments, 0, len(feed.Tasks))
+		for _, t := range feed.Tasks {
+			tasks = append(tasks, &models.TaskWithComments{Task: *t})
+		}
+		list := &models.ListWithTasksAndBuckets{List: models.List{Title: feed.Title}}
+		calendar = caldav.GetCaldavTodosForTasks(list, tasks)
+	} else {
+		calendar = caldav.GetCaldavEventsForTasks(feed.Title, feed.Tasks)
+	}
+
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+// GetListFeed returns the tasks of a list as a calendar feed
+// @Summary Get a calendar feed of a list
+// @Description Returns all tasks of a list as iCalendar feed which can be subscribed to in calendar apps. Does not need any other authentication than the feed token.
+// @tags feeds
+// @Produce text/calendar
+// @Param token path string true "The feed token"
+// @Param list path int true "The list id, optionally followed by .ics"
+// @Param type query string false "Set to `todos` to get the tasks as VTODOs. By default, all tasks with a date are returned as VEVENTs."
+// @Success 200 {string} string "The iCalendar feed."
+// @Failure 400 {object} web.HTTPError "Invalid list id."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 404 {object} web.HTTPError "The feed token does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /feeds/{token}/lists/{list} [get]
+func GetListFeed(c echo.Context) error {
+	listID, err := parseFeedID(c, "list")
+	if err != nil {
+		return err
+	}
+
+	return renderFeed(c, func(s *xorm.Session, u *user.User) (*models.TaskFeed, error) {
+		return models.GetTaskFeedForList(s, u, listID)
+	})
+}
+
+// GetSavedFilterFeed returns the tasks of a saved filter as a calendar feed
+// @Summary Get a calendar feed of a saved filter
+// @Description Returns all tasks matching a saved filter as iCalendar feed which can be subscribed to in calendar apps. Does not need any other authentication than the feed token.
+// @tags feeds
+// @Produce text/calendar
+// @Param token path string true "The feed token"
+// @Param filter path int true "The saved filter id, optionally followed by .ics"
+// @Param type query string false "Set to `todos` to get the tasks as VTODOs. By default, all tasks with a date are returned as VEVENTs."
+// @Success 200 {string} string "The iCalendar feed."
+// @Failure 400 {object} web.HTTPError "Invalid saved filter id."
+// @Failure 403 {object} web.HTTPError "The user does not own the saved filter."
+// @Failure 404 {object} web.HTTPError "The feed token does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /feeds/{token}/filters/{filter} [get]
+func GetSavedFilterFeed(c echo.Context) error {
+	filterID, err := parseFeedID(c, "filter")
+	if err != nil {
+		return err
+	}
+
+	return renderFeed(c, func(s *xorm.Session, u *user.User) (*models.TaskFeed, error) {
+		return models.GetTaskFeedForSavedFilter(s, u, filterID)
+	})
+}
+
+// GetAssignedTasksFeed returns all tasks assigned to the owner of the feed token as a calendar feed
+// @Summary Get a calendar feed of all tasks assigned to the user
+// @Description Returns all tasks assigned to the owner of the feed token as iCalendar feed which can be subscribed to in calendar apps. Does not need any other authentication than the feed token.
+// @tags feeds
+// @Produce text/calendar
+// @Param token path string true "The feed token"
+// @Param type query string false "Set to `todos` to get the tasks as VTODOs. By default, all tasks with a date are returned as VEVENTs."
+// @Success 200 {string} string "The iCalendar feed."
+// @Failure 404 {object} web.HTTPError "The feed token does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /feeds/{token}/assigned.ics [get]
+func GetAssignedTasksFeed(c echo.Context) error {
+	return renderFeed(c, models.GetTaskFeedForAssignee)
+}
@@ -0,0 +1,163 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/web/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Events recorded by other instances are only noticed when polling
+	realtimePollInterval = 2 * time.Second
+	// Comments are sent regularly so proxies don't close idle connections
+	realtimeKeepaliveInterval = 30 * time.Second
+)
+
+func getRealtimeListIDs(c echo.Context) (listIDs []int64, err error) {
+	for _, param := range c.QueryParams()["list_id"] {
+		for _, raw := range strings.Split(param, ",") {
+			if raw == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid list id.")
+			}
+			listIDs = append(listIDs, id)
+		}
+	}
+	return
+}
+
+func getRealtimeLastEventID(c echo.Context) (lastEventID int64, err error) {
+	raw := c.Request().Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = c.QueryParam("last_event_id")
+	}
+
+	if raw == "" {
+		// Only stream events which happen from now on
+		s := db.NewSession()
+		defer s.Close()
+		return models.GetLatestRealtimeEventID(s)
+	}
+
+	lastEventID, err = strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid last event id.")
+	}
+	return
+}
+
+// StreamEvents streams all events the current user can see as server-sent events
+// @Summary Stream events
+// @Description Opens a stream of server-sent events with all changes to tasks, lists and namespaces the current user has read access to. Every event has an id, its name as event type and the event itself as json data. To resume a stream after a disconnect, pass the last received event id via the `Last-Event-ID` header or the `last_event_id` query parameter. Events are kept for 24 hours.
+// @tags service
+// @Produce text/event-stream
+// @Security JWTKeyAuth
+// @Param list_id query int false "Only stream events of these lists. Can be passed multiple times or as a comma separated list."
+// @Param last_event_id query int false "Resume the stream after this event id."
+// @Success 200 {object} models.RealtimeEvent "The event stream."
+// @Failure 400 {object} web.HTTPError "Invalid list or event id."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /events [get]
+func StreamEvents(c echo.Context) error {
+	a, err := auth2.GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	listIDs, err := getRealtimeListIDs(c)
+	if err != nil {
+		return err
+	}
+
+	lastEventID, err := getRealtimeLastEventID(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	poll := time.NewTicker(realtimePollInterval)
+	defer poll.Stop()
+	keepalive := time.NewTicker(realtimeKeepaliveInterval)
+	defer keepalive.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		// Get the notification channel before querying to not miss any event recorded in between
+		available := models.RealtimeEventsAvailable()
+
+		s := db.NewSession()
+		var evts []*models.RealtimeEvent
+		evts, lastEventID, err = models.GetRealtimeEventsSince(s, a, lastEventID, listIDs)
+		s.Close()
+		if err != nil {
+			log.Errorf("Could not get realtime events: %s", err)
+			return nil
+		}
+
+		for _, e := range evts {
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Errorf("Could not marshal realtime event %d: %s", e.ID, err)
+				return nil
+			}
+			_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.EventName, data)
+			if err != nil {
+				// The client went away
+				return nil
+			}
+		}
+		if len(evts) > 0 {
+			res.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-available:
+		case <-poll.C:
+		case <-keepalive.C:
+			_, err = fmt.Fprint(res, ": keepalive\n\n")
+			if err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
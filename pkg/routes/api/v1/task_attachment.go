@@ -115,8 +115,10 @@ func UploadTaskAttachment(c echo.Context) error {
 // @Produce octet-stream
 // @Param id path int true "Task ID"
 // @Param attachmentID path int true "Attachment ID"
+// @Param preview_size query string false "If set to one of sm, md, lg or xl and the attachment is an image, a scaled down preview of at most 100, 200, 400 or 800 pixels in width and height is returned instead of the original file. If the image is already smaller than that, the original is returned."
 // @Security JWTKeyAuth
 // @Success 200 {} string "The attachment file."
+// @Failure 400 {object} web.HTTPError "Invalid preview size."
 // @Failure 403 {object} models.Message "No access to this task."
 // @Failure 404 {object} models.Message "The task does not exist."
 // @Failure 500 {object} models.Message "Internal error"
@@ -128,6 +130,15 @@ func GetTaskAttachment(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "No task ID provided")
 	}
 
+	var previewSize models.PreviewSize
+	if size := c.QueryParam("preview_size"); size != "" {
+		var valid bool
+		previewSize, valid = models.GetPreviewSizeFromString(size)
+		if !valid {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid preview size.")
+		}
+	}
+
 	// Rights check
 	auth, err := auth2.GetAuthFromClaims(c)
 	if err != nil {
@@ -153,11 +164,25 @@ func GetTaskAttachment(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	file := taskAttachment.File
+	if previewSize != "" {
+		preview, err := taskAttachment.GetPreview(s, previewSize)
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+		if preview != nil {
+			file = preview
+		}
+	}
+
 	// Open an send the file to the client
-	err = taskAttachment.File.LoadFileByID()
-	if err != nil {
-		_ = s.Rollback()
-		return handler.HandleHTTPError(err, c)
+	if file.File == nil {
+		err = file.LoadFileByID()
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
 	}
 
 	if err := s.Commit(); err != nil {
@@ -165,6 +190,6 @@ func GetTaskAttachment(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
-	http.ServeContent(c.Response(), c.Request(), taskAttachment.File.Name, taskAttachment.File.Created, taskAttachment.File.File)
+	http.ServeContent(c.Response(), c.Request(), file.Name, file.Created, file.File)
 	return nil
 }
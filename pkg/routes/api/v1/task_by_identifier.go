@@ -0,0 +1,62 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package v1
+
+import (
+	"net/http"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// GetTaskByIdentifier returns a task by its human readable identifier
+// @Summary Get a task by its identifier
+// @Description Returns the task with an identifier like `PROJ-42`, made of the identifier of its list and its index. Identifiers a task had before it was moved to another list or before the identifier of its list was changed keep working.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param identifier path string true "The task identifier, for example PROJ-42."
+// @Success 200 {object} models.Task "The task"
+// @Failure 400 {object} web.HTTPError "The identifier is invalid."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "There is no task with this identifier."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/by-identifier/{identifier} [get]
+func GetTaskByIdentifier(c echo.Context) error {
+	auth, err := auth2.GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	task, err := models.GetTaskByIdentifier(s, auth, c.Param("identifier"))
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,112 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+func parseTimeReportID(c echo.Context, name string) (int64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid "+name+".")
+	}
+	return id, nil
+}
+
+// GetTimeReport returns the time tracked on all tasks the user has access to
+// @Summary Get a time tracking report
+// @Description Returns the time tracked on all tasks the user has access to, aggregated by list, label and user. Running timers are not included.
+// @tags task
+// @Produce json
+// @Produce text/csv
+// @Security JWTKeyAuth
+// @Param list_id query int false "Only include time tracked on tasks in this list."
+// @Param label_id query int false "Only include time tracked on tasks with this label."
+// @Param user_id query int false "Only include time tracked by this user."
+// @Param from query string false "Only include time entries which started on or after this date. Either YYYY-MM-DD or RFC 3339."
+// @Param to query string false "Only include time entries which started on or before this date. Either YYYY-MM-DD or RFC 3339."
+// @Param format query string false "Set to `csv` to get the report as csv file."
+// @Success 200 {object} models.TimeReport "The time report."
+// @Failure 400 {object} web.HTTPError "Invalid filter provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the list."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /tasks/time-report [get]
+func GetTimeReport(c echo.Context) error {
+	opts := &models.TimeReportOptions{
+		From: c.QueryParam("from"),
+		To:   c.QueryParam("to"),
+	}
+
+	var err error
+	opts.ListID, err = parseTimeReportID(c, "list_id")
+	if err != nil {
+		return err
+	}
+	opts.LabelID, err = parseTimeReportID(c, "label_id")
+	if err != nil {
+		return err
+	}
+	opts.UserID, err = parseTimeReportID(c, "user_id")
+	if err != nil {
+		return err
+	}
+
+	auth, err := auth2.GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	report, err := models.GetTimeReport(s, auth, opts)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if c.QueryParam("format") != "csv" {
+		return c.JSON(http.StatusOK, report)
+	}
+
+	buf := &bytes.Buffer{}
+	err = report.WriteCSV(buf)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="time-report.csv"`)
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"time"
 
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
 
 	"code.vikunja.io/api/pkg/models"
@@ -36,6 +37,10 @@ type userWithSettings struct {
 	Settings            *UserSettings `json:"settings"`
 	DeletionScheduledAt time.Time     `json:"deletion_scheduled_at"`
 	IsLocalUser         bool          `json:"is_local_user"`
+	// The size of all files the user uploaded in bytes.
+	StorageUsage uint64 `json:"storage_usage"`
+	// The maximum size of all files the user can upload in bytes. 0 means no limit.
+	StorageQuota uint64 `json:"storage_quota"`
 }
 
 // UserShow gets all informations about the current user
@@ -63,6 +68,16 @@ func UserShow(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	storageUsage, err := files.GetStorageUsageForUser(s, u.ID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	storageQuota, err := u.GetStorageQuota()
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
 	us := &userWithSettings{
 		User: *u,
 		Settings: &UserSettings{
@@ -79,6 +94,8 @@ func UserShow(c echo.Context) error {
 		},
 		DeletionScheduledAt: u.DeletionScheduledAt,
 		IsLocalUser:         u.Issuer == user.IssuerLocal,
+		StorageUsage:        storageUsage,
+		StorageQuota:        storageQuota,
 	}
 
 	return c.JSON(http.StatusOK, us)
@@ -0,0 +1,93 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package routes
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/modules/auth"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+
+	echojwt "github.com/labstack/echo-jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+const apiRoutePrefix = "/api/v1"
+
+// setupTokenMiddleware returns the authentication middleware for all authenticated api routes.
+// It accepts both jwt tokens and api tokens as bearer token, api tokens are only allowed to access
+// routes they have the scope for.
+func setupTokenMiddleware() echo.MiddlewareFunc {
+	jwtMiddleware := echojwt.JWT([]byte(config.ServiceJWTSecret.GetString()))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		jwtNext := jwtMiddleware(next)
+		return func(c echo.Context) error {
+			authHeader := c.Request().Header.Get(echo.HeaderAuthorization)
+			if strings.HasPrefix(authHeader, "Bearer "+models.APITokenPrefix) {
+				err := checkAPITokenAndPutItInContext(strings.TrimPrefix(authHeader, "Bearer "), c)
+				if err != nil {
+					return handler.HandleHTTPError(err, c)
+				}
+				return next(c)
+			}
+
+			return jwtNext(c)
+		}
+	}
+}
+
+func checkAPITokenAndPutItInContext(tokenString string, c echo.Context) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	token, err := models.GetAPITokenByTokenString(s, tokenString)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		log.Debugf("[auth] Tried authenticating with api token %d but it expired on %s", token.ID, token.ExpiresAt.String())
+		return &models.ErrAPITokenInvalid{}
+	}
+
+	scope := models.GetAPITokenScopeForRoute(c.Request().Method, strings.TrimPrefix(c.Path(), apiRoutePrefix))
+	if scope == "" || !token.HasScope(scope) {
+		return &models.ErrAPITokenScopeMissing{Scope: scope}
+	}
+
+	u, err := user.GetUserByID(s, token.OwnerID)
+	if err != nil {
+		if user.IsErrUserDoesNotExist(err) {
+			return &models.ErrAPITokenInvalid{}
+		}
+		return err
+	}
+	if u.Status == user.StatusDisabled {
+		return &user.ErrAccountDisabled{UserID: u.ID}
+	}
+
+	c.Set("user", auth.NewUserJWTFromAPIToken(u, token))
+	c.Set("api_token", token)
+	return nil
+}
@@ -218,6 +218,11 @@ func (vcls *VikunjaCaldavListStorage) GetResource(rpath string) (*data.Resource,
 			}
 			return nil, false, err
 		}
+		// Get everything else which belongs to the task, like its reminders and checklist
+		if err := task.ReadOne(s, vcls.user); err != nil {
+			_ = s.Rollback()
+			return nil, false, err
+		}
 		if err := s.Commit(); err != nil {
 			return nil, false, err
 		}
@@ -27,6 +27,8 @@
 // @description # Authorization
 // @description **JWT-Auth:** Main authorization method, used for most of the requests. Needs `Authorization: Bearer <jwt-token>`-header to authenticate successfully.
 // @description
+// @description **API Token:** Long-lived personal access tokens created via `/user/settings/token`. Needs `Authorization: Bearer <api-token>`-header as well. API tokens can only access routes their scopes allow.
+// @description
 // @description **BasicAuth:** Only used when requesting tasks via caldav.
 // @description <!-- ReDoc-Inject: <security-definitions> -->
 // @BasePath /api/v1
@@ -78,7 +80,6 @@ import (
 
 	"github.com/getsentry/sentry-go"
 	sentryecho "github.com/getsentry/sentry-go/echo"
-	echojwt "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	elog "github.com/labstack/gommon/log"
@@ -271,8 +272,13 @@ func registerAPIRoutes(a *echo.Group) {
 		ur.POST("/shares/:share/auth", apiv1.AuthenticateLinkShare)
 	}
 
+	// Calendar feeds, authenticated by the token in their url
+	n.GET("/feeds/:token/lists/:list", apiv1.GetListFeed)
+	n.GET("/feeds/:token/filters/:filter", apiv1.GetSavedFilterFeed)
+	n.GET("/feeds/:token/assigned.ics", apiv1.GetAssignedTasksFeed)
+
 	// ===== Routes with Authentication =====
-	a.Use(echojwt.JWT([]byte(config.ServiceJWTSecret.GetString())))
+	a.Use(setupTokenMiddleware())
 
 	// Rate limit
 	setupRateLimit(a, config.RateLimitKind.GetString())
@@ -282,6 +288,9 @@ func registerAPIRoutes(a *echo.Group) {
 
 	a.POST("/tokenTest", apiv1.CheckToken)
 
+	// Realtime events
+	a.GET("/events", apiv1.StreamEvents)
+
 	// User stuff
 	u := a.Group("/user")
 
@@ -300,6 +309,19 @@ func registerAPIRoutes(a *echo.Group) {
 	u.PUT("/settings/token/caldav", apiv1.GenerateCaldavToken)
 	u.GET("/settings/token/caldav", apiv1.GetCaldavTokens)
 	u.DELETE("/settings/token/caldav/:id", apiv1.DeleteCaldavToken)
+	u.PUT("/settings/token/feeds", apiv1.GenerateFeedToken)
+	u.GET("/settings/token/feeds", apiv1.GetFeedTokens)
+	u.DELETE("/settings/token/feeds/:id", apiv1.DeleteFeedToken)
+
+	apiTokenHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.APIToken{}
+		},
+	}
+	u.GET("/settings/token", apiTokenHandler.ReadAllWeb)
+	u.PUT("/settings/token", apiTokenHandler.CreateWeb)
+	u.DELETE("/settings/token/:token", apiTokenHandler.DeleteWeb)
+	u.GET("/settings/token/scopes", apiv1.GetAvailableAPITokenScopes)
 
 	if config.ServiceEnableTotp.GetBool() {
 		u.GET("/settings/totp", apiv1.UserTOTP)
@@ -347,6 +369,13 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.GET("/lists/:list/tasks", taskCollectionHandler.ReadAllWeb)
 
+	listScheduleHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListSchedule{}
+		},
+	}
+	a.GET("/lists/:list/schedule", listScheduleHandler.ReadOneWeb)
+
 	kanbanBucketHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Bucket{}
@@ -357,6 +386,17 @@ func registerAPIRoutes(a *echo.Group) {
 	a.POST("/lists/:list/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
 	a.DELETE("/lists/:list/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
 
+	customFieldHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.CustomField{}
+		},
+	}
+	a.GET("/lists/:list/custom-fields", customFieldHandler.ReadAllWeb)
+	a.PUT("/lists/:list/custom-fields", customFieldHandler.CreateWeb)
+	a.GET("/lists/:list/custom-fields/:customfield", customFieldHandler.ReadOneWeb)
+	a.POST("/lists/:list/custom-fields/:customfield", customFieldHandler.UpdateWeb)
+	a.DELETE("/lists/:list/custom-fields/:customfield", customFieldHandler.DeleteWeb)
+
 	listDuplicateHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.ListDuplicate{}
@@ -371,6 +411,7 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.PUT("/lists/:list", taskHandler.CreateWeb)
 	a.GET("/tasks/:listtask", taskHandler.ReadOneWeb)
+	a.GET("/tasks/by-identifier/:identifier", apiv1.GetTaskByIdentifier)
 	a.GET("/tasks/all", taskCollectionHandler.ReadAllWeb)
 	a.DELETE("/tasks/:listtask", taskHandler.DeleteWeb)
 	a.POST("/tasks/:listtask", taskHandler.UpdateWeb)
@@ -382,6 +423,13 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.POST("/tasks/bulk", bulkTaskHandler.UpdateWeb)
 
+	quickAddHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.QuickAddTasks{}
+		},
+	}
+	a.PUT("/tasks/quick-add", quickAddHandler.CreateWeb)
+
 	assigneeTaskHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.TaskAssginee{}
@@ -447,6 +495,64 @@ func registerAPIRoutes(a *echo.Group) {
 		a.GET("/tasks/:task/comments/:commentid", taskCommentHandler.ReadOneWeb)
 	}
 
+	taskActivityHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskActivity{}
+		},
+	}
+	a.GET("/tasks/:task/activity", taskActivityHandler.ReadAllWeb)
+
+	taskRevisionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskRevision{}
+		},
+	}
+	a.GET("/tasks/:task/revisions", taskRevisionHandler.ReadAllWeb)
+	a.GET("/tasks/:task/revisions/:revision", taskRevisionHandler.ReadOneWeb)
+	taskRevisionDiffHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskRevisionDiff{}
+		},
+	}
+	a.GET("/tasks/:task/revisions/:revision/diff", taskRevisionDiffHandler.ReadOneWeb)
+	taskRevisionRestoreHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskRevisionRestore{}
+		},
+	}
+	a.PUT("/tasks/:task/revisions/:revision/restore", taskRevisionRestoreHandler.CreateWeb)
+
+	timeEntryHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TimeEntry{}
+		},
+	}
+	a.GET("/tasks/:task/time-entries", timeEntryHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/time-entries", timeEntryHandler.CreateWeb)
+	a.GET("/tasks/:task/time-entries/:timeentry", timeEntryHandler.ReadOneWeb)
+	a.POST("/tasks/:task/time-entries/:timeentry", timeEntryHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/time-entries/:timeentry", timeEntryHandler.DeleteWeb)
+	taskTimerHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTimer{}
+		},
+	}
+	a.GET("/tasks/:task/timer", taskTimerHandler.ReadOneWeb)
+	a.PUT("/tasks/:task/timer", taskTimerHandler.CreateWeb)
+	a.POST("/tasks/:task/timer", taskTimerHandler.UpdateWeb)
+	a.GET("/tasks/time-report", apiv1.GetTimeReport)
+
+	checklistItemHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskChecklistItem{}
+		},
+	}
+	a.GET("/tasks/:task/checklist-items", checklistItemHandler.ReadAllWeb)
+	a.PUT("/tasks/:task/checklist-items", checklistItemHandler.CreateWeb)
+	a.GET("/tasks/:task/checklist-items/:checklistitem", checklistItemHandler.ReadOneWeb)
+	a.POST("/tasks/:task/checklist-items/:checklistitem", checklistItemHandler.UpdateWeb)
+	a.DELETE("/tasks/:task/checklist-items/:checklistitem", checklistItemHandler.DeleteWeb)
+
 	labelHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Label{}
@@ -488,6 +594,31 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/filters/:filter", savedFiltersHandler.DeleteWeb)
 	a.POST("/filters/:filter", savedFiltersHandler.UpdateWeb)
 
+	savedFilterScheduleHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.SavedFilterSchedule{}
+		},
+	}
+	a.GET("/filters/:filter/schedule", savedFilterScheduleHandler.ReadOneWeb)
+
+	taskTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTemplate{}
+		},
+	}
+	a.GET("/tasktemplates", taskTemplateHandler.ReadAllWeb)
+	a.PUT("/tasktemplates", taskTemplateHandler.CreateWeb)
+	a.GET("/tasktemplates/:tasktemplate", taskTemplateHandler.ReadOneWeb)
+	a.POST("/tasktemplates/:tasktemplate", taskTemplateHandler.UpdateWeb)
+	a.DELETE("/tasktemplates/:tasktemplate", taskTemplateHandler.DeleteWeb)
+
+	taskFromTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskFromTemplate{}
+		},
+	}
+	a.PUT("/tasktemplates/:tasktemplate/tasks", taskFromTemplateHandler.CreateWeb)
+
 	namespaceHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Namespace{}
@@ -500,6 +631,31 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/namespaces/:namespace", namespaceHandler.DeleteWeb)
 	a.GET("/namespaces/:namespace/lists", apiv1.GetListsByNamespaceID)
 
+	trashHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TrashItem{}
+		},
+	}
+	a.GET("/trash", trashHandler.ReadAllWeb)
+	taskRestoreHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskRestore{}
+		},
+	}
+	a.PUT("/tasks/:task/restore", taskRestoreHandler.CreateWeb)
+	listRestoreHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ListRestore{}
+		},
+	}
+	a.PUT("/lists/:list/restore", listRestoreHandler.CreateWeb)
+	namespaceRestoreHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.NamespaceRestore{}
+		},
+	}
+	a.PUT("/namespaces/:namespace/restore", namespaceRestoreHandler.CreateWeb)
+
 	namespaceTeamHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.TeamNamespace{}
@@ -558,6 +714,31 @@ func registerAPIRoutes(a *echo.Group) {
 	a.GET("/notifications", notificationHandler.ReadAllWeb)
 	a.POST("/notifications/:notificationid", notificationHandler.UpdateWeb)
 
+	// Webhooks
+	if config.WebhooksEnabled.GetBool() {
+		webhookHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.Webhook{}
+			},
+		}
+		webhookDeliveryHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.WebhookDelivery{}
+			},
+		}
+		a.GET("/webhooks/events", apiv1.GetAvailableWebhookEvents)
+		a.GET("/lists/:list/webhooks", webhookHandler.ReadAllWeb)
+		a.PUT("/lists/:list/webhooks", webhookHandler.CreateWeb)
+		a.POST("/lists/:list/webhooks/:webhook", webhookHandler.UpdateWeb)
+		a.DELETE("/lists/:list/webhooks/:webhook", webhookHandler.DeleteWeb)
+		a.GET("/lists/:list/webhooks/:webhook/deliveries", webhookDeliveryHandler.ReadAllWeb)
+		a.GET("/namespaces/:namespace/webhooks", webhookHandler.ReadAllWeb)
+		a.PUT("/namespaces/:namespace/webhooks", webhookHandler.CreateWeb)
+		a.POST("/namespaces/:namespace/webhooks/:webhook", webhookHandler.UpdateWeb)
+		a.DELETE("/namespaces/:namespace/webhooks/:webhook", webhookHandler.DeleteWeb)
+		a.GET("/namespaces/:namespace/webhooks/:webhook/deliveries", webhookDeliveryHandler.ReadAllWeb)
+	}
+
 	// Migrations
 	m := a.Group("/migration")
 	registerMigrations(m)
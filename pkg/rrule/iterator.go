@@ -0,0 +1,377 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rrule
+
+import (
+	"time"
+)
+
+// maxEmptyPeriods is the number of consecutive periods without any occurrence after which the iteration stops.
+// This prevents endless loops for rules which can never match, like the 30th of february.
+const maxEmptyPeriods = 100000
+
+// Iterator returns the occurrences of a rule one by one
+type Iterator struct {
+	rule    *Rule
+	dtstart time.Time
+	period  time.Time
+	buffer  []time.Time
+	yielded int
+	done    bool
+}
+
+// Iterate returns an iterator over all occurrences of the rule after dtstart.
+// As in RFC 5545, dtstart itself always counts as the first occurrence and is included in Count.
+// All occurrences are computed in the location of dtstart.
+func (r *Rule) Iterate(dtstart time.Time) *Iterator {
+	effective := *r
+	if effective.Interval < 1 {
+		effective.Interval = 1
+	}
+
+	// Rules without any day part repeat on the day of the start date
+	if len(effective.ByWeekNo)+len(effective.ByYearDay)+len(effective.ByMonthDay)+len(effective.ByDay) == 0 {
+		switch effective.Freq {
+		case Yearly:
+			if len(effective.ByMonth) == 0 {
+				effective.ByMonth = []int{int(dtstart.Month())}
+			}
+			effective.ByMonthDay = []int{dtstart.Day()}
+		case Monthly:
+			effective.ByMonthDay = []int{dtstart.Day()}
+		case Weekly:
+			effective.ByDay = []Weekday{{Day: dtstart.Weekday()}}
+		}
+	}
+
+	// The same goes for the time of the day
+	if effective.Freq > Hourly && len(effective.ByHour) == 0 {
+		effective.ByHour = []int{dtstart.Hour()}
+	}
+	if effective.Freq > Minutely && len(effective.ByMinute) == 0 {
+		effective.ByMinute = []int{dtstart.Minute()}
+	}
+	if effective.Freq > Secondly && len(effective.BySecond) == 0 {
+		effective.BySecond = []int{dtstart.Second()}
+	}
+
+	return &Iterator{
+		rule:    &effective,
+		dtstart: dtstart,
+		period:  effective.periodStart(dtstart),
+	}
+}
+
+// Next returns the next occurrence of the rule. Once there are no more occurrences, the second return value is false.
+func (it *Iterator) Next() (time.Time, bool) {
+	if it.rule.Count > 0 && it.yielded >= it.rule.Count-1 {
+		it.done = true
+	}
+	if it.done {
+		return time.Time{}, false
+	}
+
+	emptyPeriods := 0
+	for len(it.buffer) == 0 {
+		if emptyPeriods >= maxEmptyPeriods || it.period.Year() > 9999 {
+			it.done = true
+			return time.Time{}, false
+		}
+
+		it.buffer = it.occurrencesInPeriod()
+		it.nextPeriod()
+		emptyPeriods++
+	}
+
+	next := it.buffer[0]
+	it.buffer = it.buffer[1:]
+
+	if !it.rule.Until.IsZero() && it.isAfterUntil(next) {
+		it.done = true
+		it.buffer = nil
+		return time.Time{}, false
+	}
+
+	it.yielded++
+	return next, true
+}
+
+func (it *Iterator) isAfterUntil(t time.Time) bool {
+	if it.rule.UntilIsDate {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).After(it.rule.Until)
+	}
+	return t.After(it.rule.Until)
+}
+
+func (r *Rule) periodStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+
+	switch r.Freq {
+	case Yearly:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case Weekly:
+		offset := (int(t.Weekday()) - int(r.WeekStart) + 7) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case Daily:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case Hourly:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, loc)
+	case Minutely:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+	default:
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	}
+}
+
+func (it *Iterator) nextPeriod() {
+	n := it.rule.Interval
+	y, m, d := it.period.Date()
+	loc := it.period.Location()
+
+	switch it.rule.Freq {
+	case Yearly:
+		it.period = time.Date(y+n, 1, 1, 0, 0, 0, 0, loc)
+	case Monthly:
+		it.period = time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, loc)
+	case Weekly:
+		it.period = time.Date(y, m, d+7*n, 0, 0, 0, 0, loc)
+	case Daily:
+		it.period = time.Date(y, m, d+n, 0, 0, 0, 0, loc)
+	case Hourly:
+		it.period = it.period.Add(time.Duration(n) * time.Hour)
+	case Minutely:
+		it.period = it.period.Add(time.Duration(n) * time.Minute)
+	default:
+		it.period = it.period.Add(time.Duration(n) * time.Second)
+	}
+}
+
+// occurrencesInPeriod returns all occurrences in the current period which are after dtstart.
+func (it *Iterator) occurrencesInPeriod() []time.Time {
+	r := it.rule
+	p := it.period
+	loc := p.Location()
+
+	days := []time.Time{p}
+	hours, minutes, seconds := r.ByHour, r.ByMinute, r.BySecond
+
+	if r.Freq >= Daily {
+		days = r.daysInPeriod(p)
+	} else {
+		// For frequencies smaller than a day, the by rules of the larger units only limit the occurrences
+		if len(r.ByHour) > 0 && !containsInt(r.ByHour, p.Hour()) {
+			return nil
+		}
+		hours = []int{p.Hour()}
+		if r.Freq <= Minutely {
+			if len(r.ByMinute) > 0 && !containsInt(r.ByMinute, p.Minute()) {
+				return nil
+			}
+			minutes = []int{p.Minute()}
+		}
+		if r.Freq == Secondly {
+			if len(r.BySecond) > 0 && !containsInt(r.BySecond, p.Second()) {
+				return nil
+			}
+			seconds = []int{p.Second()}
+		}
+	}
+
+	candidates := []time.Time{}
+	for _, day := range days {
+		if !r.dayMatches(day) {
+			continue
+		}
+		y, m, d := day.Date()
+		for _, h := range hours {
+			for _, mi := range minutes {
+				for _, s := range seconds {
+					candidates = append(candidates, time.Date(y, m, d, h, mi, s, 0, loc))
+				}
+			}
+		}
+	}
+
+	candidates = sortAndDeduplicate(candidates)
+
+	if len(r.BySetPos) > 0 {
+		selected := []time.Time{}
+		for _, pos := range r.BySetPos {
+			i := pos - 1
+			if pos < 0 {
+				i = len(candidates) + pos
+			}
+			if i >= 0 && i < len(candidates) {
+				selected = append(selected, candidates[i])
+			}
+		}
+		candidates = sortAndDeduplicate(selected)
+	}
+
+	occurrences := []time.Time{}
+	for _, c := range candidates {
+		if c.After(it.dtstart) {
+			occurrences = append(occurrences, c)
+		}
+	}
+
+	return occurrences
+}
+
+func sortAndDeduplicate(times []time.Time) []time.Time {
+	sortTimes(times)
+	unique := times[:0]
+	for i, t := range times {
+		if i > 0 && t.Equal(times[i-1]) {
+			continue
+		}
+		unique = append(unique, t)
+	}
+	return unique
+}
+
+func (r *Rule) daysInPeriod(p time.Time) []time.Time {
+	y, m, d := p.Date()
+
+	count := 1
+	switch r.Freq {
+	case Yearly:
+		count = daysInYear(y)
+	case Monthly:
+		count = daysInMonth(y, m)
+	case Weekly:
+		count = 7
+	}
+
+	days := make([]time.Time, count)
+	for i := range days {
+		days[i] = time.Date(y, m, d+i, 0, 0, 0, 0, p.Location())
+	}
+	return days
+}
+
+func (r *Rule) dayMatches(day time.Time) bool {
+	y, m, d := day.Date()
+
+	if len(r.ByMonth) > 0 && !containsInt(r.ByMonth, int(m)) {
+		return false
+	}
+
+	if len(r.ByWeekNo) > 0 && !r.weekNoMatches(day) {
+		return false
+	}
+
+	if len(r.ByYearDay) > 0 {
+		yd := day.YearDay()
+		if !containsInt(r.ByYearDay, yd) && !containsInt(r.ByYearDay, yd-daysInYear(y)-1) {
+			return false
+		}
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		if !containsInt(r.ByMonthDay, d) && !containsInt(r.ByMonthDay, d-daysInMonth(y, m)-1) {
+			return false
+		}
+	}
+
+	if len(r.ByDay) > 0 && !r.weekdayMatches(day) {
+		return false
+	}
+
+	return true
+}
+
+func (r *Rule) weekdayMatches(day time.Time) bool {
+	y, m, d := day.Date()
+
+	for _, wd := range r.ByDay {
+		if wd.Day != day.Weekday() {
+			continue
+		}
+		if wd.N == 0 {
+			return true
+		}
+
+		// Numeric values refer to the nth weekday of the month or year
+		var pos, fromEnd int
+		if r.Freq == Monthly || len(r.ByMonth) > 0 {
+			pos = (d-1)/7 + 1
+			fromEnd = -((daysInMonth(y, m)-d)/7 + 1)
+		} else {
+			yd := day.YearDay()
+			pos = (yd-1)/7 + 1
+			fromEnd = -((daysInYear(y)-yd)/7 + 1)
+		}
+		if wd.N == pos || wd.N == fromEnd {
+			return true
+		}
+	}
+
+	return false
+}
+
+// firstWeekStart returns the start of the first week of a year. As in ISO 8601,
+// the first week is the first one with at least four days in that year.
+func (r *Rule) firstWeekStart(year int) time.Time {
+	jan1 := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan1.Weekday()) - int(r.WeekStart) + 7) % 7
+	start := jan1.AddDate(0, 0, -offset)
+	if offset > 3 {
+		start = start.AddDate(0, 0, 7)
+	}
+	return start
+}
+
+func (r *Rule) weekNoMatches(day time.Time) bool {
+	y, m, d := day.Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	weeksBetween := func(from, to time.Time) int {
+		return int(to.Sub(from).Hours()/24) / 7
+	}
+
+	start, next := r.firstWeekStart(y), r.firstWeekStart(y+1)
+
+	var week, total int
+	switch {
+	case date.Before(start):
+		// The day belongs to the last week of the previous year
+		total = weeksBetween(r.firstWeekStart(y-1), start)
+		week = total
+	case !date.Before(next):
+		// The day belongs to the first week of the next year
+		total = weeksBetween(next, r.firstWeekStart(y+2))
+		week = 1
+	default:
+		total = weeksBetween(start, next)
+		week = weeksBetween(start, date) + 1
+	}
+
+	return containsInt(r.ByWeekNo, week) || containsInt(r.ByWeekNo, week-total-1)
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysInYear(year int) int {
+	return time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+}
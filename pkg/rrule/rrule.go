@@ -0,0 +1,388 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package rrule implements parsing and evaluation of RFC 5545 recurrence rules.
+package rrule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a recurrence rule
+type Frequency int
+
+// All frequencies a recurrence rule can have, ordered from the smallest to the largest
+const (
+	Secondly Frequency = iota
+	Minutely
+	Hourly
+	Daily
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencyNames = map[Frequency]string{
+	Secondly: "SECONDLY",
+	Minutely: "MINUTELY",
+	Hourly:   "HOURLY",
+	Daily:    "DAILY",
+	Weekly:   "WEEKLY",
+	Monthly:  "MONTHLY",
+	Yearly:   "YEARLY",
+}
+
+func (f Frequency) String() string {
+	return frequencyNames[f]
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// Weekday is a day of the week with an optional ordinal, for example 2MO for
+// the second monday or -1FR for the last friday of a month or year.
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+func (w Weekday) String() string {
+	if w.N == 0 {
+		return weekdayNames[w.Day]
+	}
+	return strconv.Itoa(w.N) + weekdayNames[w.Day]
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq     Frequency
+	Interval int
+	// Count is the total number of occurrences, including the first one.
+	Count int
+	Until time.Time
+	// UntilIsDate is true if Until only holds a date and all occurrences on that date are included.
+	UntilIsDate bool
+	WeekStart   time.Weekday
+
+	BySecond   []int
+	ByMinute   []int
+	ByHour     []int
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByYearDay  []int
+	ByWeekNo   []int
+	ByMonth    []int
+	BySetPos   []int
+}
+
+// ErrInvalidRule is returned when a recurrence rule can't be parsed
+type ErrInvalidRule struct {
+	Rule   string
+	Reason string
+}
+
+func (err *ErrInvalidRule) Error() string {
+	return fmt.Sprintf("invalid recurrence rule %q: %s", err.Rule, err.Reason)
+}
+
+const (
+	untilFormat     = `20060102T150405Z`
+	untilFormatDate = `20060102`
+)
+
+// Parse parses the value of an RRULE property. A leading "RRULE:" is ignored.
+func Parse(rule string) (r *Rule, err error) {
+	raw := rule
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	invalid := func(reason string) (*Rule, error) {
+		return nil, &ErrInvalidRule{Rule: raw, Reason: reason}
+	}
+
+	if rule == "" {
+		return invalid("rule is empty")
+	}
+
+	r = &Rule{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+
+	hasFreq := false
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return invalid("malformed part " + part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			found := false
+			for f, name := range frequencyNames {
+				if name == value {
+					r.Freq = f
+					found = true
+				}
+			}
+			if !found {
+				return invalid("unknown frequency " + value)
+			}
+			hasFreq = true
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return invalid("interval must be a positive number")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return invalid("count must be a positive number")
+			}
+		case "UNTIL":
+			switch {
+			case len(value) == len(untilFormatDate):
+				r.Until, err = time.Parse(untilFormatDate, value)
+				r.UntilIsDate = true
+			case strings.HasSuffix(value, "Z"):
+				r.Until, err = time.Parse(untilFormat, value)
+			default:
+				r.Until, err = time.Parse(untilFormat[:len(untilFormat)-1], value)
+			}
+			if err != nil {
+				return invalid("until is not a valid date")
+			}
+		case "WKST":
+			day, ok := parseWeekdayName(value)
+			if !ok {
+				return invalid("unknown week start " + value)
+			}
+			r.WeekStart = day
+		case "BYSECOND":
+			r.BySecond, err = parseIntList(value, 0, 60, false)
+		case "BYMINUTE":
+			r.ByMinute, err = parseIntList(value, 0, 59, false)
+		case "BYHOUR":
+			r.ByHour, err = parseIntList(value, 0, 23, false)
+		case "BYDAY":
+			r.ByDay, err = parseWeekdayList(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseIntList(value, 1, 31, true)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseIntList(value, 1, 366, true)
+		case "BYWEEKNO":
+			r.ByWeekNo, err = parseIntList(value, 1, 53, true)
+		case "BYMONTH":
+			r.ByMonth, err = parseIntList(value, 1, 12, false)
+		case "BYSETPOS":
+			r.BySetPos, err = parseIntList(value, 1, 366, true)
+		default:
+			return invalid("unknown part " + key)
+		}
+		if err != nil {
+			return invalid(err.Error())
+		}
+	}
+
+	if !hasFreq {
+		return invalid("freq is required")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return invalid("count and until can't be used together")
+	}
+	if r.Freq != Yearly && len(r.ByWeekNo) > 0 {
+		return invalid("byweekno can only be used with a yearly frequency")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return invalid("bymonthday can't be used with a weekly frequency")
+	}
+	if (r.Freq == Daily || r.Freq == Weekly || r.Freq == Monthly) && len(r.ByYearDay) > 0 {
+		return invalid("byyearday can't be used with a daily, weekly or monthly frequency")
+	}
+	for _, d := range r.ByDay {
+		if d.N != 0 && r.Freq != Monthly && r.Freq != Yearly {
+			return invalid("numeric byday values can only be used with a monthly or yearly frequency")
+		}
+		if d.N != 0 && r.Freq == Yearly && len(r.ByWeekNo) > 0 {
+			return invalid("numeric byday values can't be used with byweekno")
+		}
+	}
+	if len(r.BySetPos) > 0 &&
+		len(r.BySecond)+len(r.ByMinute)+len(r.ByHour)+len(r.ByDay)+len(r.ByMonthDay)+
+			len(r.ByYearDay)+len(r.ByWeekNo)+len(r.ByMonth) == 0 {
+		return invalid("bysetpos needs another by rule")
+	}
+
+	return r, nil
+}
+
+func parseWeekdayName(name string) (time.Weekday, bool) {
+	for day, n := range weekdayNames {
+		if n == name {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func parseIntList(value string, min, max int, allowNegative bool) (list []int, err error) {
+	for _, v := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", v)
+		}
+		abs := i
+		if abs < 0 && allowNegative {
+			abs = -abs
+		}
+		if abs < min || abs > max || (allowNegative && i == 0) {
+			return nil, fmt.Errorf("%d is out of range", i)
+		}
+		list = append(list, i)
+	}
+	return
+}
+
+func parseWeekdayList(value string) (list []Weekday, err error) {
+	for _, v := range strings.Split(value, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("%s is not a weekday", v)
+		}
+		day, ok := parseWeekdayName(v[len(v)-2:])
+		if !ok {
+			return nil, fmt.Errorf("%s is not a weekday", v)
+		}
+		wd := Weekday{Day: day}
+		if len(v) > 2 {
+			wd.N, err = strconv.Atoi(v[:len(v)-2])
+			if err != nil || wd.N == 0 || wd.N > 53 || wd.N < -53 {
+				return nil, fmt.Errorf("%s is not a weekday", v)
+			}
+		}
+		list = append(list, wd)
+	}
+	return
+}
+
+func formatIntList(list []int) string {
+	parts := make([]string, 0, len(list))
+	for _, i := range list {
+		parts = append(parts, strconv.Itoa(i))
+	}
+	return strings.Join(parts, ",")
+}
+
+// IsSimple returns whether the rule only consists of a frequency and an interval.
+func (r *Rule) IsSimple() bool {
+	return r.Count == 0 &&
+		r.Until.IsZero() &&
+		len(r.BySecond) == 0 &&
+		len(r.ByMinute) == 0 &&
+		len(r.ByHour) == 0 &&
+		len(r.ByDay) == 0 &&
+		len(r.ByMonthDay) == 0 &&
+		len(r.ByYearDay) == 0 &&
+		len(r.ByWeekNo) == 0 &&
+		len(r.ByMonth) == 0 &&
+		len(r.BySetPos) == 0
+}
+
+// String returns the rule in its RRULE property value form, without the "RRULE:" prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq.String()}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilIsDate {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilFormatDate))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilFormat))
+		}
+	}
+
+	intLists := []struct {
+		name string
+		list []int
+	}{
+		{"BYSECOND", r.BySecond},
+		{"BYMINUTE", r.ByMinute},
+		{"BYHOUR", r.ByHour},
+	}
+	for _, l := range intLists {
+		if len(l.list) > 0 {
+			parts = append(parts, l.name+"="+formatIntList(l.list))
+		}
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, d := range r.ByDay {
+			days = append(days, d.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	intLists = []struct {
+		name string
+		list []int
+	}{
+		{"BYMONTHDAY", r.ByMonthDay},
+		{"BYYEARDAY", r.ByYearDay},
+		{"BYWEEKNO", r.ByWeekNo},
+		{"BYMONTH", r.ByMonth},
+		{"BYSETPOS", r.BySetPos},
+	}
+	for _, l := range intLists {
+		if len(l.list) > 0 {
+			parts = append(parts, l.name+"="+formatIntList(l.list))
+		}
+	}
+
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+
+	return strings.Join(parts, ";")
+}
+
+func containsInt(list []int, i int) bool {
+	for _, l := range list {
+		if l == i {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTimes(times []time.Time) {
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+}
@@ -0,0 +1,201 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		r, err := Parse("FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH")
+		assert.NoError(t, err)
+		assert.Equal(t, Weekly, r.Freq)
+		assert.Equal(t, 2, r.Interval)
+		assert.Equal(t, []Weekday{{Day: time.Monday}, {Day: time.Thursday}}, r.ByDay)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", r.String())
+	})
+	t.Run("with prefix and lowercase", func(t *testing.T) {
+		r, err := Parse("RRULE:freq=monthly;byday=-1fr")
+		assert.NoError(t, err)
+		assert.Equal(t, []Weekday{{Day: time.Friday, N: -1}}, r.ByDay)
+		assert.Equal(t, "FREQ=MONTHLY;BYDAY=-1FR", r.String())
+	})
+	t.Run("until", func(t *testing.T) {
+		r, err := Parse("FREQ=DAILY;UNTIL=20221031T120000Z")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2022, 10, 31, 12, 0, 0, 0, time.UTC), r.Until)
+		assert.Equal(t, "FREQ=DAILY;UNTIL=20221031T120000Z", r.String())
+	})
+	t.Run("until date", func(t *testing.T) {
+		r, err := Parse("FREQ=DAILY;UNTIL=20221031")
+		assert.NoError(t, err)
+		assert.True(t, r.UntilIsDate)
+		assert.Equal(t, "FREQ=DAILY;UNTIL=20221031", r.String())
+	})
+	t.Run("all parts", func(t *testing.T) {
+		rule := "FREQ=YEARLY;COUNT=10;BYSECOND=0;BYMINUTE=30;BYHOUR=8,20;BYDAY=MO;BYWEEKNO=1,-1;BYMONTH=1,12;BYSETPOS=1;WKST=SU"
+		r, err := Parse(rule)
+		assert.NoError(t, err)
+		assert.Equal(t, rule, r.String())
+	})
+	t.Run("simple", func(t *testing.T) {
+		r, err := Parse("FREQ=DAILY;INTERVAL=3")
+		assert.NoError(t, err)
+		assert.True(t, r.IsSimple())
+		r, err = Parse("FREQ=DAILY;COUNT=3")
+		assert.NoError(t, err)
+		assert.False(t, r.IsSimple())
+		r, err = Parse("FREQ=WEEKLY;BYDAY=MO")
+		assert.NoError(t, err)
+		assert.False(t, r.IsSimple())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		rules := []string{
+			"",
+			"INTERVAL=2",
+			"FREQ=FORTNIGHTLY",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;COUNT=2;UNTIL=20221031",
+			"FREQ=DAILY;UNTIL=tomorrow",
+			"FREQ=DAILY;BYHOUR=24",
+			"FREQ=DAILY;BYMONTHDAY=0",
+			"FREQ=DAILY;BYDAY=XY",
+			"FREQ=WEEKLY;BYDAY=1MO",
+			"FREQ=MONTHLY;BYWEEKNO=1",
+			"FREQ=WEEKLY;BYMONTHDAY=1",
+			"FREQ=MONTHLY;BYYEARDAY=1",
+			"FREQ=MONTHLY;BYSETPOS=1",
+			"FREQ=DAILY;FOO=BAR",
+			"FREQ=DAILY;INTERVAL",
+		}
+		for _, rule := range rules {
+			_, err := Parse(rule)
+			assert.Error(t, err, rule)
+		}
+	})
+}
+
+func TestRule_Iterate(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	assertOccurrences := func(t *testing.T, rule string, dtstart time.Time, expected []time.Time, ends bool) {
+		r, err := Parse(rule)
+		assert.NoError(t, err)
+		it := r.Iterate(dtstart)
+		for _, e := range expected {
+			next, ok := it.Next()
+			assert.True(t, ok)
+			assert.Equal(t, e, next)
+		}
+		if ends {
+			_, ok := it.Next()
+			assert.False(t, ok)
+		}
+	}
+
+	t.Run("every weekday", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", date(2022, 10, 7, 9, 0), []time.Time{
+			date(2022, 10, 10, 9, 0),
+			date(2022, 10, 11, 9, 0),
+			date(2022, 10, 12, 9, 0),
+			date(2022, 10, 13, 9, 0),
+			date(2022, 10, 14, 9, 0),
+			date(2022, 10, 17, 9, 0),
+		}, false)
+	})
+	t.Run("last friday of the month", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=MONTHLY;BYDAY=-1FR", date(2022, 10, 28, 10, 0), []time.Time{
+			date(2022, 11, 25, 10, 0),
+			date(2022, 12, 30, 10, 0),
+			date(2023, 1, 27, 10, 0),
+		}, false)
+	})
+	t.Run("every 2 weeks on monday and thursday", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", date(2022, 10, 3, 8, 0), []time.Time{
+			date(2022, 10, 6, 8, 0),
+			date(2022, 10, 17, 8, 0),
+			date(2022, 10, 20, 8, 0),
+			date(2022, 10, 31, 8, 0),
+		}, false)
+	})
+	t.Run("last weekday of the month", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", date(2022, 10, 31, 12, 0), []time.Time{
+			date(2022, 11, 30, 12, 0),
+			date(2022, 12, 30, 12, 0),
+		}, false)
+	})
+	t.Run("monthly skips months without the day", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=MONTHLY", date(2022, 1, 31, 12, 0), []time.Time{
+			date(2022, 3, 31, 12, 0),
+			date(2022, 5, 31, 12, 0),
+		}, false)
+	})
+	t.Run("yearly on a leap day", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", date(2020, 2, 29, 0, 0), []time.Time{
+			date(2024, 2, 29, 0, 0),
+			date(2028, 2, 29, 0, 0),
+		}, false)
+	})
+	t.Run("week number", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=YEARLY;BYWEEKNO=1;BYDAY=MO", date(2022, 1, 3, 0, 0), []time.Time{
+			date(2023, 1, 2, 0, 0),
+			date(2024, 1, 1, 0, 0),
+		}, false)
+	})
+	t.Run("hourly", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=HOURLY;INTERVAL=8", date(2022, 10, 1, 1, 30), []time.Time{
+			date(2022, 10, 1, 9, 30),
+			date(2022, 10, 1, 17, 30),
+			date(2022, 10, 2, 1, 30),
+		}, false)
+	})
+	t.Run("count", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=DAILY;COUNT=3", date(2022, 10, 1, 0, 0), []time.Time{
+			date(2022, 10, 2, 0, 0),
+			date(2022, 10, 3, 0, 0),
+		}, true)
+	})
+	t.Run("until", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=DAILY;UNTIL=20221003T000000Z", date(2022, 10, 1, 0, 0), []time.Time{
+			date(2022, 10, 2, 0, 0),
+			date(2022, 10, 3, 0, 0),
+		}, true)
+	})
+	t.Run("until date", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=DAILY;UNTIL=20221003", date(2022, 10, 1, 12, 0), []time.Time{
+			date(2022, 10, 2, 12, 0),
+			date(2022, 10, 3, 12, 0),
+		}, true)
+	})
+	t.Run("impossible rule", func(t *testing.T) {
+		assertOccurrences(t, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", date(2022, 1, 1, 0, 0), []time.Time{}, true)
+	})
+	t.Run("keeps the local time across daylight saving changes", func(t *testing.T) {
+		loc, err := time.LoadLocation("Europe/Berlin")
+		assert.NoError(t, err)
+		assertOccurrences(t, "FREQ=DAILY", time.Date(2022, 10, 29, 9, 0, 0, 0, loc), []time.Time{
+			time.Date(2022, 10, 30, 9, 0, 0, 0, loc),
+			time.Date(2022, 10, 31, 9, 0, 0, 0, loc),
+		}, false)
+	})
+}
@@ -479,3 +479,28 @@ func (err *ErrAccountIsNotLocal) HTTPError() web.HTTPError {
 		Message:  "This account is managed by a third-party authentication provider.",
 	}
 }
+
+// ErrInvalidFeedToken represents a "InvalidFeedToken" kind of error.
+type ErrInvalidFeedToken struct{}
+
+// IsErrInvalidFeedToken checks if an error is a ErrInvalidFeedToken.
+func IsErrInvalidFeedToken(err error) bool {
+	_, ok := err.(*ErrInvalidFeedToken)
+	return ok
+}
+
+func (err *ErrInvalidFeedToken) Error() string {
+	return "Invalid feed token"
+}
+
+// ErrCodeInvalidFeedToken holds the unique world-error code of this error
+const ErrCodeInvalidFeedToken = 1022
+
+// HTTPError holds the http error description
+func (err *ErrInvalidFeedToken) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeInvalidFeedToken,
+		Message:  "This feed does not exist or its token was revoked.",
+	}
+}
@@ -0,0 +1,81 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package user
+
+import (
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/utils"
+
+	"xorm.io/xorm"
+)
+
+// Feed tokens are part of the feed urls. Only a digest of them is saved which, other than a password hash, can be
+// used to look them up. This is fine because the tokens are long and random.
+func hashFeedToken(token string) string {
+	return utils.Sha256(token)
+}
+
+func GenerateNewFeedToken(u *User) (token *Token, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	token = genToken(u, TokenFeed)
+	token.ClearTextToken = token.Token
+	token.Token = hashFeedToken(token.ClearTextToken)
+
+	_, err = s.Insert(token)
+	return
+}
+
+func GetFeedTokens(u *User) (tokens []*Token, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	return getTokensForKind(s, u, TokenFeed)
+}
+
+func DeleteFeedTokenByID(u *User, id int64) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	return removeTokenByID(s, u, TokenFeed, id)
+}
+
+// GetUserByFeedToken returns the user a feed token belongs to
+func GetUserByFeedToken(s *xorm.Session, token string) (u *User, err error) {
+	if token == "" {
+		return nil, &ErrInvalidFeedToken{}
+	}
+
+	t, err := getToken(s, hashFeedToken(token), TokenFeed)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, &ErrInvalidFeedToken{}
+	}
+
+	u, err = GetUserByID(s, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Status == StatusDisabled {
+		return nil, &ErrAccountDisabled{UserID: u.ID}
+	}
+
+	return u, nil
+}
@@ -0,0 +1,61 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-2021 Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+package user
+
+import (
+	"fmt"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/c2h5oh/datasize"
+	"xorm.io/xorm"
+)
+
+// ParseStorageQuota parses a human-readable storage quota like 1GB into bytes. An empty quota means no limit.
+func ParseStorageQuota(quota string) (uint64, error) {
+	if quota == "" {
+		return 0, nil
+	}
+
+	var size datasize.ByteSize
+	err := size.UnmarshalText([]byte(quota))
+	if err != nil {
+		return 0, fmt.Errorf("invalid storage quota %s: %w", quota, err)
+	}
+	return size.Bytes(), nil
+}
+
+// DefaultStorageQuota is the storage quota of users without a quota of their own. The quota configured in
+// defaultsettings.storage_quota applies to them, including when it is changed after they were created.
+const DefaultStorageQuota int64 = -1
+
+// GetStorageQuota returns the maximum size of all files of the user in bytes. 0 means no limit.
+func (u *User) GetStorageQuota() (uint64, error) {
+	if u.StorageQuota == DefaultStorageQuota {
+		return ParseStorageQuota(config.DefaultSettingsStorageQuota.GetString())
+	}
+	return uint64(u.StorageQuota), nil
+}
+
+// SetStorageQuota sets the maximum size of all files of a user in bytes. 0 means no limit,
+// DefaultStorageQuota makes the configured default quota apply to the user.
+func (u *User) SetStorageQuota(s *xorm.Session, quota int64) (err error) {
+	u.StorageQuota = quota
+	_, err = s.
+		Where("id = ?", u.ID).
+		Cols("storage_quota").
+		Update(u)
+	return
+}
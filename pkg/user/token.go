@@ -35,6 +35,7 @@ const (
 	TokenEmailConfirm
 	TokenAccountDeletion
 	TokenCaldavAuth
+	TokenFeed
 
 	tokenSize = 64
 )
@@ -105,6 +105,10 @@ type User struct {
 
 	ExportFileID int64 `xorm:"bigint null" json:"-"`
 
+	// The maximum size of all files of this user together in bytes. 0 means no limit,
+	// DefaultStorageQuota means the quota configured in defaultsettings.storage_quota applies.
+	StorageQuota int64 `xorm:"bigint not null default -1" json:"-"`
+
 	// A timestamp when this task was created. You cannot change this value.
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this task was last updated. You cannot change this value.
@@ -158,6 +162,17 @@ func (u *User) GetNameAndFromEmail() string {
 	return u.GetName() + " via Vikunja <" + config.MailerFromEmail.GetString() + ">"
 }
 
+// GetTimezone returns the timezone of the user or the configured default one if the user did not set any.
+func (u *User) GetTimezone() *time.Location {
+	if u.Timezone != "" {
+		if loc, err := time.LoadLocation(u.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	return config.GetTimeZone()
+}
+
 func (u *User) GetFailedTOTPAttemptsKey() string {
 	return "failed_totp_attempts_" + strconv.FormatInt(u.ID, 10)
 }
@@ -65,6 +65,7 @@ func CreateUser(s *xorm.Session, user *User) (newUser *User, err error) {
 	user.WeekStart = config.DefaultSettingsWeekStart.GetInt()
 	user.Language = config.DefaultSettingsLanguage.GetString()
 	user.Timezone = config.DefaultSettingsTimezone.GetString()
+	user.StorageQuota = DefaultStorageQuota
 
 	// Insert it
 	_, err = s.Insert(user)
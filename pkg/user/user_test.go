@@ -19,6 +19,7 @@ package user
 import (
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +43,30 @@ func TestCreateUser(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotZero(t, createdUser.Created)
 	})
+	t.Run("default storage quota", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+		config.DefaultSettingsStorageQuota.Set("1KB")
+		defer config.DefaultSettingsStorageQuota.Set("0")
+
+		createdUser, err := CreateUser(s, &User{
+			Username: "quotauser",
+			Password: "1234",
+			Email:    "quota@example.com",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultStorageQuota, createdUser.StorageQuota)
+		quota, err := createdUser.GetStorageQuota()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1024), quota)
+
+		// Changing the default quota changes the quota of existing users
+		config.DefaultSettingsStorageQuota.Set("2KB")
+		quota, err = createdUser.GetStorageQuota()
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(2048), quota)
+	})
 	t.Run("already existing", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -544,3 +569,66 @@ func TestUserPasswordReset(t *testing.T) {
 		assert.True(t, IsErrInvalidPasswordResetToken(err))
 	})
 }
+
+func TestGetUserByFeedToken(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u, err := GetUserByFeedToken(s, "feedtesttoken")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), u.ID)
+	})
+	t.Run("new token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		token, err := GenerateNewFeedToken(&User{ID: 2})
+		assert.NoError(t, err)
+		assert.NotEqual(t, token.ClearTextToken, token.Token)
+
+		u, err := GetUserByFeedToken(s, token.ClearTextToken)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), u.ID)
+	})
+	t.Run("revoked token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := DeleteFeedTokenByID(&User{ID: 1}, 4)
+		assert.NoError(t, err)
+
+		_, err = GetUserByFeedToken(s, "feedtesttoken")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidFeedToken(err))
+	})
+	t.Run("other kind of token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetUserByFeedToken(s, "passwordresettesttoken")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidFeedToken(err))
+	})
+}
+
+func TestParseStorageQuota(t *testing.T) {
+	quota, err := ParseStorageQuota("")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), quota)
+
+	quota, err = ParseStorageQuota("0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), quota)
+
+	quota, err = ParseStorageQuota("2GB")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2*1024*1024*1024), quota)
+
+	_, err = ParseStorageQuota("lots")
+	assert.Error(t, err)
+}